@@ -1,19 +1,34 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tjsturos/qtools/go-qtools/internal/client"
 	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/diagnostics"
+	"github.com/tjsturos/qtools/go-qtools/internal/frames"
 	"github.com/tjsturos/qtools/go-qtools/internal/node"
+	"github.com/tjsturos/qtools/go-qtools/internal/publicip"
+	"github.com/tjsturos/qtools/go-qtools/internal/scheduler"
 	"github.com/tjsturos/qtools/go-qtools/internal/service"
+	"github.com/tjsturos/qtools/go-qtools/internal/supervisor"
 	"github.com/tjsturos/qtools/go-qtools/internal/tui"
+	"github.com/tjsturos/qtools/go-qtools/internal/watchdog"
 )
 
 var (
@@ -47,11 +62,35 @@ func main() {
 		Use:   "setup [flags]",
 		Short: "Setup node (defaults to manual mode)",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
+
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				cfg = config.GenerateDefaultConfig()
+			}
+
 			automatic, _ := cmd.Flags().GetBool("automatic")
 			workers, _ := cmd.Flags().GetInt("workers")
 
 			fmt.Printf("Setting up node (automatic: %v, workers: %d)\n", automatic, workers)
-			// TODO: Implement actual setup logic
+
+			opts := node.SetupOptions{
+				AutomaticMode: automatic,
+				WorkerCount:   workers,
+			}
+			if err := node.SetupNode(opts, cfg); err != nil {
+				return err
+			}
+
+			if err := config.SaveConfig(cfg, configPath); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+
+			fmt.Println("✓ Node setup complete")
 			return nil
 		},
 	}
@@ -196,62 +235,74 @@ Examples:
 	nodeConfigSetCmd.Flags().String("config", "qtools", "Config type: qtools or quil")
 	nodeConfigSetCmd.Flags().Bool("quiet", false, "Suppress output")
 
-	nodeConfigCmd.AddCommand(nodeConfigGetCmd, nodeConfigSetCmd)
-
-	// Node info commands
-	nodeInfoCmd := &cobra.Command{
-		Use:   "info",
-		Short: "Get node information",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Node information:")
-			// TODO: Implement node info
-			return nil
-		},
+	// Direct peers subcommands
+	nodeDirectPeersCmd := &cobra.Command{
+		Use:   "direct-peers",
+		Short: "Manage p2p.directPeers in the node config",
 	}
 
-	nodePeerIDCmd := &cobra.Command{
-		Use:   "peer-id",
-		Short: "Get node peer ID",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Peer ID:")
-			// TODO: Implement peer-id
+	// restartAfterPeerChange restarts the node so direct peer changes take effect
+	restartAfterPeerChange := func(cmd *cobra.Command, cfg *config.Config) error {
+		if restart, _ := cmd.Flags().GetBool("restart"); !restart {
+			fmt.Println("Restart the node for the change to take effect (qtools service restart)")
 			return nil
-		},
+		}
+		fmt.Println("Restarting service...")
+		if err := service.RestartService(service.RestartOptions{}, cfg); err != nil {
+			return fmt.Errorf("failed to restart service: %w", err)
+		}
+		return nil
 	}
 
-	nodeBalanceCmd := &cobra.Command{
-		Use:   "balance",
-		Short: "Get node balance",
+	nodeDirectPeersListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List direct peers",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Balance:")
-			// TODO: Implement balance
-			return nil
-		},
-	}
+			jsonOutput, _ := cmd.Flags().GetBool("json")
 
-	nodeSeniorityCmd := &cobra.Command{
-		Use:   "seniority",
-		Short: "Get node seniority",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Seniority:")
-			// TODO: Implement seniority
-			return nil
-		},
-	}
+			peers, err := node.GetDirectPeers("")
+			if err != nil {
+				return fmt.Errorf("failed to get direct peers: %w", err)
+			}
 
-	nodeWorkerCountCmd := &cobra.Command{
-		Use:   "worker-count",
-		Short: "Get worker count",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Worker count:")
-			// TODO: Implement worker-count
+			if jsonOutput {
+				if peers == nil {
+					peers = []string{}
+				}
+				data, err := json.MarshalIndent(peers, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal direct peers: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			if len(peers) == 0 {
+				fmt.Println("No direct peers configured.")
+				return nil
+			}
+			fmt.Printf("Direct peers (%d):\n", len(peers))
+			for _, peer := range peers {
+				fmt.Printf("  %s\n", peer)
+			}
 			return nil
 		},
 	}
+	nodeDirectPeersListCmd.Flags().Bool("json", false, "Output in JSON format")
 
-	nodeUpdateCmd := &cobra.Command{
-		Use:   "update [flags]",
-		Short: "Update node binary",
+	nodeDirectPeersAddCmd := &cobra.Command{
+		Use:   "add [multiaddr]",
+		Short: "Add a direct peer",
+		Long: `Add a direct peer to p2p.directPeers.
+
+Provide either a full multiaddr or --ip and --peer-id. An existing entry
+for the same peer ID is replaced.
+
+Examples:
+  qtools node config direct-peers add /ip4/1.2.3.4/udp/8336/quic-v1/p2p/QmPeer...
+  qtools node config direct-peers add --ip 1.2.3.4 --peer-id QmPeer... --protocol tcp
+`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Load config
 			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
@@ -264,84 +315,109 @@ Examples:
 				cfg = config.GenerateDefaultConfig()
 			}
 
-			force, _ := cmd.Flags().GetBool("force")
-			skipClean, _ := cmd.Flags().GetBool("skip-clean")
-			auto, _ := cmd.Flags().GetBool("auto")
-
-			opts := node.UpdateOptions{
-				Force:     force,
-				SkipClean: skipClean,
-				Auto:      auto,
+			ip, _ := cmd.Flags().GetString("ip")
+			peerID, _ := cmd.Flags().GetString("peer-id")
+			port, _ := cmd.Flags().GetInt("port")
+			proto, _ := cmd.Flags().GetString("protocol")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			var multiaddr string
+			switch {
+			case len(args) == 1:
+				multiaddr = args[0]
+			case ip != "" && peerID != "":
+				// Defaults come from settings.listenAddr, as used by publish-multiaddr
+				var listenAddr config.ListenAddrConfig
+				if cfg.Settings != nil && cfg.Settings.ListenAddr != nil {
+					listenAddr = *cfg.Settings.ListenAddr
+				}
+				if proto == "" {
+					proto = "udp"
+					if listenAddr.Mode != "" {
+						proto = listenAddr.Mode
+					}
+				}
+				if port == 0 {
+					port = node.DefaultP2PPort
+					if listenAddr.Port > 0 {
+						port = listenAddr.Port
+					}
+				}
+				multiaddr = node.BuildDirectPeerMultiaddr(ip, port, proto, peerID)
+			default:
+				return fmt.Errorf("provide a multiaddr or both --ip and --peer-id")
 			}
 
-			if err := node.UpdateNode(opts, cfg); err != nil {
+			if peerID == "" {
+				peerID = node.PeerIDFromMultiaddr(multiaddr)
+			}
+			if err := node.ValidateDirectPeerMultiaddr(multiaddr); err != nil {
 				return err
 			}
 
-			// Update service files and restart after node update
-			fmt.Println("Updating service files...")
-			serviceOpts, err := service.LoadServiceOptionsFromConfig(cfg)
-			if err != nil {
-				return fmt.Errorf("failed to load service options: %w", err)
+			if addrIP, _, _, err := node.ParseMultiaddr(multiaddr); err == nil && node.IsLocalIP(addrIP) {
+				return fmt.Errorf("refusing to add %s: %s is a local address", multiaddr, addrIP)
+			}
+			if ownPeerID, err := node.GetPeerID(cfg); err != nil {
+				fmt.Printf("Warning: could not determine own peer ID: %v\n", err)
+			} else if ownPeerID == peerID {
+				return fmt.Errorf("refusing to add %s: it is this node's peer ID", peerID)
 			}
 
-			serviceName := "ceremonyclient"
-			if cfg.Service != nil && cfg.Service.FileName != "" {
-				serviceName = cfg.Service.FileName
+			if dryRun {
+				fmt.Printf("Would add direct peer: %s\n", multiaddr)
+				return nil
 			}
 
-			serviceConfig := &service.ServiceConfig{
-				ServiceOptions: serviceOpts,
-				ServiceName:    serviceName,
-				WorkingDir:     config.GetNodePath(),
-				BinaryPath:     "/usr/local/bin/node",
-				User:          "quilibrium",
-				Group:         "qtools",
-				IsWorker:      false,
+			added, err := node.AddDirectPeer("", peerID, multiaddr)
+			if err != nil {
+				return fmt.Errorf("failed to add direct peer: %w", err)
+			}
+			if !added {
+				fmt.Printf("Direct peer already configured: %s\n", multiaddr)
+				return nil
 			}
 
-			if err := service.UpdateServiceFile(serviceName, serviceConfig); err != nil {
-				return fmt.Errorf("failed to update service file: %w", err)
+			fmt.Printf("✓ Added direct peer: %s\n", multiaddr)
+			return restartAfterPeerChange(cmd, cfg)
+		},
+	}
+	nodeDirectPeersAddCmd.Flags().String("ip", "", "Peer IPv4 address")
+	nodeDirectPeersAddCmd.Flags().String("peer-id", "", "Peer ID (Qm... or 12D3KooW...)")
+	nodeDirectPeersAddCmd.Flags().Int("port", 0, "Peer port (default: settings.listenAddr.port or 8336)")
+	nodeDirectPeersAddCmd.Flags().String("protocol", "", "Peer protocol: udp or tcp (default: settings.listenAddr.mode or udp)")
+	nodeDirectPeersAddCmd.Flags().Bool("dry-run", false, "Show the entry without writing it")
+	nodeDirectPeersAddCmd.Flags().Bool("restart", false, "Restart the node after the change")
+
+	nodeDirectPeersRemoveCmd := &cobra.Command{
+		Use:   "remove <peer-id|multiaddr>",
+		Short: "Remove a direct peer",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
 			}
 
-			// Update worker service files if in manual mode
-			if node.IsManualMode(cfg) {
-				workerCount := node.GetWorkerCount(cfg)
-				for i := 1; i <= workerCount; i++ {
-					workerConfig := &service.ServiceConfig{
-						ServiceOptions: serviceOpts,
-						ServiceName:    serviceName,
-						WorkingDir:     config.GetNodePath(),
-						BinaryPath:     "/usr/local/bin/node",
-						User:          "quilibrium",
-						Group:         "qtools",
-						IsWorker:      true,
-						WorkerIndex:   i,
-					}
-					workerServiceName := fmt.Sprintf("%s-worker@%d", serviceName, i)
-					if err := service.UpdateServiceFile(workerServiceName, workerConfig); err != nil {
-						return fmt.Errorf("failed to update worker service file: %w", err)
-					}
-				}
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				cfg = config.GenerateDefaultConfig()
 			}
 
-			// Restart service
-			fmt.Println("Restarting service...")
-			if err := service.RestartService(service.RestartOptions{}, cfg); err != nil {
-				return fmt.Errorf("failed to restart service: %w", err)
+			if err := node.RemoveDirectPeer("", args[0]); err != nil {
+				return fmt.Errorf("failed to remove direct peer: %w", err)
 			}
 
-			fmt.Println("Node update completed successfully")
-			return nil
+			fmt.Printf("✓ Removed direct peer: %s\n", args[0])
+			return restartAfterPeerChange(cmd, cfg)
 		},
 	}
-	nodeUpdateCmd.Flags().Bool("force", false, "Force update")
-	nodeUpdateCmd.Flags().Bool("skip-clean", false, "Skip cleanup")
-	nodeUpdateCmd.Flags().Bool("auto", false, "Auto-update mode")
+	nodeDirectPeersRemoveCmd.Flags().Bool("restart", false, "Restart the node after the change")
 
-	nodeDownloadCmd := &cobra.Command{
-		Use:   "download [flags]",
-		Short: "Download node binary",
+	nodeDirectPeersClearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all direct peers",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Load config
 			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
@@ -354,116 +430,1451 @@ Examples:
 				cfg = config.GenerateDefaultConfig()
 			}
 
-			version, _ := cmd.Flags().GetString("version")
-			link, _ := cmd.Flags().GetBool("link")
+			if err := node.ClearDirectPeers(""); err != nil {
+				return fmt.Errorf("failed to clear direct peers: %w", err)
+			}
 
-			return node.DownloadNode(cfg, version, link)
+			fmt.Println("✓ Cleared direct peers")
+			return restartAfterPeerChange(cmd, cfg)
 		},
 	}
-	nodeDownloadCmd.Flags().String("version", "", "Specific version to download (default: latest)")
-	nodeDownloadCmd.Flags().Bool("link", false, "Create symlink after download")
+	nodeDirectPeersClearCmd.Flags().Bool("restart", false, "Restart the node after the change")
+
+	nodeDirectPeersSyncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Merge a remote peer list into the direct peers",
+		Long: `Merge a peer list into p2p.directPeers.
+
+The source can be a local path, an http(s) URL, ssh://user@host/path or
+user@host:path. Without --source, the list is read over SSH from
+settings.central_server using settings.publish_multiaddr.remote_file.
+
+A remote entry replaces the configured address of the same peer ID.
+Remote entries for this node's peer ID or a local IP address, and invalid
+ones, are skipped. Entries already in the node config are only deduplicated
+and stripped of this node's peer ID; --prune also removes the invalid ones
+and those pointing at a local IP address.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
 
-	nodeCmd.AddCommand(setupCmd, modeCmd, installCmd, nodeConfigCmd, nodeInfoCmd, nodePeerIDCmd, 
-		nodeBalanceCmd, nodeSeniorityCmd, nodeWorkerCountCmd, nodeUpdateCmd, nodeDownloadCmd)
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				cfg = config.GenerateDefaultConfig()
+			}
 
-	// Service commands
-	serviceCmd := &cobra.Command{
-		Use:   "service",
-		Short: "Service management commands",
-	}
+			source, _ := cmd.Flags().GetString("source")
+			peerID, _ := cmd.Flags().GetString("peer-id")
+			prune, _ := cmd.Flags().GetBool("prune")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
 
-	startCmd := &cobra.Command{
-		Use:   "start [flags]",
-		Short: "Start service(s)",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			master, _ := cmd.Flags().GetBool("master")
-			coreIndex, _ := cmd.Flags().GetInt("core-index")
-			cores, _ := cmd.Flags().GetString("cores")
+			if peerID == "" {
+				if peerID, err = node.GetPeerID(cfg); err != nil {
+					fmt.Printf("Warning: could not determine own peer ID: %v\n", err)
+				}
+			}
 
-			fmt.Printf("Starting service (master: %v, core-index: %d, cores: %s)\n", master, coreIndex, cores)
-			// TODO: Implement actual start logic
-			return nil
+			result, err := node.SyncDirectPeers("", node.DirectPeerSyncOptions{
+				Source:      source,
+				LocalPeerID: peerID,
+				Prune:       prune,
+				DryRun:      dryRun,
+			}, cfg)
+			if err != nil {
+				return fmt.Errorf("failed to sync direct peers: %w", err)
+			}
+
+			if jsonOutput {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal sync result: %w", err)
+				}
+				fmt.Println(string(data))
+			} else {
+				for _, peer := range result.Added {
+					fmt.Printf("+ %s\n", peer)
+				}
+				for _, peer := range result.Updated {
+					fmt.Printf("~ %s (new address)\n", peer)
+				}
+				for _, peer := range result.Pruned {
+					fmt.Printf("- %s (pruned)\n", peer)
+				}
+				for _, peer := range result.Skipped {
+					fmt.Printf("- %s (skipped)\n", peer)
+				}
+				if !result.Changed() {
+					fmt.Printf("✓ Direct peers up to date (%d)\n", len(result.Peers))
+					return nil
+				}
+				if dryRun {
+					fmt.Printf("Would add %d, update %d and prune %d direct peer(s)\n",
+						len(result.Added), len(result.Updated), len(result.Pruned))
+					return nil
+				}
+				fmt.Printf("✓ Added %d, updated %d and pruned %d direct peer(s), %d total\n",
+					len(result.Added), len(result.Updated), len(result.Pruned), len(result.Peers))
+			}
+
+			if dryRun || !result.Changed() {
+				return nil
+			}
+			return restartAfterPeerChange(cmd, cfg)
 		},
 	}
-	startCmd.Flags().Bool("master", false, "Start master only")
-	startCmd.Flags().Int("core-index", 0, "Start specific worker by core index")
-	startCmd.Flags().String("cores", "", "Start specific workers by core numbers (e.g., '1-4,6,8')")
-
-	stopCmd := &cobra.Command{
-		Use:   "stop [flags]",
-		Short: "Stop service(s)",
+	nodeDirectPeersSyncCmd.Flags().String("source", "", "Peer list source (path, http(s) URL or SSH location)")
+	nodeDirectPeersSyncCmd.Flags().String("peer-id", "", "Own peer ID to exclude (default: read from node)")
+	nodeDirectPeersSyncCmd.Flags().Bool("prune", false, "Also remove configured entries that are invalid or point at a local IP address")
+	nodeDirectPeersSyncCmd.Flags().Bool("dry-run", false, "Show changes without writing them")
+	nodeDirectPeersSyncCmd.Flags().Bool("json", false, "Output in JSON format")
+	nodeDirectPeersSyncCmd.Flags().Bool("restart", false, "Restart the node if the direct peers changed")
+
+	nodeDirectPeersPublishCmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish this node's multiaddr to the shared peer list",
+		Long: `Add this node's multiaddr to the peer list other nodes sync from.
+
+The target can be a local path, ssh://user@host/path or user@host:path.
+Without --target, the list at settings.publish_multiaddr.remote_file on
+settings.central_server is updated over SSH. Older entries for this
+node's peer ID or IP address are replaced.
+
+The multiaddr uses the protocol and port of p2p.listenMultiaddr and the
+public IP, or settings.internal_ip with --internal.
+`,
+		Example: `  qtools node config direct-peers publish
+  qtools node config direct-peers publish --internal
+  qtools node config direct-peers publish --target ./peers.yml --ip 1.2.3.4`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			master, _ := cmd.Flags().GetBool("master")
-			coreIndex, _ := cmd.Flags().GetInt("core-index")
-			cores, _ := cmd.Flags().GetString("cores")
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
+
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				cfg = config.GenerateDefaultConfig()
+			}
+
+			target, _ := cmd.Flags().GetString("target")
+			ip, _ := cmd.Flags().GetString("ip")
+			internal, _ := cmd.Flags().GetBool("internal")
+			peerID, _ := cmd.Flags().GetString("peer-id")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+
+			switch {
+			case ip != "":
+			case internal:
+				if cfg.Settings == nil || cfg.Settings.InternalIP == "" {
+					return fmt.Errorf("--internal requires settings.internal_ip")
+				}
+				ip = cfg.Settings.InternalIP
+			default:
+				opts, err := publicip.LoadMonitorOptionsFromConfig(cfg)
+				if err != nil {
+					return err
+				}
+				publicIP, err := publicip.ResolveFirst(cmd.Context(), opts.Providers, 0)
+				if err != nil {
+					return fmt.Errorf("failed to get public IP: %w", err)
+				}
+				ip = publicIP.String()
+			}
+
+			if peerID == "" {
+				if peerID, err = node.GetPeerID(cfg); err != nil {
+					return fmt.Errorf("failed to get peer ID: %w", err)
+				}
+			}
+
+			result, err := node.PublishDirectPeer("", node.DirectPeerPublishOptions{
+				Target: target,
+				IP:     ip,
+				PeerID: peerID,
+				DryRun: dryRun,
+			}, cfg)
+			if err != nil {
+				return fmt.Errorf("failed to publish multiaddr: %w", err)
+			}
+
+			if jsonOutput {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal publish result: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
 
-			fmt.Printf("Stopping service (master: %v, core-index: %d, cores: %s)\n", master, coreIndex, cores)
-			// TODO: Implement actual stop logic
+			for _, peer := range result.Removed {
+				fmt.Printf("- %s (replaced)\n", peer)
+			}
+			switch {
+			case !result.Changed():
+				fmt.Printf("✓ Multiaddr already published: %s\n", result.Multiaddr)
+			case dryRun:
+				fmt.Printf("Would publish %s\n", result.Multiaddr)
+			default:
+				fmt.Printf("✓ Published %s (%d peers in list)\n", result.Multiaddr, len(result.Peers))
+			}
 			return nil
 		},
 	}
-	stopCmd.Flags().Bool("master", false, "Stop master only")
-	stopCmd.Flags().Int("core-index", 0, "Stop specific worker by core index")
-	stopCmd.Flags().String("cores", "", "Stop specific workers by core numbers")
+	nodeDirectPeersPublishCmd.Flags().String("target", "", "Peer list to update (path or SSH location)")
+	nodeDirectPeersPublishCmd.Flags().String("ip", "", "IP address to publish (default: public IP)")
+	nodeDirectPeersPublishCmd.Flags().Bool("internal", false, "Publish settings.internal_ip instead of the public IP")
+	nodeDirectPeersPublishCmd.Flags().String("peer-id", "", "Peer ID to publish (default: read from node)")
+	nodeDirectPeersPublishCmd.Flags().Bool("dry-run", false, "Show changes without writing them")
+	nodeDirectPeersPublishCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	nodeDirectPeersCmd.AddCommand(nodeDirectPeersListCmd, nodeDirectPeersAddCmd, nodeDirectPeersRemoveCmd,
+		nodeDirectPeersClearCmd, nodeDirectPeersSyncCmd, nodeDirectPeersPublishCmd)
+
+	nodeConfigCmd.AddCommand(nodeConfigGetCmd, nodeConfigSetCmd, nodeDirectPeersCmd)
+
+	// loadNodeClient creates a node client from the qtools config
+	loadNodeClient := func() client.NodeAPI {
+		configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+		if configPath == "" {
+			configPath = "/home/quilibrium/qtools/config.yml"
+		}
+
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			cfg = config.GenerateDefaultConfig()
+		}
+
+		return client.NewNodeClient(cfg)
+	}
 
-	restartCmd := &cobra.Command{
-		Use:   "restart [flags]",
-		Short: "Restart service(s)",
+	// loadNodeInfoFor queries the node over --via (default: hybrid), or parses saved output given with --input
+	// In hybrid mode, transports that answer without field (has reports false) fall through to the next
+	loadNodeInfoFor := func(cmd *cobra.Command, field string, has func(*client.NodeInfo) bool) (*client.NodeInfo, error) {
+		if input, _ := cmd.Flags().GetString("input"); input != "" {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read node info output: %w", err)
+			}
+			info, err := node.ParseNodeInfo(data)
+			if err != nil {
+				return nil, err
+			}
+			return &client.NodeInfo{NodeInfo: *info, Transport: "file"}, nil
+		}
+
+		nc := loadNodeClient()
+		if via, _ := cmd.Flags().GetString("via"); via != "" {
+			return nc.GetNodeInfoVia(via)
+		}
+		return nc.GetNodeInfoHybridFor(field, has)
+	}
+
+	// loadNodeInfo is loadNodeInfoFor with any answer accepted
+	loadNodeInfo := func(cmd *cobra.Command) (*client.NodeInfo, error) {
+		return loadNodeInfoFor(cmd, "", nil)
+	}
+
+	// printJSON prints a value as indented JSON
+	printJSON := func(v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal output: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	// Node info commands
+	nodeInfoCmd := &cobra.Command{
+		Use:   "info",
+		Short: "Get node information",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			master, _ := cmd.Flags().GetBool("master")
-			coreIndex, _ := cmd.Flags().GetInt("core-index")
-			cores, _ := cmd.Flags().GetString("cores")
+			info, err := loadNodeInfo(cmd)
+			if err != nil {
+				return err
+			}
 
-			fmt.Printf("Restarting service (master: %v, core-index: %d, cores: %s)\n", master, coreIndex, cores)
-			// TODO: Implement actual restart logic
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(info)
+			}
+
+			fmt.Printf("Peer ID:         %s\n", info.PeerID)
+			fmt.Printf("Version:         %s\n", info.Version)
+			fmt.Printf("Network:         %s\n", info.Network)
+			fmt.Printf("Max Frame:       %d\n", info.MaxFrame)
+			fmt.Printf("Peer Score:      %d\n", info.PeerScore)
+			if info.ProverRing >= 0 {
+				fmt.Printf("Prover Ring:     %d\n", info.ProverRing)
+			}
+			fmt.Printf("Seniority:       %s\n", info.Seniority)
+			fmt.Printf("Active Workers:  %d\n", info.ActiveWorkers)
+			if info.RunningWorkers > 0 {
+				fmt.Printf("Running Workers: %d\n", info.RunningWorkers)
+			}
+			fmt.Printf("Balance:         %s %s\n", info.Balance, info.BalanceUnit)
+			if info.UnconfirmedBalance != "" {
+				fmt.Printf("Unconfirmed:     %s %s\n", info.UnconfirmedBalance, info.BalanceUnit)
+			}
+			labels := make([]string, 0, len(info.Extra))
+			for label := range info.Extra {
+				labels = append(labels, label)
+			}
+			sort.Strings(labels)
+			for _, label := range labels {
+				fmt.Printf("%-16s %s\n", label+":", info.Extra[label])
+			}
+			fmt.Printf("Transport:       %s\n", info.Transport)
 			return nil
 		},
 	}
-	restartCmd.Flags().Bool("master", false, "Restart master only")
-	restartCmd.Flags().Int("core-index", 0, "Restart specific worker by core index")
-	restartCmd.Flags().String("cores", "", "Restart specific workers by core numbers")
+	nodeInfoCmd.Flags().Bool("json", false, "Output in JSON format")
+	nodeInfoCmd.Flags().String("input", "", "Parse saved node --node-info output instead of running the node")
+	nodeInfoCmd.Flags().String("via", "", "Force a transport: grpc, rest or binary (default: try each in order)")
 
-	statusCmd := &cobra.Command{
-		Use:   "status",
-		Short: "Get service status",
+	nodePeerIDCmd := &cobra.Command{
+		Use:   "peer-id",
+		Short: "Get node peer ID",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Service status:")
-			// TODO: Implement actual status logic
+			peerID, err := loadNodeClient().GetPeerID()
+			if err != nil {
+				return fmt.Errorf("failed to get peer ID: %w", err)
+			}
+
+			fmt.Println(peerID)
 			return nil
 		},
 	}
 
-	serviceEnableCmd := &cobra.Command{
+	nodeIdentityCmd := &cobra.Command{
+		Use:   "identity [peer-id]",
+		Short: "Show peer identity and account address",
+		Long: `Decode a peer ID and derive its QUIL account address.
+
+Without an argument, the identity is derived from p2p.peerPrivKey in the
+node config (or the peer key in the node's keys.yml when it is not set), so
+the node does not need to be running. With --from-node, the peer ID is read from the
+node binary instead.
+
+Examples:
+  qtools node identity
+  qtools node identity QmPeer... --json
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
+
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				cfg = config.GenerateDefaultConfig()
+			}
+
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			fromNode, _ := cmd.Flags().GetBool("from-node")
+
+			var identity *node.PeerIdentity
+			switch {
+			case len(args) == 1:
+				if identity, err = node.DecodePeerID(args[0]); err != nil {
+					return err
+				}
+				identity.Source = "argument"
+			case fromNode:
+				output, err := node.ExecuteNodeCommand([]string{"--peer-id"}, cfg)
+				if err != nil {
+					return fmt.Errorf("failed to get peer ID from node: %w", err)
+				}
+				peerID := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(output)), "Peer ID:"))
+				if identity, err = node.DecodePeerID(peerID); err != nil {
+					return err
+				}
+				identity.Source = "node"
+			default:
+				if identity, err = node.LoadPeerIdentity(""); err != nil {
+					return fmt.Errorf("failed to load peer identity: %w", err)
+				}
+			}
+
+			// Hashed peer IDs don't embed the key; fill it in when it is this node's peer ID
+			if identity.PublicKey == "" {
+				if local, err := node.LoadPeerIdentity(""); err == nil && local.PeerID == identity.PeerID {
+					identity.KeyType = local.KeyType
+					identity.PublicKey = local.PublicKey
+				}
+			}
+
+			if jsonOutput {
+				data, err := json.MarshalIndent(identity, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal identity: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			fmt.Printf("Peer ID:         %s\n", identity.PeerID)
+			fmt.Printf("Account address: %s\n", identity.AccountAddress)
+			fmt.Printf("Key type:        %s\n", identity.KeyType)
+			if identity.PublicKey != "" {
+				fmt.Printf("Public key:      %s\n", identity.PublicKey)
+			} else {
+				fmt.Println("Public key:      not embedded in peer ID (hashed key)")
+			}
+			fmt.Printf("Multihash:       %s\n", identity.Multihash)
+			fmt.Printf("Source:          %s\n", identity.Source)
+			return nil
+		},
+	}
+	nodeIdentityCmd.Flags().Bool("json", false, "Output in JSON format")
+	nodeIdentityCmd.Flags().Bool("from-node", false, "Read the peer ID from the node binary instead of the config")
+
+	nodeBalanceCmd := &cobra.Command{
+		Use:   "balance",
+		Short: "Get node balance",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := loadNodeInfoFor(cmd, "balance", func(info *client.NodeInfo) bool {
+				return info.Balance != ""
+			})
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(map[string]string{
+					"balance":             info.Balance,
+					"unit":                info.BalanceUnit,
+					"unconfirmed_balance": info.UnconfirmedBalance,
+				})
+			}
+
+			if info.Balance == "" {
+				return fmt.Errorf("balance not reported via %s", info.Transport)
+			}
+			fmt.Printf("%s %s\n", info.Balance, info.BalanceUnit)
+			return nil
+		},
+	}
+	nodeBalanceCmd.Flags().Bool("json", false, "Output in JSON format")
+	nodeBalanceCmd.Flags().String("input", "", "Parse saved node --node-info output instead of running the node")
+	nodeBalanceCmd.Flags().String("via", "", "Force a transport: grpc, rest or binary (default: try each in order)")
+
+	nodeSeniorityCmd := &cobra.Command{
+		Use:   "seniority",
+		Short: "Get node seniority",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := loadNodeInfo(cmd)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(map[string]interface{}{
+					"seniority":   info.Seniority,
+					"prover_ring": info.ProverRing,
+				})
+			}
+
+			fmt.Println(info.Seniority)
+			return nil
+		},
+	}
+	nodeSeniorityCmd.Flags().Bool("json", false, "Output in JSON format")
+	nodeSeniorityCmd.Flags().String("input", "", "Parse saved node --node-info output instead of running the node")
+	nodeSeniorityCmd.Flags().String("via", "", "Force a transport: grpc, rest or binary (default: try each in order)")
+
+	nodeWorkerCountCmd := &cobra.Command{
+		Use:   "worker-count",
+		Short: "Get worker count",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := loadNodeInfo(cmd)
+			if err != nil {
+				return err
+			}
+
+			activeOnly, _ := cmd.Flags().GetBool("active")
+			runningOnly, _ := cmd.Flags().GetBool("running")
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(map[string]int{
+					"running_workers": info.RunningWorkers,
+					"active_workers":  info.ActiveWorkers,
+					"worker_count":    info.WorkerCount,
+				})
+			}
+
+			switch {
+			case activeOnly:
+				fmt.Println(info.ActiveWorkers)
+			case runningOnly:
+				fmt.Println(info.RunningWorkers)
+			default:
+				fmt.Printf("Running Workers: %d\n", info.RunningWorkers)
+				fmt.Printf("Active Workers: %d\n", info.ActiveWorkers)
+			}
+			return nil
+		},
+	}
+	nodeWorkerCountCmd.Flags().Bool("json", false, "Output in JSON format")
+	nodeWorkerCountCmd.Flags().Bool("active", false, "Print only the active worker count")
+	nodeWorkerCountCmd.Flags().Bool("running", false, "Print only the running worker count")
+	nodeWorkerCountCmd.Flags().String("input", "", "Parse saved node --node-info output instead of running the node")
+	nodeWorkerCountCmd.Flags().String("via", "", "Force a transport: grpc, rest or binary (default: try each in order)")
+
+	// Node query commands (typed NodeService RPCs)
+	nodeQueryCmd := &cobra.Command{
+		Use:   "query",
+		Short: "Query the running node (frames, tokens, prover ring, network, seniority)",
+	}
+
+	nodeQueryFramesCmd := &cobra.Command{
+		Use:   "frames",
+		Short: "Show the latest frame the node has processed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			via, _ := cmd.Flags().GetString("via")
+			frames, err := loadNodeClient().GetFrameInfo(via)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(frames)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PEER ID\tMAX FRAME\tTRANSPORT")
+			fmt.Fprintf(w, "%s\t%d\t%s\n", frames.PeerID, frames.MaxFrame, frames.Transport)
+			return w.Flush()
+		},
+	}
+
+	nodeQueryTokensCmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Show token supply and owned tokens",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			via, _ := cmd.Flags().GetString("via")
+			tokens, err := loadNodeClient().GetTokenInfo(via)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(tokens)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "\tCONFIRMED\tUNCONFIRMED")
+			fmt.Fprintf(w, "Supply\t%s %s\t%s %s\n", tokens.ConfirmedSupply, tokens.Unit, tokens.UnconfirmedSupply, tokens.Unit)
+			fmt.Fprintf(w, "Owned\t%s %s\t%s %s\n", tokens.Owned, tokens.Unit, tokens.UnconfirmedOwned, tokens.Unit)
+			return w.Flush()
+		},
+	}
+
+	nodeQueryProverRingCmd := &cobra.Command{
+		Use:   "prover-ring",
+		Short: "Show the node's prover ring and seniority",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			via, _ := cmd.Flags().GetString("via")
+			ring, err := loadNodeClient().GetProverRing(via)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(ring)
+			}
+
+			proverRing := "none"
+			if ring.ProverRing >= 0 {
+				proverRing = fmt.Sprintf("%d", ring.ProverRing)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PEER ID\tPROVER RING\tSENIORITY")
+			fmt.Fprintf(w, "%s\t%s\t%s\n", ring.PeerID, proverRing, ring.Seniority)
+			return w.Flush()
+		},
+	}
+
+	nodeQueryNetworkCmd := &cobra.Command{
+		Use:   "network",
+		Short: "List connected peers (use --count for the number of peers seen)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			via, _ := cmd.Flags().GetString("via")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			nc := loadNodeClient()
+
+			if countOnly, _ := cmd.Flags().GetBool("count"); countOnly {
+				count, err := nc.GetPeerCount(via)
+				if err != nil {
+					return err
+				}
+				if jsonOutput {
+					return printJSON(count)
+				}
+				fmt.Println(count.Peers + count.Uncooperative)
+				return nil
+			}
+
+			info, err := nc.GetNetworkInfo(via)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(info)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PEER ID\tSCORE\tMULTIADDRS")
+			for _, peer := range info.Peers {
+				fmt.Fprintf(w, "%s\t%g\t%s\n", peer.PeerID, peer.PeerScore, strings.Join(peer.Multiaddrs, ","))
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("\n%d peers (via %s)\n", len(info.Peers), info.Transport)
+			return nil
+		},
+	}
+	nodeQueryNetworkCmd.Flags().Bool("count", false, "Print the number of peers seen (GetPeerInfo) instead of the peer list")
+
+	nodeQuerySeniorityListCmd := &cobra.Command{
+		Use:   "seniority-list <configs_dir>",
+		Short: "Run node --node-info for each config in a directory and list seniority, highest first",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			nodeBinary, _ := cmd.Flags().GetString("node-binary")
+			entries, err := loadNodeClient().ListSeniority(args[0], nodeBinary)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(entries)
+			}
+
+			if len(entries) == 0 {
+				return fmt.Errorf("no configs found in %s", args[0])
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PEER ID\tSENIORITY\tCONFIG")
+			for _, entry := range entries {
+				if entry.Error != "" {
+					fmt.Fprintf(w, "-\t-\t%s (%s)\n", entry.Config, entry.Error)
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", entry.PeerID, entry.Seniority, entry.Config)
+			}
+			return w.Flush()
+		},
+	}
+	nodeQuerySeniorityListCmd.Flags().String("node-binary", "", "Node binary to run (default: service.link_name)")
+
+	for _, queryCmd := range []*cobra.Command{nodeQueryFramesCmd, nodeQueryTokensCmd, nodeQueryProverRingCmd,
+		nodeQueryNetworkCmd, nodeQuerySeniorityListCmd} {
+		queryCmd.Flags().Bool("json", false, "Output in JSON format")
+		if queryCmd != nodeQuerySeniorityListCmd {
+			queryCmd.Flags().String("via", "", "Force a transport: grpc, rest or binary (default: try each in order)")
+		}
+	}
+	nodeQueryCmd.AddCommand(nodeQueryFramesCmd, nodeQueryTokensCmd, nodeQueryProverRingCmd,
+		nodeQueryNetworkCmd, nodeQuerySeniorityListCmd)
+
+	// rollingOptionsFromFlags reads --batch/--pause/--timeout and prints each batch as it progresses
+	rollingOptionsFromFlags := func(cmd *cobra.Command, cores string) service.RollingOptions {
+		batch, _ := cmd.Flags().GetInt("batch")
+		pause, _ := cmd.Flags().GetDuration("pause")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		return service.RollingOptions{
+			BatchSize: batch,
+			Pause:     pause,
+			Timeout:   timeout,
+			Cores:     cores,
+			Progress: func(event service.RollingEvent) {
+				if event.Batch == 0 {
+					if event.Phase == service.RollingPhaseMaster {
+						fmt.Println("Restarting master...")
+					} else {
+						fmt.Printf("✓ Master restarted (%s)\n", event.Duration.Round(time.Millisecond))
+					}
+					return
+				}
+				prefix := fmt.Sprintf("Batch %d/%d (workers %s)", event.Batch, event.Batches, service.FormatWorkerList(event.Workers))
+				switch event.Phase {
+				case service.RollingPhaseRestarting:
+					fmt.Printf("%s: restarting...\n", prefix)
+				case service.RollingPhaseWaiting:
+					fmt.Printf("%s: waiting for workers to become active and listen...\n", prefix)
+				case service.RollingPhaseHealthy:
+					fmt.Printf("✓ %s healthy (%s)\n", prefix, event.Duration.Round(time.Millisecond))
+				case service.RollingPhaseFailed:
+					fmt.Printf("✗ %s failed\n", prefix)
+				}
+			},
+		}
+	}
+
+	// addRollingFlags adds the rolling restart flags to a command
+	addRollingFlags := func(cmd *cobra.Command) {
+		cmd.Flags().Bool("rolling", false, "Restart workers in batches, waiting for each batch to be healthy (manual mode)")
+		cmd.Flags().Int("batch", service.DefaultRollingBatchSize, "Workers per batch for --rolling")
+		cmd.Flags().Duration("pause", service.DefaultRollingPause, "Pause after each healthy batch for --rolling")
+		cmd.Flags().Duration("timeout", service.DefaultRollingTimeout, "How long each batch may take to become healthy for --rolling")
+	}
+
+	nodeUpdateCmd := &cobra.Command{
+		Use:   "update [flags]",
+		Short: "Update node binary",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
+
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				cfg = config.GenerateDefaultConfig()
+			}
+
+			force, _ := cmd.Flags().GetBool("force")
+			skipClean, _ := cmd.Flags().GetBool("skip-clean")
+			auto, _ := cmd.Flags().GetBool("auto")
+
+			opts := node.UpdateOptions{
+				Force:     force,
+				SkipClean: skipClean,
+				Auto:      auto,
+			}
+
+			if err := node.UpdateNode(opts, cfg); err != nil {
+				return err
+			}
+
+			// Update service files and restart after node update
+			fmt.Println("Updating service files...")
+			if warning := service.LingerWarning(cfg); warning != "" {
+				fmt.Printf("Warning: %s\n", warning)
+			}
+			updated, removed, err := service.UpdateServiceFiles(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to update service files: %w", err)
+			}
+			for _, file := range updated {
+				if file.Rendered == nil {
+					fmt.Printf("✓ Removed %s\n", file.Path)
+				} else {
+					fmt.Printf("✓ Updated %s\n", file.Path)
+				}
+			}
+			for _, path := range removed {
+				fmt.Printf("✓ Removed %s (workers now use the template unit)\n", path)
+			}
+
+			// Restart service
+			rolling, _ := cmd.Flags().GetBool("rolling")
+			if rolling && !node.IsManualMode(cfg) {
+				fmt.Println("Warning: --rolling requires manual mode; restarting normally")
+				rolling = false
+			}
+			if rolling {
+				fmt.Println("Restarting service (rolling)...")
+				if err := service.RollingRestart(rollingOptionsFromFlags(cmd, ""), cfg); err != nil {
+					return fmt.Errorf("failed to restart service: %w", err)
+				}
+			} else {
+				fmt.Println("Restarting service...")
+				if err := service.RestartService(service.RestartOptions{}, cfg); err != nil {
+					return fmt.Errorf("failed to restart service: %w", err)
+				}
+			}
+
+			// Wait for the restarted node to answer with the new version
+			if healthTimeout, _ := cmd.Flags().GetDuration("health-timeout"); healthTimeout > 0 {
+				fmt.Printf("Waiting for node %s to answer...\n", cfg.CurrentNodeVersion)
+				ctx, cancel := context.WithTimeout(cmd.Context(), healthTimeout)
+				defer cancel()
+				health, err := client.WaitForNodeHealth(ctx, loadNodeClient(), cfg.CurrentNodeVersion, 5*time.Second)
+				if err != nil {
+					return fmt.Errorf("node update health check failed after %s: %w", healthTimeout, err)
+				}
+				fmt.Printf("✓ Node %s answering via %s (frame %d)\n", health.Version, health.Transport, health.MaxFrame)
+			}
+
+			fmt.Println("Node update completed successfully")
+			return nil
+		},
+	}
+	nodeUpdateCmd.Flags().Bool("force", false, "Force update")
+	nodeUpdateCmd.Flags().Duration("health-timeout", 0, "Wait up to this long (e.g. 2m) for the restarted node to answer over gRPC or REST with the new version (default: no check)")
+	addRollingFlags(nodeUpdateCmd)
+	nodeUpdateCmd.Flags().Bool("skip-clean", false, "Skip cleanup")
+	nodeUpdateCmd.Flags().Bool("auto", false, "Auto-update mode")
+
+	nodeDownloadCmd := &cobra.Command{
+		Use:   "download [flags]",
+		Short: "Download node binary",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
+
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				cfg = config.GenerateDefaultConfig()
+			}
+
+			version, _ := cmd.Flags().GetString("version")
+			link, _ := cmd.Flags().GetBool("link")
+
+			return node.DownloadNode(cfg, version, link)
+		},
+	}
+	nodeDownloadCmd.Flags().String("version", "", "Specific version to download (default: latest)")
+	nodeDownloadCmd.Flags().Bool("link", false, "Create symlink after download")
+
+	nodeCmd.AddCommand(setupCmd, modeCmd, installCmd, nodeConfigCmd, nodeInfoCmd, nodePeerIDCmd, nodeIdentityCmd, 
+		nodeBalanceCmd, nodeSeniorityCmd, nodeWorkerCountCmd, nodeQueryCmd, nodeUpdateCmd, nodeDownloadCmd)
+
+	// Service commands
+	serviceCmd := &cobra.Command{
+		Use:   "service",
+		Short: "Service management commands",
+	}
+
+	// loadServiceConfig loads the qtools config for service commands
+	loadServiceConfig := func() *config.Config {
+		configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+		if configPath == "" {
+			configPath = "/home/quilibrium/qtools/config.yml"
+		}
+
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			cfg = config.GenerateDefaultConfig()
+		}
+		for _, warning := range cfg.Warnings() {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+		return cfg
+	}
+
+	// warnLinger warns when user services will not survive logout
+	warnLinger := func(cfg *config.Config) {
+		if warning := service.LingerWarning(cfg); warning != "" {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+	}
+
+	// workerOptionsFromFlags reads --concurrency/--continue-on-error and prints each worker's result as it finishes
+	workerOptionsFromFlags := func(cmd *cobra.Command) service.WorkerOptions {
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		continueOnError, _ := cmd.Flags().GetBool("continue-on-error")
+		return service.WorkerOptions{
+			Concurrency:     concurrency,
+			ContinueOnError: continueOnError,
+			Progress: func(event service.WorkerEvent) {
+				switch {
+				case event.Skipped:
+					fmt.Printf("[%d/%d] - %s skipped\n", event.Done, event.Total, event.Service)
+				case event.Err != nil:
+					fmt.Printf("[%d/%d] ✗ %s: %s\n", event.Done, event.Total, event.Service, strings.Join(strings.Fields(event.Err.Error()), " "))
+				default:
+					fmt.Printf("[%d/%d] ✓ %s (%s)\n", event.Done, event.Total, event.Service, event.Duration.Round(time.Millisecond))
+				}
+			},
+		}
+	}
+
+	// reportServiceError prints the per-worker summary table for worker failures
+	reportServiceError := func(err error) error {
+		var workerErrs *service.WorkerErrors
+		if errors.As(err, &workerErrs) {
+			fmt.Println()
+			fmt.Print(workerErrs.Summary())
+			return fmt.Errorf("failed to %s %d of %d workers", workerErrs.Action, len(workerErrs.Unwrap()), workerErrs.Total)
+		}
+		return err
+	}
+
+	orphanedSuffix := func(process service.NodeProcess) string {
+		if process.Orphaned {
+			return " (orphaned)"
+		}
+		return ""
+	}
+
+	startCmd := &cobra.Command{
+		Use:   "start [flags]",
+		Short: "Start service(s)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			master, _ := cmd.Flags().GetBool("master")
+			coreIndex, _ := cmd.Flags().GetInt("core-index")
+			cores, _ := cmd.Flags().GetString("cores")
+
+			cfg := loadServiceConfig()
+			warnLinger(cfg)
+			err := service.StartService(service.StartOptions{
+				WorkerOptions: workerOptionsFromFlags(cmd),
+				MasterOnly:    master,
+				CoreIndex:     coreIndex,
+				Cores:         cores,
+			}, cfg)
+			if err != nil {
+				return reportServiceError(err)
+			}
+
+			fmt.Println("✓ Service started")
+			return nil
+		},
+	}
+	startCmd.Flags().Bool("master", false, "Start master only")
+	startCmd.Flags().Int("core-index", 0, "Start specific worker by core index")
+	startCmd.Flags().String("cores", "", "Start specific workers by core numbers (e.g., '1-4,6,8')")
+
+	stopCmd := &cobra.Command{
+		Use:   "stop [flags]",
+		Short: "Stop service(s)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			master, _ := cmd.Flags().GetBool("master")
+			coreIndex, _ := cmd.Flags().GetInt("core-index")
+			cores, _ := cmd.Flags().GetString("cores")
+			kill, _ := cmd.Flags().GetBool("kill")
+			grace, _ := cmd.Flags().GetDuration("grace")
+
+			forced := make(map[int]bool)
+			err := service.StopService(service.StopOptions{
+				WorkerOptions: workerOptionsFromFlags(cmd),
+				MasterOnly:    master,
+				CoreIndex:     coreIndex,
+				Cores:         cores,
+				Kill:          kill,
+				GracePeriod:   grace,
+				OnSignal: func(t service.Termination) {
+					forced[t.PID] = true
+					if kill {
+						fmt.Printf("Sending %s to %s (PID %d)\n", t.Signal, t.Label(), t.PID)
+					} else {
+						fmt.Printf("Warning: %s (PID %d) is still running; sending %s\n", t.Label(), t.PID, t.Signal)
+					}
+				},
+			}, loadServiceConfig())
+			if err != nil {
+				return reportServiceError(err)
+			}
+
+			fmt.Println("✓ Service stopped")
+			if len(forced) > 0 && !kill {
+				fmt.Printf("Warning: %d process(es) did not stop on SIGINT and were forced\n", len(forced))
+			}
+			return nil
+		},
+	}
+	stopCmd.Flags().Bool("master", false, "Stop master only")
+	stopCmd.Flags().Int("core-index", 0, "Stop specific worker by core index")
+	stopCmd.Flags().String("cores", "", "Stop specific workers by core numbers")
+	stopCmd.Flags().Bool("kill", false, "Send SIGKILL instead of waiting for the node to shut down")
+	stopCmd.Flags().Duration("grace", 0, "Wait after SIGINT before sending SIGTERM, then SIGKILL (default: service.stop_grace_period or 3m)")
+
+	restartCmd := &cobra.Command{
+		Use:   "restart [flags]",
+		Short: "Restart service(s)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			master, _ := cmd.Flags().GetBool("master")
+			coreIndex, _ := cmd.Flags().GetInt("core-index")
+			cores, _ := cmd.Flags().GetString("cores")
+
+			if rolling, _ := cmd.Flags().GetBool("rolling"); rolling {
+				if err := service.RollingRestart(rollingOptionsFromFlags(cmd, cores), loadServiceConfig()); err != nil {
+					return reportServiceError(err)
+				}
+				fmt.Println("✓ Rolling restart completed")
+				return nil
+			}
+
+			err := service.RestartService(service.RestartOptions{
+				WorkerOptions: workerOptionsFromFlags(cmd),
+				MasterOnly:    master,
+				CoreIndex:     coreIndex,
+				Cores:         cores,
+			}, loadServiceConfig())
+			if err != nil {
+				return reportServiceError(err)
+			}
+
+			fmt.Println("✓ Service restarted")
+			return nil
+		},
+	}
+	restartCmd.Flags().Bool("master", false, "Restart master only")
+	restartCmd.Flags().Int("core-index", 0, "Restart specific worker by core index")
+	restartCmd.Flags().String("cores", "", "Restart specific workers by core numbers")
+	addRollingFlags(restartCmd)
+
+	for _, workerCmd := range []*cobra.Command{startCmd, stopCmd, restartCmd} {
+		workerCmd.Flags().Int("concurrency", 0, "Workers acted on at once (default: service.worker_service.concurrency or 8)")
+		workerCmd.Flags().Bool("continue-on-error", false, "Keep going when a worker fails and report all failures at the end")
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Get service status",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
+
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				cfg = config.GenerateDefaultConfig()
+			}
+
+			workerIndex, _ := cmd.Flags().GetInt("worker")
+			status, err := service.GetStatus(service.StatusOptions{WorkerIndex: workerIndex}, cfg)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(status)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SERVICE\tSTATE\tPID\tUPTIME\tRESTARTS\tMEMORY\tCPU\tRESULT")
+			printStatus := func(st *service.ServiceStatus) {
+				uptime, memory, cpu := "-", "-", "-"
+				if d := st.Uptime(); d > 0 {
+					uptime = service.FormatDuration(d)
+				}
+				if st.MemoryBytes > 0 {
+					memory = service.FormatBytes(st.MemoryBytes)
+				}
+				if st.CPUUsage > 0 {
+					cpu = service.FormatDuration(st.CPUUsage)
+				}
+				result := st.Result
+				if st.ExitCode != 0 {
+					result = fmt.Sprintf("%s (exit %d)", result, st.ExitCode)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\t%s\t%s\t%s\n",
+					st.Name, st.State(), st.PID, uptime, st.Restarts, memory, cpu, result)
+			}
+
+			if workerIndex == 0 && status.Master != nil && status.Master.Name != "" {
+				printStatus(status.Master)
+			}
+			indexes := make([]int, 0, len(status.Workers))
+			for i := range status.Workers {
+				indexes = append(indexes, i)
+			}
+			sort.Ints(indexes)
+			for _, i := range indexes {
+				printStatus(status.Workers[i])
+			}
+			return w.Flush()
+		},
+	}
+	statusCmd.Flags().Int("worker", 0, "Show only the given worker (manual mode)")
+	statusCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	serviceEnableCmd := &cobra.Command{
 		Use:   "enable",
 		Short: "Enable service on boot",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			fmt.Println("Enabling service...")
-			// TODO: Implement service enable
+			cfg := loadServiceConfig()
+			warnLinger(cfg)
+			if err := service.EnableServices(workerOptionsFromFlags(cmd), cfg); err != nil {
+				return reportServiceError(err)
+			}
+
+			fmt.Println("✓ Service enabled")
+			return nil
+		},
+	}
+
+	serviceDisableCmd := &cobra.Command{
+		Use:   "disable",
+		Short: "Disable service on boot",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println("Disabling service...")
+			if err := service.DisableServices(workerOptionsFromFlags(cmd), loadServiceConfig()); err != nil {
+				return reportServiceError(err)
+			}
+
+			fmt.Println("✓ Service disabled")
+			return nil
+		},
+	}
+
+	for _, bootCmd := range []*cobra.Command{serviceEnableCmd, serviceDisableCmd} {
+		bootCmd.Flags().Int("concurrency", 0, "Workers acted on at once (default: service.worker_service.concurrency or 8)")
+		bootCmd.Flags().Bool("continue-on-error", false, "Keep going when a worker fails and report all failures at the end")
+	}
+
+	serviceUpdateCmd := &cobra.Command{
+		Use:   "update [flags]",
+		Short: "Update service configuration",
+		Long:  "Regenerate the service files from config and write those that changed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			if dryRun {
+				files, err := service.LoadServiceFiles(cfg)
+				if err != nil {
+					return err
+				}
+				changed := 0
+				for _, file := range files {
+					if file.Changed() {
+						changed++
+						fmt.Print(file.Diff())
+					}
+				}
+				if changed == 0 {
+					fmt.Println("Service files are up to date")
+				}
+				return nil
+			}
+
+			fmt.Println("Updating service configuration...")
+			warnLinger(cfg)
+			updated, removed, err := service.UpdateServiceFiles(cfg)
+			for _, file := range updated {
+				if file.Rendered == nil {
+					fmt.Printf("✓ Removed %s\n", file.Path)
+				} else {
+					fmt.Printf("✓ Updated %s\n", file.Path)
+				}
+				fmt.Print(file.Diff())
+			}
+			for _, path := range removed {
+				fmt.Printf("✓ Removed %s (workers now use the template unit)\n", path)
+			}
+			if err != nil {
+				return err
+			}
+			if len(updated) == 0 && len(removed) == 0 {
+				fmt.Println("Service files are up to date")
+			}
+			return nil
+		},
+	}
+	serviceUpdateCmd.Flags().Bool("dry-run", false, "Show what would change without writing")
+
+	serviceRenderCmd := &cobra.Command{
+		Use:   "render [flags]",
+		Short: "Print the generated service file",
+		Long:  "Print the generated service file and, when service.overrides are set, its drop-in (headed by path like systemctl cat)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			worker, _ := cmd.Flags().GetInt("worker")
+			files, err := service.LoadServiceFilesFor(loadServiceConfig(), worker)
+			if err != nil {
+				return err
+			}
+			var rendered []*service.ServiceFile
+			for _, file := range files {
+				if file.Rendered != nil {
+					rendered = append(rendered, file)
+				}
+			}
+			for i, file := range rendered {
+				if len(rendered) > 1 {
+					if i > 0 {
+						fmt.Println()
+					}
+					fmt.Printf("# %s\n", file.Path)
+				}
+				fmt.Print(string(file.Rendered))
+			}
+			return nil
+		},
+	}
+	serviceRenderCmd.Flags().Int("worker", 0, "Render the service file for this worker instead of the master")
+
+	serviceDiffCmd := &cobra.Command{
+		Use:   "diff [flags]",
+		Short: "Show differences between the installed and generated service files",
+		Long:  "Show a unified diff from the installed service files to the ones generated from config (master and, in manual mode, the worker template)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			var files []*service.ServiceFile
+			if cmd.Flags().Changed("worker") {
+				worker, _ := cmd.Flags().GetInt("worker")
+				var err error
+				if files, err = service.LoadServiceFilesFor(cfg, worker); err != nil {
+					return err
+				}
+			} else {
+				var err error
+				if files, err = service.LoadServiceFiles(cfg); err != nil {
+					return err
+				}
+			}
+
+			changed := 0
+			for _, file := range files {
+				if diff := file.Diff(); diff != "" {
+					changed++
+					fmt.Print(diff)
+				}
+			}
+			if changed == 0 {
+				fmt.Println("Service files are up to date")
+			}
 			return nil
 		},
 	}
+	serviceDiffCmd.Flags().Int("worker", 0, "Only diff this worker's service file (0 for the master)")
 
-	serviceDisableCmd := &cobra.Command{
-		Use:   "disable",
-		Short: "Disable service on boot",
+	serviceEventsCmd := &cobra.Command{
+		Use:   "events [flags]",
+		Short: "Show start, stop, crash and restart events from the journal",
+		Long:  "Show start, stop, crash, OOM kill and restart events of the master and workers from the systemd journal, with per-service counts",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Disabling service...")
-			// TODO: Implement service disable
-			return nil
+			cfg := loadServiceConfig()
+			worker, _ := cmd.Flags().GetInt("worker")
+			since, _ := cmd.Flags().GetDuration("since")
+			file, _ := cmd.Flags().GetString("file")
+
+			opts := service.EventOptions{WorkerIndex: worker, File: file}
+			if since > 0 {
+				opts.Since = time.Now().Add(-since)
+			}
+			events, err := service.LoadServiceEvents(cfg, opts)
+			if err != nil {
+				return err
+			}
+			summaries := service.SummarizeEvents(events)
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(map[string]interface{}{
+					"events":  events,
+					"summary": summaries,
+				})
+			}
+
+			if len(events) == 0 {
+				fmt.Println("No service events found")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tSERVICE\tEVENT\tDETAIL")
+			for _, event := range events {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", event.Time.Local().Format("2006-01-02 15:04:05"),
+					strings.TrimSuffix(event.Unit, ".service"), event.Type, event.Detail())
+			}
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "SERVICE\tSTARTS\tSTOPS\tCRASHES\tOOM\tRESTARTS\tLAST CRASH")
+			for _, summary := range summaries {
+				lastCrash := "-"
+				if !summary.LastCrash.IsZero() {
+					lastCrash = service.FormatDuration(time.Since(summary.LastCrash)) + " ago"
+				}
+				fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n", strings.TrimSuffix(summary.Unit, ".service"),
+					summary.Starts, summary.Stops, summary.Crashes, summary.OOMKills, summary.Restarts, lastCrash)
+			}
+			return w.Flush()
 		},
 	}
+	serviceEventsCmd.Flags().Int("worker", 0, "Show only the given worker's events")
+	serviceEventsCmd.Flags().Duration("since", 24*time.Hour, "Show events from this long ago (0 for the whole journal)")
+	serviceEventsCmd.Flags().Bool("json", false, "Output in JSON format")
+	serviceEventsCmd.Flags().String("file", "", "Read journalctl -o json output from a file instead of the journal")
+
+	serviceKillCmd := &cobra.Command{
+		Use:   "kill --cores <cores> [flags]",
+		Short: "Kill stuck worker processes by core",
+		Long: `Stop worker processes by core whether or not their services still respond, including
+orphaned processes no service tracks. Each is sent SIGINT, then SIGTERM after the grace period
+and SIGKILL if it still runs after another grace period. A worker service with Restart=on-failure may start it again.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			coresFlag, _ := cmd.Flags().GetString("cores")
+			grace, _ := cmd.Flags().GetDuration("grace")
+			force, _ := cmd.Flags().GetBool("force")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
 
-	serviceUpdateCmd := &cobra.Command{
-		Use:   "update [flags]",
-		Short: "Update service configuration",
+			cores, err := service.ParseCoreNumbers(coresFlag)
+			if err != nil {
+				return err
+			}
+			cfg := loadServiceConfig()
+
+			if dryRun {
+				processes, err := service.FindNodeProcesses(cores, cfg)
+				if err != nil {
+					return err
+				}
+				if jsonOutput {
+					return printJSON(processes)
+				}
+				for _, process := range processes {
+					fmt.Printf("[DRY RUN] Would kill %s (PID %d)%s\n", process.Label(), process.PID, orphanedSuffix(process))
+				}
+				if len(processes) == 0 {
+					fmt.Printf("No worker processes found for cores %s\n", coresFlag)
+				}
+				return nil
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			results, err := service.KillWorkers(ctx, cores, service.TerminateOptions{
+				GracePeriod: grace,
+				TermTimeout: grace,
+				Force:       force,
+				OnSignal: func(t service.Termination) {
+					if !jsonOutput && !force {
+						fmt.Printf("Warning: %s (PID %d) is still running; sending %s\n", t.Label(), t.PID, t.Signal)
+					}
+				},
+			}, cfg)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				return printJSON(results)
+			}
+
+			failed := 0
+			for _, result := range results {
+				if !result.Stopped {
+					failed++
+					detail := result.Error
+					if detail == "" {
+						detail = "still running after " + result.Signal
+					}
+					fmt.Printf("✗ %s (PID %d)%s: %s\n", result.Label(), result.PID, orphanedSuffix(result.NodeProcess), detail)
+					continue
+				}
+				fmt.Printf("✓ %s (PID %d)%s stopped after %s in %s\n", result.Label(), result.PID,
+					orphanedSuffix(result.NodeProcess), result.Signal, result.Duration.Round(time.Millisecond))
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d processes are still running", failed, len(results))
+			}
+			return nil
+		},
+	}
+	serviceKillCmd.Flags().String("cores", "", "Workers to kill by core numbers (e.g., '1-4,6,8')")
+	serviceKillCmd.Flags().Duration("grace", service.DefaultKillGracePeriod, "Wait after SIGINT before sending SIGTERM, and after SIGTERM before SIGKILL")
+	serviceKillCmd.Flags().Bool("force", false, "Send SIGKILL straight away")
+	serviceKillCmd.Flags().Bool("dry-run", false, "List the processes that would be killed")
+	serviceKillCmd.Flags().Bool("json", false, "Output in JSON format")
+	serviceKillCmd.MarkFlagRequired("cores")
+
+	servicePidCmd := &cobra.Command{
+		Use:   "pid [flags]",
+		Short: "Show node process IDs",
+		Long: `List the running node processes with the service each belongs to. Processes no service
+tracks, e.g., workers left behind by a crashed master, are marked orphaned.
+With --worker, print only that worker's PIDs (0 for the master), one per line.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Updating service configuration...")
-			// TODO: Implement service update
+			cfg := loadServiceConfig()
+			processes, err := service.ListNodeProcesses(cfg)
+			if err != nil {
+				return err
+			}
+
+			if cmd.Flags().Changed("worker") {
+				worker, _ := cmd.Flags().GetInt("worker")
+				processes, err = service.FindNodeProcesses([]int{worker}, cfg)
+				if err != nil {
+					return err
+				}
+				if len(processes) == 0 {
+					if worker == 0 {
+						return fmt.Errorf("master is not running")
+					}
+					return fmt.Errorf("worker %d is not running", worker)
+				}
+			}
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(processes)
+			}
+			if cmd.Flags().Changed("worker") {
+				for _, process := range processes {
+					fmt.Println(process.PID)
+				}
+				return nil
+			}
+
+			if len(processes) == 0 {
+				fmt.Println("No node processes running")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PID\tPPID\tPROCESS\tSERVICE\tCOMMAND")
+			orphaned := 0
+			for _, process := range processes {
+				unit := process.Unit
+				if process.Orphaned {
+					unit = "(orphaned)"
+					orphaned++
+				}
+				fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n", process.PID, process.PPID, process.Label(), unit, strings.Join(process.Command, " "))
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if orphaned > 0 {
+				fmt.Printf("Warning: %d orphaned process(es); stop them with qtools service kill --cores\n", orphaned)
+			}
 			return nil
 		},
 	}
+	servicePidCmd.Flags().Int("worker", 0, "Print only the given worker's PIDs (0 for the master)")
+	servicePidCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	serviceCmd.AddCommand(startCmd, stopCmd, restartCmd, statusCmd, serviceEnableCmd, serviceDisableCmd, serviceUpdateCmd, serviceRenderCmd, serviceDiffCmd, serviceEventsCmd, serviceKillCmd, servicePidCmd)
+
+	// Supervisor command
+	superviseCmd := &cobra.Command{
+		Use:   "supervise",
+		Short: "Run the built-in process supervisor",
+		Long: `Run the master and worker processes without an init system (e.g., as a container's command).
+Services start when enabled or when requested by qtools service start/stop/restart/status,
+which talk to the supervisor over a unix socket. Select it with service.backend: supervisor
+(chosen automatically when systemd is not running). SIGINT or SIGTERM stops all services
+gracefully before exiting.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			paths := supervisor.PathsFromConfig(cfg)
+			if socket, _ := cmd.Flags().GetString("socket"); socket != "" {
+				paths.Socket = socket
+			}
+			opts, err := supervisor.OptionsFromConfig(cfg)
+			if err != nil {
+				return err
+			}
+			opts.Logf = func(format string, args ...interface{}) {
+				fmt.Printf("%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+			}
 
-	serviceCmd.AddCommand(startCmd, stopCmd, restartCmd, statusCmd, serviceEnableCmd, serviceDisableCmd, serviceUpdateCmd)
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return supervisor.New(paths, opts).Run(ctx)
+		},
+	}
+	superviseCmd.Flags().String("socket", "", "Control socket (default: service.supervisor.socket)")
 
 	// Backup commands
 	backupCmd := &cobra.Command{
@@ -519,45 +1930,581 @@ Examples:
 	backupRestoreCmd.Flags().String("peer-id", "", "Peer ID to restore")
 	backupRestoreCmd.Flags().Bool("no-store", false, "Skip store restore")
 
-	backupCmd.AddCommand(backupPeerCmd, backupStoreCmd, backupLocalCmd, backupRestoreCmd)
+	backupCmd.AddCommand(backupPeerCmd, backupStoreCmd, backupLocalCmd, backupRestoreCmd)
+
+	// Diagnostics commands
+	diagnosticsCmd := &cobra.Command{
+		Use:   "diagnostics",
+		Short: "Diagnostic commands",
+	}
+
+	diagnosticsStatusReportCmd := &cobra.Command{
+		Use:   "status-report [flags]",
+		Short: "Generate comprehensive status report",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			status, statusErr := service.GetStatus(service.StatusOptions{}, cfg)
+			report := diagnostics.BuildStatusReport(loadNodeClient(), status, statusErr)
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(report)
+			}
+
+			fmt.Printf("Status report (%s)\n\n", report.GeneratedAt.Format(time.RFC3339))
+			if report.ServiceError != "" {
+				fmt.Printf("✗ Service status: %s\n", report.ServiceError)
+			} else {
+				if report.Services.Master != nil && report.Services.Master.Name != "" {
+					fmt.Printf("Master:          %s\n", report.Services.Master.State())
+				}
+				running := 0
+				for _, worker := range report.Services.Workers {
+					if worker.Running {
+						running++
+					}
+				}
+				if len(report.Services.Workers) > 0 {
+					fmt.Printf("Workers:         %d/%d running\n", running, len(report.Services.Workers))
+				}
+			}
+
+			if report.NodeError != "" {
+				fmt.Printf("✗ Node: %s\n", report.NodeError)
+				return nil
+			}
+			fmt.Printf("Node answering:  %v\n", report.Running)
+			fmt.Printf("Peer ID:         %s\n", report.Node.PeerID)
+			fmt.Printf("Version:         %s\n", report.Node.Version)
+			fmt.Printf("Max Frame:       %d\n", report.Node.MaxFrame)
+			fmt.Printf("Seniority:       %s\n", report.Node.Seniority)
+			fmt.Printf("Active Workers:  %d\n", report.Node.ActiveWorkers)
+			if report.Node.Balance != "" {
+				fmt.Printf("Balance:         %s %s\n", report.Node.Balance, report.Node.BalanceUnit)
+			}
+			if report.Peers != nil {
+				fmt.Printf("Peers:           %d (%d uncooperative)\n", report.Peers.Peers, report.Peers.Uncooperative)
+			}
+			fmt.Printf("Transport:       %s\n", report.Node.Transport)
+			return nil
+		},
+	}
+	diagnosticsStatusReportCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	diagnosticsCheckFilesCmd := &cobra.Command{
+		Use:   "check-files",
+		Short: "Check node file integrity",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println("Checking node files...")
+			// TODO: Implement check files
+			return nil
+		},
+	}
+
+	diagnosticsCheckPortsCmd := &cobra.Command{
+		Use:   "check-ports",
+		Short: "Check planned node ports for conflicts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
+
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				cfg = config.GenerateDefaultConfig()
+			}
+
+			workers, _ := cmd.Flags().GetInt("workers")
+			includeNode, _ := cmd.Flags().GetBool("include-node")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+
+			plan, conflicts, err := node.CheckPortPlan(cfg, workers, includeNode)
+			if err != nil && plan == nil {
+				return err
+			}
+			if err != nil {
+				fmt.Printf("Warning: live socket check failed: %v\n", err)
+			}
+
+			if jsonOutput {
+				data, err := json.MarshalIndent(map[string]interface{}{
+					"plan":      plan,
+					"conflicts": conflicts,
+				}, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal port plan: %w", err)
+				}
+				fmt.Println(string(data))
+			} else {
+				p2pStart, p2pEnd := plan.P2PRange()
+				streamStart, streamEnd := plan.StreamRange()
+				fmt.Printf("Port plan for %d workers:\n", plan.WorkerCount)
+				for _, use := range plan.Ports {
+					if strings.HasPrefix(use.Owner, "master") {
+						fmt.Printf("  %-14s %s/%d\n", use.Owner, use.Protocol, use.Port)
+					}
+				}
+				fmt.Printf("  %-14s tcp/%d-%d\n", "worker p2p", p2pStart, p2pEnd)
+				fmt.Printf("  %-14s tcp/%d-%d\n", "worker stream", streamStart, streamEnd)
+				fmt.Println()
+
+				if len(conflicts) == 0 {
+					fmt.Println("✓ No port conflicts found")
+					return nil
+				}
+				for _, conflict := range conflicts {
+					fmt.Printf("✗ [%s] %s\n", conflict.Kind, conflict.Detail)
+				}
+			}
+
+			if len(conflicts) > 0 {
+				return fmt.Errorf("found %d port conflict(s)", len(conflicts))
+			}
+			return nil
+		},
+	}
+	diagnosticsCheckPortsCmd.Flags().Int("workers", 0, "Worker count to plan for (0 = configured count)")
+	diagnosticsCheckPortsCmd.Flags().Bool("include-node", false, "Also report ports held by a running node process")
+	diagnosticsCheckPortsCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	diagnosticsCheckCrashLoopsCmd := &cobra.Command{
+		Use:   "check-crash-loops",
+		Short: "Detect crash-looping units and apply the configured remediation",
+		Long: `Sample the restart counters of the master and workers and remediate crash loops.
+
+A unit is crash looping when it restarted max_restarts times within window,
+max_restarts_per_hour times within an hour, or systemd gave up restarting it.
+The remediation is set by scheduled_tasks.diagnostics.crash_loop.remediation:
+  notify    only record the action and send a notification
+  backoff   stop the unit and start it again after backoff, doubling each time
+  disable   stop and disable the worker and drop it from manual.worker_count
+  rollback  switch the node symlink to the previous node binary and restart
+
+Restarts are counted between checks, so run this on a schedule or with
+--interval. Every action is appended to the watchdog action log (see
+diagnostics watchdog-history) and sent to settings.notifications.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
+
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			opts, err := watchdog.LoadCrashLoopOptionsFromConfig(cfg)
+			if err != nil {
+				return err
+			}
+			opts.ConfigPath = configPath
+
+			if cmd.Flags().Changed("remediation") {
+				opts.Remediation, _ = cmd.Flags().GetString("remediation")
+				if err := watchdog.ValidateRemediation(opts.Remediation); err != nil {
+					return err
+				}
+			}
+			opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			interval, _ := cmd.Flags().GetDuration("interval")
+
+			opts.Recorder.OnAction = func(action watchdog.Action) {
+				if jsonOutput {
+					data, _ := json.Marshal(action)
+					fmt.Println(string(data))
+					return
+				}
+				prefix := ""
+				if action.DryRun {
+					prefix = "[DRY RUN] "
+				}
+				fmt.Printf("%s%s: %s (%s)\n", prefix, action.Unit, action.Action, action.Reason)
+				if action.Detail != "" {
+					fmt.Printf("  %s\n", action.Detail)
+				}
+				if action.Error != "" {
+					fmt.Printf("✗ %s\n", action.Error)
+				}
+			}
+
+			if interval > 0 {
+				ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				watchdog.WatchCrashLoops(ctx, cfg, opts, interval, func(err error) {
+					fmt.Printf("Warning: %v\n", err)
+				})
+				return nil
+			}
+
+			result, err := watchdog.CheckCrashLoops(cmd.Context(), cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				if len(result.Actions) == 0 {
+					return printJSON(result)
+				}
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "UNIT\tSTATE\tRESTARTS\tLAST %s\tLAST HOUR\tWATCHDOG\n", opts.Window)
+			for _, unit := range result.Units {
+				watch := "ok"
+				switch {
+				case unit.Disabled:
+					watch = "disabled"
+				case unit.BackoffUntil != nil:
+					watch = "backoff until " + unit.BackoffUntil.Format("15:04:05")
+				case unit.CrashLooping:
+					watch = "crash loop"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n", unit.Unit, unit.ActiveState, unit.Restarts, unit.RestartsInWindow, unit.RestartsLastHour, watch)
+			}
+			return w.Flush()
+		},
+	}
+	diagnosticsCheckCrashLoopsCmd.Flags().Duration("interval", 0, "Keep checking at this interval (0 = check once)")
+	diagnosticsCheckCrashLoopsCmd.Flags().String("remediation", "", "Override the remediation (notify, backoff, disable or rollback)")
+	diagnosticsCheckCrashLoopsCmd.Flags().Bool("dry-run", false, "Show remediations without applying them")
+	diagnosticsCheckCrashLoopsCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	diagnosticsCheckMemoryCmd := &cobra.Command{
+		Use:   "check-memory",
+		Short: "Restart the heaviest workers when host memory runs low",
+		Long: `Compare the memory of the master and workers to host memory and restart units.
+
+When host memory use reaches scheduled_tasks.cluster.memory_check.memory_threshold
+percent, the heaviest workers are restarted first until the memory they hold
+would bring use back under the threshold (restart_workers). The master is
+only restarted, last, with restart_master. Workers above worker_threshold_mb
+are restarted regardless of host memory. A unit is not restarted again
+within cooldown.
+
+Every restart is appended to the watchdog action log (see diagnostics
+watchdog-history) and sent to settings.notifications.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			opts := watchdog.LoadMemoryOptionsFromConfig(cfg)
+
+			if cmd.Flags().Changed("threshold") {
+				opts.Threshold, _ = cmd.Flags().GetFloat64("threshold")
+			}
+			if cmd.Flags().Changed("restart-master") {
+				opts.RestartMaster, _ = cmd.Flags().GetBool("restart-master")
+			}
+			opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			interval, _ := cmd.Flags().GetDuration("interval")
+
+			opts.Recorder.OnAction = func(action watchdog.Action) {
+				if jsonOutput {
+					data, _ := json.Marshal(action)
+					fmt.Println(string(data))
+					return
+				}
+				prefix := ""
+				if action.DryRun {
+					prefix = "[DRY RUN] "
+				}
+				fmt.Printf("%s%s: %s (%s)\n", prefix, action.Unit, action.Action, action.Reason)
+				if action.Detail != "" {
+					fmt.Printf("  %s\n", action.Detail)
+				}
+				if action.Error != "" {
+					fmt.Printf("✗ %s\n", action.Error)
+				}
+			}
+
+			if interval > 0 {
+				ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				watchdog.WatchMemory(ctx, cfg, opts, interval, func(err error) {
+					fmt.Printf("Warning: %v\n", err)
+				})
+				return nil
+			}
+
+			result, err := watchdog.CheckMemory(cmd.Context(), cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				if len(result.Actions) == 0 {
+					return printJSON(result)
+				}
+				return nil
+			}
+
+			host := result.Host
+			fmt.Printf("Host memory: %.1f%% used (%s of %s), threshold %.0f%%\n\n", host.UsedPercent,
+				service.FormatBytes(host.TotalBytes-host.AvailableBytes), service.FormatBytes(host.TotalBytes), opts.Threshold)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "UNIT\tPID\tMEMORY\tHOST %\tLAST RESTART")
+			for _, unit := range result.Units {
+				lastRestart := "-"
+				if !unit.LastRestart.IsZero() {
+					lastRestart = unit.LastRestart.Format("2006-01-02 15:04:05")
+				}
+				fmt.Fprintf(w, "%s\t%d\t%s\t%.1f\t%s\n", unit.Unit, unit.PID, service.FormatBytes(unit.Bytes), unit.Percent, lastRestart)
+			}
+			return w.Flush()
+		},
+	}
+	diagnosticsCheckMemoryCmd.Flags().Float64("threshold", 0, "Host memory use, in percent, that triggers restarts (default: memory_threshold)")
+	diagnosticsCheckMemoryCmd.Flags().Bool("restart-master", false, "Also restart the master when restarting workers is not enough")
+	diagnosticsCheckMemoryCmd.Flags().Duration("interval", 0, "Keep checking at this interval (0 = check once)")
+	diagnosticsCheckMemoryCmd.Flags().Bool("dry-run", false, "Show restarts without applying them")
+	diagnosticsCheckMemoryCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	diagnosticsFramesCmd := &cobra.Command{
+		Use:   "frames",
+		Short: "Show frame and proof statistics, optionally restarting a stalled node",
+		Long: `Follow the node log for frames and proofs and show live statistics.
+
+The master's last --lines log lines are read first, then new output is
+followed (journalctl for systemd, the service log file otherwise, or
+--log-file). With --via, the node is queried for its latest frame instead;
+queries carry no proof details.
+
+With --auto-restart, the node is restarted when no frame arrived within
+--stall-threshold (275s). After N restarts, the next one waits
+threshold*(N+1) after the last; the count resets once that passes without
+a stall. Restarts are recorded like other watchdog actions.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			limit, _ := cmd.Flags().GetInt("limit")
+			lines, _ := cmd.Flags().GetInt("lines")
+			interval, _ := cmd.Flags().GetDuration("interval")
+			oneShot, _ := cmd.Flags().GetBool("one-shot")
+			display, _ := cmd.Flags().GetBool("display")
+			via, _ := cmd.Flags().GetString("via")
+			logFile, _ := cmd.Flags().GetString("log-file")
+			autoRestart, _ := cmd.Flags().GetBool("auto-restart")
+			threshold, _ := cmd.Flags().GetDuration("stall-threshold")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+
+			if oneShot && via != "" {
+				return fmt.Errorf("--one-shot reads the node log and cannot be combined with --via")
+			}
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			openLog := func(lines int, follow bool) (io.ReadCloser, error) {
+				if logFile != "" {
+					return service.OpenLogFile(ctx, logFile, lines, follow)
+				}
+				return service.MasterLog(ctx, cfg, lines, follow)
+			}
+
+			tracker := frames.NewTracker(limit)
+			readErrs := make(chan error, 1)
+			if via != "" {
+				go frames.PollNode(ctx, client.NewNodeClient(cfg), via, 5*time.Second, tracker, func(err error) {
+					if jsonOutput {
+						return
+					}
+					fmt.Printf("Warning: %v\n", err)
+				})
+			} else {
+				history, err := openLog(lines, false)
+				if err != nil {
+					return err
+				}
+				err = frames.ReadLog(history, tracker)
+				history.Close()
+				if err != nil {
+					return fmt.Errorf("failed to read node log: %w", err)
+				}
+
+				if !oneShot {
+					live, err := openLog(0, true)
+					if err != nil {
+						return err
+					}
+					defer live.Close()
+					go func() { readErrs <- frames.ReadLog(live, tracker) }()
+				}
+			}
+
+			var stall *watchdog.StallWatchdog
+			if autoRestart {
+				recorder := watchdog.NewRecorder(cfg)
+				stall = watchdog.NewStallWatchdog(watchdog.StallOptions{Threshold: threshold, DryRun: dryRun, Recorder: recorder})
+			}
+			var lastAction *watchdog.Action
+
+			render := func() error {
+				now := time.Now()
+				stats := tracker.Stats(now)
+				if jsonOutput {
+					data, err := json.Marshal(map[string]interface{}{"stats": stats, "frames": tracker.Frames(), "last_action": lastAction})
+					if err != nil {
+						return fmt.Errorf("failed to marshal frame stats: %w", err)
+					}
+					fmt.Println(string(data))
+					return nil
+				}
+
+				if !oneShot {
+					fmt.Print("\033[H\033[2J")
+				}
+				fmt.Println("Frame Statistics")
+				fmt.Printf("Last updated: %s", now.Format("2006-01-02 15:04:05"))
+				if !oneShot {
+					fmt.Printf(" (every %s)", interval)
+				}
+				fmt.Println()
+				fmt.Println()
+
+				if display {
+					fmt.Println("Frame age when received / proof started / proof submitted (proof duration)")
+					for _, frame := range tracker.Frames() {
+						if frame.ReceivedAt.IsZero() {
+							continue
+						}
+						received := frame.ReceivedAt.Format("15:04:05")
+						switch {
+						case frame.ProofCompleted:
+							fmt.Printf("  %s  Frame %d (ring %d, %d workers): %.2f / %.2f / %.2f (%.2fs)\n", received, frame.Number, frame.Ring, frame.Workers,
+								frame.ReceivedAge, frame.ProofStartedAge, frame.ProofCompletedAge, frame.ProofDuration())
+						case frame.ProofStarted:
+							fmt.Printf("  %s  Frame %d: proof started at %.2f (in progress)\n", received, frame.Number, frame.ProofStartedAge)
+						default:
+							fmt.Printf("  %s  Frame %d: received at %.2f (no proof started)\n", received, frame.Number, frame.ReceivedAge)
+						}
+					}
+					fmt.Println()
+				}
+
+				if stats.Frames == 0 {
+					fmt.Println("No frames received yet")
+				} else {
+					w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					fmt.Fprintf(w, "Last frame:\t%d\n", stats.LastFrame)
+					fmt.Fprintf(w, "Frames tracked:\t%d\n", stats.Frames)
+					fmt.Fprintf(w, "Proofs submitted:\t%d (%.2f per frame, %.1f per hour)\n", stats.Proofs, stats.ProofRate, stats.ProofsPerHour)
+					fmt.Fprintf(w, "Average time between frames:\t%.2fs\n", stats.AvgFrameInterval)
+					if stats.Proofs > 0 {
+						fmt.Fprintf(w, "Average frame age:\t%.2fs received, %.2fs evaluating, %.2fs proving, %.2fs submitted\n",
+							stats.AvgReceivedAge, stats.AvgEvaluationTime, stats.AvgProofDuration, stats.AvgCompletedAge)
+					}
+					fmt.Fprintf(w, "Last frame received:\t%s (%s ago)\n", stats.LastFrameAt.Format("2006-01-02 15:04:05"), stats.SinceLastFrame.Round(time.Second))
+					if stall != nil {
+						restarts := fmt.Sprintf("%d", stall.Restarts())
+						if last := stall.LastRestart(); !last.IsZero() {
+							restarts += fmt.Sprintf(" (last %s ago)", now.Sub(last).Round(time.Second))
+						}
+						fmt.Fprintf(w, "Stall restarts:\t%s\n", restarts)
+					}
+					if err := w.Flush(); err != nil {
+						return err
+					}
+				}
 
-	// Diagnostics commands
-	diagnosticsCmd := &cobra.Command{
-		Use:   "diagnostics",
-		Short: "Diagnostic commands",
-	}
+				if lastAction != nil {
+					prefix := ""
+					if lastAction.DryRun {
+						prefix = "[DRY RUN] "
+					}
+					fmt.Printf("\n%s%s: %s (%s) at %s\n", prefix, lastAction.Unit, lastAction.Action, lastAction.Reason, lastAction.Time.Format("15:04:05"))
+					if lastAction.Error != "" {
+						fmt.Printf("✗ %s\n", lastAction.Error)
+					}
+				}
+				return nil
+			}
 
-	diagnosticsStatusReportCmd := &cobra.Command{
-		Use:   "status-report [flags]",
-		Short: "Generate comprehensive status report",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			json, _ := cmd.Flags().GetBool("json")
-			fmt.Printf("Generating status report (json: %v)\n", json)
-			// TODO: Implement status report
-			return nil
-		},
-	}
-	diagnosticsStatusReportCmd.Flags().Bool("json", false, "Output in JSON format")
+			if oneShot {
+				return render()
+			}
 
-	diagnosticsCheckFilesCmd := &cobra.Command{
-		Use:   "check-files",
-		Short: "Check node file integrity",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Checking node files...")
-			// TODO: Implement check files
-			return nil
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				if stall != nil {
+					if action := stall.Check(cfg, tracker.LastFrameAt(), time.Now()); action != nil {
+						lastAction = action
+					}
+				}
+				if err := render(); err != nil {
+					return err
+				}
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case err := <-readErrs:
+					if ctx.Err() != nil {
+						return nil
+					}
+					if err != nil {
+						return fmt.Errorf("failed to follow node log: %w", err)
+					}
+					return fmt.Errorf("node log ended")
+				case <-ticker.C:
+				}
+			}
 		},
 	}
-
-	diagnosticsCheckPortsCmd := &cobra.Command{
-		Use:   "check-ports",
-		Short: "Check listening ports",
+	diagnosticsFramesCmd.Flags().IntP("limit", "l", frames.DefaultLimit, "Number of recent frames to keep statistics for")
+	diagnosticsFramesCmd.Flags().Int("lines", 5000, "Log lines to read for past frames")
+	diagnosticsFramesCmd.Flags().DurationP("interval", "u", 25*time.Second, "Update interval")
+	diagnosticsFramesCmd.Flags().BoolP("one-shot", "o", false, "Show statistics for past frames and exit")
+	diagnosticsFramesCmd.Flags().BoolP("display", "d", false, "Show each frame")
+	diagnosticsFramesCmd.Flags().String("via", "", "Query the node for frames over this transport (grpc, rest or binary) instead of reading the log")
+	diagnosticsFramesCmd.Flags().String("log-file", "", "Read this node log file instead of the service log")
+	diagnosticsFramesCmd.Flags().Bool("auto-restart", false, "Restart the node when no frame arrives (see --stall-threshold)")
+	diagnosticsFramesCmd.Flags().Duration("stall-threshold", watchdog.DefaultStallThreshold, "Time without a frame before restarting (with --auto-restart)")
+	diagnosticsFramesCmd.Flags().Bool("dry-run", false, "Show restarts without applying them")
+	diagnosticsFramesCmd.Flags().Bool("json", false, "Output statistics as JSON lines")
+
+	diagnosticsWatchdogHistoryCmd := &cobra.Command{
+		Use:   "watchdog-history",
+		Short: "Show actions taken by the watchdogs",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Checking ports...")
-			// TODO: Implement check ports
-			return nil
+			limit, _ := cmd.Flags().GetInt("limit")
+			actions, err := watchdog.ReadActions(watchdog.ActionLogPath(), limit)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				return printJSON(actions)
+			}
+
+			if len(actions) == 0 {
+				fmt.Println("No watchdog actions recorded")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tWATCHDOG\tUNIT\tACTION\tREASON\tDETAIL")
+			for _, action := range actions {
+				detail := action.Detail
+				if action.Error != "" {
+					detail = "✗ " + action.Error
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", action.Time.Format("2006-01-02 15:04:05"), action.Watchdog, action.Unit, action.Action, action.Reason, detail)
+			}
+			return w.Flush()
 		},
 	}
+	diagnosticsWatchdogHistoryCmd.Flags().IntP("limit", "n", 20, "Show at most this many recent actions (0 = all)")
+	diagnosticsWatchdogHistoryCmd.Flags().Bool("json", false, "Output in JSON format")
 
 	diagnosticsRunCmd := &cobra.Command{
 		Use:   "run",
@@ -569,7 +2516,7 @@ Examples:
 		},
 	}
 
-	diagnosticsCmd.AddCommand(diagnosticsStatusReportCmd, diagnosticsCheckFilesCmd, diagnosticsCheckPortsCmd, diagnosticsRunCmd)
+	diagnosticsCmd.AddCommand(diagnosticsStatusReportCmd, diagnosticsCheckFilesCmd, diagnosticsCheckPortsCmd, diagnosticsCheckCrashLoopsCmd, diagnosticsCheckMemoryCmd, diagnosticsFramesCmd, diagnosticsWatchdogHistoryCmd, diagnosticsRunCmd)
 
 	// Update commands
 	updateCmd := &cobra.Command{
@@ -694,6 +2641,60 @@ Examples:
 	qclientCmd.AddCommand(qclientDownloadCmd, qclientCreateSymlinkCmd)
 
 	// Toggle commands
+	// schedulerConfigPath returns the config file the scheduler settings are saved to
+	schedulerConfigPath := func() string {
+		if configPath := os.Getenv("QTOOLS_CONFIG_FILE"); configPath != "" {
+			return configPath
+		}
+		return "/home/quilibrium/qtools/config.yml"
+	}
+
+	// setSchedulerBackend records how scheduled tasks run in settings.scheduler.backend
+	setSchedulerBackend := func(backend string) error {
+		configPath := schedulerConfigPath()
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if err := config.SetConfigValue(cfg, "settings.scheduler.backend", backend); err != nil {
+			return fmt.Errorf("failed to set config value: %w", err)
+		}
+		if err := config.SaveConfig(cfg, configPath); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		return nil
+	}
+
+	// syncScheduledTasks reinstalls the crontab entries or systemd timers after a task is toggled
+	// Enabling a task qtools cannot run, or one the daemon backend would run while the daemon
+	// is not installed, prints a warning instead of implying it will run
+	syncScheduledTasks := func(cfg *config.Config, task string, enabled bool) {
+		unsupported := slices.Contains(scheduler.UnsupportedTasks, task)
+		if enabled && unsupported {
+			fmt.Printf("Warning: %s\n", scheduler.UnsupportedTaskWarning(task))
+		}
+
+		result, err := scheduler.SyncSchedule(cfg)
+		if err != nil {
+			fmt.Printf("Warning: failed to update scheduled tasks: %v\n", err)
+			return
+		}
+		if result != nil {
+			fmt.Printf("Updated the %s schedule (%d tasks).\n", result.Backend, len(result.Installed))
+			return
+		}
+		if !enabled || unsupported {
+			return
+		}
+		path, err := service.CommandServiceFilePath(cfg, scheduler.DaemonServiceName)
+		if err != nil {
+			return
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			fmt.Println("Warning: scheduled tasks run in the qtools daemon, which is not installed; run qtools daemon install")
+		}
+	}
+
 	toggleCmd := &cobra.Command{
 		Use:   "toggle",
 		Short: "Toggle configuration settings",
@@ -763,9 +2764,7 @@ Examples:
 			}
 			fmt.Printf("Node auto-updates have been turned %s.\n", statusText)
 
-			// TODO: Update cron (when install-cron is implemented)
-			// fmt.Println("Updating cron tasks...")
-
+			syncScheduledTasks(cfg, "updates.node", newStatus)
 			return nil
 		},
 	}
@@ -836,8 +2835,7 @@ Examples:
 			}
 			fmt.Printf("Qtools auto-updates have been turned %s.\n", statusText)
 
-			// TODO: Update cron (when install-cron is implemented)
-
+			syncScheduledTasks(cfg, "updates.qtools", newStatus)
 			return nil
 		},
 	}
@@ -856,35 +2854,143 @@ Examples:
 		Use:   "public-ip",
 		Short: "Get public IP address",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Try multiple services to get public IP
-			services := []string{
-				"https://api.ipify.org",
-				"https://icanhazip.com",
-				"https://ifconfig.me",
+			specs, _ := cmd.Flags().GetStringSlice("provider")
+			quorum, _ := cmd.Flags().GetInt("quorum")
+
+			providers, err := publicip.ParseProviders(specs)
+			if err != nil {
+				return err
 			}
 
-			for _, service := range services {
-				resp, err := http.Get(service)
+			// Without a quorum, take the first provider that answers
+			if quorum <= 0 {
+				ip, err := publicip.ResolveFirst(cmd.Context(), providers, 0)
 				if err != nil {
-					continue
+					return err
 				}
-				defer resp.Body.Close()
+				fmt.Println(ip)
+				return nil
+			}
 
-				if resp.StatusCode == http.StatusOK {
-					body, err := io.ReadAll(resp.Body)
-					if err == nil {
-						ip := strings.TrimSpace(string(body))
-						fmt.Println(ip)
-						return nil
+			result, err := publicip.Resolve(cmd.Context(), providers, quorum, 0)
+			if err != nil {
+				return err
+			}
+			fmt.Println(result.IP)
+			return nil
+		},
+	}
+	publicIPCmd.Flags().StringSlice("provider", publicip.DefaultProviders, "Providers to query (http(s)://url, stun:host:port or static:ip)")
+	publicIPCmd.Flags().Int("quorum", 0, "Require this many providers to agree (0 = first answer)")
+
+	monitorPublicIPCmd := &cobra.Command{
+		Use:   "monitor-public-ip",
+		Short: "Update announce multiaddrs when the public IP changes",
+		Long: `Check the public IP and react when it changed since the last check.
+
+Providers are queried in parallel and must agree on the address (quorum).
+When the address changed, the announce multiaddrs in the node config are
+rewritten, scheduled_tasks.public_ip.previous_ip is updated and, with
+--restart or scheduled_tasks.public_ip.restart_master, the master is
+restarted. The first run only records the current address.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load config
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
+
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			opts, err := publicip.LoadMonitorOptionsFromConfig(cfg)
+			if err != nil {
+				return err
+			}
+			opts.ConfigPath = configPath
+
+			if cmd.Flags().Changed("provider") {
+				specs, _ := cmd.Flags().GetStringSlice("provider")
+				if opts.Providers, err = publicip.ParseProviders(specs); err != nil {
+					return err
+				}
+			}
+			if cmd.Flags().Changed("quorum") {
+				opts.Quorum, _ = cmd.Flags().GetInt("quorum")
+			}
+			if cmd.Flags().Changed("restart") {
+				opts.RestartMaster, _ = cmd.Flags().GetBool("restart")
+			}
+			opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			interval, _ := cmd.Flags().GetDuration("interval")
+
+			opts.OnChange = func(event publicip.ChangeEvent) {
+				if jsonOutput {
+					data, _ := json.Marshal(event)
+					fmt.Println(string(data))
+					return
+				}
+				prefix := ""
+				if event.DryRun {
+					prefix = "[DRY RUN] "
+				}
+				fmt.Printf("%sPublic IP updated from %s to %s\n", prefix, event.PreviousIP, event.CurrentIP)
+				if event.Announce != nil {
+					fmt.Printf("  p2p.announceListenMultiaddr = %s\n", event.Announce.ListenMultiaddr)
+					fmt.Printf("  p2p.announceStreamListenMultiaddr = %s\n", event.Announce.StreamListenMultiaddr)
+					fmt.Printf("  worker announce multiaddrs: %d\n", len(event.Announce.WorkerP2PMultiaddrs))
+				}
+				if event.Restarted {
+					fmt.Println("✓ Master restarted")
+				}
+			}
+
+			if interval > 0 {
+				ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				publicip.Watch(ctx, cfg, opts, interval, func(err error) {
+					fmt.Printf("Warning: %v\n", err)
+				})
+				return nil
+			}
+
+			result, err := publicip.Check(cmd.Context(), cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				if !result.Changed {
+					data, err := json.MarshalIndent(result, "", "  ")
+					if err != nil {
+						return fmt.Errorf("failed to marshal result: %w", err)
 					}
+					fmt.Println(string(data))
 				}
+				return nil
 			}
 
-			return fmt.Errorf("failed to get public IP from any service")
+			switch {
+			case result.FirstRun:
+				fmt.Printf("✓ Recorded public IP %s\n", result.Lookup.IP)
+			case !result.Changed:
+				fmt.Printf("✓ Public IP unchanged (%s)\n", result.Lookup.IP)
+			}
+			return nil
 		},
 	}
+	monitorPublicIPCmd.Flags().StringSlice("provider", publicip.DefaultProviders, "Providers to query (http(s)://url, stun:host:port or static:ip)")
+	monitorPublicIPCmd.Flags().Int("quorum", 0, "Providers that must agree (0 = majority of answers)")
+	monitorPublicIPCmd.Flags().Bool("restart", false, "Restart the master when the IP changes")
+	monitorPublicIPCmd.Flags().Bool("dry-run", false, "Show changes without writing them")
+	monitorPublicIPCmd.Flags().Bool("json", false, "Output in JSON format")
+	monitorPublicIPCmd.Flags().Duration("interval", 0, "Keep checking at this interval (e.g., 5m)")
 
-	utilCmd.AddCommand(publicIPCmd)
+	utilCmd.AddCommand(publicIPCmd, monitorPublicIPCmd)
 
 	// Log commands
 	logsCmd := &cobra.Command{
@@ -1092,6 +3198,348 @@ Supported shells: bash, zsh, fish, powershell
 	}
 	completionCmd.Flags().Bool("generate", false, "Generate completion script to stdout instead of installing")
 
+	// Scheduler commands
+	daemonCmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the scheduled tasks daemon",
+		Long: `Run each enabled task in scheduled_tasks on its cron_expression (or its default schedule),
+with a per-task lock so runs never overlap, a timeout and a random start delay (jitter).
+Runs are recorded in the history shown by qtools tasks history. The config is reloaded when
+it changes. SIGINT or SIGTERM waits for running tasks before exiting. Use qtools daemon install
+to run it as a service instead of crontab entries.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath := os.Getenv("QTOOLS_CONFIG_FILE")
+			if configPath == "" {
+				configPath = "/home/quilibrium/qtools/config.yml"
+			}
+			logf := func(format string, args ...interface{}) {
+				fmt.Printf("%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return scheduler.NewDaemon(configPath, logf).Run(ctx)
+		},
+	}
+
+	daemonInstallCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the scheduled tasks daemon as a service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			noStart, _ := cmd.Flags().GetBool("no-start")
+
+			svc, err := service.NewCommandService(scheduler.DaemonServiceName, "qtools scheduled tasks daemon", "daemon")
+			if err != nil {
+				return err
+			}
+			if err := service.InstallCommandService(cfg, svc, !noStart); err != nil {
+				return err
+			}
+			path, _ := service.CommandServiceFilePath(cfg, svc.Name)
+			fmt.Printf("✓ Installed %s (%s)\n", svc.Name, path)
+			if !noStart {
+				fmt.Printf("✓ Started %s\n", svc.Name)
+			}
+
+			// Tasks scheduled by qtools tasks install would run twice
+			if previous := scheduler.LoadBackend(cfg); previous != scheduler.BackendDaemon {
+				removed, err := scheduler.UninstallSchedule(cfg, previous)
+				if err != nil {
+					fmt.Printf("Warning: failed to remove the %s schedule: %v\n", previous, err)
+				} else {
+					fmt.Printf("✓ Removed the %s schedule (%d entries)\n", previous, len(removed))
+				}
+				return setSchedulerBackend(scheduler.BackendDaemon)
+			}
+			return nil
+		},
+	}
+	daemonInstallCmd.Flags().Bool("no-start", false, "Install and enable without starting")
+
+	daemonUninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop and remove the scheduled tasks daemon service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			if err := service.RemoveCommandService(cfg, scheduler.DaemonServiceName); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Removed %s\n", scheduler.DaemonServiceName)
+			return nil
+		},
+	}
+
+	daemonCmd.AddCommand(daemonInstallCmd, daemonUninstallCmd)
+
+	tasksCmd := &cobra.Command{
+		Use:   "tasks",
+		Short: "Scheduled task commands",
+	}
+
+	tasksListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List scheduled tasks with their schedules and last runs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			jsonOut, _ := cmd.Flags().GetBool("json")
+
+			tasks := scheduler.LoadTaskConfigs(cfg)
+			lastRuns, err := scheduler.LastRuns()
+			if err != nil {
+				return err
+			}
+
+			type taskStatus struct {
+				scheduler.TaskConfig
+				NextRun *time.Time     `json:"next_run,omitempty"`
+				LastRun *scheduler.Run `json:"last_run,omitempty"`
+			}
+			now := time.Now()
+			statuses := make([]taskStatus, len(tasks))
+			for i, tc := range tasks {
+				statuses[i].TaskConfig = tc
+				if tc.Enabled && tc.Schedule != nil {
+					if next := tc.Schedule.Next(now); !next.IsZero() {
+						statuses[i].NextRun = &next
+					}
+				}
+				if run, ok := lastRuns[tc.Name]; ok {
+					statuses[i].LastRun = &run
+				}
+			}
+			if jsonOut {
+				return printJSON(statuses)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tENABLED\tSCHEDULE\tNEXT RUN\tLAST RUN\tSTATUS\tCOMMAND")
+			for _, status := range statuses {
+				schedule := status.Expression
+				if status.Error != "" {
+					schedule += " (invalid)"
+				}
+				nextRun, lastRun, lastStatus := "-", "-", "-"
+				if status.NextRun != nil {
+					nextRun = status.NextRun.Format("2006-01-02 15:04")
+				}
+				if status.LastRun != nil {
+					lastRun = status.LastRun.Start.Format("2006-01-02 15:04")
+					lastStatus = status.LastRun.Status
+				}
+				fmt.Fprintf(w, "%s\t%t\t%s\t%s\t%s\t%s\tqtools %s\n", status.Name, status.Enabled, schedule, nextRun, lastRun, lastStatus, strings.Join(status.Args, " "))
+			}
+			w.Flush()
+
+			for _, status := range statuses {
+				if status.Enabled && status.Error != "" {
+					fmt.Printf("Warning: %s will not run: %s\n", status.Name, status.Error)
+				}
+			}
+			for _, name := range scheduler.EnabledUnsupportedTasks(cfg) {
+				fmt.Printf("Warning: %s\n", scheduler.UnsupportedTaskWarning(name))
+			}
+			return nil
+		},
+	}
+	tasksListCmd.Flags().Bool("json", false, "Output as JSON")
+
+	tasksHistoryCmd := &cobra.Command{
+		Use:   "history [task]",
+		Short: "Show recent scheduled task runs",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, _ := cmd.Flags().GetInt("limit")
+			jsonOut, _ := cmd.Flags().GetBool("json")
+
+			task := ""
+			if len(args) == 1 {
+				if _, err := scheduler.FindTask(args[0]); err != nil {
+					return err
+				}
+				task = args[0]
+			}
+			runs, err := scheduler.ReadHistory(task, limit)
+			if err != nil {
+				return err
+			}
+			if jsonOut {
+				if runs == nil {
+					runs = []scheduler.Run{}
+				}
+				return printJSON(runs)
+			}
+			if len(runs) == 0 {
+				fmt.Println("No task runs recorded")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "START\tTASK\tTRIGGER\tDURATION\tSTATUS\tERROR")
+			for _, run := range runs {
+				runErr := run.Error
+				if runErr == "" {
+					runErr = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", run.Start.Format("2006-01-02 15:04:05"), run.Task, run.Trigger, run.Duration.Round(time.Second), run.Status, runErr)
+			}
+			return w.Flush()
+		},
+	}
+	tasksHistoryCmd.Flags().IntP("limit", "n", 20, "Number of runs to show (0 for all)")
+	tasksHistoryCmd.Flags().Bool("json", false, "Output as JSON")
+
+	tasksRunCmd := &cobra.Command{
+		Use:   "run <task>",
+		Short: "Run a scheduled task now",
+		Long: `Run a scheduled task now, under the same lock, timeout and run history as the daemon.
+The task runs even when it is disabled. With --scheduled (used by the crontab entries and
+systemd timers qtools tasks install writes) a disabled task is skipped and the task's jitter
+applies first.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			task, err := scheduler.FindTask(args[0])
+			if err != nil {
+				return err
+			}
+			tc := scheduler.LoadTaskConfig(cfg, task)
+			if cmd.Flags().Changed("timeout") {
+				tc.Timeout, _ = cmd.Flags().GetDuration("timeout")
+			}
+
+			trigger := scheduler.TriggerManual
+			if scheduled, _ := cmd.Flags().GetBool("scheduled"); scheduled {
+				if !tc.Enabled {
+					fmt.Printf("Skipping %s: disabled (scheduled_tasks.%s.enabled)\n", tc.Name, tc.Name)
+					return nil
+				}
+				ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+				err := scheduler.Delay(ctx, tc)
+				stop()
+				if err != nil {
+					return err
+				}
+				trigger = scheduler.TriggerSchedule
+			} else if !tc.Enabled {
+				fmt.Printf("Warning: %s is disabled (scheduled_tasks.%s.enabled); running it anyway\n", tc.Name, tc.Name)
+			}
+
+			fmt.Printf("Running %s (qtools %s)...\n", tc.Name, strings.Join(tc.Args, " "))
+			run, err := scheduler.RunTask(cmd.Context(), cfg, tc, trigger)
+			if run.Output != "" {
+				fmt.Println(run.Output)
+			}
+			if run.Status == scheduler.StatusSkipped {
+				return fmt.Errorf("skipped %s: %s", tc.Name, run.Error)
+			}
+			if run.Status != scheduler.StatusSuccess {
+				return err
+			}
+			if err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+			fmt.Printf("✓ %s finished in %s\n", tc.Name, run.Duration.Round(time.Millisecond))
+			return nil
+		},
+	}
+	tasksRunCmd.Flags().Duration("timeout", 0, "Override the task's timeout (0 for none)")
+	tasksRunCmd.Flags().Bool("scheduled", false, "Run as a scheduled run: skip a disabled task and apply jitter")
+
+	tasksInstallCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Schedule the enabled tasks with crontab entries or systemd timers",
+		Long: `Schedule each enabled task in scheduled_tasks with a crontab entry (--backend cron) or a
+systemd .timer and .service pair (--backend systemd-timer) instead of running qtools daemon.
+Each runs qtools tasks run --scheduled <task> on the task's cron_expression, or its default
+schedule when that is blank. Entries for disabled tasks are removed. The backend is saved to
+settings.scheduler.backend so qtools toggle keeps the schedule in sync.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			backend, _ := cmd.Flags().GetString("backend")
+			jsonOut, _ := cmd.Flags().GetBool("json")
+			if err := scheduler.ValidateBackend(backend); err != nil {
+				return err
+			}
+
+			result, err := scheduler.InstallSchedule(cfg, backend)
+			if err != nil {
+				return err
+			}
+			if previous := scheduler.LoadBackend(cfg); previous != backend {
+				if previous != scheduler.BackendDaemon {
+					removed, err := scheduler.UninstallSchedule(cfg, previous)
+					if err != nil {
+						fmt.Printf("Warning: failed to remove the %s schedule: %v\n", previous, err)
+					}
+					result.Removed = append(result.Removed, removed...)
+				}
+				if err := setSchedulerBackend(backend); err != nil {
+					return err
+				}
+			}
+			if jsonOut {
+				return printJSON(result)
+			}
+
+			for _, tc := range result.Installed {
+				fmt.Printf("✓ %s (%s): qtools %s\n", tc.Name, tc.Expression, strings.Join(tc.Args, " "))
+			}
+			for _, name := range result.Removed {
+				fmt.Printf("- Removed %s\n", name)
+			}
+			for _, tc := range result.Invalid {
+				fmt.Printf("Warning: %s is enabled but not scheduled: %s\n", tc.Name, tc.Error)
+			}
+			for _, name := range scheduler.EnabledUnsupportedTasks(cfg) {
+				fmt.Printf("Warning: %s\n", scheduler.UnsupportedTaskWarning(name))
+			}
+			if len(result.Installed) == 0 {
+				fmt.Println("No scheduled tasks are enabled")
+			}
+
+			// The daemon would run the same tasks
+			if path, err := service.CommandServiceFilePath(cfg, scheduler.DaemonServiceName); err == nil {
+				if _, err := os.Stat(path); err == nil {
+					fmt.Printf("Warning: qtools daemon is also installed (%s); remove it with qtools daemon uninstall\n", path)
+				}
+			}
+			return nil
+		},
+	}
+	tasksInstallCmd.Flags().String("backend", scheduler.BackendCron, "cron or systemd-timer")
+	tasksInstallCmd.Flags().Bool("json", false, "Output as JSON")
+
+	tasksUninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the crontab entries or systemd timers qtools tasks install wrote",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadServiceConfig()
+			backend := scheduler.LoadBackend(cfg)
+			if cmd.Flags().Changed("backend") {
+				backend, _ = cmd.Flags().GetString("backend")
+			} else if backend == scheduler.BackendDaemon {
+				return fmt.Errorf("settings.scheduler.backend is daemon; use --backend cron or --backend systemd-timer")
+			}
+
+			removed, err := scheduler.UninstallSchedule(cfg, backend)
+			if err != nil {
+				return err
+			}
+			for _, name := range removed {
+				fmt.Printf("- Removed %s\n", name)
+			}
+			fmt.Printf("✓ Removed the %s schedule\n", backend)
+			if scheduler.LoadBackend(cfg) == backend {
+				return setSchedulerBackend(scheduler.BackendDaemon)
+			}
+			return nil
+		},
+	}
+	tasksUninstallCmd.Flags().String("backend", "", "cron or systemd-timer (default: settings.scheduler.backend)")
+
+	tasksCmd.AddCommand(tasksListCmd, tasksHistoryCmd, tasksRunCmd, tasksInstallCmd, tasksUninstallCmd)
+
 	// TUI command
 	tuiCmd := &cobra.Command{
 		Use:   "tui",
@@ -1114,7 +3562,7 @@ Supported shells: bash, zsh, fish, powershell
 		},
 	}
 
-	rootCmd.AddCommand(nodeCmd, serviceCmd, backupCmd, diagnosticsCmd, updateCmd, logsCmd, configCmd, qclientCmd, toggleCmd, utilCmd, completionCmd, tuiCmd)
+	rootCmd.AddCommand(nodeCmd, serviceCmd, superviseCmd, backupCmd, diagnosticsCmd, updateCmd, logsCmd, configCmd, qclientCmd, toggleCmd, utilCmd, daemonCmd, tasksCmd, completionCmd, tuiCmd)
 
 	// Register custom completions
 	registerCompletions(rootCmd)
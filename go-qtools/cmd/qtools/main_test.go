@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/client"
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/diagnostics"
+	"github.com/tjsturos/qtools/go-qtools/internal/fakenode"
+)
+
+// TestMain runs the CLI instead of the tests when re-executed by runQtools
+func TestMain(m *testing.M) {
+	if os.Getenv("QTOOLS_TEST_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeNodeEnv starts a fake node and returns the environment pointing qtools at it:
+// a node config with its gRPC and REST addresses and a qtools config whose
+// service.link_name is the stub node executable
+func fakeNodeEnv(t *testing.T, fake *fakenode.Node) []string {
+	t.Helper()
+	dir := t.TempDir()
+
+	nodePath, err := fake.WriteStubExecutable(filepath.Join(dir, "bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multiaddr := func(hostPort string) string {
+		host, port, err := net.SplitHostPort(strings.TrimPrefix(hostPort, "http://"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fmt.Sprintf("/ip4/%s/tcp/%s", host, port)
+	}
+	nodeConfigPath := filepath.Join(dir, "node", "config.yml")
+	nodeConfig := fmt.Sprintf("grpc:\n  listenMultiaddr: %s\nrest:\n  listenMultiaddr: %s\n",
+		multiaddr(fake.StartGRPC()), multiaddr(fake.Start()))
+	if err := os.MkdirAll(filepath.Dir(nodeConfigPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(nodeConfigPath, []byte(nodeConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.GenerateDefaultConfig()
+	if err := config.SetConfigValue(cfg, "service.link_name", nodePath); err != nil {
+		t.Fatal(err)
+	}
+	qtoolsConfigPath := filepath.Join(dir, "qtools", "config.yml")
+	if err := config.SaveConfig(cfg, qtoolsConfigPath); err != nil {
+		t.Fatal(err)
+	}
+
+	return []string{
+		"QTOOLS_TEST_RUN_MAIN=1",
+		"QTOOLS_CONFIG_FILE=" + qtoolsConfigPath,
+		"QUIL_CONFIG_FILE=" + nodeConfigPath,
+	}
+}
+
+// runQtools runs the CLI with args and returns its output
+func runQtools(t *testing.T, env []string, args ...string) (string, error) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+func TestNodeInfoCommand(t *testing.T) {
+	fake := fakenode.New()
+	fake.MaxFrame = 4242
+	defer fake.Close()
+	env := fakeNodeEnv(t, fake)
+
+	for _, via := range []string{client.TransportGRPC, client.TransportREST, client.TransportBinary} {
+		output, err := runQtools(t, env, "node", "info", "--via", via, "--json")
+		if err != nil {
+			t.Fatalf("node info --via %s failed: %v\n%s", via, err, output)
+		}
+		var info client.NodeInfo
+		if err := json.Unmarshal([]byte(output), &info); err != nil {
+			t.Fatalf("node info --via %s printed invalid JSON: %v\n%s", via, err, output)
+		}
+		if info.PeerID != fake.PeerID || info.MaxFrame != 4242 || info.Transport != via {
+			t.Errorf("node info --via %s = %s frame %d via %s", via, info.PeerID, info.MaxFrame, info.Transport)
+		}
+	}
+}
+
+func TestNodeQueryCommands(t *testing.T) {
+	fake := fakenode.New()
+	fake.Peers = []fakenode.Peer{{PeerID: "Qm" + strings.Repeat("A", 44), Multiaddrs: []string{"/ip4/198.51.100.1/udp/8336/quic-v1"}}}
+	defer fake.Close()
+	env := fakeNodeEnv(t, fake)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"node", "query", "frames"}, want: "100000"},
+		{args: []string{"node", "query", "tokens", "--via", "rest"}, want: "1 QUIL"},
+		{args: []string{"node", "query", "network"}, want: fake.Peers[0].PeerID},
+		{args: []string{"node", "query", "network", "--count"}, want: "1"},
+		{args: []string{"node", "peer-id"}, want: fake.PeerID},
+	}
+	for _, tt := range tests {
+		output, err := runQtools(t, env, tt.args...)
+		if err != nil {
+			t.Fatalf("%s failed: %v\n%s", strings.Join(tt.args, " "), err, output)
+		}
+		if !strings.Contains(output, tt.want) {
+			t.Errorf("%s output does not contain %q:\n%s", strings.Join(tt.args, " "), tt.want, output)
+		}
+	}
+}
+
+func TestStatusReportCommand(t *testing.T) {
+	fake := fakenode.New()
+	defer fake.Close()
+	env := fakeNodeEnv(t, fake)
+
+	output, err := runQtools(t, env, "diagnostics", "status-report", "--json")
+	if err != nil {
+		t.Fatalf("status-report failed: %v\n%s", err, output)
+	}
+	var report diagnostics.StatusReport
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("status-report printed invalid JSON: %v\n%s", err, output)
+	}
+	if !report.Running || report.Node == nil || report.Node.PeerID != fake.PeerID || report.Peers == nil {
+		t.Errorf("report = %+v", report)
+	}
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+)
+
+// binaryQuerier queries the node by running the node binary (works while the service is stopped)
+type binaryQuerier struct {
+	config *config.Config
+}
+
+// NewBinaryQuerier creates a NodeQuerier using node --node-info
+func NewBinaryQuerier(cfg *config.Config) NodeQuerier {
+	return &binaryQuerier{config: cfg}
+}
+
+// Transport returns the transport name
+func (q *binaryQuerier) Transport() string {
+	return TransportBinary
+}
+
+// GetNodeInfo runs node --node-info
+func (q *binaryQuerier) GetNodeInfo() (*NodeInfo, error) {
+	info, err := node.GetNodeInfo(q.config)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeInfo{NodeInfo: *info, Transport: TransportBinary}, nil
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	grpcTimeout = 10 * time.Second
+	// GetPeerInfo can exceed gRPC's 4 MB default; the scripts raise it with grpcurl -max-msg-sz
+	grpcMaxMessageSize = 32 << 20
+)
+
+// grpcCaller calls NodeService methods over gRPC without TLS, like grpcurl -plaintext
+// The NodeService schema is read from the node through gRPC server reflection, so responses
+// are decoded with the node's own node.proto and then read through their protojson names
+type grpcCaller struct {
+	addr string // e.g., "localhost:8337"
+
+	mu      sync.Mutex
+	service protoreflect.ServiceDescriptor // Cached after the first successful reflection
+}
+
+// NewGRPCQuerier creates a NodeQuerier using the node's gRPC API
+func NewGRPCQuerier(addr string) NodeQuerier {
+	return &rpcQuerier{
+		transport: TransportGRPC,
+		caller:    &grpcCaller{addr: addr},
+	}
+}
+
+// call invokes a NodeService method with an empty request and decodes the protojson form of the response
+func (c *grpcCaller) call(method string, out any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), grpcTimeout)
+	defer cancel()
+
+	conn, err := grpc.NewClient(c.addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(grpcMaxMessageSize)))
+	if err != nil {
+		return fmt.Errorf("failed to create gRPC client for %s: %w", c.addr, err)
+	}
+	defer conn.Close()
+
+	service, err := c.nodeService(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("gRPC call %s failed (node may not be running): %w", method, err)
+	}
+	md := service.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return fmt.Errorf("gRPC call %s failed: %s has no method %s", method, nodeService, method)
+	}
+
+	resp := dynamicpb.NewMessage(md.Output())
+	if err := conn.Invoke(ctx, fmt.Sprintf("/%s/%s", nodeService, method), dynamicpb.NewMessage(md.Input()), resp); err != nil {
+		return fmt.Errorf("gRPC call %s failed: %w", method, err)
+	}
+
+	data, err := protojson.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s response: %w", method, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	return nil
+}
+
+// nodeService returns the NodeService descriptor, asking the node for it on first use
+func (c *grpcCaller) nodeService(ctx context.Context, conn *grpc.ClientConn) (protoreflect.ServiceDescriptor, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.service != nil {
+		return c.service, nil
+	}
+
+	service, err := reflectNodeService(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	c.service = service
+	return service, nil
+}
+
+// reflectNodeService fetches the file declaring NodeService and its dependencies through gRPC server reflection
+func reflectNodeService(ctx context.Context, conn *grpc.ClientConn) (protoreflect.ServiceDescriptor, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("server reflection failed: %w", err)
+	}
+	defer stream.CloseSend()
+
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	requested := make(map[string]bool)
+	req := &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: nodeService},
+	}
+	for req != nil {
+		if err := stream.Send(req); err != nil {
+			return nil, fmt.Errorf("server reflection failed: %w", err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, fmt.Errorf("server reflection failed: %w", err)
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return nil, fmt.Errorf("server reflection failed: %s", errResp.GetErrorMessage())
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := new(descriptorpb.FileDescriptorProto)
+			if err := proto.Unmarshal(raw, file); err != nil {
+				return nil, fmt.Errorf("invalid file descriptor from server reflection: %w", err)
+			}
+			files[file.GetName()] = file
+		}
+
+		// Ask for any dependency the server did not send along
+		req = nil
+		for _, file := range files {
+			for _, dep := range file.GetDependency() {
+				if files[dep] != nil {
+					continue
+				}
+				if requested[dep] {
+					return nil, fmt.Errorf("server reflection did not return %s", dep)
+				}
+				requested[dep] = true
+				req = &reflectionpb.ServerReflectionRequest{
+					MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+				}
+				break
+			}
+			if req != nil {
+				break
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		set.File = append(set.File, file)
+	}
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptors from server reflection: %w", err)
+	}
+	desc, err := registry.FindDescriptorByName(nodeService)
+	if err != nil {
+		return nil, fmt.Errorf("server reflection did not describe %s: %w", nodeService, err)
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", nodeService)
+	}
+	return service, nil
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NodeHealth represents the outcome of a node health check
+type NodeHealth struct {
+	Healthy   bool   `json:"healthy"`
+	Reason    string `json:"reason,omitempty"`
+	PeerID    string `json:"peer_id,omitempty"`
+	Version   string `json:"version,omitempty"`
+	MaxFrame  uint64 `json:"max_frame,omitempty"`
+	Transport string `json:"transport,omitempty"`
+}
+
+// CheckNodeHealth checks that the running node answers over gRPC or REST and, when wantVersion
+// is set, that it runs that version
+// The binary transport is not tried: it answers even when the service is down
+func CheckNodeHealth(nc NodeAPI, wantVersion string) *NodeHealth {
+	var failures []string
+	for _, transport := range []string{TransportGRPC, TransportREST} {
+		info, err := nc.GetNodeInfoVia(transport)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+
+		health := &NodeHealth{
+			Healthy:   true,
+			PeerID:    info.PeerID,
+			Version:   info.Version,
+			MaxFrame:  info.MaxFrame,
+			Transport: info.Transport,
+		}
+		if wantVersion != "" && !versionMatches(info.Version, wantVersion) {
+			health.Healthy = false
+			health.Reason = fmt.Sprintf("running %s, expected %s", info.Version, wantVersion)
+		}
+		return health
+	}
+
+	return &NodeHealth{Reason: "node not answering: " + strings.Join(failures, "; ")}
+}
+
+// WaitForNodeHealth checks the node every interval until it is healthy or ctx is done
+func WaitForNodeHealth(ctx context.Context, nc NodeAPI, wantVersion string, interval time.Duration) (*NodeHealth, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		health := CheckNodeHealth(nc, wantVersion)
+		if health.Healthy {
+			return health, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return health, fmt.Errorf("node not healthy: %s", health.Reason)
+		case <-ticker.C:
+		}
+	}
+}
+
+// versionMatches reports whether a reported version is the wanted one
+// The RPC version bytes may leave out the patch number (2.1.0 for 2.1.0.2)
+func versionMatches(reported, want string) bool {
+	return reported == want || (reported != "" && strings.HasPrefix(want, reported+"."))
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/fakenode"
+)
+
+func TestCheckNodeHealth(t *testing.T) {
+	fake, nc := newFakeClient(t)
+	fake.Version = "2.1.0"
+
+	tests := []struct {
+		name        string
+		wantVersion string
+		stopped     bool
+		healthy     bool
+		reason      string
+	}{
+		{name: "any version", healthy: true},
+		{name: "same version", wantVersion: "2.1.0", healthy: true},
+		{name: "patch not reported", wantVersion: "2.1.0.2", healthy: true},
+		{name: "old version", wantVersion: "2.1.1", reason: "running 2.1.0, expected 2.1.1"},
+		{name: "stopped", stopped: true, reason: "node not answering"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.Update(func(n *fakenode.Node) { n.Stopped = tt.stopped })
+			health := CheckNodeHealth(nc, tt.wantVersion)
+			if health.Healthy != tt.healthy || !strings.Contains(health.Reason, tt.reason) {
+				t.Errorf("health = %+v, want healthy %t with reason %q", health, tt.healthy, tt.reason)
+			}
+			if tt.healthy && (health.PeerID != fake.PeerID || health.Transport != TransportGRPC) {
+				t.Errorf("health = %+v, want %s via grpc", health, fake.PeerID)
+			}
+		})
+	}
+}
+
+func TestWaitForNodeHealth(t *testing.T) {
+	fake, nc := newFakeClient(t)
+	fake.Stopped = true
+
+	// The node comes up with the new version after a restart
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		fake.Update(func(n *fakenode.Node) {
+			n.Stopped = false
+			n.Version = "2.1.1"
+		})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	health, err := WaitForNodeHealth(ctx, nc, "2.1.1", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForNodeHealth failed: %v", err)
+	}
+	if health.Version != "2.1.1" {
+		t.Errorf("health = %+v", health)
+	}
+
+	// A node stuck on the old version times out
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := WaitForNodeHealth(ctx, nc, "2.2.0", 10*time.Millisecond); err == nil || !strings.Contains(err.Error(), "expected 2.2.0") {
+		t.Errorf("WaitForNodeHealth error = %v", err)
+	}
+}
@@ -2,24 +2,25 @@ package client
 
 import (
 	"fmt"
-	"os/exec"
-	"regexp"
-	"strings"
 
 	"github.com/tjsturos/qtools/go-qtools/internal/config"
 	"github.com/tjsturos/qtools/go-qtools/internal/node"
 )
 
 // NodeClient provides a client interface for interacting with Quilibrium nodes
-// Supports both binary commands and gRPC (when available)
+// Supports gRPC, REST and binary commands
 type NodeClient struct {
 	binaryPath string
 	configPath string
 	config     *config.Config
 	grpcAddr   string // e.g., "localhost:8337"
+	restURL    string // e.g., "http://localhost:8338"
+	queriers   map[string]NodeQuerier
+	order      []string
 }
 
 // NewNodeClient creates a new node client
+// API addresses are read from grpc.listenMultiaddr and rest.listenMultiaddr in the node config
 func NewNodeClient(cfg *config.Config) *NodeClient {
 	binaryPath := "/usr/local/bin/node"
 	if cfg != nil && cfg.Service != nil && cfg.Service.LinkName != "" {
@@ -27,24 +28,82 @@ func NewNodeClient(cfg *config.Config) *NodeClient {
 	}
 
 	configPath := config.GetNodeConfigPath()
-	grpcAddr := "localhost:8337"
+	grpcAddr := fmt.Sprintf("localhost:%d", node.DefaultGRPCPort)
+	restURL := fmt.Sprintf("http://localhost:%d", node.DefaultRESTPort)
+
+	if mgr, err := node.NewNodeConfigManager(configPath); err == nil {
+		if nodeConfig, err := mgr.Load(); err == nil {
+			if nodeConfig.GRPC != nil {
+				if ip, port, _, err := node.ParseMultiaddr(nodeConfig.GRPC.ListenMultiaddr); err == nil {
+					grpcAddr = fmt.Sprintf("%s:%d", localAPIHost(ip), port)
+				}
+			}
+			if nodeConfig.REST != nil {
+				if ip, port, _, err := node.ParseMultiaddr(nodeConfig.REST.ListenMultiaddr); err == nil {
+					restURL = fmt.Sprintf("http://%s:%d", localAPIHost(ip), port)
+				}
+			}
+		}
+	}
 
-	return &NodeClient{
+	nc := &NodeClient{
 		binaryPath: binaryPath,
 		configPath: configPath,
 		config:     cfg,
 		grpcAddr:   grpcAddr,
+		restURL:    restURL,
+		order:      DefaultTransportOrder,
+	}
+	nc.queriers = map[string]NodeQuerier{
+		TransportGRPC:   NewGRPCQuerier(grpcAddr),
+		TransportREST:   NewRESTQuerier(restURL),
+		TransportBinary: NewBinaryQuerier(cfg),
+	}
+
+	// Transport order override from settings.node_query.transports
+	if cfg != nil && cfg.Settings != nil && cfg.Settings.NodeQuery != nil && len(cfg.Settings.NodeQuery.Transports) > 0 {
+		if order, err := ParseTransportOrder(cfg.Settings.NodeQuery.Transports); err == nil {
+			nc.order = order
+		}
+	}
+
+	return nc
+}
+
+// NewNodeClientWithQueriers creates a node client whose transports are served by the given queriers
+// Queriers replace the default for their Transport(); used to point the client at a fake node
+func NewNodeClientWithQueriers(cfg *config.Config, queriers ...NodeQuerier) *NodeClient {
+	nc := NewNodeClient(cfg)
+	for _, querier := range queriers {
+		nc.queriers[querier.Transport()] = querier
+	}
+	return nc
+}
+
+// SetTransportOrder sets the order GetNodeInfoHybrid tries transports in
+func (nc *NodeClient) SetTransportOrder(order []string) error {
+	order, err := ParseTransportOrder(order)
+	if err != nil {
+		return err
+	}
+	nc.order = order
+	return nil
+}
+
+// Querier returns the querier for a transport
+func (nc *NodeClient) Querier(transport string) (NodeQuerier, error) {
+	querier, ok := nc.queriers[transport]
+	if !ok {
+		return nil, fmt.Errorf("unknown transport %q (expected grpc, rest or binary)", transport)
 	}
+	return querier, nil
 }
 
 // NodeInfo represents comprehensive node information
 type NodeInfo struct {
-	PeerID      string
-	Version     string
-	Seniority   string
-	Balance     string
-	WorkerCount int
-	Network     string // "mainnet" or "testnet"
+	node.NodeInfo
+	Network   string `json:"network"`   // "mainnet" or "testnet"
+	Transport string `json:"transport"` // Transport that answered: grpc, rest or binary
 }
 
 // PeerInfo represents peer information from gRPC
@@ -58,27 +117,23 @@ type PeerInfo struct {
 // GetNodeInfo gets node information using the node binary command
 // This works even when the node service is not running
 func (nc *NodeClient) GetNodeInfo() (*NodeInfo, error) {
-	info, err := node.GetNodeInfo(nc.config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get node info: %w", err)
-	}
+	return nc.GetNodeInfoVia(TransportBinary)
+}
 
-	nodeInfo := &NodeInfo{
-		PeerID:      info.PeerID,
-		Version:     info.Version,
-		Seniority:   info.Seniority,
-		Balance:     info.Balance,
-		WorkerCount: info.WorkerCount,
+// GetNodeInfoVia gets node information over a single transport
+func (nc *NodeClient) GetNodeInfoVia(transport string) (*NodeInfo, error) {
+	querier, err := nc.Querier(transport)
+	if err != nil {
+		return nil, err
 	}
 
-	// Determine network
-	if nc.config != nil && nc.config.Service != nil && nc.config.Service.Testnet {
-		nodeInfo.Network = "testnet"
-	} else {
-		nodeInfo.Network = "mainnet"
+	info, err := querier.GetNodeInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node info via %s: %w", transport, err)
 	}
 
-	return nodeInfo, nil
+	info.Network = nc.network()
+	return info, nil
 }
 
 // GetPeerID gets the peer ID using the node binary command
@@ -87,72 +142,27 @@ func (nc *NodeClient) GetPeerID() (string, error) {
 }
 
 // GetPeerInfoViaGRPC gets peer information via gRPC (when node is running)
-// This is a stub implementation - would use actual gRPC client in production
 func (nc *NodeClient) GetPeerInfoViaGRPC() (*PeerInfo, error) {
-	// Stub: Would use grpcurl or gRPC client library
-	// Reference: scripts/grpc/peer-info.sh uses `grpcurl -plaintext localhost:8337`
-	
-	// For now, try to use grpcurl if available
-	cmd := exec.Command("grpcurl", "-plaintext", nc.grpcAddr, 
-		"quilibrium.node.node.pb.NodeService.GetPeerInfo")
-	output, err := cmd.Output()
+	info, err := nc.GetNodeInfoVia(TransportGRPC)
 	if err != nil {
-		return nil, fmt.Errorf("gRPC call failed (node may not be running): %w", err)
+		return nil, err
 	}
 
-	// Parse output (simplified - would use proper protobuf parsing in production)
-	peerInfo := &PeerInfo{
-		Address: nc.grpcAddr,
-	}
-
-	// Try to extract peer ID from output
-	outputStr := string(output)
-	if strings.Contains(outputStr, "peerId") {
-		// Extract peer ID using regex
-		re := regexp.MustCompile(`"peerId"\s*:\s*"([^"]+)"`)
-		matches := re.FindStringSubmatch(outputStr)
-		if len(matches) > 1 {
-			peerInfo.PeerID = matches[1]
-		}
-	}
-
-	peerInfo.Connected = peerInfo.PeerID != ""
-
-	return peerInfo, nil
+	return &PeerInfo{
+		PeerID:    info.PeerID,
+		Address:   nc.grpcAddr,
+		Connected: true,
+	}, nil
 }
 
 // GetNodeInfoViaGRPC gets node information via gRPC (when node is running)
 func (nc *NodeClient) GetNodeInfoViaGRPC() (*NodeInfo, error) {
-	// Stub: Would use grpcurl or gRPC client library
-	cmd := exec.Command("grpcurl", "-plaintext", nc.grpcAddr,
-		"quilibrium.node.node.pb.NodeService.GetNodeInfo")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("gRPC call failed (node may not be running): %w", err)
-	}
-
-	// Parse output (simplified - would use proper protobuf parsing in production)
-	nodeInfo := &NodeInfo{}
-
-	outputStr := string(output)
-	
-	// Extract fields using regex patterns
-	if peerIDMatch := regexp.MustCompile(`"peerId"\s*:\s*"([^"]+)"`).FindStringSubmatch(outputStr); len(peerIDMatch) > 1 {
-		nodeInfo.PeerID = peerIDMatch[1]
-	}
-	
-	if versionMatch := regexp.MustCompile(`"version"\s*:\s*"([^"]+)"`).FindStringSubmatch(outputStr); len(versionMatch) > 1 {
-		nodeInfo.Version = versionMatch[1]
-	}
-
-	// Determine network
-	if nc.config != nil && nc.config.Service != nil && nc.config.Service.Testnet {
-		nodeInfo.Network = "testnet"
-	} else {
-		nodeInfo.Network = "mainnet"
-	}
+	return nc.GetNodeInfoVia(TransportGRPC)
+}
 
-	return nodeInfo, nil
+// GetNodeInfoViaREST gets node information via the REST API (when node is running)
+func (nc *NodeClient) GetNodeInfoViaREST() (*NodeInfo, error) {
+	return nc.GetNodeInfoVia(TransportREST)
 }
 
 // GetWorkerCount gets the worker count from node info
@@ -184,20 +194,51 @@ func (nc *NodeClient) GetSeniority() (string, error) {
 
 // IsNodeRunning checks if the node is running by attempting a gRPC call
 func (nc *NodeClient) IsNodeRunning() bool {
-	_, err := nc.GetPeerInfoViaGRPC()
+	_, err := nc.GetPeerCount(TransportGRPC)
 	return err == nil
 }
 
 // GetNodeInfoHybrid gets node info using the best available method
-// Tries gRPC first (real-time), falls back to binary command (static)
+// Transports are tried in the configured order (default: gRPC, REST, binary);
+// the one that answered is recorded in NodeInfo.Transport
 func (nc *NodeClient) GetNodeInfoHybrid() (*NodeInfo, error) {
-	// Try gRPC first for real-time data
-	if info, err := nc.GetNodeInfoViaGRPC(); err == nil {
-		return info, nil
+	return nc.GetNodeInfoHybridFor("", nil)
+}
+
+// GetNodeInfoHybridFor gets node info from the first transport whose answer reports field
+// has checks an answer; transports that answer without the field are skipped like failures
+func (nc *NodeClient) GetNodeInfoHybridFor(field string, has func(*NodeInfo) bool) (*NodeInfo, error) {
+	failures := &TransportError{Errors: make(map[string]error), Order: nc.order}
+
+	for _, transport := range nc.order {
+		info, err := nc.queriers[transport].GetNodeInfo()
+		if err == nil && has != nil && !has(info) {
+			err = fmt.Errorf("%s not reported", field)
+		}
+		if err == nil {
+			info.Network = nc.network()
+			return info, nil
+		}
+		failures.Errors[transport] = err
+	}
+
+	return nil, failures
+}
+
+// network returns the network the node is configured for
+func (nc *NodeClient) network() string {
+	if nc.config != nil && nc.config.Service != nil && nc.config.Service.Testnet {
+		return "testnet"
 	}
+	return "mainnet"
+}
 
-	// Fall back to binary command
-	return nc.GetNodeInfo()
+// localAPIHost maps a wildcard listen address to localhost
+func localAPIHost(ip string) string {
+	if ip == "" || ip == "0.0.0.0" {
+		return "localhost"
+	}
+	return ip
 }
 
 // RegisterNode registers this node with the desktop app registry
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Transports used to query the node
+const (
+	TransportGRPC   = "grpc"
+	TransportREST   = "rest"
+	TransportBinary = "binary"
+)
+
+// DefaultTransportOrder is the order GetNodeInfoHybrid tries transports in
+var DefaultTransportOrder = []string{TransportGRPC, TransportREST, TransportBinary}
+
+// NodeQuerier queries node information over a single transport
+type NodeQuerier interface {
+	Transport() string
+	GetNodeInfo() (*NodeInfo, error)
+}
+
+// NodeAPI is the node query surface of NodeClient
+// Consumers depend on it so they can be exercised against a fake node
+type NodeAPI interface {
+	GetNodeInfoHybrid() (*NodeInfo, error)
+	GetNodeInfoHybridFor(field string, has func(*NodeInfo) bool) (*NodeInfo, error)
+	GetNodeInfoVia(transport string) (*NodeInfo, error)
+	GetFrameInfo(transport string) (*FrameInfo, error)
+	GetProverRing(transport string) (*ProverRingInfo, error)
+	GetTokenInfo(transport string) (*TokenInfo, error)
+	GetNetworkInfo(transport string) (*NetworkInfo, error)
+	GetPeerCount(transport string) (*PeerCount, error)
+	ListSeniority(configsDir string, nodeBinary string) ([]SeniorityEntry, error)
+	GetPeerID() (string, error)
+	IsNodeRunning() bool
+}
+
+var _ NodeAPI = (*NodeClient)(nil)
+
+// TransportError collects the failures of each transport tried
+type TransportError struct {
+	Errors map[string]error
+	Order  []string
+}
+
+// Error implements error
+func (e *TransportError) Error() string {
+	var parts []string
+	for _, transport := range e.Order {
+		if err, ok := e.Errors[transport]; ok {
+			parts = append(parts, fmt.Sprintf("%s: %v", transport, err))
+		}
+	}
+	return "all node transports failed: " + strings.Join(parts, "; ")
+}
+
+// ParseTransportOrder validates a list of transport names
+func ParseTransportOrder(names []string) ([]string, error) {
+	var order []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case TransportGRPC, TransportREST, TransportBinary:
+		default:
+			return nil, fmt.Errorf("unknown transport %q (expected grpc, rest or binary)", name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			order = append(order, name)
+		}
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("no transports given")
+	}
+	return order, nil
+}
@@ -0,0 +1,285 @@
+package client
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mr-tron/base58/base58"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+)
+
+// RPCQuerier is a NodeQuerier that can also answer RPC-only queries (gRPC and REST)
+type RPCQuerier interface {
+	NodeQuerier
+	GetTokenInfo() (*TokenInfo, error)
+	GetNetworkInfo() (*NetworkInfo, error)
+	GetPeerCount() (*PeerCount, error)
+}
+
+// FrameInfo represents the latest frame the node has processed
+type FrameInfo struct {
+	PeerID    string `json:"peer_id"`
+	MaxFrame  uint64 `json:"max_frame"`
+	Transport string `json:"transport"`
+}
+
+// ProverRingInfo represents the node's prover ring and seniority
+type ProverRingInfo struct {
+	PeerID     string `json:"peer_id"`
+	ProverRing int    `json:"prover_ring"` // -1 when not in a prover ring
+	Seniority  string `json:"seniority"`
+	Transport  string `json:"transport"`
+}
+
+// TokenInfo represents token supply and the node's owned tokens in QUIL
+type TokenInfo struct {
+	ConfirmedSupply   string `json:"confirmed_supply"`
+	UnconfirmedSupply string `json:"unconfirmed_supply"`
+	Owned             string `json:"owned"`
+	UnconfirmedOwned  string `json:"unconfirmed_owned"`
+	Unit              string `json:"unit"`
+	Transport         string `json:"transport"`
+}
+
+// NetworkPeer represents a peer from GetNetworkInfo
+type NetworkPeer struct {
+	PeerID     string   `json:"peer_id"`
+	Multiaddrs []string `json:"multiaddrs"`
+	PeerScore  float64  `json:"peer_score"`
+}
+
+// NetworkInfo represents the peers the node is connected to
+type NetworkInfo struct {
+	Peers     []NetworkPeer `json:"peers"`
+	Transport string        `json:"transport"`
+}
+
+// PeerCount represents the number of peers the node has seen (GetPeerInfo)
+type PeerCount struct {
+	Peers         int    `json:"peers"`
+	Uncooperative int    `json:"uncooperative"`
+	Transport     string `json:"transport"`
+}
+
+// SeniorityEntry represents the seniority of one node config
+type SeniorityEntry struct {
+	Config    string `json:"config"`
+	PeerID    string `json:"peer_id,omitempty"`
+	Seniority string `json:"seniority,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// networkInfoResponse is the protojson form of NetworkInfoResponse
+type networkInfoResponse struct {
+	NetworkInfo []networkInfoEntry `json:"networkInfo"`
+}
+
+// networkInfoEntry is the protojson form of NetworkInfo
+type networkInfoEntry struct {
+	PeerID     []byte   `json:"peerId"`
+	Multiaddrs []string `json:"multiaddrs"`
+	PeerScore  float64  `json:"peerScore"`
+}
+
+// peerInfoResponse is the protojson form of PeerInfoResponse (only peer IDs are decoded)
+type peerInfoResponse struct {
+	PeerInfo              []peerInfoEntry `json:"peerInfo"`
+	UncooperativePeerInfo []peerInfoEntry `json:"uncooperativePeerInfo"`
+}
+
+// peerInfoEntry is the protojson form of PeerInfo
+type peerInfoEntry struct {
+	PeerID []byte `json:"peerId"`
+}
+
+// GetTokenInfo calls GetTokenInfo
+func (q *rpcQuerier) GetTokenInfo() (*TokenInfo, error) {
+	var resp tokenInfoResponse
+	if err := q.caller.call("GetTokenInfo", &resp); err != nil {
+		return nil, err
+	}
+
+	return &TokenInfo{
+		ConfirmedSupply:   formatQuil(resp.ConfirmedTokenSupply),
+		UnconfirmedSupply: formatQuil(resp.UnconfirmedTokenSupply),
+		Owned:             formatQuil(resp.OwnedTokens),
+		UnconfirmedOwned:  formatQuil(resp.UnconfirmedOwnedTokens),
+		Unit:              "QUIL",
+		Transport:         q.transport,
+	}, nil
+}
+
+// GetNetworkInfo calls GetNetworkInfo
+func (q *rpcQuerier) GetNetworkInfo() (*NetworkInfo, error) {
+	var resp networkInfoResponse
+	if err := q.caller.call("GetNetworkInfo", &resp); err != nil {
+		return nil, err
+	}
+
+	info := &NetworkInfo{Peers: []NetworkPeer{}, Transport: q.transport}
+	for _, peer := range resp.NetworkInfo {
+		info.Peers = append(info.Peers, NetworkPeer{
+			PeerID:     base58.Encode(peer.PeerID),
+			Multiaddrs: peer.Multiaddrs,
+			PeerScore:  peer.PeerScore,
+		})
+	}
+
+	return info, nil
+}
+
+// GetPeerCount calls GetPeerInfo and counts the peers
+func (q *rpcQuerier) GetPeerCount() (*PeerCount, error) {
+	var resp peerInfoResponse
+	if err := q.caller.call("GetPeerInfo", &resp); err != nil {
+		return nil, err
+	}
+
+	return &PeerCount{
+		Peers:         len(resp.PeerInfo),
+		Uncooperative: len(resp.UncooperativePeerInfo),
+		Transport:     q.transport,
+	}, nil
+}
+
+// GetFrameInfo gets the latest frame number
+// An empty transport tries transports in the configured order
+func (nc *NodeClient) GetFrameInfo(transport string) (*FrameInfo, error) {
+	info, err := nc.nodeInfo(transport)
+	if err != nil {
+		return nil, err
+	}
+	return &FrameInfo{PeerID: info.PeerID, MaxFrame: info.MaxFrame, Transport: info.Transport}, nil
+}
+
+// GetProverRing gets the node's prover ring and seniority
+// An empty transport tries transports in the configured order
+func (nc *NodeClient) GetProverRing(transport string) (*ProverRingInfo, error) {
+	info, err := nc.nodeInfo(transport)
+	if err != nil {
+		return nil, err
+	}
+	return &ProverRingInfo{
+		PeerID:     info.PeerID,
+		ProverRing: info.ProverRing,
+		Seniority:  info.Seniority,
+		Transport:  info.Transport,
+	}, nil
+}
+
+// GetTokenInfo gets token supply and owned tokens (gRPC or REST only)
+func (nc *NodeClient) GetTokenInfo(transport string) (*TokenInfo, error) {
+	var info *TokenInfo
+	err := nc.withRPC(transport, "token info", func(q RPCQuerier) error {
+		var err error
+		info, err = q.GetTokenInfo()
+		return err
+	})
+	return info, err
+}
+
+// GetNetworkInfo gets the connected peers (gRPC or REST only)
+func (nc *NodeClient) GetNetworkInfo(transport string) (*NetworkInfo, error) {
+	var info *NetworkInfo
+	err := nc.withRPC(transport, "network info", func(q RPCQuerier) error {
+		var err error
+		info, err = q.GetNetworkInfo()
+		return err
+	})
+	return info, err
+}
+
+// GetPeerCount gets the number of peers the node has seen (gRPC or REST only)
+func (nc *NodeClient) GetPeerCount(transport string) (*PeerCount, error) {
+	var count *PeerCount
+	err := nc.withRPC(transport, "peer info", func(q RPCQuerier) error {
+		var err error
+		count, err = q.GetPeerCount()
+		return err
+	})
+	return count, err
+}
+
+// ListSeniority runs node --node-info for every config in configsDir and sorts the results by seniority (highest first)
+// Entries are directories or files; configs that fail are kept with their error and sorted last
+func (nc *NodeClient) ListSeniority(configsDir string, nodeBinary string) ([]SeniorityEntry, error) {
+	if nodeBinary == "" {
+		nodeBinary = nc.binaryPath
+	}
+
+	dirEntries, err := os.ReadDir(configsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configs directory: %w", err)
+	}
+
+	var entries []SeniorityEntry
+	for _, dirEntry := range dirEntries {
+		configPath := filepath.Join(configsDir, dirEntry.Name())
+		entry := SeniorityEntry{Config: configPath}
+
+		info, err := node.GetNodeInfoFor(nodeBinary, configPath, nc.config)
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.PeerID = info.PeerID
+			entry.Seniority = info.Seniority
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, aok := new(big.Int).SetString(entries[i].Seniority, 10)
+		b, bok := new(big.Int).SetString(entries[j].Seniority, 10)
+		if aok != bok {
+			return aok
+		}
+		return aok && a.Cmp(b) > 0
+	})
+
+	return entries, nil
+}
+
+// nodeInfo gets node info over one transport, or in the configured order when transport is empty
+func (nc *NodeClient) nodeInfo(transport string) (*NodeInfo, error) {
+	if transport != "" {
+		return nc.GetNodeInfoVia(transport)
+	}
+	return nc.GetNodeInfoHybrid()
+}
+
+// withRPC runs fn with each RPC-capable querier until one succeeds
+// An empty transport tries the configured order, skipping transports without RPC support
+func (nc *NodeClient) withRPC(transport string, what string, fn func(RPCQuerier) error) error {
+	order := nc.order
+	if transport != "" {
+		if _, err := nc.Querier(transport); err != nil {
+			return err
+		}
+		order = []string{transport}
+	}
+
+	failures := &TransportError{Errors: make(map[string]error)}
+	for _, name := range order {
+		querier, ok := nc.queriers[name].(RPCQuerier)
+		if !ok {
+			if transport != "" {
+				return fmt.Errorf("%s is not available via %s (use grpc or rest)", what, transport)
+			}
+			continue
+		}
+		if err := fn(querier); err != nil {
+			failures.Errors[name] = err
+			failures.Order = append(failures.Order, name)
+			continue
+		}
+		return nil
+	}
+
+	if len(failures.Order) == 0 {
+		return fmt.Errorf("%s requires the grpc or rest transport", what)
+	}
+	return failures
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/fakenode"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+)
+
+// rpcTransports are the transports served by the fake node's gRPC and REST servers
+var rpcTransports = []string{TransportGRPC, TransportREST}
+
+// newFakeClient starts a fake node and returns a client whose gRPC, REST and binary
+// transports all talk to it
+func newFakeClient(t *testing.T) (*fakenode.Node, *NodeClient) {
+	t.Helper()
+	t.Setenv("QUIL_CONFIG_FILE", filepath.Join(t.TempDir(), "config.yml"))
+
+	fake := fakenode.New()
+	t.Cleanup(fake.Close)
+	previous := node.SetCommandRunner(fake.Runner())
+	t.Cleanup(func() { node.SetCommandRunner(previous) })
+
+	nc := NewNodeClientWithQueriers(&config.Config{}, NewGRPCQuerier(fake.StartGRPC()), NewRESTQuerier(fake.Start()))
+	return fake, nc
+}
+
+// testPeerID returns a valid Qm peer ID made of c
+func testPeerID(c string) string {
+	return "Qm" + strings.Repeat(c, 44)
+}
+
+func TestGetFrameInfo(t *testing.T) {
+	fake, nc := newFakeClient(t)
+	fake.MaxFrame = 123456
+
+	for _, transport := range append(rpcTransports, TransportBinary) {
+		frames, err := nc.GetFrameInfo(transport)
+		if err != nil {
+			t.Fatalf("GetFrameInfo(%s) failed: %v", transport, err)
+		}
+		want := &FrameInfo{PeerID: fake.PeerID, MaxFrame: 123456, Transport: transport}
+		if !reflect.DeepEqual(frames, want) {
+			t.Errorf("GetFrameInfo(%s) = %+v, want %+v", transport, frames, want)
+		}
+	}
+}
+
+func TestGetTokenInfo(t *testing.T) {
+	fake, nc := newFakeClient(t)
+	fake.OwnedTokens = new(big.Int).Add(fakenode.Quil(12), big.NewInt(4000000000))
+	fake.UnconfirmedOwnedTokens = fakenode.Quil(2)
+	fake.ConfirmedSupply = fakenode.Quil(5000)
+	fake.UnconfirmedSupply = fakenode.Quil(5001)
+
+	for _, transport := range rpcTransports {
+		tokens, err := nc.GetTokenInfo(transport)
+		if err != nil {
+			t.Fatalf("GetTokenInfo(%s) failed: %v", transport, err)
+		}
+		want := &TokenInfo{
+			ConfirmedSupply:   "5000",
+			UnconfirmedSupply: "5001",
+			Owned:             "12.5",
+			UnconfirmedOwned:  "2",
+			Unit:              "QUIL",
+			Transport:         transport,
+		}
+		if !reflect.DeepEqual(tokens, want) {
+			t.Errorf("GetTokenInfo(%s) = %+v, want %+v", transport, tokens, want)
+		}
+	}
+
+	if _, err := nc.GetTokenInfo(TransportBinary); err == nil || !strings.Contains(err.Error(), "not available via binary") {
+		t.Errorf("GetTokenInfo(binary) error = %v", err)
+	}
+}
+
+func TestGetProverRing(t *testing.T) {
+	fake, nc := newFakeClient(t)
+
+	for _, ring := range []int{-1, 2} {
+		fake.Update(func(n *fakenode.Node) {
+			n.ProverRing = ring
+			n.Seniority = 98765
+		})
+		for _, transport := range append(rpcTransports, TransportBinary) {
+			info, err := nc.GetProverRing(transport)
+			if err != nil {
+				t.Fatalf("GetProverRing(%s) failed: %v", transport, err)
+			}
+			want := &ProverRingInfo{PeerID: fake.PeerID, ProverRing: ring, Seniority: "98765", Transport: transport}
+			if !reflect.DeepEqual(info, want) {
+				t.Errorf("GetProverRing(%s) = %+v, want %+v", transport, info, want)
+			}
+		}
+	}
+}
+
+func TestGetNetworkInfo(t *testing.T) {
+	fake, nc := newFakeClient(t)
+	fake.Peers = []fakenode.Peer{
+		{PeerID: testPeerID("A"), Multiaddrs: []string{"/ip4/198.51.100.1/udp/8336/quic-v1", "/ip4/198.51.100.1/tcp/8336"}, PeerScore: 1.5},
+		{PeerID: testPeerID("B"), Multiaddrs: []string{"/ip4/198.51.100.2/udp/8336/quic-v1"}, PeerScore: -20},
+	}
+
+	for _, transport := range rpcTransports {
+		info, err := nc.GetNetworkInfo(transport)
+		if err != nil {
+			t.Fatalf("GetNetworkInfo(%s) failed: %v", transport, err)
+		}
+		want := &NetworkInfo{Transport: transport}
+		for _, peer := range fake.Peers {
+			want.Peers = append(want.Peers, NetworkPeer{PeerID: peer.PeerID, Multiaddrs: peer.Multiaddrs, PeerScore: peer.PeerScore})
+		}
+		if !reflect.DeepEqual(info, want) {
+			t.Errorf("GetNetworkInfo(%s) = %+v, want %+v", transport, info, want)
+		}
+	}
+}
+
+func TestGetPeerCount(t *testing.T) {
+	fake, nc := newFakeClient(t)
+	for _, c := range []string{"A", "B", "C"} {
+		fake.Peers = append(fake.Peers, fakenode.Peer{PeerID: testPeerID(c), MaxFrame: 100})
+	}
+	fake.Uncooperative = []fakenode.Peer{{PeerID: testPeerID("D")}}
+
+	for _, transport := range rpcTransports {
+		count, err := nc.GetPeerCount(transport)
+		if err != nil {
+			t.Fatalf("GetPeerCount(%s) failed: %v", transport, err)
+		}
+		want := &PeerCount{Peers: 3, Uncooperative: 1, Transport: transport}
+		if !reflect.DeepEqual(count, want) {
+			t.Errorf("GetPeerCount(%s) = %+v, want %+v", transport, count, want)
+		}
+	}
+}
+
+// configRunner answers node commands with the fake node of their --config argument
+type configRunner map[string]*fakenode.Node
+
+func (r configRunner) Output(name string, args ...string) ([]byte, error) {
+	return r.CombinedOutput(name, args...)
+}
+
+func (r configRunner) CombinedOutput(name string, args ...string) ([]byte, error) {
+	for i, arg := range args {
+		if arg == "--config" && i+1 < len(args) {
+			if fake, ok := r[filepath.Base(args[i+1])]; ok {
+				return fake.Runner().CombinedOutput(name, args...)
+			}
+		}
+	}
+	return nil, fmt.Errorf("no such config")
+}
+
+func TestListSeniority(t *testing.T) {
+	_, nc := newFakeClient(t)
+
+	configsDir := t.TempDir()
+	runner := configRunner{}
+	for name, seniority := range map[string]uint64{"low": 10, "high": 500} {
+		fake := fakenode.New()
+		fake.PeerID = testPeerID(strings.ToUpper(name[:1]))
+		fake.Seniority = seniority
+		runner[name] = fake
+	}
+	for _, name := range []string{"broken", "high", "low"} {
+		if err := os.Mkdir(filepath.Join(configsDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	previous := node.SetCommandRunner(runner)
+	defer node.SetCommandRunner(previous)
+
+	entries, err := nc.ListSeniority(configsDir, "/opt/node")
+	if err != nil {
+		t.Fatalf("ListSeniority failed: %v", err)
+	}
+
+	var got []string
+	for _, entry := range entries {
+		got = append(got, fmt.Sprintf("%s %s %s", filepath.Base(entry.Config), entry.PeerID, entry.Seniority))
+	}
+	want := []string{
+		"high " + runner["high"].PeerID + " 500",
+		"low " + runner["low"].PeerID + " 10",
+		"broken  ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+	if entries[2].Error == "" {
+		t.Errorf("failing config has no error: %+v", entries[2])
+	}
+}
+
+func TestGetNodeInfoStoppedNode(t *testing.T) {
+	fake, nc := newFakeClient(t)
+	fake.Stopped = true
+
+	if nc.IsNodeRunning() {
+		t.Error("IsNodeRunning = true for a stopped node")
+	}
+	_, err := nc.GetNodeInfoVia(TransportGRPC)
+	if err == nil || !strings.Contains(err.Error(), "code = Unavailable desc = connection refused") {
+		t.Errorf("GetNodeInfoVia(grpc) error = %v", err)
+	}
+
+	// The binary still answers, so hybrid queries fall through to it
+	info, err := nc.GetNodeInfoHybrid()
+	if err != nil {
+		t.Fatalf("GetNodeInfoHybrid failed: %v", err)
+	}
+	if info.Transport != TransportBinary || info.PeerID != fake.PeerID {
+		t.Errorf("hybrid info = %s from %s", info.PeerID, info.Transport)
+	}
+
+	fake.Update(func(n *fakenode.Node) { n.Stopped = false })
+	if !nc.IsNodeRunning() {
+		t.Error("IsNodeRunning = false for a running node")
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// restCaller calls NodeService methods through the node's REST gateway
+// Methods are served at POST /<service>/<method> with a JSON body
+type restCaller struct {
+	baseURL string // e.g., "http://localhost:8338"
+	client  *http.Client
+}
+
+// NewRESTQuerier creates a NodeQuerier using the node's REST API
+func NewRESTQuerier(baseURL string) NodeQuerier {
+	return &rpcQuerier{
+		transport: TransportREST,
+		caller: &restCaller{
+			baseURL: strings.TrimSuffix(baseURL, "/"),
+			client:  &http.Client{Timeout: 10 * time.Second},
+		},
+	}
+}
+
+// call invokes a NodeService method over HTTP and decodes the protojson response
+func (c *restCaller) call(method string, out any) error {
+	url := fmt.Sprintf("%s/%s/%s", c.baseURL, nodeService, method)
+	resp, err := c.client.Post(url, "application/json", bytes.NewBufferString("{}"))
+	if err != nil {
+		return fmt.Errorf("REST call %s failed (node may not be running): %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read REST response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("REST call %s failed: status %d: %s", method, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	return nil
+}
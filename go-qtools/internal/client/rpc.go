@@ -0,0 +1,122 @@
+package client
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+)
+
+// nodeService is the fully qualified name of the node's gRPC service
+const nodeService = "quilibrium.node.node.pb.NodeService"
+
+// quilUnitsPerToken converts raw token amounts to QUIL (0x1DCD65000)
+var quilUnitsPerToken = big.NewInt(8000000000)
+
+// rpcCaller calls a NodeService method with an empty request and decodes the response into resp
+type rpcCaller interface {
+	call(method string, resp any) error
+}
+
+// rpcQuerier implements NodeQuerier on top of an rpcCaller (gRPC or REST)
+type rpcQuerier struct {
+	transport string
+	caller    rpcCaller
+}
+
+// Transport returns the transport name
+func (q *rpcQuerier) Transport() string {
+	return q.transport
+}
+
+// GetNodeInfo calls GetNodeInfo and, best-effort, GetTokenInfo for the balance
+func (q *rpcQuerier) GetNodeInfo() (*NodeInfo, error) {
+	var resp nodeInfoResponse
+	if err := q.caller.call("GetNodeInfo", &resp); err != nil {
+		return nil, err
+	}
+	if resp.PeerID == "" {
+		return nil, fmt.Errorf("GetNodeInfo response has no peer ID")
+	}
+
+	info := &NodeInfo{
+		NodeInfo: node.NodeInfo{
+			PeerID:        resp.PeerID,
+			Version:       formatVersionBytes(resp.Version),
+			MaxFrame:      uint64(resp.MaxFrame),
+			PeerScore:     int64(resp.PeerScore),
+			ProverRing:    resp.ProverRing,
+			Seniority:     new(big.Int).SetBytes(resp.PeerSeniority).String(),
+			ActiveWorkers: int(resp.Workers),
+			WorkerCount:   int(resp.Workers),
+			Format:        q.transport,
+		},
+		Transport: q.transport,
+	}
+
+	if tokens, err := q.GetTokenInfo(); err == nil {
+		info.Balance = tokens.Owned
+		info.BalanceUnit = tokens.Unit
+		if tokens.UnconfirmedOwned != "0" {
+			info.UnconfirmedBalance = tokens.UnconfirmedOwned
+		}
+	}
+
+	return info, nil
+}
+
+// nodeInfoResponse is the protojson form of NodeInfoResponse
+type nodeInfoResponse struct {
+	PeerID        string      `json:"peerId"`
+	MaxFrame      protoUint64 `json:"maxFrame"`
+	PeerScore     protoUint64 `json:"peerScore"`
+	Version       []byte      `json:"version"`
+	PeerSeniority []byte      `json:"peerSeniority"`
+	ProverRing    int         `json:"proverRing"`
+	Workers       protoUint64 `json:"workers"`
+}
+
+// tokenInfoResponse is the protojson form of TokenInfoResponse
+type tokenInfoResponse struct {
+	ConfirmedTokenSupply   []byte `json:"confirmedTokenSupply"`
+	UnconfirmedTokenSupply []byte `json:"unconfirmedTokenSupply"`
+	OwnedTokens            []byte `json:"ownedTokens"`
+	UnconfirmedOwnedTokens []byte `json:"unconfirmedOwnedTokens"`
+}
+
+// protoUint64 decodes 64-bit integers, which protojson encodes as strings
+type protoUint64 uint64
+
+// UnmarshalJSON accepts both "123" and 123
+func (p *protoUint64) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*p = 0
+		return nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s: %w", s, err)
+	}
+	*p = protoUint64(v)
+	return nil
+}
+
+// formatVersionBytes formats version bytes (e.g., [2 0 6 2]) as "2.0.6.2"
+func formatVersionBytes(version []byte) string {
+	parts := make([]string, len(version))
+	for i, b := range version {
+		parts[i] = strconv.Itoa(int(b))
+	}
+	return strings.Join(parts, ".")
+}
+
+// formatQuil converts a big-endian raw token amount to a QUIL decimal string
+func formatQuil(amount []byte) string {
+	r := new(big.Rat).SetFrac(new(big.Int).SetBytes(amount), quilUnitsPerToken)
+	s := r.FloatString(12)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -49,6 +49,36 @@ type ServiceConfig struct {
 	Clustering        *ClusteringConfig   `yaml:"clustering,omitempty"`
 	Args              string              `yaml:"args"`
 	MaxThreads        interface{}         `yaml:"max_threads"` // Can be bool or int
+	Overrides         *ServiceOverridesConfig `yaml:"overrides,omitempty"`
+	Backend           string              `yaml:"backend"` // auto, systemd, systemd-user, launchd, openrc, runit or supervisor
+	StopGracePeriod   string              `yaml:"stop_grace_period"` // Wait after SIGINT before SIGTERM, then SIGKILL (default 180s)
+	Supervisor        *SupervisorConfig   `yaml:"supervisor,omitempty"`
+}
+
+// SupervisorConfig represents settings for the built-in process supervisor (qtools supervise)
+type SupervisorConfig struct {
+	Socket       string `yaml:"socket"`          // Control socket (default <qtools path>/supervise/supervise.sock)
+	LogDir       string `yaml:"log_dir"`         // Process output (default <qtools path>/supervise/logs)
+	LogMaxSizeMB int    `yaml:"log_max_size_mb"` // Rotate a log at this size (default 100)
+	LogMaxFiles  int    `yaml:"log_max_files"`   // Rotated logs kept per process (default 5)
+	StopTimeout  string `yaml:"stop_timeout"`    // Wait after SIGINT before SIGKILL (default 240s)
+}
+
+// ServiceOverridesConfig holds systemd settings rendered as drop-in override.conf files
+type ServiceOverridesConfig struct {
+	Master *UnitOverrides `yaml:"master,omitempty"`
+	Worker *UnitOverrides `yaml:"worker,omitempty"`
+}
+
+// UnitOverrides represents [Service] settings for one unit
+type UnitOverrides struct {
+	Hardening         bool              `yaml:"hardening"`           // NoNewPrivileges, ProtectSystem=full, ProtectHome=read-only, PrivateTmp
+	MemoryMax         string            `yaml:"memory_max"`          // e.g., "16G"
+	CPUQuota          string            `yaml:"cpu_quota"`           // e.g., "400%"
+	Nice              *int              `yaml:"nice"`                // -20 to 19
+	IOSchedulingClass string            `yaml:"io_scheduling_class"` // realtime, best-effort, idle or none
+	LimitNOFILE       int               `yaml:"limit_nofile"`
+	Extra             map[string]string `yaml:"extra,omitempty"` // Additional [Service] settings
 }
 
 // WorkerServiceConfig represents worker service configuration
@@ -56,6 +86,7 @@ type WorkerServiceConfig struct {
 	GOGC        string `yaml:"gogc"`
 	GOMEMLimit  string `yaml:"gomemlimit"`
 	RestartTime string `yaml:"restart_time"`
+	Concurrency int    `yaml:"concurrency"` // Workers started/stopped/restarted at once
 }
 
 // ClusteringConfig represents clustering configuration
@@ -94,28 +125,311 @@ type ManualConfig struct {
 }
 
 // ScheduledTasksConfig represents scheduled tasks configuration
+// Each section keeps keys it does not know in Extra, so nothing is lost when it is written back
 type ScheduledTasksConfig struct {
-	Cluster      map[string]interface{} `yaml:"cluster,omitempty"`
-	DirectPeers  map[string]interface{} `yaml:"direct_peers,omitempty"`
-	Backup       map[string]interface{} `yaml:"backup,omitempty"`
-	Updates      map[string]interface{} `yaml:"updates,omitempty"`
-	Logs         map[string]interface{} `yaml:"logs,omitempty"`
-	Statistics   map[string]interface{} `yaml:"statistics,omitempty"`
-	Diagnostics  map[string]interface{} `yaml:"diagnostics,omitempty"`
-	PublicIP     map[string]interface{} `yaml:"public_ip,omitempty"`
+	Cluster     *ClusterTasksConfig    `yaml:"cluster,omitempty"`
+	DirectPeers *TaskConfig            `yaml:"direct_peers,omitempty"`
+	Backup      *BackupTaskConfig      `yaml:"backup,omitempty"`
+	Updates     *UpdatesTasksConfig    `yaml:"updates,omitempty"`
+	Logs        *TaskConfig            `yaml:"logs,omitempty"`
+	Statistics  *StatisticsTaskConfig  `yaml:"statistics,omitempty"`
+	Diagnostics *DiagnosticsTaskConfig `yaml:"diagnostics,omitempty"`
+	PublicIP    *PublicIPTaskConfig    `yaml:"public_ip,omitempty"`
+	Extra       map[string]interface{} `yaml:",inline"`
+
+	warnings []string // Values of the wrong type, see Config.Warnings
+}
+
+// Task returns the schedule of the task at a path under scheduled_tasks, e.g., updates.node,
+// or nil when the task is unknown or not configured
+func (c *ScheduledTasksConfig) Task(path string) *TaskSchedule {
+	if c == nil {
+		return nil
+	}
+	switch path {
+	case "updates.node":
+		if c.Updates != nil && c.Updates.Node != nil {
+			return &c.Updates.Node.TaskSchedule
+		}
+	case "updates.qtools":
+		if c.Updates != nil && c.Updates.Qtools != nil {
+			return &c.Updates.Qtools.TaskSchedule
+		}
+	case "updates.system":
+		if c.Updates != nil && c.Updates.System != nil {
+			return &c.Updates.System.TaskSchedule
+		}
+	case "cluster.memory_check":
+		if c.Cluster != nil && c.Cluster.MemoryCheck != nil {
+			return &c.Cluster.MemoryCheck.TaskSchedule
+		}
+	case "cluster.auto_reconnect":
+		if c.Cluster != nil && c.Cluster.AutoReconnect != nil {
+			return &c.Cluster.AutoReconnect.TaskSchedule
+		}
+	case "direct_peers":
+		if c.DirectPeers != nil {
+			return &c.DirectPeers.TaskSchedule
+		}
+	case "backup":
+		if c.Backup != nil {
+			return &c.Backup.TaskSchedule
+		}
+	case "logs":
+		if c.Logs != nil {
+			return &c.Logs.TaskSchedule
+		}
+	case "statistics":
+		if c.Statistics != nil {
+			return &TaskSchedule{Enabled: c.Statistics.Enabled}
+		}
+	case "diagnostics":
+		if c.Diagnostics != nil {
+			return &c.Diagnostics.TaskSchedule
+		}
+	case "diagnostics.crash_loop":
+		if c.Diagnostics != nil && c.Diagnostics.CrashLoop != nil {
+			return &c.Diagnostics.CrashLoop.TaskSchedule
+		}
+	case "public_ip":
+		if c.PublicIP != nil {
+			return &c.PublicIP.TaskSchedule
+		}
+	}
+	return nil
+}
+
+// TaskSchedule represents when a scheduled task runs
+// Timeout and Jitter override settings.scheduler for the task (see ParseDuration)
+type TaskSchedule struct {
+	Enabled        bool   `yaml:"enabled"`
+	CronExpression string `yaml:"cron_expression"` // Empty uses the task's default schedule
+	Timeout        string `yaml:"timeout,omitempty"`
+	Jitter         string `yaml:"jitter,omitempty"`
+}
+
+// TaskConfig represents a scheduled task with no settings of its own
+type TaskConfig struct {
+	TaskSchedule `yaml:",inline"`
+	Extra        map[string]interface{} `yaml:",inline"`
+}
+
+// ClusterTasksConfig represents scheduled_tasks.cluster
+type ClusterTasksConfig struct {
+	MemoryCheck   *MemoryCheckTaskConfig   `yaml:"memory_check,omitempty"`
+	AutoReconnect *AutoReconnectTaskConfig `yaml:"auto_reconnect,omitempty"`
+	Extra         map[string]interface{}   `yaml:",inline"`
+}
+
+// MemoryCheckTaskConfig represents the memory watchdog (qtools diagnostics check-memory)
+type MemoryCheckTaskConfig struct {
+	TaskSchedule      `yaml:",inline"`
+	RestartWorkers    *bool                  `yaml:"restart_workers"` // Unset restarts workers
+	MemoryThreshold   int                    `yaml:"memory_threshold"` // Percent of host memory in use
+	RestartMaster     bool                   `yaml:"restart_master"`
+	WorkerThresholdMB int                    `yaml:"worker_threshold_mb"`
+	Cooldown          string                 `yaml:"cooldown"`
+	Extra             map[string]interface{} `yaml:",inline"`
+}
+
+// AutoReconnectTaskConfig represents reconnecting cluster workers
+type AutoReconnectTaskConfig struct {
+	TaskSchedule    `yaml:",inline"`
+	IntervalSeconds int                    `yaml:"interval_seconds"`
+	RetryCount      int                    `yaml:"retry_count"`
+	Extra           map[string]interface{} `yaml:",inline"`
+}
+
+// BackupTaskConfig represents store backups to a remote server
+type BackupTaskConfig struct {
+	TaskSchedule    `yaml:",inline"`
+	NodeBackupName  string                 `yaml:"node_backup_name"`
+	BackupURL       string                 `yaml:"backup_url"`
+	RemoteUser      string                 `yaml:"remote_user"`
+	SSHKeyPath      string                 `yaml:"ssh_key_path"`
+	RemoteBackupDir string                 `yaml:"remote_backup_dir"`
+	Extra           map[string]interface{} `yaml:",inline"`
+}
+
+// UpdatesTasksConfig represents scheduled_tasks.updates
+type UpdatesTasksConfig struct {
+	Qtools *TaskConfig            `yaml:"qtools,omitempty"`
+	Node   *NodeUpdateTaskConfig  `yaml:"node,omitempty"`
+	System *TaskConfig            `yaml:"system,omitempty"`
+	Extra  map[string]interface{} `yaml:",inline"`
+}
+
+// NodeUpdateTaskConfig represents node auto-updates
+type NodeUpdateTaskConfig struct {
+	TaskSchedule `yaml:",inline"`
+	SkipVersion  string                 `yaml:"skip_version"` // Release version not to update to
+	Extra        map[string]interface{} `yaml:",inline"`
+}
+
+// StatisticsTaskConfig represents shipping metrics and logs to the stats server
+type StatisticsTaskConfig struct {
+	Enabled     bool                   `yaml:"enabled"`
+	ServiceName string                 `yaml:"service_name"`
+	Prometheus  *RemoteWriteConfig     `yaml:"prometheus,omitempty"`
+	Loki        *RemoteWriteConfig     `yaml:"loki,omitempty"`
+	Grafana     *GrafanaConfig         `yaml:"grafana,omitempty"`
+	Extra       map[string]interface{} `yaml:",inline"`
+}
+
+// RemoteWriteConfig represents an endpoint statistics are pushed to
+type RemoteWriteConfig struct {
+	Endpoint  string                 `yaml:"endpoint"`
+	TLSConfig *TLSConfig             `yaml:"tls_config,omitempty"`
+	Extra     map[string]interface{} `yaml:",inline"`
+}
+
+// TLSConfig represents a client certificate for a statistics endpoint
+type TLSConfig struct {
+	CertFile   string                 `yaml:"cert_file"`
+	KeyFile    string                 `yaml:"key_file"`
+	ServerName string                 `yaml:"server_name"`
+	Extra      map[string]interface{} `yaml:",inline"`
+}
+
+// GrafanaConfig represents the Grafana agents statistics runs
+type GrafanaConfig struct {
+	Alloy *AlloyConfig           `yaml:"alloy,omitempty"`
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+// AlloyConfig represents the Grafana Alloy agent
+type AlloyConfig struct {
+	Enabled      bool                   `yaml:"enabled"`
+	TemplateFile string                 `yaml:"template_file"`
+	ConfigFile   string                 `yaml:"config_file"`
+	Extra        map[string]interface{} `yaml:",inline"`
+}
+
+// DiagnosticsTaskConfig represents scheduled diagnostics and the crash-loop watchdog
+type DiagnosticsTaskConfig struct {
+	TaskSchedule `yaml:",inline"`
+	CrashLoop    *CrashLoopConfig       `yaml:"crash_loop,omitempty"`
+	Extra        map[string]interface{} `yaml:",inline"`
+}
+
+// CrashLoopConfig represents the crash-loop watchdog (qtools diagnostics check-crash-loops):
+// when a unit is crash looping and what is done about it
+type CrashLoopConfig struct {
+	TaskSchedule       `yaml:",inline"`
+	Window             string                 `yaml:"window"`
+	MaxRestarts        int                    `yaml:"max_restarts"`
+	MaxRestartsPerHour int                    `yaml:"max_restarts_per_hour"`
+	Remediation        string                 `yaml:"remediation"` // notify, backoff, disable or rollback
+	Backoff            string                 `yaml:"backoff"`
+	MaxBackoff         string                 `yaml:"max_backoff"`
+	Extra              map[string]interface{} `yaml:",inline"`
+}
+
+// PublicIPTaskConfig represents the public IP monitor (qtools util monitor-public-ip)
+type PublicIPTaskConfig struct {
+	TaskSchedule  `yaml:",inline"`
+	PreviousIP    string                 `yaml:"previous_ip"`
+	Providers     []string               `yaml:"providers,omitempty"` // http(s)://url, stun:host:port or static:ip
+	Quorum        int                    `yaml:"quorum"`
+	RestartMaster bool                   `yaml:"restart_master"`
+	Extra         map[string]interface{} `yaml:",inline"`
 }
 
 // SettingsConfig represents settings configuration
 type SettingsConfig struct {
-	UseAVX512        bool                   `yaml:"use_avx512"`
-	PublishMultiaddr map[string]interface{} `yaml:"publish_multiaddr,omitempty"`
-	CentralServer    map[string]interface{} `yaml:"central_server,omitempty"`
-	ListenAddr       map[string]interface{} `yaml:"listenAddr,omitempty"`
-	SourceRepository map[string]interface{} `yaml:"source_repository,omitempty"`
-	Install          map[string]interface{} `yaml:"install,omitempty"`
-	LogFile          string                 `yaml:"log_file"`
-	Snapshots        map[string]interface{} `yaml:"snapshots,omitempty"`
-	InternalIP       string                 `yaml:"internal_ip"`
+	UseAVX512        bool                    `yaml:"use_avx512"`
+	PublishMultiaddr *PublishMultiaddrConfig `yaml:"publish_multiaddr,omitempty"`
+	CentralServer    *CentralServerConfig    `yaml:"central_server,omitempty"`
+	ListenAddr       *ListenAddrConfig       `yaml:"listenAddr,omitempty"`
+	SourceRepository *SourceRepositoryConfig `yaml:"source_repository,omitempty"`
+	Install          *InstallSettingsConfig  `yaml:"install,omitempty"`
+	LogFile          string                  `yaml:"log_file"`
+	Snapshots        *SnapshotsConfig        `yaml:"snapshots,omitempty"`
+	InternalIP       string                  `yaml:"internal_ip"`
+	NodeQuery        *NodeQueryConfig        `yaml:"node_query,omitempty"`
+	Notifications    *NotificationsConfig    `yaml:"notifications,omitempty"`
+	Scheduler        *SchedulerConfig        `yaml:"scheduler,omitempty"`
+	Extra            map[string]interface{}  `yaml:",inline"`
+
+	warnings []string // Values of the wrong type, see Config.Warnings
+}
+
+// PublishMultiaddrConfig represents publishing this node's multiaddr to the central server
+type PublishMultiaddrConfig struct {
+	Enabled    bool                   `yaml:"enabled"`
+	RemoteFile string                 `yaml:"remote_file"`
+	Extra      map[string]interface{} `yaml:",inline"`
+}
+
+// CentralServerConfig represents the server direct peers are shared through
+type CentralServerConfig struct {
+	SSHKeyPath string                 `yaml:"ssh_key_path"`
+	RemoteUser string                 `yaml:"remote_user"`
+	RemoteHost string                 `yaml:"remote_host"`
+	Extra      map[string]interface{} `yaml:",inline"`
+}
+
+// ListenAddrConfig represents the node's P2P listen address
+type ListenAddrConfig struct {
+	Mode  string                 `yaml:"mode"` // udp or tcp
+	Port  int                    `yaml:"port"`
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+// SourceRepositoryConfig represents where the node source is cloned from
+type SourceRepositoryConfig struct {
+	Default string                 `yaml:"default"`
+	Mirrors []string               `yaml:"mirrors,omitempty"`
+	Extra   map[string]interface{} `yaml:",inline"`
+}
+
+// InstallSettingsConfig represents options for qtools install
+type InstallSettingsConfig struct {
+	Tailscale *TailscaleInstallConfig `yaml:"tailscale,omitempty"`
+	SSH       *SSHInstallConfig       `yaml:"ssh,omitempty"`
+	Extra     map[string]interface{}  `yaml:",inline"`
+}
+
+// TailscaleInstallConfig represents joining a tailnet on install
+type TailscaleInstallConfig struct {
+	EphemeralKey string                 `yaml:"ephemeral_key"`
+	Extra        map[string]interface{} `yaml:",inline"`
+}
+
+// SSHInstallConfig represents SSH hardening on install
+type SSHInstallConfig struct {
+	DisablePasswordLogin bool                   `yaml:"disable_password_login"`
+	PublicKeyURL         string                 `yaml:"public_key_url"`
+	PublicKeyString      string                 `yaml:"public_key_string"`
+	Extra                map[string]interface{} `yaml:",inline"`
+}
+
+// SnapshotsConfig represents node store snapshots
+type SnapshotsConfig struct {
+	Enabled bool                   `yaml:"enabled"`
+	Extra   map[string]interface{} `yaml:",inline"`
+}
+
+// NodeQueryConfig represents how node info is queried
+type NodeQueryConfig struct {
+	Transports []string               `yaml:"transports,omitempty"` // Order to try grpc, rest and binary in
+	Extra      map[string]interface{} `yaml:",inline"`
+}
+
+// NotificationsConfig represents where watchdog actions are sent
+type NotificationsConfig struct {
+	Command    string                 `yaml:"command"`     // Receives each action as JSON on stdin
+	WebhookURL string                 `yaml:"webhook_url"` // Receives each action as a JSON POST
+	Extra      map[string]interface{} `yaml:",inline"`
+}
+
+// SchedulerConfig represents how scheduled tasks run
+type SchedulerConfig struct {
+	Backend      string                 `yaml:"backend"` // daemon, cron or systemd-timer
+	Timeout      string                 `yaml:"timeout"`
+	Jitter       string                 `yaml:"jitter"`
+	HistoryLimit int                    `yaml:"history_limit"`
+	Extra        map[string]interface{} `yaml:",inline"`
 }
 
 // DevConfig represents development configuration
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sampleConfigPath is the sample config shipped at the root of the repository
+const sampleConfigPath = "../../../config.sample.yml"
+
+// TestSampleConfigRoundTrip checks that the typed scheduled_tasks and settings sections
+// hold every key of the sample config, so marshaling them gives back the same values
+func TestSampleConfigRoundTrip(t *testing.T) {
+	data, err := os.ReadFile(sampleConfigPath)
+	if err != nil {
+		t.Fatalf("failed to read sample config: %v", err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("failed to parse sample config: %v", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("failed to unmarshal sample config: %v", err)
+	}
+	if warnings := cfg.Warnings(); len(warnings) > 0 {
+		t.Errorf("unexpected warnings for the sample config: %v", warnings)
+	}
+
+	sections := map[string]interface{}{
+		"scheduled_tasks": cfg.ScheduledTasks,
+		"settings":        cfg.Settings,
+	}
+	for name, section := range sections {
+		out, err := yaml.Marshal(section)
+		if err != nil {
+			t.Fatalf("failed to marshal %s: %v", name, err)
+		}
+		var got interface{}
+		if err := yaml.Unmarshal(out, &got); err != nil {
+			t.Fatalf("failed to parse marshaled %s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, raw[name]) {
+			t.Errorf("%s does not round-trip\ngot:\n%s", name, out)
+		}
+	}
+
+	// Spot-check values reach the typed fields
+	if got := cfg.ScheduledTasks.Task("updates.node"); got == nil {
+		t.Error("scheduled_tasks.updates.node is missing")
+	}
+	if cfg.Settings.Scheduler == nil || cfg.Settings.Scheduler.Backend != "daemon" {
+		t.Errorf("settings.scheduler.backend = %+v, want daemon", cfg.Settings.Scheduler)
+	}
+}
+
+// TestLoadConfigMalformedValues checks that values of the wrong type are ignored with a
+// warning instead of failing LoadConfig, and that they are kept when the config is saved
+func TestLoadConfigMalformedValues(t *testing.T) {
+	const content = `scheduled_tasks:
+    cluster:
+        memory_check:
+            enabled: true
+            memory_threshold: "80%"
+            worker_threshold_mb: 2048
+    public_ip:
+        enabled: yes please
+        providers: https://api.ipify.org
+        quorum: 2
+    diagnostics: off
+    my_task:
+        enabled: true
+settings:
+    scheduler:
+        backend: cron
+        history_limit: [1000]
+        timeout: 300
+    notifications: "ops@example.com"
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	memoryCheck := cfg.ScheduledTasks.Cluster.MemoryCheck
+	if !memoryCheck.Enabled || memoryCheck.MemoryThreshold != 0 || memoryCheck.WorkerThresholdMB != 2048 {
+		t.Errorf("memory_check = %+v, want enabled, threshold unset and worker_threshold_mb 2048", memoryCheck)
+	}
+	publicIP := cfg.ScheduledTasks.PublicIP
+	if publicIP.Enabled || publicIP.Providers != nil || publicIP.Quorum != 2 {
+		t.Errorf("public_ip = %+v, want disabled, no providers and quorum 2", publicIP)
+	}
+	if cfg.ScheduledTasks.Diagnostics != nil {
+		t.Errorf("diagnostics = %+v, want unset", cfg.ScheduledTasks.Diagnostics)
+	}
+	if _, ok := cfg.ScheduledTasks.Extra["my_task"]; !ok {
+		t.Error("unknown task my_task was not kept")
+	}
+	scheduler := cfg.Settings.Scheduler
+	if scheduler.Backend != "cron" || scheduler.HistoryLimit != 0 || scheduler.Timeout != "300" {
+		t.Errorf("scheduler = %+v, want backend cron, history_limit unset and timeout 300", scheduler)
+	}
+	if DurationOr(scheduler.Timeout, time.Minute) != 300*time.Second {
+		t.Errorf("timeout %q did not parse as seconds", scheduler.Timeout)
+	}
+
+	wantWarnings := []string{
+		"scheduled_tasks.cluster.memory_check.memory_threshold",
+		"scheduled_tasks.public_ip.enabled",
+		"scheduled_tasks.public_ip.providers",
+		"scheduled_tasks.diagnostics",
+		"settings.scheduler.history_limit",
+		"settings.notifications",
+	}
+	warnings := cfg.Warnings()
+	if len(warnings) != len(wantWarnings) {
+		t.Errorf("got %d warnings, want %d: %q", len(warnings), len(wantWarnings), warnings)
+	}
+	for _, want := range wantWarnings {
+		found := false
+		for _, warning := range warnings {
+			if strings.HasPrefix(warning, want+": ") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no warning for %s in %q", want, warnings)
+		}
+	}
+
+	// The raw config is saved as it was read
+	if err := SaveConfig(cfg, path); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	saved, err := ReadConfigRaw(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	threshold, err := GetConfigValue(&Config{Raw: saved}, "scheduled_tasks.cluster.memory_check.memory_threshold")
+	if err != nil || threshold != "80%" {
+		t.Errorf("saved memory_threshold = %v (%v), want 80%%", threshold, err)
+	}
+}
+
+// TestSetConfigValueSyncsTypedSection checks that typed sections follow SetConfigValue
+func TestSetConfigValueSyncsTypedSection(t *testing.T) {
+	cfg := &Config{Raw: map[string]interface{}{}}
+	if err := SetConfigValue(cfg, "scheduled_tasks.updates.node.skip_version", "2.1.0"); err != nil {
+		t.Fatalf("SetConfigValue failed: %v", err)
+	}
+	if cfg.ScheduledTasks.Updates.Node.SkipVersion != "2.1.0" {
+		t.Errorf("skip_version = %q, want 2.1.0", cfg.ScheduledTasks.Updates.Node.SkipVersion)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "10m", want: 10 * time.Minute},
+		{value: "90", want: 90 * time.Second},
+		{value: " 1h30m ", want: 90 * time.Minute},
+		{value: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v (error: %t)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // GenerateDefaultConfig generates a default config programmatically
@@ -174,6 +176,7 @@ func GetConfigValue(config *Config, path string) (interface{}, error) {
 }
 
 // SetConfigValue sets a config value by dot-separated path
+// The typed scheduled_tasks and settings sections are refreshed to match
 func SetConfigValue(config *Config, path string, value interface{}) error {
 	if config.Raw == nil {
 		config.Raw = make(map[string]interface{})
@@ -186,7 +189,7 @@ func SetConfigValue(config *Config, path string, value interface{}) error {
 		if i == len(keys)-1 {
 			// Last key, set value
 			current[key] = value
-			return nil
+			return syncTypedSection(config, keys[0])
 		}
 
 		// Navigate deeper, creating maps as needed
@@ -202,6 +205,30 @@ func SetConfigValue(config *Config, path string, value interface{}) error {
 	return nil
 }
 
+// syncTypedSection refreshes the typed scheduled_tasks or settings section after its raw value changes
+func syncTypedSection(config *Config, key string) error {
+	var target interface{}
+	switch key {
+	case "scheduled_tasks":
+		config.ScheduledTasks = &ScheduledTasksConfig{}
+		target = config.ScheduledTasks
+	case "settings":
+		config.Settings = &SettingsConfig{}
+		target = config.Settings
+	default:
+		return nil
+	}
+
+	data, err := yaml.Marshal(config.Raw[key])
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+	if err := yaml.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", key, err)
+	}
+	return nil
+}
+
 // isExplicitlySet checks if a config value was explicitly set (not just default)
 func isExplicitlySet(raw map[string]interface{}, path string) bool {
 	if raw == nil {
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalYAML decodes scheduled_tasks leniently: a value of the wrong type leaves its field
+// unset and is reported by Config.Warnings instead of failing the whole config
+func (c *ScheduledTasksConfig) UnmarshalYAML(value *yaml.Node) error {
+	*c = ScheduledTasksConfig{}
+	c.warnings = decodeLenient(value, reflect.ValueOf(c).Elem(), "scheduled_tasks")
+	return nil
+}
+
+// UnmarshalYAML decodes settings leniently, like ScheduledTasksConfig
+func (c *SettingsConfig) UnmarshalYAML(value *yaml.Node) error {
+	*c = SettingsConfig{}
+	c.warnings = decodeLenient(value, reflect.ValueOf(c).Elem(), "settings")
+	return nil
+}
+
+// Warnings returns the values in scheduled_tasks and settings that were ignored because
+// they have the wrong type, e.g., memory_threshold: "80%"
+// The raw config keeps them, so saving the config does not lose them
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if c.ScheduledTasks != nil {
+		warnings = append(warnings, c.ScheduledTasks.warnings...)
+	}
+	if c.Settings != nil {
+		warnings = append(warnings, c.Settings.warnings...)
+	}
+	return warnings
+}
+
+// lenientField is a struct field decoded by decodeLenient
+type lenientField struct {
+	index []int
+	typ   reflect.Type
+}
+
+// decodeLenient decodes a mapping node into the struct out field by field
+// Nested config structs are decoded the same way; keys without a field go to the struct's
+// inline map; values that do not fit their field are skipped and returned as warnings
+func decodeLenient(node *yaml.Node, out reflect.Value, path string) []string {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return []string{fmt.Sprintf("%s: expected a mapping, got %s; ignoring it", path, describeNode(node))}
+	}
+
+	fields, extra := lenientFields(out.Type())
+	var warnings []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		keyPath := path + "." + key
+
+		field, ok := fields[key]
+		if !ok {
+			if extra == nil {
+				continue
+			}
+			var v interface{}
+			if err := value.Decode(&v); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: %v; ignoring it", keyPath, err))
+				continue
+			}
+			m := out.FieldByIndex(extra)
+			if m.IsNil() {
+				m.Set(reflect.MakeMap(m.Type()))
+			}
+			m.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(&v).Elem())
+			continue
+		}
+
+		target := out.FieldByIndex(field.index)
+		if elem := configStruct(field.typ); elem != nil {
+			if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+				continue
+			}
+			decoded := reflect.New(elem)
+			if value.Kind != yaml.MappingNode {
+				warnings = append(warnings, fmt.Sprintf("%s: expected a mapping, got %s; ignoring it", keyPath, describeNode(value)))
+				continue
+			}
+			warnings = append(warnings, decodeLenient(value, decoded.Elem(), keyPath)...)
+			if field.typ.Kind() == reflect.Ptr {
+				target.Set(decoded)
+			} else {
+				target.Set(decoded.Elem())
+			}
+			continue
+		}
+
+		decoded := reflect.New(field.typ)
+		if err := value.Decode(decoded.Interface()); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: expected %s, got %s; ignoring it", keyPath, describeType(field.typ), describeNode(value)))
+			continue
+		}
+		target.Set(decoded.Elem())
+	}
+	return warnings
+}
+
+// lenientFields maps the yaml keys of a struct to its fields, following inline structs,
+// and returns the index of its inline map, if any
+func lenientFields(t reflect.Type) (map[string]lenientField, []int) {
+	fields := make(map[string]lenientField)
+	var extra []int
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if strings.Contains(opts, "inline") {
+			switch f.Type.Kind() {
+			case reflect.Map:
+				extra = []int{i}
+			case reflect.Struct:
+				inner, _ := lenientFields(f.Type)
+				for key, field := range inner {
+					field.index = append([]int{i}, field.index...)
+					fields[key] = field
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = lenientField{index: []int{i}, typ: f.Type}
+	}
+	return fields, extra
+}
+
+// configStruct returns the struct type of a nested config section (a struct or a pointer to one)
+// Such sections are decoded leniently too
+func configStruct(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.PkgPath() != reflect.TypeOf(Config{}).PkgPath() {
+		return nil
+	}
+	return t
+}
+
+// describeNode describes a YAML value for a warning
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("%q", node.Value)
+	}
+}
+
+// describeType describes a field type for a warning
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return describeType(t.Elem())
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64, reflect.Uint64, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "a mapping"
+	default:
+		return t.String()
+	}
+}
+
+// ParseDuration parses a duration setting: a Go duration such as "10m", or a number of seconds
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// DurationOr parses a duration setting, returning def when it is empty or invalid
+func DurationOr(value string, def time.Duration) time.Duration {
+	if strings.TrimSpace(value) == "" {
+		return def
+	}
+	d, err := ParseDuration(value)
+	if err != nil {
+		return def
+	}
+	return d
+}
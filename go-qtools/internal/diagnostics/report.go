@@ -0,0 +1,44 @@
+// Package diagnostics assembles reports about the node and its services
+package diagnostics
+
+import (
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/client"
+	"github.com/tjsturos/qtools/go-qtools/internal/service"
+)
+
+// StatusReport combines the service status with what the node reports about itself
+type StatusReport struct {
+	GeneratedAt  time.Time         `json:"generated_at"`
+	Services     *service.Status   `json:"services,omitempty"`
+	ServiceError string            `json:"service_error,omitempty"`
+	Running      bool              `json:"running"` // Node answered over gRPC
+	Node         *client.NodeInfo  `json:"node,omitempty"`
+	NodeError    string            `json:"node_error,omitempty"`
+	Peers        *client.PeerCount `json:"peers,omitempty"`
+}
+
+// BuildStatusReport queries the node through nc and adds the given service status
+// The peer count is best-effort: it is left out when the node does not answer over RPC
+func BuildStatusReport(nc client.NodeAPI, services *service.Status, serviceErr error) *StatusReport {
+	report := &StatusReport{
+		GeneratedAt: time.Now(),
+		Services:    services,
+		Running:     nc.IsNodeRunning(),
+	}
+	if serviceErr != nil {
+		report.ServiceError = serviceErr.Error()
+	}
+
+	if info, err := nc.GetNodeInfoHybrid(); err != nil {
+		report.NodeError = err.Error()
+	} else {
+		report.Node = info
+	}
+	if peers, err := nc.GetPeerCount(""); err == nil {
+		report.Peers = peers
+	}
+
+	return report
+}
@@ -0,0 +1,43 @@
+package diagnostics
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/client"
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/fakenode"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+	"github.com/tjsturos/qtools/go-qtools/internal/service"
+)
+
+func TestBuildStatusReport(t *testing.T) {
+	t.Setenv("QUIL_CONFIG_FILE", filepath.Join(t.TempDir(), "config.yml"))
+	fake := fakenode.New()
+	defer fake.Close()
+	previous := node.SetCommandRunner(fake.Runner())
+	defer node.SetCommandRunner(previous)
+	fake.Peers = []fakenode.Peer{{PeerID: "Qm" + strings.Repeat("A", 44)}}
+	nc := client.NewNodeClientWithQueriers(&config.Config{}, client.NewGRPCQuerier(fake.StartGRPC()), client.NewRESTQuerier(fake.Start()))
+	services := &service.Status{Master: &service.ServiceStatus{Name: "ceremonyclient", Running: true}}
+
+	report := BuildStatusReport(nc, services, nil)
+	if !report.Running || report.Node == nil || report.Node.Transport != client.TransportGRPC || report.NodeError != "" {
+		t.Fatalf("report = %+v", report)
+	}
+	if report.Services != services || report.Peers == nil || report.Peers.Peers != 1 {
+		t.Errorf("report = %+v", report)
+	}
+
+	// A stopped node is reported from the binary, without RPC-only details
+	fake.Update(func(n *fakenode.Node) { n.Stopped = true })
+	report = BuildStatusReport(nc, nil, errors.New("no service manager"))
+	if report.Running || report.Node == nil || report.Node.Transport != client.TransportBinary || report.Peers != nil {
+		t.Errorf("stopped node report = %+v", report)
+	}
+	if report.ServiceError != "no service manager" {
+		t.Errorf("service error = %q", report.ServiceError)
+	}
+}
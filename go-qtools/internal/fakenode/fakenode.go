@@ -0,0 +1,280 @@
+// Package fakenode provides an in-process fake Quilibrium node for tests
+// It serves the NodeService methods over gRPC and the REST gateway, answers
+// node binary invocations through node.CommandRunner, and can write a stub
+// node executable for CLI integration tests
+package fakenode
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mr-tron/base58/base58"
+	"google.golang.org/grpc"
+)
+
+// NodeService is the fully qualified name of the node's gRPC service
+const NodeService = "quilibrium.node.node.pb.NodeService"
+
+// quilUnitsPerToken converts QUIL to raw token units
+var quilUnitsPerToken = big.NewInt(8000000000)
+
+// Peer represents a peer returned by GetNetworkInfo and GetPeerInfo
+type Peer struct {
+	PeerID     string
+	Multiaddrs []string
+	PeerScore  float64
+	MaxFrame   uint64
+}
+
+// Node is a fake node; set fields before use or change them with Update
+type Node struct {
+	PeerID         string
+	Version        string // e.g., "2.1.0"; selects the --node-info format
+	MaxFrame       uint64
+	PeerScore      uint64
+	ProverRing     int // -1 when not in a prover ring
+	Seniority      uint64
+	ActiveWorkers  int
+	RunningWorkers int
+
+	// Token amounts in raw units (1 QUIL = 8000000000 units)
+	OwnedTokens            *big.Int
+	UnconfirmedOwnedTokens *big.Int
+	ConfirmedSupply        *big.Int
+	UnconfirmedSupply      *big.Int
+
+	Peers         []Peer
+	Uncooperative []Peer
+
+	// Stopped makes RPCs fail as if the service were down; the binary still answers
+	Stopped bool
+
+	mu         sync.Mutex
+	server     *httptest.Server
+	grpcServer *grpc.Server
+	grpcAddr   string
+}
+
+// New creates a fake node with plausible defaults
+func New() *Node {
+	return &Node{
+		PeerID:                 "QmeSvwCq3uzsYcd5TuYXRmsWzQ7YdYQKuMyJZB3T1nshdA",
+		Version:                "2.1.0",
+		MaxFrame:               100000,
+		PeerScore:              100,
+		ProverRing:             -1,
+		Seniority:              1000,
+		ActiveWorkers:          4,
+		RunningWorkers:         4,
+		OwnedTokens:            Quil(1),
+		UnconfirmedOwnedTokens: big.NewInt(0),
+		ConfirmedSupply:        Quil(1000000),
+		UnconfirmedSupply:      Quil(1000000),
+	}
+}
+
+// Quil converts whole QUIL to raw token units
+func Quil(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), quilUnitsPerToken)
+}
+
+// Update changes the node state while it may be serving requests
+func (n *Node) Update(fn func(n *Node)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	fn(n)
+}
+
+// Start serves the REST gateway on a local port and returns its base URL
+func (n *Node) Start() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.server == nil {
+		n.server = httptest.NewServer(n)
+	}
+	return n.server.URL
+}
+
+// Close stops the REST gateway and the gRPC server
+func (n *Node) Close() {
+	n.mu.Lock()
+	server, grpcServer := n.server, n.grpcServer
+	n.server, n.grpcServer = nil, nil
+	n.mu.Unlock()
+
+	// In-flight requests take n.mu, so the servers are stopped without it
+	if server != nil {
+		server.Close()
+	}
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+}
+
+// ServeHTTP serves POST /<service>/<method> like the node's REST gateway
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if r.Method != http.MethodPost || !ok || service != NodeService {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := n.Response(method)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// Response returns the protojson response for a NodeService method
+func (n *Node) Response(method string) ([]byte, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Stopped {
+		return nil, fmt.Errorf("connection refused")
+	}
+
+	var resp interface{}
+	switch method {
+	case "GetNodeInfo":
+		resp = map[string]interface{}{
+			"peerId":        n.PeerID,
+			"maxFrame":      strconv.FormatUint(n.MaxFrame, 10),
+			"peerScore":     strconv.FormatUint(n.PeerScore, 10),
+			"version":       versionBytes(n.Version),
+			"peerSeniority": new(big.Int).SetUint64(n.Seniority).Bytes(),
+			"proverRing":    n.ProverRing,
+			"workers":       strconv.Itoa(n.ActiveWorkers),
+		}
+	case "GetTokenInfo":
+		resp = map[string]interface{}{
+			"confirmedTokenSupply":   amountBytes(n.ConfirmedSupply),
+			"unconfirmedTokenSupply": amountBytes(n.UnconfirmedSupply),
+			"ownedTokens":            amountBytes(n.OwnedTokens),
+			"unconfirmedOwnedTokens": amountBytes(n.UnconfirmedOwnedTokens),
+		}
+	case "GetNetworkInfo":
+		var peers []map[string]interface{}
+		for _, peer := range n.Peers {
+			peers = append(peers, map[string]interface{}{
+				"peerId":     peerIDBytes(peer.PeerID),
+				"multiaddrs": peer.Multiaddrs,
+				"peerScore":  peer.PeerScore,
+			})
+		}
+		resp = map[string]interface{}{"networkInfo": peers}
+	case "GetPeerInfo":
+		resp = map[string]interface{}{
+			"peerInfo":              peerInfos(n.Peers),
+			"uncooperativePeerInfo": peerInfos(n.Uncooperative),
+		}
+	default:
+		return nil, fmt.Errorf("unknown method %s", method)
+	}
+
+	return json.Marshal(resp)
+}
+
+// NodeInfoOutput returns the node --node-info output for the configured version
+func (n *Node) NodeInfoOutput() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Peer ID: %s\n", n.PeerID)
+	fmt.Fprintf(&b, "Version: %s\n", n.Version)
+	fmt.Fprintf(&b, "Max Frame: %d\n", n.MaxFrame)
+	fmt.Fprintf(&b, "Peer Score: %d\n", n.PeerScore)
+	if n.ProverRing >= 0 {
+		fmt.Fprintf(&b, "Prover Ring: %d\n", n.ProverRing)
+	}
+	fmt.Fprintf(&b, "Seniority: %d\n", n.Seniority)
+	if versionAtLeast(n.Version, 2, 1) {
+		fmt.Fprintf(&b, "Running Workers: %d\n", n.RunningWorkers)
+	}
+	fmt.Fprintf(&b, "Active Workers: %d\n", n.ActiveWorkers)
+	fmt.Fprintf(&b, "Owned balance: %s QUIL\n", formatQuil(n.OwnedTokens))
+	fmt.Fprintf(&b, "Unconfirmed balance: %s QUIL\n", formatQuil(n.UnconfirmedOwnedTokens))
+	return b.String()
+}
+
+// PeerIDOutput returns the node --peer-id output
+func (n *Node) PeerIDOutput() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return fmt.Sprintf("Peer ID: %s\n", n.PeerID)
+}
+
+// peerInfos builds PeerInfo entries
+func peerInfos(peers []Peer) []map[string]interface{} {
+	var infos []map[string]interface{}
+	for _, peer := range peers {
+		infos = append(infos, map[string]interface{}{
+			"peerId":     peerIDBytes(peer.PeerID),
+			"multiaddrs": peer.Multiaddrs,
+			"maxFrame":   strconv.FormatUint(peer.MaxFrame, 10),
+		})
+	}
+	return infos
+}
+
+// peerIDBytes decodes a base58 peer ID; invalid IDs are sent as raw bytes
+func peerIDBytes(peerID string) []byte {
+	if raw, err := base58.Decode(peerID); err == nil {
+		return raw
+	}
+	return []byte(peerID)
+}
+
+// amountBytes encodes a raw token amount as big-endian bytes
+func amountBytes(amount *big.Int) []byte {
+	if amount == nil {
+		return []byte{}
+	}
+	return amount.Bytes()
+}
+
+// formatQuil converts a raw token amount to a QUIL decimal string
+func formatQuil(amount *big.Int) string {
+	if amount == nil {
+		return "0"
+	}
+	s := new(big.Rat).SetFrac(amount, quilUnitsPerToken).FloatString(12)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// versionBytes encodes "2.1.0" as [2 1 0]
+func versionBytes(version string) []byte {
+	var out []byte
+	for _, part := range strings.Split(version, ".") {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		out = append(out, byte(v))
+	}
+	return out
+}
+
+// versionAtLeast reports whether version is at least major.minor
+func versionAtLeast(version string, major, minor int) bool {
+	parts := versionBytes(version)
+	for len(parts) < 2 {
+		parts = append(parts, 0)
+	}
+	if int(parts[0]) != major {
+		return int(parts[0]) > major
+	}
+	return int(parts[1]) >= minor
+}
@@ -0,0 +1,105 @@
+package fakenode
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestNodeInfoOutput(t *testing.T) {
+	for _, version := range []string{"2.0.6", "2.1.0"} {
+		fake := New()
+		fake.Version = version
+		fake.ProverRing = 3
+		fake.RunningWorkers = 6
+		fake.ActiveWorkers = 5
+
+		info, err := node.ParseNodeInfo([]byte(fake.NodeInfoOutput()))
+		if err != nil {
+			t.Fatalf("%s: ParseNodeInfo failed: %v", version, err)
+		}
+		if info.PeerID != fake.PeerID || info.Version != version || info.MaxFrame != fake.MaxFrame ||
+			info.ProverRing != 3 || info.Seniority != "1000" || info.ActiveWorkers != 5 || info.Balance != "1" {
+			t.Errorf("%s: parsed %+v", version, info)
+		}
+
+		// Running workers are only reported from 2.1
+		wantRunning := 0
+		if version == "2.1.0" {
+			wantRunning = 6
+		}
+		if info.RunningWorkers != wantRunning {
+			t.Errorf("%s: running workers = %d, want %d", version, info.RunningWorkers, wantRunning)
+		}
+	}
+}
+
+func TestWriteStubExecutable(t *testing.T) {
+	fake := New()
+	path, err := fake.WriteStubExecutable(filepath.Join(t.TempDir(), "bin"))
+	if err != nil {
+		t.Fatalf("WriteStubExecutable failed: %v", err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--signature-check=false", "--config", "/tmp/config.yml", "--node-info"}, want: fake.NodeInfoOutput()},
+		{args: []string{"--config", "/tmp/config.yml", "--peer-id"}, want: fake.PeerIDOutput()},
+	}
+	for _, tt := range tests {
+		output, err := exec.Command(path, tt.args...).Output()
+		if err != nil {
+			t.Fatalf("stub %q failed: %v", tt.args, err)
+		}
+		if string(output) != tt.want {
+			t.Errorf("stub %q printed %q, want %q", tt.args, output, tt.want)
+		}
+	}
+
+	if err := exec.Command(path, "--version").Run(); err == nil {
+		t.Error("stub accepted unsupported arguments")
+	}
+}
+
+// TestServeGRPCStatus checks the gRPC status of failing calls
+func TestServeGRPCStatus(t *testing.T) {
+	fake := New()
+	defer fake.Close()
+
+	conn, err := grpc.NewClient(fake.StartGRPC(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	call := func(method string) error {
+		t.Helper()
+		return conn.Invoke(context.Background(), method, &emptypb.Empty{}, &emptypb.Empty{})
+	}
+
+	if err := call("/" + NodeService + "/GetNodeInfo"); err != nil {
+		t.Errorf("GetNodeInfo failed: %v", err)
+	}
+	if err := call("/" + NodeService + "/GetFoo"); status.Code(err) != codes.Unimplemented || !strings.Contains(err.Error(), "GetFoo") {
+		t.Errorf("unknown method error = %v, want Unimplemented", err)
+	}
+	if err := call("/other.Service/GetNodeInfo"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("unknown service error = %v, want Unimplemented", err)
+	}
+
+	fake.Update(func(n *Node) { n.Stopped = true })
+	if err := call("/" + NodeService + "/GetNodeInfo"); status.Code(err) != codes.Unavailable {
+		t.Errorf("stopped node error = %v, want Unavailable", err)
+	}
+}
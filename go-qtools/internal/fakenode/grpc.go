@@ -0,0 +1,188 @@
+package fakenode
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// nodeProto describes the part of the node's node.proto the fake serves
+// qtools does not depend on these field numbers: it learns the schema from the
+// server through reflection and reads responses by their protojson names, so
+// only the field names have to match the REST responses
+var nodeProto = &descriptorpb.FileDescriptorProto{
+	Name:    proto.String("fakenode/node.proto"),
+	Package: proto.String("quilibrium.node.node.pb"),
+	Syntax:  proto.String("proto3"),
+	MessageType: []*descriptorpb.DescriptorProto{
+		message("GetNodeInfoRequest"),
+		message("NodeInfoResponse",
+			field("peer_id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			field("max_frame", 2, descriptorpb.FieldDescriptorProto_TYPE_UINT64),
+			field("peer_score", 3, descriptorpb.FieldDescriptorProto_TYPE_UINT64),
+			field("version", 4, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+			field("peer_seniority", 5, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+			field("prover_ring", 6, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+			field("workers", 7, descriptorpb.FieldDescriptorProto_TYPE_UINT32)),
+		message("GetTokenInfoRequest"),
+		message("TokenInfoResponse",
+			field("confirmed_token_supply", 1, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+			field("unconfirmed_token_supply", 2, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+			field("owned_tokens", 3, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+			field("unconfirmed_owned_tokens", 4, descriptorpb.FieldDescriptorProto_TYPE_BYTES)),
+		message("GetNetworkInfoRequest"),
+		message("NetworkInfo",
+			field("peer_id", 1, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+			repeated(field("multiaddrs", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+			field("peer_score", 3, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE)),
+		message("NetworkInfoResponse",
+			repeated(messageField("network_info", 1, "NetworkInfo"))),
+		message("GetPeerInfoRequest"),
+		message("PeerInfo",
+			field("peer_id", 1, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+			repeated(field("multiaddrs", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+			field("max_frame", 3, descriptorpb.FieldDescriptorProto_TYPE_UINT64)),
+		message("PeerInfoResponse",
+			repeated(messageField("peer_info", 1, "PeerInfo")),
+			repeated(messageField("uncooperative_peer_info", 2, "PeerInfo"))),
+	},
+	Service: []*descriptorpb.ServiceDescriptorProto{{
+		Name: proto.String("NodeService"),
+		Method: []*descriptorpb.MethodDescriptorProto{
+			rpc("GetNodeInfo", "GetNodeInfoRequest", "NodeInfoResponse"),
+			rpc("GetTokenInfo", "GetTokenInfoRequest", "TokenInfoResponse"),
+			rpc("GetNetworkInfo", "GetNetworkInfoRequest", "NetworkInfoResponse"),
+			rpc("GetPeerInfo", "GetPeerInfoRequest", "PeerInfoResponse"),
+		},
+	}},
+}
+
+// nodeFiles and nodeService are nodeProto as served through reflection
+var nodeFiles, nodeService = mustNodeService()
+
+// StartGRPC serves the NodeService gRPC methods and server reflection on a local port and returns its host:port
+// Connections are not encrypted, like the node's grpc.listenMultiaddr
+func (n *Node) StartGRPC() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.grpcServer != nil {
+		return n.grpcAddr
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("fakenode: failed to listen: %v", err))
+	}
+
+	n.grpcServer = grpc.NewServer(
+		grpc.UnknownServiceHandler(n.serveGRPC),
+		grpc.StreamInterceptor(n.refuseWhenStopped))
+	reflectionpb.RegisterServerReflectionServer(n.grpcServer, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           n.grpcServer,
+		DescriptorResolver: nodeFiles,
+	}))
+	n.grpcAddr = listener.Addr().String()
+	go n.grpcServer.Serve(listener)
+
+	return n.grpcAddr
+}
+
+// refuseWhenStopped fails every call, including reflection, while the node is stopped
+func (n *Node) refuseWhenStopped(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	n.mu.Lock()
+	stopped := n.Stopped
+	n.mu.Unlock()
+	if stopped {
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	return handler(srv, stream)
+}
+
+// serveGRPC answers a NodeService call with the protobuf form of Response
+func (n *Node) serveGRPC(_ any, stream grpc.ServerStream) error {
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if service != NodeService {
+		return status.Errorf(codes.Unimplemented, "unknown service %s", service)
+	}
+	md := nodeService.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+
+	if err := stream.RecvMsg(dynamicpb.NewMessage(md.Input())); err != nil {
+		return err
+	}
+	body, err := n.Response(method)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	resp := dynamicpb.NewMessage(md.Output())
+	if err := protojson.Unmarshal(body, resp); err != nil {
+		return status.Errorf(codes.Internal, "fakenode: %s response does not match node.proto: %v", method, err)
+	}
+	return stream.SendMsg(resp)
+}
+
+// mustNodeService builds nodeProto, panicking if it is invalid
+func mustNodeService() (*protoregistry.Files, protoreflect.ServiceDescriptor) {
+	file, err := protodesc.NewFile(nodeProto, nil)
+	if err != nil {
+		panic(fmt.Sprintf("fakenode: invalid node.proto: %v", err))
+	}
+	files := new(protoregistry.Files)
+	if err := files.RegisterFile(file); err != nil {
+		panic(fmt.Sprintf("fakenode: invalid node.proto: %v", err))
+	}
+	return files, file.Services().ByName("NodeService")
+}
+
+// message describes a message type
+func message(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+// field describes a singular scalar field
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   typ.Enum(),
+	}
+}
+
+// messageField describes a field holding a message from nodeProto
+func messageField(name string, number int32, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := field(name, number, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+	f.TypeName = proto.String(".quilibrium.node.node.pb." + typeName)
+	return f
+}
+
+// repeated makes f a repeated field
+func repeated(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+// rpc describes a unary NodeService method
+func rpc(name, input, output string) *descriptorpb.MethodDescriptorProto {
+	return &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String(name),
+		InputType:  proto.String(".quilibrium.node.node.pb." + input),
+		OutputType: proto.String(".quilibrium.node.node.pb." + output),
+	}
+}
@@ -0,0 +1,76 @@
+package fakenode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+)
+
+// Runner returns a node.CommandRunner answering node binary invocations from this node
+// Install it with node.SetCommandRunner; other commands fail
+func (n *Node) Runner() node.CommandRunner {
+	return &runner{node: n}
+}
+
+// runner emulates the node binary
+type runner struct {
+	node *Node
+}
+
+// Output implements node.CommandRunner
+func (r *runner) Output(name string, args ...string) ([]byte, error) {
+	return r.run(name, args)
+}
+
+// CombinedOutput implements node.CommandRunner
+func (r *runner) CombinedOutput(name string, args ...string) ([]byte, error) {
+	return r.run(name, args)
+}
+
+// run answers --node-info and --peer-id
+func (r *runner) run(name string, args []string) ([]byte, error) {
+	for _, arg := range args {
+		switch arg {
+		case "--node-info":
+			return []byte(r.node.NodeInfoOutput()), nil
+		case "--peer-id":
+			return []byte(r.node.PeerIDOutput()), nil
+		}
+	}
+
+	return nil, fmt.Errorf("fakenode: unsupported command %s %s", name, strings.Join(args, " "))
+}
+
+// WriteStubExecutable writes a node shell script answering --node-info and --peer-id from a
+// snapshot of the node state; point service.link_name at it
+// RPCs are served by Start and StartGRPC
+func (n *Node) WriteStubExecutable(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create stub directory: %w", err)
+	}
+
+	script := fmt.Sprintf(`#!/bin/sh
+# fakenode stub for the node binary
+for arg in "$@"; do
+	case "$arg" in
+		--node-info) cat <<'EOF'
+%sEOF
+			exit 0 ;;
+		--peer-id) cat <<'EOF'
+%sEOF
+			exit 0 ;;
+	esac
+done
+echo "fakenode: unsupported arguments: $*" >&2
+exit 1
+`, n.NodeInfoOutput(), n.PeerIDOutput())
+
+	path := filepath.Join(dir, "node")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", fmt.Errorf("failed to write node stub: %w", err)
+	}
+	return path, nil
+}
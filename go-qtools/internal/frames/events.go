@@ -0,0 +1,119 @@
+package frames
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/client"
+)
+
+// Frame event kinds
+const (
+	EventReceived       = "received"        // "evaluating next frame"
+	EventProofStarted   = "proof_started"   // "creating data shard ring proof"
+	EventProofCompleted = "proof_completed" // "submitting data proof"
+)
+
+// logMessages maps node log messages to frame event kinds
+var logMessages = map[string]string{
+	"evaluating next frame":          EventReceived,
+	"creating data shard ring proof": EventProofStarted,
+	"submitting data proof":          EventProofCompleted,
+}
+
+// Event represents a frame event from the node log or a node query
+type Event struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Frame    uint64    `json:"frame"`
+	FrameAge float64   `json:"frame_age"` // seconds since the frame was produced
+	Workers  int       `json:"workers,omitempty"`
+	Ring     int       `json:"ring,omitempty"`
+}
+
+// logLine is the part of a node log line (zap JSON) used for frame events
+type logLine struct {
+	TS            float64 `json:"ts"`
+	Msg           string  `json:"msg"`
+	FrameNumber   *uint64 `json:"frame_number"`
+	FrameAge      float64 `json:"frame_age"`
+	ActiveWorkers int     `json:"active_workers"`
+	Ring          int     `json:"ring"`
+}
+
+// ParseLogLine parses a frame event from a node log line
+// Anything before the JSON object, e.g., an svlogd timestamp, is ignored
+func ParseLogLine(line string) (*Event, bool) {
+	start := strings.IndexByte(line, '{')
+	if start < 0 || !strings.Contains(line, "frame_number") {
+		return nil, false
+	}
+
+	var entry logLine
+	if err := json.Unmarshal([]byte(line[start:]), &entry); err != nil || entry.FrameNumber == nil || entry.TS == 0 {
+		return nil, false
+	}
+
+	kind := ""
+	for msg, k := range logMessages {
+		if strings.Contains(entry.Msg, msg) {
+			kind = k
+			break
+		}
+	}
+	if kind == "" {
+		return nil, false
+	}
+
+	seconds := int64(entry.TS)
+	return &Event{
+		Time:     time.Unix(seconds, int64((entry.TS-float64(seconds))*1e9)),
+		Kind:     kind,
+		Frame:    *entry.FrameNumber,
+		FrameAge: entry.FrameAge,
+		Workers:  entry.ActiveWorkers,
+		Ring:     entry.Ring,
+	}, true
+}
+
+// ReadLog records the frame events in node log output until it ends
+func ReadLog(r io.Reader, tracker *Tracker) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if event, ok := ParseLogLine(scanner.Text()); ok {
+			tracker.Record(*event)
+		}
+	}
+	return scanner.Err()
+}
+
+// PollNode records a received event whenever the node's latest frame increases
+// Node queries carry no proof details, so only frame arrival is tracked
+func PollNode(ctx context.Context, nc client.NodeAPI, transport string, interval time.Duration, tracker *Tracker, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last uint64
+	for {
+		info, err := nc.GetFrameInfo(transport)
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+		} else if info.MaxFrame > last {
+			last = info.MaxFrame
+			tracker.Record(Event{Time: time.Now(), Kind: EventReceived, Frame: info.MaxFrame})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
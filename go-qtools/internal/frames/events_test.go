@@ -0,0 +1,97 @@
+package frames
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ts converts a zap timestamp to a time
+func ts(seconds float64) time.Time {
+	whole := int64(seconds)
+	return time.Unix(whole, int64((seconds-float64(whole))*1e9))
+}
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want *Event
+	}{
+		{
+			name: "received",
+			line: `{"level":"info","ts":1717430000.5,"msg":"evaluating next frame","frame_number":150001,"frame_age":3}`,
+			want: &Event{Time: ts(1717430000.5), Kind: EventReceived, Frame: 150001, FrameAge: 3},
+		},
+		{
+			name: "proof started",
+			line: `{"level":"info","ts":1717430001.5,"msg":"creating data shard ring proof","frame_number":150001,"frame_age":4,"active_workers":32,"ring":1}`,
+			want: &Event{Time: ts(1717430001.5), Kind: EventProofStarted, Frame: 150001, FrameAge: 4, Workers: 32, Ring: 1},
+		},
+		{
+			name: "proof completed",
+			line: `{"level":"info","ts":1717430010.5,"msg":"submitting data proof","frame_number":150001,"frame_age":13,"ring":1}`,
+			want: &Event{Time: ts(1717430010.5), Kind: EventProofCompleted, Frame: 150001, FrameAge: 13, Ring: 1},
+		},
+		{
+			name: "svlogd timestamp",
+			line: `2024-06-03_16:33:30.50000 {"ts":1717430010.5,"msg":"evaluating next frame","frame_number":150002,"frame_age":2}`,
+			want: &Event{Time: ts(1717430010.5), Kind: EventReceived, Frame: 150002, FrameAge: 2},
+		},
+		{
+			name: "journald prefix",
+			line: `Jun 03 16:33:32 node-1 node[2113]: {"ts":1717430012,"msg":"creating data shard ring proof","frame_number":150002,"frame_age":3.5,"active_workers":32}`,
+			want: &Event{Time: ts(1717430012), Kind: EventProofStarted, Frame: 150002, FrameAge: 3.5, Workers: 32},
+		},
+		{name: "other message", line: `{"ts":1717430015,"msg":"frame received from peer","frame_number":150002}`},
+		{name: "no frame number", line: `{"ts":1717429999.75,"msg":"evaluating next frame"}`},
+		{name: "null frame number", line: `{"ts":1717429999.75,"msg":"evaluating next frame","frame_number":null}`},
+		{name: "no timestamp", line: `{"msg":"evaluating next frame","frame_number":150004}`},
+		{name: "truncated", line: `{"ts":1717430031,"msg":"evaluating next frame","frame_number":`},
+		{name: "not JSON", line: `panic: frame_number out of range`},
+		{name: "empty", line: ``},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseLogLine(tt.line)
+		if ok != (tt.want != nil) {
+			t.Errorf("%s: ParseLogLine ok = %t, want %t", tt.name, ok, tt.want != nil)
+			continue
+		}
+		if ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: event = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadLog(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "node.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tracker := NewTracker(0)
+	if err := ReadLog(file, tracker); err != nil {
+		t.Fatalf("ReadLog failed: %v", err)
+	}
+
+	want := []Frame{
+		// Only the proof was logged; its receipt came before the log starts
+		{Number: 150000, ProofCompleted: true, ProofCompletedAt: ts(1717429995.25), ProofCompletedAge: 14.1, Ring: 1},
+		{Number: 150001, ReceivedAt: ts(1717430000.5), ReceivedAge: 3, ProofStarted: true, ProofStartedAge: 4,
+			ProofCompleted: true, ProofCompletedAt: ts(1717430010.5), ProofCompletedAge: 13, Workers: 32, Ring: 1},
+		// A dynamic proof reports the workers it ended up using
+		{Number: 150002, ReceivedAt: ts(1717430010.5), ReceivedAge: 2, ProofStarted: true, ProofStartedAge: 3.5,
+			ProofCompleted: true, ProofCompletedAt: ts(1717430020), ProofCompletedAge: 12, Workers: 30, Ring: 1},
+		{Number: 150003, ReceivedAt: ts(1717430020.5), ReceivedAge: 2.5},
+	}
+	if got := tracker.Frames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("frames = %+v\nwant %+v", got, want)
+	}
+	if got := tracker.LastFrameAt(); !got.Equal(ts(1717430020.5)) {
+		t.Errorf("last frame at %s", got)
+	}
+}
@@ -0,0 +1,181 @@
+package frames
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLimit is how many recent frames are kept (matches monitor-frame-proofs.sh)
+const DefaultLimit = 25
+
+// Frame represents what the node logged about one frame
+type Frame struct {
+	Number            uint64    `json:"number"`
+	ReceivedAt        time.Time `json:"received_at"`
+	ReceivedAge       float64   `json:"received_age"`
+	ProofStarted      bool      `json:"proof_started"`
+	ProofStartedAge   float64   `json:"proof_started_age,omitempty"`
+	ProofCompleted    bool      `json:"proof_completed"`
+	ProofCompletedAt  time.Time `json:"proof_completed_at"`
+	ProofCompletedAge float64   `json:"proof_completed_age,omitempty"`
+	Workers           int       `json:"workers,omitempty"`
+	Ring              int       `json:"ring,omitempty"`
+}
+
+// ProofDuration returns the time from receiving the frame to submitting its proof, in seconds
+func (f Frame) ProofDuration() float64 {
+	return f.ProofCompletedAge - f.ReceivedAge
+}
+
+// Stats represents proof statistics over the tracked frames
+type Stats struct {
+	Frames            int           `json:"frames"`
+	Proofs            int           `json:"proofs"`
+	LastFrame         uint64        `json:"last_frame"`
+	FirstFrameAt      time.Time     `json:"first_frame_at"`
+	LastFrameAt       time.Time     `json:"last_frame_at"`
+	SinceLastFrame    time.Duration `json:"since_last_frame_ns"`
+	AvgFrameInterval  float64       `json:"avg_frame_interval"`  // seconds between received frames
+	AvgReceivedAge    float64       `json:"avg_received_age"`    // frame age when received
+	AvgEvaluationTime float64       `json:"avg_evaluation_time"` // seconds from receiving to starting the proof
+	AvgProofDuration  float64       `json:"avg_proof_duration"`  // seconds from receiving to submitting the proof
+	AvgCompletedAge   float64       `json:"avg_completed_age"`   // frame age when the proof was submitted
+	ProofRate         float64       `json:"proof_rate"`          // proofs per frame
+	ProofsPerHour     float64       `json:"proofs_per_hour"`
+}
+
+// Tracker keeps the most recent frames and the time the last frame was received
+// It is safe for concurrent use
+type Tracker struct {
+	mu          sync.Mutex
+	limit       int
+	frames      map[uint64]*Frame
+	lastFrameAt time.Time
+}
+
+// NewTracker creates a tracker keeping the last limit frames
+func NewTracker(limit int) *Tracker {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Tracker{limit: limit, frames: make(map[uint64]*Frame)}
+}
+
+// Record adds a frame event
+func (t *Tracker) Record(event Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	frame, ok := t.frames[event.Frame]
+	if !ok {
+		frame = &Frame{Number: event.Frame}
+		t.frames[event.Frame] = frame
+	}
+
+	switch event.Kind {
+	case EventReceived:
+		frame.ReceivedAt = event.Time
+		frame.ReceivedAge = event.FrameAge
+		if event.Time.After(t.lastFrameAt) {
+			t.lastFrameAt = event.Time
+		}
+	case EventProofStarted:
+		frame.ProofStarted = true
+		frame.ProofStartedAge = event.FrameAge
+		frame.Workers = event.Workers
+	case EventProofCompleted:
+		frame.ProofCompleted = true
+		frame.ProofCompletedAt = event.Time
+		frame.ProofCompletedAge = event.FrameAge
+		frame.Ring = event.Ring
+		if event.Workers > 0 {
+			frame.Workers = event.Workers
+		}
+	}
+
+	t.truncate()
+}
+
+// truncate drops the oldest frames beyond the limit
+func (t *Tracker) truncate() {
+	if len(t.frames) <= t.limit {
+		return
+	}
+	numbers := make([]uint64, 0, len(t.frames))
+	for number := range t.frames {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	for _, number := range numbers[:len(numbers)-t.limit] {
+		delete(t.frames, number)
+	}
+}
+
+// LastFrameAt returns when the last frame was received, or zero before the first frame
+func (t *Tracker) LastFrameAt() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastFrameAt
+}
+
+// Frames returns the tracked frames, oldest first
+func (t *Tracker) Frames() []Frame {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	frames := make([]Frame, 0, len(t.frames))
+	for _, frame := range t.frames {
+		frames = append(frames, *frame)
+	}
+	sort.Slice(frames, func(i, j int) bool { return frames[i].Number < frames[j].Number })
+	return frames
+}
+
+// Stats computes proof statistics over the tracked frames
+// Only frames whose receipt was seen count; proofs for frames received before tracking began are ignored
+func (t *Tracker) Stats(now time.Time) Stats {
+	frames := t.Frames()
+	stats := Stats{LastFrameAt: t.LastFrameAt()}
+	if !stats.LastFrameAt.IsZero() {
+		stats.SinceLastFrame = now.Sub(stats.LastFrameAt)
+	}
+
+	var received []time.Time
+	var evaluation, duration, receivedAge, completedAge float64
+	for _, frame := range frames {
+		if frame.ReceivedAt.IsZero() {
+			continue
+		}
+		stats.Frames++
+		stats.LastFrame = frame.Number
+		received = append(received, frame.ReceivedAt)
+		if frame.ProofCompleted {
+			stats.Proofs++
+			receivedAge += frame.ReceivedAge
+			evaluation += frame.ProofStartedAge - frame.ReceivedAge
+			duration += frame.ProofDuration()
+			completedAge += frame.ProofCompletedAge
+		}
+	}
+	if stats.Frames == 0 {
+		return stats
+	}
+
+	sort.Slice(received, func(i, j int) bool { return received[i].Before(received[j]) })
+	stats.FirstFrameAt = received[0]
+	if len(received) > 1 {
+		span := received[len(received)-1].Sub(received[0])
+		stats.AvgFrameInterval = span.Seconds() / float64(len(received)-1)
+		stats.ProofsPerHour = float64(stats.Proofs) / span.Hours()
+	}
+	stats.ProofRate = float64(stats.Proofs) / float64(stats.Frames)
+	if stats.Proofs > 0 {
+		proofs := float64(stats.Proofs)
+		stats.AvgReceivedAge = receivedAge / proofs
+		stats.AvgEvaluationTime = evaluation / proofs
+		stats.AvgProofDuration = duration / proofs
+		stats.AvgCompletedAge = completedAge / proofs
+	}
+	return stats
+}
@@ -0,0 +1,92 @@
+package frames
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// approx reports whether two statistics agree to well below their precision
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+// recordFrame records a frame's receipt and, when completedAge is set, its proof
+func recordFrame(tracker *Tracker, number uint64, receivedAt time.Time, receivedAge, startedAge, completedAge float64) {
+	tracker.Record(Event{Time: receivedAt, Kind: EventReceived, Frame: number, FrameAge: receivedAge})
+	if completedAge == 0 {
+		return
+	}
+	tracker.Record(Event{Time: receivedAt.Add(time.Second), Kind: EventProofStarted, Frame: number, FrameAge: startedAge, Workers: 8})
+	tracker.Record(Event{Time: receivedAt.Add(10 * time.Second), Kind: EventProofCompleted, Frame: number, FrameAge: completedAge, Ring: 2})
+}
+
+func TestTrackerStats(t *testing.T) {
+	start := time.Date(2024, 6, 3, 16, 0, 0, 0, time.UTC)
+	tracker := NewTracker(0)
+
+	// A proof for a frame received before tracking began does not count
+	tracker.Record(Event{Time: start.Add(-5 * time.Second), Kind: EventProofCompleted, Frame: 99, FrameAge: 14})
+	recordFrame(tracker, 100, start, 3, 4, 13)
+	recordFrame(tracker, 101, start.Add(10*time.Second), 2, 3.5, 12)
+	recordFrame(tracker, 102, start.Add(20*time.Second), 2.5, 0, 0)
+
+	stats := tracker.Stats(start.Add(50 * time.Second))
+	if stats.Frames != 3 || stats.Proofs != 2 || stats.LastFrame != 102 {
+		t.Errorf("frames = %d, proofs = %d, last frame = %d; want 3, 2, 102", stats.Frames, stats.Proofs, stats.LastFrame)
+	}
+	if !stats.FirstFrameAt.Equal(start) || !stats.LastFrameAt.Equal(start.Add(20*time.Second)) {
+		t.Errorf("frames from %s to %s", stats.FirstFrameAt, stats.LastFrameAt)
+	}
+	if stats.SinceLastFrame != 30*time.Second {
+		t.Errorf("since last frame = %s, want 30s", stats.SinceLastFrame)
+	}
+
+	for name, got := range map[string][2]float64{
+		"frame interval":  {stats.AvgFrameInterval, 10},
+		"received age":    {stats.AvgReceivedAge, 2.5},
+		"evaluation time": {stats.AvgEvaluationTime, 1.25}, // (4-3 + 3.5-2) / 2
+		"proof duration":  {stats.AvgProofDuration, 10},    // (13-3 + 12-2) / 2
+		"completed age":   {stats.AvgCompletedAge, 12.5},
+		"proof rate":      {stats.ProofRate, 2.0 / 3},
+		"proofs per hour": {stats.ProofsPerHour, 360}, // 2 proofs in 20s
+	} {
+		if !approx(got[0], got[1]) {
+			t.Errorf("%s = %g, want %g", name, got[0], got[1])
+		}
+	}
+}
+
+func TestTrackerStatsEmpty(t *testing.T) {
+	tracker := NewTracker(0)
+	if stats := tracker.Stats(time.Now()); stats != (Stats{}) {
+		t.Errorf("stats before any frame = %+v", stats)
+	}
+
+	// A single frame has no interval to average
+	start := time.Date(2024, 6, 3, 16, 0, 0, 0, time.UTC)
+	recordFrame(tracker, 100, start, 3, 4, 13)
+	stats := tracker.Stats(start.Add(time.Minute))
+	if stats.Frames != 1 || stats.Proofs != 1 || stats.AvgFrameInterval != 0 || stats.ProofsPerHour != 0 || stats.ProofRate != 1 {
+		t.Errorf("stats for one frame = %+v", stats)
+	}
+}
+
+func TestTrackerLimit(t *testing.T) {
+	start := time.Date(2024, 6, 3, 16, 0, 0, 0, time.UTC)
+	tracker := NewTracker(2)
+
+	// Frames arriving out of order still drop the lowest numbers
+	for _, number := range []uint64{101, 100, 103, 102} {
+		recordFrame(tracker, number, start.Add(time.Duration(number-100)*10*time.Second), 2, 0, 0)
+	}
+
+	frames := tracker.Frames()
+	if len(frames) != 2 || frames[0].Number != 102 || frames[1].Number != 103 {
+		t.Errorf("frames = %+v, want 102 and 103", frames)
+	}
+	// The newest receipt is kept even when its frame is dropped later
+	if got := tracker.LastFrameAt(); !got.Equal(start.Add(30 * time.Second)) {
+		t.Errorf("last frame at %s", got)
+	}
+}
@@ -0,0 +1,111 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// AnnounceMultiaddrs represents the multiaddrs the node announces for a public IP
+type AnnounceMultiaddrs struct {
+	ListenMultiaddr        string   `json:"announce_listen_multiaddr"`
+	StreamListenMultiaddr  string   `json:"announce_stream_listen_multiaddr"`
+	WorkerP2PMultiaddrs    []string `json:"worker_announce_p2p_multiaddrs"`
+	WorkerStreamMultiaddrs []string `json:"worker_announce_stream_multiaddrs"`
+	Multiaddrs             []string `json:"announce_multiaddrs,omitempty"`
+}
+
+// BuildAnnounceMultiaddrs builds the announce multiaddrs for a public IP (matches set-announce-multiaddrs.sh)
+// A worker count of 0 uses the number of entries in engine.dataWorkerP2PMultiaddrs
+func BuildAnnounceMultiaddrs(nodeConfig *NodeConfig, cfg *config.Config, publicIP string, workerCount int) (*AnnounceMultiaddrs, error) {
+	ip := net.ParseIP(publicIP)
+	if ip == nil || ip.To4() == nil {
+		return nil, fmt.Errorf("invalid IPv4 address: %s", publicIP)
+	}
+
+	if nodeConfig.P2P == nil || nodeConfig.P2P.ListenMultiaddr == "" {
+		return nil, fmt.Errorf("p2p.listenMultiaddr not found in node config")
+	}
+
+	_, p2pPort, p2pProto, err := ParseMultiaddr(nodeConfig.P2P.ListenMultiaddr)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse p2p.listenMultiaddr: %w", err)
+	}
+
+	streamPort := DefaultStreamPort
+	if nodeConfig.P2P.StreamListenMultiaddr != "" {
+		if _, port, _, err := ParseMultiaddr(nodeConfig.P2P.StreamListenMultiaddr); err == nil {
+			streamPort = port
+		}
+	}
+
+	if workerCount <= 0 && nodeConfig.Engine != nil {
+		workerCount = len(nodeConfig.Engine.DataWorkerP2PMultiaddrs)
+	}
+	baseP2P, baseStream := resolveWorkerBasePorts(cfg, nodeConfig, 0, 0)
+
+	announce := &AnnounceMultiaddrs{
+		ListenMultiaddr:        BuildMultiaddr(publicIP, p2pPort, p2pProto),
+		StreamListenMultiaddr:  BuildMultiaddr(publicIP, streamPort, "tcp"),
+		WorkerP2PMultiaddrs:    []string{},
+		WorkerStreamMultiaddrs: []string{},
+	}
+
+	for i := 0; i < workerCount; i++ {
+		announce.WorkerP2PMultiaddrs = append(announce.WorkerP2PMultiaddrs, BuildMultiaddr(publicIP, baseP2P+i, "tcp"))
+		announce.WorkerStreamMultiaddrs = append(announce.WorkerStreamMultiaddrs, BuildMultiaddr(publicIP, baseStream+i, "tcp"))
+	}
+
+	// Rewrite the host of any existing p2p.announceMultiaddrs entries
+	for _, addr := range nodeConfig.P2P.AnnounceMultiaddrs {
+		announce.Multiaddrs = append(announce.Multiaddrs, ReplaceMultiaddrIP(addr, publicIP))
+	}
+
+	return announce, nil
+}
+
+// SetAnnounceMultiaddrs rewrites the node's announce multiaddrs for a public IP
+func SetAnnounceMultiaddrs(configPath string, cfg *config.Config, publicIP string, workerCount int) (*AnnounceMultiaddrs, error) {
+	mgr, err := NewNodeConfigManager(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeConfig, err := mgr.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load node config: %w", err)
+	}
+
+	announce, err := BuildAnnounceMultiaddrs(nodeConfig, cfg, publicIP, workerCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodeConfig.Raw == nil {
+		nodeConfig.Raw = make(map[string]interface{})
+	}
+	setNestedValue(nodeConfig.Raw, "p2p.announceListenMultiaddr", announce.ListenMultiaddr)
+	setNestedValue(nodeConfig.Raw, "p2p.announceStreamListenMultiaddr", announce.StreamListenMultiaddr)
+	setNestedValue(nodeConfig.Raw, "engine.dataWorkerAnnounceP2PMultiaddrs", announce.WorkerP2PMultiaddrs)
+	setNestedValue(nodeConfig.Raw, "engine.dataWorkerAnnounceStreamMultiaddrs", announce.WorkerStreamMultiaddrs)
+	if len(announce.Multiaddrs) > 0 {
+		setNestedValue(nodeConfig.Raw, "p2p.announceMultiaddrs", announce.Multiaddrs)
+	}
+
+	if err := mgr.Save(nodeConfig); err != nil {
+		return nil, err
+	}
+
+	return announce, nil
+}
+
+// ReplaceMultiaddrIP replaces the /ip4/<addr> component of a multiaddr
+func ReplaceMultiaddrIP(multiaddr, ip string) string {
+	parts := strings.Split(multiaddr, "/")
+	if len(parts) > 2 && parts[1] == "ip4" {
+		parts[2] = ip
+	}
+	return strings.Join(parts, "/")
+}
@@ -2,7 +2,6 @@ package node
 
 import (
 	"fmt"
-	"os/exec"
 	"strings"
 
 	"github.com/tjsturos/qtools/go-qtools/internal/config"
@@ -16,6 +15,11 @@ func ExecuteNodeCommand(args []string, cfg *config.Config) ([]byte, error) {
 		nodePath = cfg.Service.LinkName
 	}
 
+	return ExecuteNodeCommandWith(nodePath, config.GetNodeConfigPath(), args, cfg)
+}
+
+// ExecuteNodeCommandWith executes a specific node binary against a specific node config
+func ExecuteNodeCommandWith(nodePath string, configPath string, args []string, cfg *config.Config) ([]byte, error) {
 	// Build command with flags from config
 	cmdArgs := []string{}
 
@@ -35,15 +39,13 @@ func ExecuteNodeCommand(args []string, cfg *config.Config) ([]byte, error) {
 	}
 
 	// Add config path
-	configPath := config.GetNodeConfigPath()
 	cmdArgs = append(cmdArgs, "--config", configPath)
 
 	// Add user-provided arguments
 	cmdArgs = append(cmdArgs, args...)
 
 	// Execute command
-	cmd := exec.Command(nodePath, cmdArgs...)
-	output, err := cmd.CombinedOutput()
+	output, err := GetCommandRunner().CombinedOutput(nodePath, cmdArgs...)
 	if err != nil {
 		return output, fmt.Errorf("node command failed: %w", err)
 	}
@@ -53,11 +55,20 @@ func ExecuteNodeCommand(args []string, cfg *config.Config) ([]byte, error) {
 
 // NodeInfo represents node information
 type NodeInfo struct {
-	PeerID      string
-	Version     string
-	Seniority   string
-	Balance     string
-	WorkerCount int
+	PeerID             string            `json:"peer_id"`
+	Version            string            `json:"version"`
+	MaxFrame           uint64            `json:"max_frame"`
+	PeerScore          int64             `json:"peer_score"`
+	ProverRing         int               `json:"prover_ring"` // -1 when not reported
+	Seniority          string            `json:"seniority"`
+	ActiveWorkers      int               `json:"active_workers"`
+	RunningWorkers     int               `json:"running_workers"`
+	WorkerCount        int               `json:"worker_count"`
+	Balance            string            `json:"balance"`
+	BalanceUnit        string            `json:"balance_unit,omitempty"`
+	UnconfirmedBalance string            `json:"unconfirmed_balance,omitempty"`
+	Format             string            `json:"format"`
+	Extra              map[string]string `json:"extra,omitempty"`
 }
 
 // GetNodeInfo gets node information by running node --node-info
@@ -67,40 +78,39 @@ func GetNodeInfo(cfg *config.Config) (*NodeInfo, error) {
 		return nil, err
 	}
 
-	// Parse output (similar to bash scripts)
-	info := &NodeInfo{}
-	lines := strings.Split(string(output), "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+	return ParseNodeInfo(output)
+}
 
-		// Parse different fields from output
-		// This is a simplified parser - actual implementation should match bash script parsing
-		if strings.HasPrefix(line, "Peer ID:") {
-			info.PeerID = strings.TrimSpace(strings.TrimPrefix(line, "Peer ID:"))
-		} else if strings.HasPrefix(line, "Version:") {
-			info.Version = strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
-		} else if strings.HasPrefix(line, "Seniority:") {
-			info.Seniority = strings.TrimSpace(strings.TrimPrefix(line, "Seniority:"))
-		} else if strings.HasPrefix(line, "Balance:") {
-			info.Balance = strings.TrimSpace(strings.TrimPrefix(line, "Balance:"))
-		}
+// GetNodeInfoFor runs node --node-info with a specific node binary and config
+func GetNodeInfoFor(nodePath string, configPath string, cfg *config.Config) (*NodeInfo, error) {
+	output, err := ExecuteNodeCommandWith(nodePath, configPath, []string{"--node-info"}, cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	return info, nil
+	return ParseNodeInfo(output)
 }
 
-// GetPeerID gets the peer ID by running node --peer-id
+// GetPeerID gets the node peer ID
+// The peer ID is derived from p2p.peerPrivKey or keys.yml when possible, falling back to the node binary
 func GetPeerID(cfg *config.Config) (string, error) {
+	if identity, err := LoadPeerIdentity(""); err == nil {
+		return identity.PeerID, nil
+	}
+
 	output, err := ExecuteNodeCommand([]string{"--peer-id"}, cfg)
 	if err != nil {
 		return "", err
 	}
 
-	// Parse peer ID from output
+	// Parse peer ID from output ("Peer ID: Qm...")
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Peer ID:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Peer ID:")), nil
+		}
+	}
+
 	peerID := strings.TrimSpace(string(output))
 	return peerID, nil
 }
@@ -88,6 +88,19 @@ func setConfigValue(path string, value string, opts ConfigCommandOptions, cfg *c
 	} else {
 		// Qtools config
 		parsedValue := parseValue(value)
+		if path == "manual.worker_count" {
+			workerCount, ok := parsedValue.(int)
+			if !ok {
+				return fmt.Errorf("manual.worker_count must be a number, got %q", value)
+			}
+			if err := CheckWorkerPorts(cfg, workerCount); err != nil {
+				return err
+			}
+			if cfg.Manual == nil {
+				cfg.Manual = &config.ManualConfig{}
+			}
+			cfg.Manual.WorkerCount = workerCount
+		}
 		err = config.SetConfigValue(cfg, path, parsedValue)
 		if err == nil {
 			// Save config
@@ -3,6 +3,7 @@ package node
 import (
 	"fmt"
 	"net"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -52,116 +53,143 @@ func SetDataWorkerMultiaddrs(configPath string, multiaddrs []string) error {
 	return mgr.SetValue("engine.dataWorkerMultiaddrs", multiaddrs)
 }
 
-// SetDataWorkerP2PMultiaddrs sets the data worker P2P multiaddrs
-func SetDataWorkerP2PMultiaddrs(configPath string, multiaddrs []string) error {
-	mgr, err := NewNodeConfigManager(configPath)
-	if err != nil {
-		return err
-	}
-	return mgr.SetValue("engine.dataWorkerP2PMultiaddrs", multiaddrs)
+// dataWorkerListPaths are the node config lists with an entry per data worker
+// Worker N uses entry N-1 of each list, so entries are only ever removed from the end
+var dataWorkerListPaths = []string{
+	"engine.dataWorkerMultiaddrs",
+	"engine.dataWorkerP2PMultiaddrs",
+	"engine.dataWorkerStreamMultiaddrs",
 }
 
-// SetDataWorkerStreamMultiaddrs sets the data worker stream multiaddrs
-func SetDataWorkerStreamMultiaddrs(configPath string, multiaddrs []string) error {
-	mgr, err := NewNodeConfigManager(configPath)
-	if err != nil {
-		return err
+// DataWorkerEntries holds entries trimmed from the end of the data worker lists, by config path
+type DataWorkerEntries map[string][]string
+
+// dataWorkerList returns the data worker list at one of dataWorkerListPaths
+func dataWorkerList(engine *EngineConfig, path string) []string {
+	if engine == nil {
+		return nil
 	}
-	return mgr.SetValue("engine.dataWorkerStreamMultiaddrs", multiaddrs)
+	switch path {
+	case "engine.dataWorkerMultiaddrs":
+		return engine.DataWorkerMultiaddrs
+	case "engine.dataWorkerP2PMultiaddrs":
+		return engine.DataWorkerP2PMultiaddrs
+	case "engine.dataWorkerStreamMultiaddrs":
+		return engine.DataWorkerStreamMultiaddrs
+	}
+	return nil
 }
 
-// ClearDataWorkers clears all data worker arrays
-func ClearDataWorkers(configPath string) error {
+// TrimDataWorkerMultiaddrs cuts the data worker lists down to the first count workers after
+// the highest workers were dropped from the worker count; lists that are already short enough
+// are left alone. Returns the removed entries for RestoreDataWorkerMultiaddrs
+func TrimDataWorkerMultiaddrs(configPath string, count int) (DataWorkerEntries, error) {
 	mgr, err := NewNodeConfigManager(configPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// Clear all three arrays
-	if err := mgr.SetValue("engine.dataWorkerMultiaddrs", []string{}); err != nil {
-		return err
+	nodeConfig, err := mgr.Load()
+	if err != nil {
+		return nil, err
 	}
-	if err := mgr.SetValue("engine.dataWorkerP2PMultiaddrs", []string{}); err != nil {
-		return err
+
+	trimmed := DataWorkerEntries{}
+	for _, path := range dataWorkerListPaths {
+		list := dataWorkerList(nodeConfig.Engine, path)
+		if count < 0 || len(list) <= count {
+			continue
+		}
+		trimmed[path] = append([]string{}, list[count:]...)
+		if err := setNestedValue(nodeConfig.Raw, path, append([]string{}, list[:count]...)); err != nil {
+			return nil, err
+		}
 	}
-	if err := mgr.SetValue("engine.dataWorkerStreamMultiaddrs", []string{}); err != nil {
-		return err
+	if len(trimmed) == 0 {
+		return nil, nil
 	}
-
-	return nil
+	return trimmed, mgr.Save(nodeConfig)
 }
 
-// AddDirectPeer adds a direct peer to the config
-func AddDirectPeer(configPath, peerID, multiaddr string) error {
+// RestoreDataWorkerMultiaddrs appends entries removed by TrimDataWorkerMultiaddrs(count) back to
+// the data worker lists. Lists that already hold them are left alone; a list whose length changed
+// since it was trimmed is not touched and makes RestoreDataWorkerMultiaddrs fail, since
+// appending would give the entries to the wrong workers
+func RestoreDataWorkerMultiaddrs(configPath string, count int, entries DataWorkerEntries) error {
 	mgr, err := NewNodeConfigManager(configPath)
 	if err != nil {
 		return err
 	}
+	nodeConfig, err := mgr.Load()
+	if err != nil {
+		return err
+	}
 
-	// Get current direct peers
-	var directPeers []map[string]interface{}
-	if peers, err := mgr.GetValue("p2p.directPeers"); err == nil {
-		if peersList, ok := peers.([]interface{}); ok {
-			for _, p := range peersList {
-				if peerMap, ok := p.(map[string]interface{}); ok {
-					directPeers = append(directPeers, peerMap)
-				}
-			}
+	var restored []string
+	for _, path := range dataWorkerListPaths {
+		restore := entries[path]
+		if len(restore) == 0 {
+			continue
+		}
+		list := dataWorkerList(nodeConfig.Engine, path)
+		switch {
+		case len(list) == count:
+			restored = append(restored, path)
+		case len(list) >= count+len(restore) && slices.Equal(list[count:count+len(restore)], restore):
+			// Already restored
+		default:
+			return fmt.Errorf("%s has %d entries, expected %d; restore %s by hand", path, len(list), count, strings.Join(restore, ", "))
 		}
 	}
+	if len(restored) == 0 {
+		return nil
+	}
 
-	// Check if peer already exists
-	for _, peer := range directPeers {
-		if pID, ok := peer["peerId"].(string); ok && pID == peerID {
-			return fmt.Errorf("peer %s already exists", peerID)
+	for _, path := range restored {
+		list := dataWorkerList(nodeConfig.Engine, path)
+		if err := setNestedValue(nodeConfig.Raw, path, append(append([]string{}, list...), entries[path]...)); err != nil {
+			return err
 		}
 	}
+	return mgr.Save(nodeConfig)
+}
 
-	// Add new peer
-	newPeer := map[string]interface{}{
-		"peerId":    peerID,
-		"multiaddr": multiaddr,
+// SetDataWorkerP2PMultiaddrs sets the data worker P2P multiaddrs
+func SetDataWorkerP2PMultiaddrs(configPath string, multiaddrs []string) error {
+	mgr, err := NewNodeConfigManager(configPath)
+	if err != nil {
+		return err
 	}
-	directPeers = append(directPeers, newPeer)
-
-	return mgr.SetValue("p2p.directPeers", directPeers)
+	return mgr.SetValue("engine.dataWorkerP2PMultiaddrs", multiaddrs)
 }
 
-// RemoveDirectPeer removes a direct peer from the config
-func RemoveDirectPeer(configPath, peerID string) error {
+// SetDataWorkerStreamMultiaddrs sets the data worker stream multiaddrs
+func SetDataWorkerStreamMultiaddrs(configPath string, multiaddrs []string) error {
 	mgr, err := NewNodeConfigManager(configPath)
 	if err != nil {
 		return err
 	}
+	return mgr.SetValue("engine.dataWorkerStreamMultiaddrs", multiaddrs)
+}
 
-	// Get current direct peers
-	var directPeers []map[string]interface{}
-	if peers, err := mgr.GetValue("p2p.directPeers"); err == nil {
-		if peersList, ok := peers.([]interface{}); ok {
-			for _, p := range peersList {
-				if peerMap, ok := p.(map[string]interface{}); ok {
-					directPeers = append(directPeers, peerMap)
-				}
-			}
-		}
+// ClearDataWorkers clears all data worker arrays
+func ClearDataWorkers(configPath string) error {
+	mgr, err := NewNodeConfigManager(configPath)
+	if err != nil {
+		return err
 	}
 
-	// Remove peer
-	found := false
-	var updatedPeers []map[string]interface{}
-	for _, peer := range directPeers {
-		if pID, ok := peer["peerId"].(string); ok && pID == peerID {
-			found = true
-			continue
-		}
-		updatedPeers = append(updatedPeers, peer)
+	// Clear all three arrays
+	if err := mgr.SetValue("engine.dataWorkerMultiaddrs", []string{}); err != nil {
+		return err
 	}
-
-	if !found {
-		return fmt.Errorf("peer %s not found", peerID)
+	if err := mgr.SetValue("engine.dataWorkerP2PMultiaddrs", []string{}); err != nil {
+		return err
+	}
+	if err := mgr.SetValue("engine.dataWorkerStreamMultiaddrs", []string{}); err != nil {
+		return err
 	}
 
-	return mgr.SetValue("p2p.directPeers", updatedPeers)
+	return nil
 }
 
 // SetEngineSetting sets an engine setting
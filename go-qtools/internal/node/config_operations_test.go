@@ -0,0 +1,126 @@
+package node
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+func TestTrimRestoreDataWorkerMultiaddrs(t *testing.T) {
+	workers := func(port int, n int) []string {
+		var multiaddrs []string
+		for i := 0; i < n; i++ {
+			multiaddrs = append(multiaddrs, BuildMultiaddr("127.0.0.1", port+i, "tcp"))
+		}
+		return multiaddrs
+	}
+	multiaddrs, p2p, stream := workers(40000, 4), workers(50000, 4), workers(60000, 3)
+
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	if err := SetDataWorkerMultiaddrs(configPath, multiaddrs); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetDataWorkerP2PMultiaddrs(configPath, p2p); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetDataWorkerStreamMultiaddrs(configPath, stream); err != nil {
+		t.Fatal(err)
+	}
+	lists := func() [][]string {
+		mgr, _ := NewNodeConfigManager(configPath)
+		nodeConfig, err := mgr.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return [][]string{nodeConfig.Engine.DataWorkerMultiaddrs, nodeConfig.Engine.DataWorkerP2PMultiaddrs, nodeConfig.Engine.DataWorkerStreamMultiaddrs}
+	}
+
+	// Dropping workers 3 and 4 only removes entries from the end, so workers 1 and 2 keep theirs
+	trimmed, err := TrimDataWorkerMultiaddrs(configPath, 2)
+	if err != nil {
+		t.Fatalf("TrimDataWorkerMultiaddrs failed: %v", err)
+	}
+	want := DataWorkerEntries{
+		"engine.dataWorkerMultiaddrs":       multiaddrs[2:],
+		"engine.dataWorkerP2PMultiaddrs":    p2p[2:],
+		"engine.dataWorkerStreamMultiaddrs": stream[2:],
+	}
+	if !reflect.DeepEqual(trimmed, want) {
+		t.Errorf("trimmed %q, want %q", trimmed, want)
+	}
+	if got := lists(); !reflect.DeepEqual(got, [][]string{multiaddrs[:2], p2p[:2], stream[:2]}) {
+		t.Errorf("after trim: %q", got)
+	}
+
+	// Lists that are already short enough are left alone
+	if trimmed, err := TrimDataWorkerMultiaddrs(configPath, 3); err != nil || trimmed != nil {
+		t.Errorf("TrimDataWorkerMultiaddrs(3) = %q, %v; want nothing trimmed", trimmed, err)
+	}
+
+	// Restoring twice appends the entries once
+	for i := 0; i < 2; i++ {
+		if err := RestoreDataWorkerMultiaddrs(configPath, 2, want); err != nil {
+			t.Fatalf("RestoreDataWorkerMultiaddrs failed: %v", err)
+		}
+	}
+	if got := lists(); !reflect.DeepEqual(got, [][]string{multiaddrs, p2p, stream}) {
+		t.Errorf("after restore: %q", got)
+	}
+
+	// A list that changed length since the trim is not restored into the wrong positions
+	if err := SetDataWorkerMultiaddrs(configPath, multiaddrs[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreDataWorkerMultiaddrs(configPath, 2, want); err == nil {
+		t.Error("RestoreDataWorkerMultiaddrs accepted a list of the wrong length")
+	}
+	if got := lists()[0]; !reflect.DeepEqual(got, multiaddrs[:1]) {
+		t.Errorf("list of the wrong length changed to %q", got)
+	}
+}
+
+// TestRollbackNodePrivileged checks that the node link is switched with the given command
+// builder instead of always using sudo
+func TestRollbackNodePrivileged(t *testing.T) {
+	nodePath := t.TempDir()
+	t.Setenv("QUIL_NODE_PATH", nodePath)
+	for _, version := range []string{"2.0.6.1", "2.1.0", "2.1.0.2"} {
+		if err := os.WriteFile(filepath.Join(nodePath, "node-"+version+"-"+getOSArch()), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	link := filepath.Join(nodePath, "node")
+	cfg := &config.Config{
+		Raw:                map[string]interface{}{},
+		CurrentNodeVersion: "2.1.0.2",
+		Service:            &config.ServiceConfig{LinkName: link},
+	}
+
+	var commands [][]string
+	privileged := func(name string, args ...string) *exec.Cmd {
+		commands = append(commands, append([]string{name}, args...))
+		return exec.Command(name, args...)
+	}
+
+	rollback, err := RollbackNode(cfg, privileged)
+	if err != nil {
+		t.Fatalf("RollbackNode failed: %v", err)
+	}
+	if rollback.ToVersion != "2.1.0" || cfg.CurrentNodeVersion != "2.1.0" {
+		t.Errorf("rolled back to %s (config %s), want 2.1.0", rollback.ToVersion, cfg.CurrentNodeVersion)
+	}
+	if len(commands) != 1 || commands[0][0] != "ln" {
+		t.Errorf("commands = %q, want a single ln", commands)
+	}
+	if target, err := os.Readlink(link); err != nil || target != rollback.Binary {
+		t.Errorf("link points to %q (%v), want %q", target, err, rollback.Binary)
+	}
+	if skip, _ := config.GetConfigValue(cfg, skipVersionPath); skip != "2.1.0.2" {
+		t.Errorf("skip_version = %v, want 2.1.0.2", skip)
+	}
+}
@@ -15,17 +15,11 @@ type NodeConfig struct {
 type P2PConfig struct {
 	ListenMultiaddr      string                 `yaml:"listenMultiaddr,omitempty"`
 	StreamListenMultiaddr string                 `yaml:"streamListenMultiaddr,omitempty"`
-	DirectPeers          []DirectPeer           `yaml:"directPeers,omitempty"`
+	DirectPeers          []string               `yaml:"directPeers,omitempty"`
 	AnnounceMultiaddrs   []string                `yaml:"announceMultiaddrs,omitempty"`
 	AdditionalSettings   map[string]interface{} `yaml:",inline"`
 }
 
-// DirectPeer represents a direct peer configuration
-type DirectPeer struct {
-	PeerID   string `yaml:"peerId"`
-	Multiaddr string `yaml:"multiaddr"`
-}
-
 // GRPCConfig represents gRPC settings
 type GRPCConfig struct {
 	ListenMultiaddr string `yaml:"listenMultiaddr,omitempty"`
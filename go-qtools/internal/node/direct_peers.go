@@ -0,0 +1,509 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// base58Alphabet is the bitcoin base58 alphabet used by libp2p peer IDs
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// DirectPeerSyncOptions represents options for syncing direct peers
+type DirectPeerSyncOptions struct {
+	Source      string // Local path, http(s):// URL, ssh://user@host/path or user@host:path
+	LocalPeerID string // Own peer ID, excluded from the merged list
+	Prune       bool   // Also drop configured entries that are invalid or point at a local IP
+	DryRun      bool
+}
+
+// DirectPeerSyncResult represents the outcome of a direct peer sync
+type DirectPeerSyncResult struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"` // New addresses for configured peer IDs
+	Pruned  []string `json:"pruned"`  // Local entries dropped as duplicates, our own or, with Prune, invalid
+	Skipped []string `json:"skipped"` // Remote entries that were not merged
+	Peers   []string `json:"peers"`
+}
+
+// Changed returns true if the sync changed the configured direct peers
+func (r *DirectPeerSyncResult) Changed() bool {
+	return len(r.Added) > 0 || len(r.Updated) > 0 || len(r.Pruned) > 0
+}
+
+// ValidatePeerID validates a libp2p peer ID (base58 "Qm..." or "12D3KooW...")
+func ValidatePeerID(peerID string) error {
+	if peerID == "" {
+		return fmt.Errorf("peer ID is empty")
+	}
+
+	for _, c := range peerID {
+		if !strings.ContainsRune(base58Alphabet, c) {
+			return fmt.Errorf("invalid peer ID %s: character %q is not base58", peerID, c)
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(peerID, "Qm"):
+		if len(peerID) != 46 {
+			return fmt.Errorf("invalid peer ID %s: expected 46 characters, got %d", peerID, len(peerID))
+		}
+	case strings.HasPrefix(peerID, "12D3KooW"):
+		if len(peerID) != 52 {
+			return fmt.Errorf("invalid peer ID %s: expected 52 characters, got %d", peerID, len(peerID))
+		}
+	default:
+		return fmt.Errorf("invalid peer ID %s: must start with 'Qm' or '12D3KooW'", peerID)
+	}
+
+	return nil
+}
+
+// ValidateDirectPeerMultiaddr validates a direct peer multiaddr
+// Expected form: /ip4/<ip>/tcp/<port>/p2p/<peer-id> or /ip4/<ip>/udp/<port>/quic-v1/p2p/<peer-id>
+func ValidateDirectPeerMultiaddr(multiaddr string) error {
+	parts := strings.Split(multiaddr, "/")
+	if len(parts) < 7 || parts[0] != "" {
+		return fmt.Errorf("invalid multiaddr %s: expected /ip4/<ip>/<tcp|udp>/<port>/.../p2p/<peer-id>", multiaddr)
+	}
+
+	switch parts[1] {
+	case "ip4":
+		if ip := net.ParseIP(parts[2]); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("invalid multiaddr %s: invalid IPv4 address %s", multiaddr, parts[2])
+		}
+	case "ip6":
+		if ip := net.ParseIP(parts[2]); ip == nil {
+			return fmt.Errorf("invalid multiaddr %s: invalid IPv6 address %s", multiaddr, parts[2])
+		}
+	case "dns", "dns4", "dns6":
+		if parts[2] == "" {
+			return fmt.Errorf("invalid multiaddr %s: empty hostname", multiaddr)
+		}
+	default:
+		return fmt.Errorf("invalid multiaddr %s: unsupported address type %s", multiaddr, parts[1])
+	}
+
+	proto := parts[3]
+	if proto != "tcp" && proto != "udp" {
+		return fmt.Errorf("invalid multiaddr %s: protocol must be tcp or udp", multiaddr)
+	}
+
+	port, err := strconv.Atoi(parts[4])
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid multiaddr %s: port must be between 1 and 65535", multiaddr)
+	}
+
+	rest := parts[5:]
+	if proto == "udp" {
+		if len(rest) == 0 || rest[0] != "quic-v1" {
+			return fmt.Errorf("invalid multiaddr %s: udp addresses must use /quic-v1", multiaddr)
+		}
+		rest = rest[1:]
+	}
+
+	if len(rest) != 2 || rest[0] != "p2p" {
+		return fmt.Errorf("invalid multiaddr %s: must end with /p2p/<peer-id>", multiaddr)
+	}
+
+	return ValidatePeerID(rest[1])
+}
+
+// PeerIDFromMultiaddr extracts the peer ID following the last /p2p/ component
+func PeerIDFromMultiaddr(multiaddr string) string {
+	idx := strings.LastIndex(multiaddr, "/p2p/")
+	if idx < 0 {
+		return ""
+	}
+	return strings.SplitN(multiaddr[idx+len("/p2p/"):], "/", 2)[0]
+}
+
+// BuildDirectPeerMultiaddr builds a direct peer multiaddr from its parts
+func BuildDirectPeerMultiaddr(ip string, port int, proto string, peerID string) string {
+	return fmt.Sprintf("%s/p2p/%s", BuildMultiaddr(ip, port, proto), peerID)
+}
+
+// GetDirectPeers gets the direct peers from the node config
+func GetDirectPeers(configPath string) ([]string, error) {
+	mgr, err := NewNodeConfigManager(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeConfig, err := mgr.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if nodeConfig.P2P == nil {
+		return []string{}, nil // No direct peers configured
+	}
+
+	return nodeConfig.P2P.DirectPeers, nil
+}
+
+// SetDirectPeers replaces the direct peers in the node config
+func SetDirectPeers(configPath string, peers []string) error {
+	mgr, err := NewNodeConfigManager(configPath)
+	if err != nil {
+		return err
+	}
+	if peers == nil {
+		peers = []string{}
+	}
+	return mgr.SetValue("p2p.directPeers", peers)
+}
+
+// AddDirectPeer adds a direct peer to the config
+// The multiaddr may omit the /p2p/<peer-id> suffix, in which case peerID is appended.
+// An existing entry with the same peer ID is replaced. Returns false if the exact entry already exists.
+func AddDirectPeer(configPath, peerID, multiaddr string) (bool, error) {
+	if peerID == "" {
+		peerID = PeerIDFromMultiaddr(multiaddr)
+	}
+	if PeerIDFromMultiaddr(multiaddr) == "" {
+		multiaddr = fmt.Sprintf("%s/p2p/%s", strings.TrimSuffix(multiaddr, "/"), peerID)
+	}
+	if PeerIDFromMultiaddr(multiaddr) != peerID {
+		return false, fmt.Errorf("peer ID %s does not match multiaddr %s", peerID, multiaddr)
+	}
+	if err := ValidateDirectPeerMultiaddr(multiaddr); err != nil {
+		return false, err
+	}
+
+	directPeers, err := GetDirectPeers(configPath)
+	if err != nil {
+		return false, err
+	}
+
+	var updatedPeers []string
+	for _, peer := range directPeers {
+		if peer == multiaddr {
+			return false, nil
+		}
+		if PeerIDFromMultiaddr(peer) == peerID {
+			continue // Replace existing entry for this peer
+		}
+		updatedPeers = append(updatedPeers, peer)
+	}
+	updatedPeers = append(updatedPeers, multiaddr)
+
+	return true, SetDirectPeers(configPath, updatedPeers)
+}
+
+// RemoveDirectPeer removes a direct peer from the config
+func RemoveDirectPeer(configPath, peerID string) error {
+	directPeers, err := GetDirectPeers(configPath)
+	if err != nil {
+		return err
+	}
+
+	// Remove peer
+	found := false
+	var updatedPeers []string
+	for _, peer := range directPeers {
+		if PeerIDFromMultiaddr(peer) == peerID || peer == peerID {
+			found = true
+			continue
+		}
+		updatedPeers = append(updatedPeers, peer)
+	}
+
+	if !found {
+		return fmt.Errorf("peer %s not found", peerID)
+	}
+
+	return SetDirectPeers(configPath, updatedPeers)
+}
+
+// ClearDirectPeers removes all direct peers from the config
+func ClearDirectPeers(configPath string) error {
+	return SetDirectPeers(configPath, []string{})
+}
+
+// SyncDirectPeers merges a peer list from the given source into p2p.directPeers
+// A remote entry replaces the configured address of the same peer ID; remote entries that are
+// invalid, our own or for a local IP address are skipped. Configured entries are only deduplicated
+// and stripped of our own peer ID, unless Prune also drops the invalid and local ones
+func SyncDirectPeers(configPath string, opts DirectPeerSyncOptions, cfg *config.Config) (*DirectPeerSyncResult, error) {
+	source := opts.Source
+	if source == "" {
+		source = defaultPeerListSource(cfg)
+		if source == "" {
+			return nil, fmt.Errorf("no peer list source given and settings.central_server is not configured")
+		}
+	}
+
+	remotePeers, err := FetchPeerList(source, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	directPeers, err := GetDirectPeers(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	localIPs := localIPAddresses()
+	valid := func(peer string) bool {
+		if PeerIDFromMultiaddr(peer) == "" {
+			return false
+		}
+		if ip, _, _, err := ParseMultiaddr(peer); err == nil && localIPs[ip] {
+			return false
+		}
+		return ValidateDirectPeerMultiaddr(peer) == nil
+	}
+	own := func(peer string) bool {
+		return opts.LocalPeerID != "" && PeerIDFromMultiaddr(peer) == opts.LocalPeerID
+	}
+
+	result := &DirectPeerSyncResult{}
+	byPeerID := make(map[string]string)
+	var order []string
+
+	for _, peer := range directPeers {
+		// Entries without a peer ID are kept by their multiaddr
+		key := PeerIDFromMultiaddr(peer)
+		if key == "" {
+			key = peer
+		}
+		if _, ok := byPeerID[key]; ok || own(peer) || (opts.Prune && !valid(peer)) {
+			result.Pruned = append(result.Pruned, peer)
+			continue
+		}
+		byPeerID[key] = peer
+		order = append(order, key)
+	}
+
+	// The first remote entry for a peer ID wins
+	merged := make(map[string]bool)
+	for _, peer := range remotePeers {
+		peerID := PeerIDFromMultiaddr(peer)
+		if merged[peerID] || own(peer) || !valid(peer) {
+			result.Skipped = append(result.Skipped, peer)
+			continue
+		}
+		merged[peerID] = true
+
+		existing, ok := byPeerID[peerID]
+		switch {
+		case !ok:
+			order = append(order, peerID)
+			result.Added = append(result.Added, peer)
+		case existing != peer:
+			result.Updated = append(result.Updated, peer)
+		}
+		byPeerID[peerID] = peer
+	}
+
+	for _, peerID := range order {
+		result.Peers = append(result.Peers, byPeerID[peerID])
+	}
+
+	if !opts.DryRun && result.Changed() {
+		if err := SetDirectPeers(configPath, result.Peers); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// FetchPeerList fetches a peer list from a local path, an http(s) URL or an SSH source
+func FetchPeerList(source string, cfg *config.Config) ([]string, error) {
+	var data []byte
+	var err error
+
+	switch {
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		data, err = fetchPeerListHTTP(source)
+	case strings.HasPrefix(source, "ssh://"), isSCPStyleSource(source):
+		data, err = fetchPeerListSSH(source, cfg)
+	default:
+		data, err = os.ReadFile(expandHome(source))
+		if err != nil {
+			err = fmt.Errorf("failed to read peer list: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePeerList(data)
+}
+
+// ParsePeerList parses a peer list file
+// Accepts YAML with a directPeers (or p2p.directPeers) list, or one multiaddr per line
+func ParsePeerList(data []byte) ([]string, error) {
+	var doc struct {
+		DirectPeers []string `yaml:"directPeers"`
+		P2P         struct {
+			DirectPeers []string `yaml:"directPeers"`
+		} `yaml:"p2p"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err == nil {
+		if len(doc.DirectPeers) > 0 {
+			return doc.DirectPeers, nil
+		}
+		if len(doc.P2P.DirectPeers) > 0 {
+			return doc.P2P.DirectPeers, nil
+		}
+	}
+
+	var peers []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+		line = strings.Trim(line, `"'`)
+		if line == "" || strings.HasPrefix(line, "#") || !strings.HasPrefix(line, "/") {
+			continue
+		}
+		peers = append(peers, line)
+	}
+
+	return peers, nil
+}
+
+// defaultPeerListSource builds the SSH source from settings.central_server and settings.publish_multiaddr
+func defaultPeerListSource(cfg *config.Config) string {
+	if cfg == nil || cfg.Settings == nil || cfg.Settings.CentralServer == nil || cfg.Settings.PublishMultiaddr == nil {
+		return ""
+	}
+	remoteHost := cfg.Settings.CentralServer.RemoteHost
+	remoteUser := cfg.Settings.CentralServer.RemoteUser
+	remoteFile := cfg.Settings.PublishMultiaddr.RemoteFile
+	if remoteHost == "" || remoteFile == "" {
+		return ""
+	}
+	if remoteUser != "" {
+		remoteHost = remoteUser + "@" + remoteHost
+	}
+	return remoteHost + ":" + remoteFile
+}
+
+// fetchPeerListHTTP downloads a peer list over HTTP
+func fetchPeerListHTTP(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peer list: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch peer list: status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// fetchPeerListSSH reads a remote peer list over SSH
+func fetchPeerListSSH(source string, cfg *config.Config) ([]byte, error) {
+	host, port, path := parseSSHSource(source)
+	if host == "" || path == "" {
+		return nil, fmt.Errorf("invalid SSH source: %s", source)
+	}
+
+	output, err := sshCommand(host, port, cfg, "cat "+remoteShellPath(path)).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peer list from %s: %w", host, err)
+	}
+
+	return output, nil
+}
+
+// sshCommand builds an ssh command running remoteCommand on host
+// The key from settings.central_server.ssh_key_path is used when set
+func sshCommand(host, port string, cfg *config.Config, remoteCommand string) *exec.Cmd {
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=15"}
+	if cfg != nil && cfg.Settings != nil && cfg.Settings.CentralServer != nil && cfg.Settings.CentralServer.SSHKeyPath != "" {
+		args = append(args, "-i", expandHome(cfg.Settings.CentralServer.SSHKeyPath))
+	}
+	if port != "" {
+		args = append(args, "-p", port)
+	}
+	args = append(args, host, remoteCommand)
+	return exec.Command("ssh", args...)
+}
+
+// remoteShellPath quotes a path for the remote shell, keeping a leading ~/ relative to
+// the remote user's home directory
+func remoteShellPath(path string) string {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+	if strings.HasPrefix(path, "~/") {
+		return `"$HOME"/` + quote(strings.TrimPrefix(path, "~/"))
+	}
+	return quote(path)
+}
+
+// parseSSHSource splits ssh://user@host[:port]/path or user@host:path
+func parseSSHSource(source string) (host, port, path string) {
+	if strings.HasPrefix(source, "ssh://") {
+		rest := strings.TrimPrefix(source, "ssh://")
+		slash := strings.Index(rest, "/")
+		if slash < 0 {
+			return rest, "", ""
+		}
+		host, path = rest[:slash], rest[slash:]
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			host, port = h, p
+		}
+		// Allow ssh://host/~/file for home-relative paths
+		path = strings.TrimPrefix(path, "/~/")
+		return host, port, path
+	}
+
+	colon := strings.Index(source, ":")
+	return source[:colon], "", source[colon+1:]
+}
+
+// isSCPStyleSource checks for the user@host:path form
+func isSCPStyleSource(source string) bool {
+	colon := strings.Index(source, ":")
+	if colon <= 0 || strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~") {
+		return false
+	}
+	return !strings.Contains(source[:colon], "/")
+}
+
+// IsLocalIP checks if the IP address is assigned to a local interface
+func IsLocalIP(ip string) bool {
+	return localIPAddresses()[ip]
+}
+
+// localIPAddresses returns the IP addresses assigned to local interfaces
+func localIPAddresses() map[string]bool {
+	ips := make(map[string]bool)
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ips
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			ips[ipNet.IP.String()] = true
+		}
+	}
+	return ips
+}
+
+// expandHome expands a leading ~ to the user's home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
@@ -0,0 +1,243 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// testPeerID returns a valid Qm peer ID made of c
+func testPeerID(c string) string {
+	return "Qm" + strings.Repeat(c, 44)
+}
+
+// writeNodeConfig writes a node config with the given direct peers and returns its path
+func writeNodeConfig(t *testing.T, peers []string) string {
+	t.Helper()
+	content, err := yaml.Marshal(map[string]interface{}{
+		"p2p": map[string]interface{}{
+			"listenMultiaddr": "/ip4/0.0.0.0/tcp/8336",
+			"directPeers":     peers,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSyncDirectPeers(t *testing.T) {
+	peerA, peerB, peerC, own := testPeerID("A"), testPeerID("B"), testPeerID("C"), testPeerID("Z")
+	local := []string{
+		"/ip4/198.51.100.1/tcp/8336/p2p/" + peerA,
+		"/ip4/198.51.100.2/tcp/8336/p2p/" + peerB,
+		"/ip4/198.51.100.9/tcp/8336/p2p/" + peerB, // Duplicate peer ID
+		"/ip4/198.51.100.3/tcp/8336",              // No peer ID
+		"/ip4/198.51.100.4/udp/8336/p2p/" + peerC, // udp without quic-v1
+		"/ip4/198.51.100.5/tcp/8336/p2p/" + own,
+	}
+	remote := []string{
+		"/ip4/198.51.100.1/tcp/8336/p2p/" + peerA,             // Unchanged
+		"/ip4/203.0.113.2/udp/8336/quic-v1/p2p/" + peerB,      // New address
+		"/ip4/203.0.113.3/tcp/8336/p2p/" + peerC,              // New peer
+		"/ip4/203.0.113.4/tcp/8336/p2p/" + peerC,              // Second entry for the same peer
+		"/ip4/203.0.113.5/tcp/8336/p2p/" + own,                // Own peer ID
+		"/ip4/127.0.0.1/tcp/8336/p2p/" + testPeerID("D"),      // Local address
+		"/ip4/203.0.113.6/tcp/8336/p2p/" + testPeerID("0bad"), // Invalid peer ID
+	}
+
+	source := filepath.Join(t.TempDir(), "peers.yml")
+	content, _ := yaml.Marshal(map[string]interface{}{"directPeers": remote})
+	if err := os.WriteFile(source, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		prune       bool
+		wantPeers   []string
+		wantAdded   []string
+		wantUpdated []string
+		wantPruned  []string
+	}{
+		{
+			// Invalid configured entries stay until --prune; peer C's is replaced by the remote one
+			name:        "default",
+			wantPeers:   []string{local[0], remote[1], local[3], remote[2]},
+			wantUpdated: []string{remote[1], remote[2]},
+			wantPruned:  []string{local[2], local[5]},
+		},
+		{
+			name:        "prune",
+			prune:       true,
+			wantPeers:   []string{local[0], remote[1], remote[2]},
+			wantAdded:   []string{remote[2]},
+			wantUpdated: []string{remote[1]},
+			wantPruned:  local[2:],
+		},
+	}
+
+	for _, tt := range tests {
+		for _, dryRun := range []bool{true, false} {
+			configPath := writeNodeConfig(t, local)
+			opts := DirectPeerSyncOptions{Source: source, LocalPeerID: own, Prune: tt.prune, DryRun: dryRun}
+			result, err := SyncDirectPeers(configPath, opts, nil)
+			if err != nil {
+				t.Fatalf("%s: SyncDirectPeers failed: %v", tt.name, err)
+			}
+
+			if !reflect.DeepEqual(result.Peers, tt.wantPeers) {
+				t.Errorf("%s: peers = %q, want %q", tt.name, result.Peers, tt.wantPeers)
+			}
+			if !reflect.DeepEqual(result.Added, tt.wantAdded) {
+				t.Errorf("%s: added = %q, want %q", tt.name, result.Added, tt.wantAdded)
+			}
+			if !reflect.DeepEqual(result.Updated, tt.wantUpdated) {
+				t.Errorf("%s: updated = %q, want %q", tt.name, result.Updated, tt.wantUpdated)
+			}
+			if !reflect.DeepEqual(result.Pruned, tt.wantPruned) {
+				t.Errorf("%s: pruned = %q, want %q", tt.name, result.Pruned, tt.wantPruned)
+			}
+			if !reflect.DeepEqual(result.Skipped, remote[3:]) {
+				t.Errorf("%s: skipped = %q, want %q", tt.name, result.Skipped, remote[3:])
+			}
+
+			saved, err := GetDirectPeers(configPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.wantPeers
+			if dryRun {
+				want = local
+			}
+			if !reflect.DeepEqual(saved, want) {
+				t.Errorf("%s, dry run %t: saved peers = %q, want %q", tt.name, dryRun, saved, want)
+			}
+		}
+	}
+}
+
+// TestSyncDirectPeersPrunesWithoutRemoteChanges checks that invalid local entries are
+// kept by default and removed with Prune even when the remote list adds nothing
+func TestSyncDirectPeersPrunesWithoutRemoteChanges(t *testing.T) {
+	valid := "/ip4/198.51.100.1/tcp/8336/p2p/" + testPeerID("A")
+	local := []string{valid, "/ip4/198.51.100.2/tcp/8336"}
+	configPath := writeNodeConfig(t, local)
+
+	source := filepath.Join(t.TempDir(), "peers.txt")
+	if err := os.WriteFile(source, []byte(valid+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := SyncDirectPeers(configPath, DirectPeerSyncOptions{Source: source}, nil)
+	if err != nil {
+		t.Fatalf("SyncDirectPeers failed: %v", err)
+	}
+	if result.Changed() {
+		t.Fatalf("result = %+v, want no changes without Prune", result)
+	}
+	if saved, _ := GetDirectPeers(configPath); !reflect.DeepEqual(saved, local) {
+		t.Errorf("saved peers = %q, want %q", saved, local)
+	}
+
+	result, err = SyncDirectPeers(configPath, DirectPeerSyncOptions{Source: source, Prune: true}, nil)
+	if err != nil {
+		t.Fatalf("SyncDirectPeers failed: %v", err)
+	}
+	if !result.Changed() || len(result.Pruned) != 1 {
+		t.Fatalf("result = %+v, want one pruned entry", result)
+	}
+	if saved, _ := GetDirectPeers(configPath); !reflect.DeepEqual(saved, []string{valid}) {
+		t.Errorf("saved peers = %q", saved)
+	}
+}
+
+func TestPublishDirectPeer(t *testing.T) {
+	own, other := testPeerID("Z"), testPeerID("A")
+	configPath := writeNodeConfig(t, nil)
+	multiaddr := "/ip4/203.0.113.10/tcp/8336/p2p/" + own
+	otherPeer := "/ip4/198.51.100.1/udp/8336/quic-v1/p2p/" + other
+
+	target := filepath.Join(t.TempDir(), "directPeersList.yml")
+	content := "# Shared by the cluster\nowner: ops\ndirectPeers:\n" +
+		"    - " + otherPeer + "\n" +
+		"    - /ip4/203.0.113.9/tcp/8336/p2p/" + own + "\n" + // Old address
+		"    - /ip4/203.0.113.10/tcp/8340/p2p/" + testPeerID("B") + "\n" // Stale entry for our IP
+	if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DirectPeerPublishOptions{Target: target, IP: "203.0.113.10", PeerID: own}
+	result, err := PublishDirectPeer(configPath, opts, nil)
+	if err != nil {
+		t.Fatalf("PublishDirectPeer failed: %v", err)
+	}
+	if result.Multiaddr != multiaddr || !result.Added || len(result.Removed) != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Owner       string   `yaml:"owner"`
+		DirectPeers []string `yaml:"directPeers"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("published list is not YAML: %v", err)
+	}
+	if doc.Owner != "ops" {
+		t.Errorf("other keys were not kept:\n%s", data)
+	}
+	if want := []string{otherPeer, multiaddr}; !reflect.DeepEqual(doc.DirectPeers, want) {
+		t.Errorf("published peers = %q, want %q", doc.DirectPeers, want)
+	}
+
+	// Publishing again changes nothing
+	result, err = PublishDirectPeer(configPath, opts, nil)
+	if err != nil {
+		t.Fatalf("PublishDirectPeer failed: %v", err)
+	}
+	if result.Changed() {
+		t.Errorf("second publish changed the list: %+v", result)
+	}
+}
+
+func TestPublishDirectPeerCreatesList(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "peers.yml")
+	opts := DirectPeerPublishOptions{Target: target, IP: "203.0.113.10", PeerID: testPeerID("Z")}
+	if _, err := PublishDirectPeer(writeNodeConfig(t, nil), opts, nil); err != nil {
+		t.Fatalf("PublishDirectPeer failed: %v", err)
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peers, err := ParsePeerList(data)
+	if err != nil || len(peers) != 1 {
+		t.Errorf("created list has peers %q (%v)", peers, err)
+	}
+}
+
+func TestRemoteShellPath(t *testing.T) {
+	tests := map[string]string{
+		"~/directPeersList.yml": `"$HOME"/'directPeersList.yml'`,
+		"/srv/peers list.yml":   `'/srv/peers list.yml'`,
+		"it's.yml":              `'it'\''s.yml'`,
+	}
+	for path, want := range tests {
+		if got := remoteShellPath(path); got != want {
+			t.Errorf("remoteShellPath(%q) = %s, want %s", path, got, want)
+		}
+	}
+}
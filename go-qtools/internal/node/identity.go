@@ -0,0 +1,305 @@
+package node
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudflare/circl/sign/ed448"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/mr-tron/base58/base58"
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// libp2p key types (crypto.pb.KeyType, Ed448 as added by Quilibrium)
+var keyTypeNames = map[uint64]string{
+	0: "RSA",
+	1: "Ed25519",
+	2: "Secp256k1",
+	3: "ECDSA",
+	4: "Ed448",
+}
+
+// Multihash codes used in peer IDs
+const (
+	multihashIdentity = 0x00
+	multihashSHA256   = 0x12
+
+	// Public keys up to this size are inlined in the peer ID (libp2p maxInlineKeyLength)
+	maxInlineKeyLength = 42
+
+	keyTypeEd448 = 4
+)
+
+// PeerIdentity represents a decoded peer ID and the account address derived from it
+type PeerIdentity struct {
+	PeerID         string `json:"peer_id"`
+	Multihash      string `json:"multihash"`
+	KeyType        string `json:"key_type"`
+	PublicKey      string `json:"public_key,omitempty"`
+	AccountAddress string `json:"account_address"`
+	Source         string `json:"source"`
+}
+
+// DecodePeerID decodes a peer ID and derives its account address
+// The public key is only available when it is inlined in the peer ID (identity multihash)
+func DecodePeerID(peerID string) (*PeerIdentity, error) {
+	raw, err := base58.Decode(peerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode peer ID: %w", err)
+	}
+
+	code, n := binary.Uvarint(raw)
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid peer ID %s: bad multihash code", peerID)
+	}
+	length, m := binary.Uvarint(raw[n:])
+	if m <= 0 || int(length) != len(raw)-n-m {
+		return nil, fmt.Errorf("invalid peer ID %s: bad multihash length", peerID)
+	}
+	digest := raw[n+m:]
+
+	identity := &PeerIdentity{
+		PeerID:  peerID,
+		KeyType: "unknown",
+	}
+
+	switch code {
+	case multihashSHA256:
+		identity.Multihash = "sha2-256"
+	case multihashIdentity:
+		identity.Multihash = "identity"
+		keyType, pubKey, err := parsePublicKeyProto(digest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer ID %s: %w", peerID, err)
+		}
+		identity.KeyType = keyTypeName(keyType)
+		identity.PublicKey = hex.EncodeToString(pubKey)
+	default:
+		return nil, fmt.Errorf("invalid peer ID %s: unsupported multihash 0x%x", peerID, code)
+	}
+
+	identity.AccountAddress, err = accountAddress(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// AccountAddress derives the QUIL account address (Poseidon hash of the peer ID bytes)
+func AccountAddress(peerID string) (string, error) {
+	raw, err := base58.Decode(peerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode peer ID: %w", err)
+	}
+	return accountAddress(raw)
+}
+
+// PeerIdentityFromPrivateKey derives the peer identity from a hex-encoded Ed448 peer key (p2p.peerPrivKey)
+func PeerIdentityFromPrivateKey(hexKey string) (*PeerIdentity, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimSpace(hexKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode peer private key: %w", err)
+	}
+
+	var pubKey []byte
+	switch len(keyBytes) {
+	case ed448.PrivateKeySize:
+		pubKey = keyBytes[ed448.SeedSize:]
+	case ed448.SeedSize:
+		pubKey = ed448.NewKeyFromSeed(keyBytes).Public().(ed448.PublicKey)
+	default:
+		return nil, fmt.Errorf("unexpected peer private key length %d (expected Ed448 key of %d or %d bytes)",
+			len(keyBytes), ed448.SeedSize, ed448.PrivateKeySize)
+	}
+
+	return peerIdentityFromPublicKey(pubKey)
+}
+
+// peerIdentityFromPublicKey derives the peer identity from an Ed448 public key
+func peerIdentityFromPublicKey(pubKey []byte) (*PeerIdentity, error) {
+	// Marshal the public key as a libp2p crypto.pb.PublicKey
+	keyProto := []byte{0x08, keyTypeEd448, 0x12}
+	keyProto = binary.AppendUvarint(keyProto, uint64(len(pubKey)))
+	keyProto = append(keyProto, pubKey...)
+
+	var mh []byte
+	multihashName := "identity"
+	if len(keyProto) <= maxInlineKeyLength {
+		mh = binary.AppendUvarint([]byte{multihashIdentity}, uint64(len(keyProto)))
+		mh = append(mh, keyProto...)
+	} else {
+		multihashName = "sha2-256"
+		digest := sha256.Sum256(keyProto)
+		mh = append([]byte{multihashSHA256, byte(len(digest))}, digest[:]...)
+	}
+
+	address, err := accountAddress(mh)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PeerIdentity{
+		PeerID:         base58.Encode(mh),
+		Multihash:      multihashName,
+		KeyType:        keyTypeName(keyTypeEd448),
+		PublicKey:      hex.EncodeToString(pubKey),
+		AccountAddress: address,
+	}, nil
+}
+
+// keyStoreEntry is a key in the node's keys.yml (key.keyManagerFile)
+// Private keys are encrypted with key.keyManagerFile.encryptionKey; public keys are plain hex
+type keyStoreEntry struct {
+	ID        string `yaml:"id"`
+	PublicKey string `yaml:"publicKey"`
+}
+
+// LoadPeerIdentity derives the peer identity without running the node
+// p2p.peerPrivKey in the node config is the key the node runs with, so it is used whenever it
+// is set; otherwise the identity comes from a key keys.yml names as the peer key. Other Ed448
+// keys in keys.yml, such as default-proving-key, are never taken for the peer key
+func LoadPeerIdentity(configPath string) (*PeerIdentity, error) {
+	mgr, err := NewNodeConfigManager(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if val, err := mgr.GetValue("p2p.peerPrivKey"); err == nil {
+		if hexKey, ok := val.(string); ok && hexKey != "" {
+			identity, err := PeerIdentityFromPrivateKey(hexKey)
+			if err != nil {
+				return nil, fmt.Errorf("invalid p2p.peerPrivKey: %w", err)
+			}
+			identity.Source = "config"
+			return identity, nil
+		}
+	}
+
+	identity, err := peerIdentityFromKeyStore(KeysFilePath(mgr.configPath))
+	if err != nil {
+		return nil, fmt.Errorf("p2p.peerPrivKey is not set in the node config and %w", err)
+	}
+	identity.Source = "keys.yml"
+	return identity, nil
+}
+
+// KeysFilePath returns the path of keys.yml for a node config
+// key.keyManagerFile.path is relative to the node directory, the parent of .config
+func KeysFilePath(configPath string) string {
+	if configPath == "" {
+		configPath = config.GetNodeConfigPath()
+	}
+	configDir := filepath.Dir(configPath)
+
+	if mgr, err := NewNodeConfigManager(configPath); err == nil {
+		if val, err := mgr.GetValue("key.keyManagerFile.path"); err == nil {
+			if path, ok := val.(string); ok && path != "" {
+				if filepath.IsAbs(path) {
+					return path
+				}
+				candidate := filepath.Join(filepath.Dir(configDir), path)
+				if _, err := os.Stat(candidate); err == nil {
+					return candidate
+				}
+			}
+		}
+	}
+	return filepath.Join(configDir, "keys.yml")
+}
+
+// peerIdentityFromKeyStore derives the identity from the key keys.yml names as the peer key
+// (an ID containing "peer"); keys under any other name are ignored
+func peerIdentityFromKeyStore(path string) (*PeerIdentity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys file: %w", err)
+	}
+
+	var keys map[string]keyStoreEntry
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	ids := make([]string, 0, len(keys))
+	for id := range keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if !strings.Contains(id, "peer") {
+			continue
+		}
+		pubKey, err := hex.DecodeString(strings.TrimSpace(keys[id].PublicKey))
+		if err != nil || len(pubKey) != ed448.PublicKeySize {
+			return nil, fmt.Errorf("%s in %s is not an Ed448 public key", id, path)
+		}
+		return peerIdentityFromPublicKey(pubKey)
+	}
+
+	return nil, fmt.Errorf("%s has no peer key (keys: %s)", path, strings.Join(ids, ", "))
+}
+
+// accountAddress hashes raw peer ID bytes to a 0x-prefixed 32-byte address
+func accountAddress(peerIDBytes []byte) (string, error) {
+	addr, err := poseidon.HashBytes(peerIDBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash peer ID: %w", err)
+	}
+	return "0x" + hex.EncodeToString(addr.FillBytes(make([]byte, 32))), nil
+}
+
+// parsePublicKeyProto parses a crypto.pb.PublicKey message (field 1: type, field 2: data)
+func parsePublicKeyProto(data []byte) (uint64, []byte, error) {
+	var keyType uint64
+	var keyData []byte
+
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, nil, fmt.Errorf("malformed public key")
+		}
+		data = data[n:]
+
+		switch tag {
+		case 0x08: // field 1, varint
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return 0, nil, fmt.Errorf("malformed key type")
+			}
+			keyType = v
+			data = data[n:]
+		case 0x12: // field 2, bytes
+			l, n := binary.Uvarint(data)
+			if n <= 0 || int(l) > len(data)-n {
+				return 0, nil, fmt.Errorf("malformed key data")
+			}
+			keyData = data[n : n+int(l)]
+			data = data[n+int(l):]
+		default:
+			return 0, nil, fmt.Errorf("unexpected public key field tag 0x%x", tag)
+		}
+	}
+
+	if keyData == nil {
+		return 0, nil, fmt.Errorf("public key has no key data")
+	}
+
+	return keyType, keyData, nil
+}
+
+// keyTypeName returns the name of a libp2p key type
+func keyTypeName(keyType uint64) string {
+	if name, ok := keyTypeNames[keyType]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", keyType)
+}
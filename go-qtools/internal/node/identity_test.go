@@ -0,0 +1,123 @@
+package node
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/ed448"
+)
+
+// testEd448Key returns the hex private and public keys for a seed made of b
+func testEd448Key(b byte) (string, string) {
+	key := ed448.NewKeyFromSeed(bytes.Repeat([]byte{b}, ed448.SeedSize))
+	return hex.EncodeToString(key), hex.EncodeToString(key.Public().(ed448.PublicKey))
+}
+
+// writeNodeKeys writes .config/config.yml and, when keys is not empty, .config/keys.yml
+// under a node directory and returns the config path
+func writeNodeKeys(t *testing.T, configYAML, keysYAML string) string {
+	t.Helper()
+	configDir := filepath.Join(t.TempDir(), ".config")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if keysYAML != "" {
+		if err := os.WriteFile(filepath.Join(configDir, "keys.yml"), []byte(keysYAML), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := filepath.Join(configDir, "config.yml")
+	if err := os.WriteFile(configPath, []byte(configYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return configPath
+}
+
+func TestLoadPeerIdentity(t *testing.T) {
+	peerPriv, peerPub := testEd448Key(1)
+	_, provingPub := testEd448Key(2)
+	configPriv, _ := testEd448Key(3)
+
+	want, err := PeerIdentityFromPrivateKey(peerPriv)
+	if err != nil {
+		t.Fatalf("PeerIdentityFromPrivateKey failed: %v", err)
+	}
+	fromConfig, err := PeerIdentityFromPrivateKey(configPriv)
+	if err != nil {
+		t.Fatalf("PeerIdentityFromPrivateKey failed: %v", err)
+	}
+
+	// keys.yml as a node writes it: the Ed448 default-proving-key and the BLS48-581
+	// q-prover-key, and no peer key. Private keys are encrypted; only public keys are read
+	nodeKeys := `default-proving-key:
+  id: default-proving-key
+  type: 0
+  privateKey: 00112233
+  publicKey: ` + provingPub + `
+q-prover-key:
+  id: q-prover-key
+  type: 3
+  privateKey: 8899aabb
+  publicKey: ` + hex.EncodeToString(bytes.Repeat([]byte{7}, 585)) + `
+`
+	// The same with a key explicitly named as the peer key
+	peerKeys := nodeKeys + `q-peer-key:
+  id: q-peer-key
+  type: 0
+  privateKey: 44556677
+  publicKey: ` + peerPub + `
+`
+	keyManager := "key:\n  keyManagerFile:\n    path: .config/keys.yml\n"
+	peerPrivKey := "p2p:\n  peerPrivKey: " + configPriv + "\n"
+
+	tests := []struct {
+		name       string
+		config     string
+		keys       string
+		wantPeerID string
+		wantSource string
+		wantErr    bool
+	}{
+		{name: "node keys.yml and peerPrivKey", config: keyManager + peerPrivKey, keys: nodeKeys, wantPeerID: fromConfig.PeerID, wantSource: "config"},
+		// default-proving-key is Ed448 too, but it is not the peer key
+		{name: "node keys.yml without peerPrivKey", config: keyManager, keys: nodeKeys, wantErr: true},
+		{name: "peer key in keys.yml", config: keyManager, keys: peerKeys, wantPeerID: want.PeerID, wantSource: "keys.yml"},
+		{name: "peer key in keys.yml beside config", config: "p2p:\n  listenMultiaddr: /ip4/0.0.0.0/udp/8336/quic-v1\n", keys: peerKeys, wantPeerID: want.PeerID, wantSource: "keys.yml"},
+		{name: "peerPrivKey wins over a different keys.yml peer key", config: keyManager + peerPrivKey, keys: peerKeys, wantPeerID: fromConfig.PeerID, wantSource: "config"},
+		{name: "no keys.yml", config: peerPrivKey, wantPeerID: fromConfig.PeerID, wantSource: "config"},
+		{name: "peer key is not Ed448", config: keyManager, keys: "q-peer-key:\n  id: q-peer-key\n  publicKey: 0102\n", wantErr: true},
+		{name: "no key at all", config: "p2p:\n  listenMultiaddr: /ip4/0.0.0.0/udp/8336/quic-v1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := LoadPeerIdentity(writeNodeKeys(t, tt.config, tt.keys))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadPeerIdentity failed: %v", err)
+			}
+			if identity.PeerID != tt.wantPeerID || identity.Source != tt.wantSource {
+				t.Errorf("identity = %s from %s, want %s from %s", identity.PeerID, identity.Source, tt.wantPeerID, tt.wantSource)
+			}
+		})
+	}
+
+	// The derived peer ID decodes to the same account address
+	decoded, err := DecodePeerID(want.PeerID)
+	if err != nil {
+		t.Fatalf("DecodePeerID failed: %v", err)
+	}
+	if decoded.AccountAddress != want.AccountAddress || decoded.Multihash != "sha2-256" {
+		t.Errorf("decoded %+v, want account address %s", decoded, want.AccountAddress)
+	}
+	if want.PublicKey != peerPub || want.KeyType != "Ed448" {
+		t.Errorf("identity key = %s %s, want Ed448 %s", want.KeyType, want.PublicKey, peerPub)
+	}
+}
@@ -91,7 +91,12 @@ func ToggleMode(cfg *config.Config) error {
 
 	// If enabling manual mode, calculate worker count if not set
 	if cfg.Manual.Enabled && cfg.Manual.WorkerCount == 0 {
-		cfg.Manual.WorkerCount = calculateDefaultWorkerCount()
+		workerCount := calculateDefaultWorkerCount()
+		if err := CheckWorkerPorts(cfg, workerCount); err != nil {
+			cfg.Manual.Enabled = false
+			return err
+		}
+		cfg.Manual.WorkerCount = workerCount
 	}
 
 	return nil
@@ -0,0 +1,233 @@
+package node
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NodeInfo field keys used by the node info formats
+const (
+	infoPeerID             = "peer_id"
+	infoVersion            = "version"
+	infoMaxFrame           = "max_frame"
+	infoPeerScore          = "peer_score"
+	infoProverRing         = "prover_ring"
+	infoSeniority          = "seniority"
+	infoActiveWorkers      = "active_workers"
+	infoRunningWorkers     = "running_workers"
+	infoBalance            = "balance"
+	infoUnconfirmedBalance = "unconfirmed_balance"
+)
+
+// nodeInfoFormat describes the --node-info output of a range of node releases
+type nodeInfoFormat struct {
+	Name       string
+	MinVersion string            // First release printing this format
+	Labels     map[string]string // Output label -> field key
+}
+
+// nodeInfoFormats lists known --node-info formats, newest first
+var nodeInfoFormats = []nodeInfoFormat{
+	{
+		Name:       "2.1",
+		MinVersion: "2.1.0",
+		Labels: map[string]string{
+			"Peer ID":             infoPeerID,
+			"Version":             infoVersion,
+			"Max Frame":           infoMaxFrame,
+			"Peer Score":          infoPeerScore,
+			"Prover Ring":         infoProverRing,
+			"Seniority":           infoSeniority,
+			"Running Workers":     infoRunningWorkers,
+			"Active Workers":      infoActiveWorkers,
+			"Owned balance":       infoBalance,
+			"Unconfirmed balance": infoUnconfirmedBalance,
+		},
+	},
+	{
+		Name:       "2.0",
+		MinVersion: "2.0.0",
+		Labels: map[string]string{
+			"Peer ID":             infoPeerID,
+			"Version":             infoVersion,
+			"Max Frame":           infoMaxFrame,
+			"Peer Score":          infoPeerScore,
+			"Prover Ring":         infoProverRing,
+			"Seniority":           infoSeniority,
+			"Active Workers":      infoActiveWorkers,
+			"Owned balance":       infoBalance,
+			"Unconfirmed balance": infoUnconfirmedBalance,
+		},
+	},
+	{
+		Name:       "1.4",
+		MinVersion: "1.4.0",
+		Labels: map[string]string{
+			"Peer ID":           infoPeerID,
+			"Version":           infoVersion,
+			"Max Frame":         infoMaxFrame,
+			"Peer Score":        infoPeerScore,
+			"Seniority":         infoSeniority,
+			"Unclaimed balance": infoBalance,
+			"Balance":           infoBalance,
+		},
+	},
+}
+
+// infoLineRegex matches "Label: value" lines; log lines and JSON are ignored
+var infoLineRegex = regexp.MustCompile(`^([A-Z][A-Za-z0-9 ]*?):\s*(.*)$`)
+
+// ParseNodeInfo parses the output of node --node-info
+// The format is chosen from the reported version; unknown labels are kept in Extra
+func ParseNodeInfo(output []byte) (*NodeInfo, error) {
+	type pair struct{ label, value string }
+	var pairs []pair
+	version := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		matches := infoLineRegex.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if matches == nil {
+			continue
+		}
+		label, value := matches[1], strings.TrimSpace(matches[2])
+		pairs = append(pairs, pair{label, value})
+		if label == "Version" {
+			version = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read node info: %w", err)
+	}
+
+	format := nodeInfoFormatFor(version)
+	info := &NodeInfo{Format: format.Name, ProverRing: -1}
+
+	for _, p := range pairs {
+		key, ok := format.Labels[p.label]
+		if !ok {
+			if info.Extra == nil {
+				info.Extra = make(map[string]string)
+			}
+			info.Extra[p.label] = p.value
+			continue
+		}
+		if err := info.setField(key, p.value); err != nil {
+			return nil, fmt.Errorf("failed to parse %s %q: %w", p.label, p.value, err)
+		}
+	}
+
+	if info.PeerID == "" {
+		return nil, fmt.Errorf("no node info found in output")
+	}
+
+	// Running workers are only reported by newer releases
+	info.WorkerCount = info.ActiveWorkers
+	if info.RunningWorkers > 0 {
+		info.WorkerCount = info.RunningWorkers
+	}
+
+	return info, nil
+}
+
+// setField sets a NodeInfo field from its output value
+func (info *NodeInfo) setField(key, value string) error {
+	var err error
+	switch key {
+	case infoPeerID:
+		info.PeerID = value
+	case infoVersion:
+		info.Version = value
+	case infoMaxFrame:
+		info.MaxFrame, err = strconv.ParseUint(value, 10, 64)
+	case infoPeerScore:
+		info.PeerScore, err = strconv.ParseInt(value, 10, 64)
+	case infoProverRing:
+		info.ProverRing, err = strconv.Atoi(value)
+	case infoSeniority:
+		info.Seniority = value
+	case infoActiveWorkers:
+		info.ActiveWorkers, err = strconv.Atoi(value)
+	case infoRunningWorkers:
+		info.RunningWorkers, err = strconv.Atoi(value)
+	case infoBalance:
+		info.Balance, info.BalanceUnit, err = parseBalance(value)
+	case infoUnconfirmedBalance:
+		info.UnconfirmedBalance, _, err = parseBalance(value)
+	}
+	return err
+}
+
+// parseBalance splits "123.456 QUIL" into amount and unit
+func parseBalance(value string) (string, string, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return "", "", fmt.Errorf("empty balance")
+	}
+	if _, err := strconv.ParseFloat(fields[0], 64); err != nil {
+		return "", "", fmt.Errorf("invalid amount")
+	}
+	unit := ""
+	if len(fields) > 1 {
+		unit = fields[1]
+	}
+	return fields[0], unit, nil
+}
+
+// nodeInfoFormatFor returns the newest format whose minimum version is at or below version
+func nodeInfoFormatFor(version string) nodeInfoFormat {
+	if version == "" {
+		return nodeInfoFormats[0]
+	}
+	for _, format := range nodeInfoFormats {
+		if compareVersions(version, format.MinVersion) >= 0 {
+			return format
+		}
+	}
+	return nodeInfoFormats[len(nodeInfoFormats)-1]
+}
+
+// compareVersions compares dotted numeric versions, ignoring suffixes like "-p2"
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts parses the leading numeric components of a version
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		end := 0
+		for end < len(field) && field[end] >= '0' && field[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		n, _ := strconv.Atoi(field[:end])
+		parts = append(parts, n)
+		if end < len(field) {
+			break
+		}
+	}
+	return parts
+}
@@ -0,0 +1,112 @@
+package node
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// updateGolden rewrites the expected results from the parser: go test ./internal/node -update
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// TestParseNodeInfoFormats parses the --node-info output of each release format in
+// testdata/node-info/<format>.txt and compares it to <format>.json
+func TestParseNodeInfoFormats(t *testing.T) {
+	tests := []struct {
+		format         string
+		wantWorkers    int
+		wantProverRing int
+	}{
+		{format: "1.4", wantWorkers: 0, wantProverRing: -1},
+		{format: "2.0", wantWorkers: 32, wantProverRing: 2},
+		{format: "2.1", wantWorkers: 30, wantProverRing: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			base := filepath.Join("testdata", "node-info", tt.format)
+			output, err := os.ReadFile(base + ".txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			info, err := ParseNodeInfo(output)
+			if err != nil {
+				t.Fatalf("ParseNodeInfo failed: %v", err)
+			}
+			if info.Format != tt.format {
+				t.Errorf("format = %q, want %q", info.Format, tt.format)
+			}
+			if info.WorkerCount != tt.wantWorkers {
+				t.Errorf("worker count = %d, want %d", info.WorkerCount, tt.wantWorkers)
+			}
+			if info.ProverRing != tt.wantProverRing {
+				t.Errorf("prover ring = %d, want %d", info.ProverRing, tt.wantProverRing)
+			}
+
+			got, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+			if *updateGolden {
+				if err := os.WriteFile(base+".json", got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(base + ".json")
+			if err != nil {
+				t.Fatalf("failed to read golden file (run go test -update to create it): %v", err)
+			}
+			var gotInfo, wantInfo NodeInfo
+			json.Unmarshal(got, &gotInfo)
+			if err := json.Unmarshal(want, &wantInfo); err != nil {
+				t.Fatalf("invalid golden file: %v", err)
+			}
+			if !reflect.DeepEqual(gotInfo, wantInfo) {
+				t.Errorf("parsed node info does not match %s.json:\ngot:\n%s\nwant:\n%s", base, got, want)
+			}
+		})
+	}
+}
+
+func TestParseNodeInfoErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{name: "empty", output: ""},
+		{name: "no peer ID", output: "Version: 2.0.4.1\nMax Frame: 1\n"},
+		{name: "invalid max frame", output: "Peer ID: Qm1\nVersion: 2.0.4.1\nMax Frame: soon\n"},
+		{name: "invalid balance", output: "Peer ID: Qm1\nVersion: 2.0.4.1\nOwned balance: lots QUIL\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if info, err := ParseNodeInfo([]byte(tt.output)); err == nil {
+				t.Errorf("expected an error, got %+v", info)
+			}
+		})
+	}
+}
+
+func TestNodeInfoFormatFor(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "", want: "2.1"},
+		{version: "2.1.0", want: "2.1"},
+		{version: "2.1.0.2-p1", want: "2.1"},
+		{version: "2.0.6.2", want: "2.0"},
+		{version: "1.4.21.1", want: "1.4"},
+		{version: "1.2.0", want: "1.4"},
+		{version: "v3.0", want: "2.1"},
+	}
+	for _, tt := range tests {
+		if got := nodeInfoFormatFor(tt.version).Name; got != tt.want {
+			t.Errorf("nodeInfoFormatFor(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// Default ports used by the master process
+const (
+	DefaultP2PPort        = 8336
+	DefaultGRPCPort       = 8337
+	DefaultRESTPort       = 8338
+	DefaultStreamPort     = 8340
+	DefaultBaseP2PPort    = 50000
+	DefaultBaseStreamPort = 60000
+)
+
+// PortUse represents a single port the node expects to bind
+type PortUse struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"` // "tcp" or "udp"
+	Owner    string `json:"owner"`    // e.g., "master p2p", "worker 3 stream"
+}
+
+// PortPlan represents every port the node will use for a given worker count
+type PortPlan struct {
+	WorkerCount    int       `json:"worker_count"`
+	BaseP2PPort    int       `json:"base_p2p_port"`
+	BaseStreamPort int       `json:"base_stream_port"`
+	Ports          []PortUse `json:"ports"`
+}
+
+// PortConflict represents a port collision found while checking a plan
+type PortConflict struct {
+	Kind     string   `json:"kind"` // "overlap", "range-overlap", "out-of-range" or "in-use"
+	Port     int      `json:"port,omitempty"`
+	Protocol string   `json:"protocol,omitempty"`
+	Owners   []string `json:"owners"`
+	Detail   string   `json:"detail"`
+}
+
+// Conflict kinds
+const (
+	ConflictOverlap      = "overlap"
+	ConflictRangeOverlap = "range-overlap"
+	ConflictOutOfRange   = "out-of-range"
+	ConflictInUse        = "in-use"
+)
+
+// PlanPorts computes every port the node will use from the qtools and node configs
+// A worker count of 0 falls back to the default worker count for this machine
+func PlanPorts(cfg *config.Config, nodeConfig *NodeConfig, workerCount int) *PortPlan {
+	return planPorts(cfg, nodeConfig, workerCount, 0, 0)
+}
+
+// planPorts computes the port plan, preferring explicit base ports when non-zero
+func planPorts(cfg *config.Config, nodeConfig *NodeConfig, workerCount, baseP2P, baseStream int) *PortPlan {
+	if workerCount <= 0 {
+		workerCount = GetWorkerCount(cfg)
+		if workerCount <= 0 {
+			workerCount = calculateDefaultWorkerCount()
+		}
+	}
+
+	baseP2P, baseStream = resolveWorkerBasePorts(cfg, nodeConfig, baseP2P, baseStream)
+
+	plan := &PortPlan{
+		WorkerCount:    workerCount,
+		BaseP2PPort:    baseP2P,
+		BaseStreamPort: baseStream,
+	}
+
+	// Master ports
+	p2pPort, p2pProto := DefaultP2PPort, "udp"
+	grpcPort := DefaultGRPCPort
+	restPort := DefaultRESTPort
+	streamPort := DefaultStreamPort
+	if nodeConfig != nil {
+		if nodeConfig.P2P != nil {
+			if _, port, proto, err := ParseMultiaddr(nodeConfig.P2P.ListenMultiaddr); err == nil {
+				p2pPort, p2pProto = port, proto
+			}
+			if _, port, _, err := ParseMultiaddr(nodeConfig.P2P.StreamListenMultiaddr); err == nil {
+				streamPort = port
+			}
+		}
+		if nodeConfig.GRPC != nil {
+			if _, port, _, err := ParseMultiaddr(nodeConfig.GRPC.ListenMultiaddr); err == nil {
+				grpcPort = port
+			}
+		}
+		if nodeConfig.REST != nil {
+			if _, port, _, err := ParseMultiaddr(nodeConfig.REST.ListenMultiaddr); err == nil {
+				restPort = port
+			}
+		}
+	}
+
+	plan.Ports = append(plan.Ports,
+		PortUse{Port: p2pPort, Protocol: p2pProto, Owner: "master p2p"},
+		PortUse{Port: grpcPort, Protocol: "tcp", Owner: "master grpc"},
+		PortUse{Port: restPort, Protocol: "tcp", Owner: "master rest"},
+		PortUse{Port: streamPort, Protocol: "tcp", Owner: "master stream"},
+	)
+
+	// Worker ports (matches SetupManualMode)
+	for i := 0; i < workerCount; i++ {
+		plan.Ports = append(plan.Ports,
+			PortUse{Port: baseP2P + i, Protocol: "tcp", Owner: fmt.Sprintf("worker %d p2p", i+1)},
+			PortUse{Port: baseStream + i, Protocol: "tcp", Owner: fmt.Sprintf("worker %d stream", i+1)},
+		)
+	}
+
+	return plan
+}
+
+// P2PRange returns the first and last worker P2P port
+func (p *PortPlan) P2PRange() (int, int) {
+	return p.BaseP2PPort, p.BaseP2PPort + p.WorkerCount - 1
+}
+
+// StreamRange returns the first and last worker stream port
+func (p *PortPlan) StreamRange() (int, int) {
+	return p.BaseStreamPort, p.BaseStreamPort + p.WorkerCount - 1
+}
+
+// Overlaps reports ports that are assigned more than once within the plan,
+// including overlaps between the worker P2P and stream ranges
+func (p *PortPlan) Overlaps() []PortConflict {
+	var conflicts []PortConflict
+
+	if p.WorkerCount > 0 {
+		p2pStart, p2pEnd := p.P2PRange()
+		streamStart, streamEnd := p.StreamRange()
+		if p2pStart <= streamEnd && streamStart <= p2pEnd {
+			conflicts = append(conflicts, PortConflict{
+				Kind:   ConflictRangeOverlap,
+				Owners: []string{"worker p2p", "worker stream"},
+				Detail: fmt.Sprintf("worker P2P range %d-%d overlaps worker stream range %d-%d",
+					p2pStart, p2pEnd, streamStart, streamEnd),
+			})
+		}
+		if p2pEnd > 65535 || streamEnd > 65535 {
+			conflicts = append(conflicts, PortConflict{
+				Kind:   ConflictOutOfRange,
+				Owners: []string{"worker p2p", "worker stream"},
+				Detail: fmt.Sprintf("worker port ranges exceed 65535 with %d workers", p.WorkerCount),
+			})
+		}
+	}
+
+	owners := make(map[string][]string)
+	var keys []string
+	for _, use := range p.Ports {
+		key := portKey(use.Protocol, use.Port)
+		if _, ok := owners[key]; !ok {
+			keys = append(keys, key)
+		}
+		owners[key] = append(owners[key], use.Owner)
+	}
+
+	for _, key := range keys {
+		if len(owners[key]) < 2 {
+			continue
+		}
+		proto, port := splitPortKey(key)
+		conflicts = append(conflicts, PortConflict{
+			Kind:     ConflictOverlap,
+			Port:     port,
+			Protocol: proto,
+			Owners:   owners[key],
+			Detail:   fmt.Sprintf("%s/%d is assigned to %s", proto, port, strings.Join(owners[key], ", ")),
+		})
+	}
+
+	return conflicts
+}
+
+// CheckLive reports planned ports that already have a bound socket
+// Sockets held by the node binary itself are skipped unless includeNode is true
+func (p *PortPlan) CheckLive(includeNode bool) ([]PortConflict, error) {
+	sockets, err := ListBoundSockets()
+	if err != nil {
+		return nil, err
+	}
+
+	bound := make(map[string]BoundSocket)
+	for _, s := range sockets {
+		bound[portKey(s.Protocol, s.Port)] = s
+	}
+
+	var conflicts []PortConflict
+	for _, use := range p.Ports {
+		s, ok := bound[portKey(use.Protocol, use.Port)]
+		if !ok {
+			continue
+		}
+		if !includeNode && s.IsNodeProcess() {
+			continue
+		}
+		holder := s.ProcessName
+		if holder == "" {
+			holder = "unknown process"
+		}
+		if s.PID > 0 {
+			holder = fmt.Sprintf("%s (pid %d)", holder, s.PID)
+		}
+		conflicts = append(conflicts, PortConflict{
+			Kind:     ConflictInUse,
+			Port:     use.Port,
+			Protocol: use.Protocol,
+			Owners:   []string{use.Owner},
+			Detail:   fmt.Sprintf("%s/%d planned for %s is already bound by %s", use.Protocol, use.Port, use.Owner, holder),
+		})
+	}
+
+	return conflicts, nil
+}
+
+// Check runs the overlap check and the live socket check
+func (p *PortPlan) Check(includeNode bool) ([]PortConflict, error) {
+	conflicts := p.Overlaps()
+	live, err := p.CheckLive(includeNode)
+	if err != nil {
+		return conflicts, err
+	}
+	conflicts = append(conflicts, live...)
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflictRank(conflicts[i].Kind) < conflictRank(conflicts[j].Kind)
+	})
+
+	return conflicts, nil
+}
+
+// CheckPortPlan builds the port plan for the current configs and checks it
+func CheckPortPlan(cfg *config.Config, workerCount int, includeNode bool) (*PortPlan, []PortConflict, error) {
+	mgr, err := NewNodeConfigManager("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create node config manager: %w", err)
+	}
+
+	nodeConfig, err := mgr.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load node config: %w", err)
+	}
+
+	plan := PlanPorts(cfg, nodeConfig, workerCount)
+	conflicts, err := plan.Check(includeNode)
+	return plan, conflicts, err
+}
+
+// CheckWorkerPorts checks that the port plan for workerCount workers has no overlaps and fits
+// below port 65535, before a new worker count is saved. A missing node config is planned with
+// the default ports; live sockets are not checked
+func CheckWorkerPorts(cfg *config.Config, workerCount int) error {
+	if workerCount <= 0 {
+		workerCount = calculateDefaultWorkerCount()
+	}
+
+	var nodeConfig *NodeConfig
+	if mgr, err := NewNodeConfigManager(""); err == nil {
+		nodeConfig, _ = mgr.Load()
+	}
+
+	conflicts := PlanPorts(cfg, nodeConfig, workerCount).Overlaps()
+	if len(conflicts) == 0 {
+		return nil
+	}
+	details := make([]string, len(conflicts))
+	for i, conflict := range conflicts {
+		details[i] = conflict.Detail
+	}
+	return fmt.Errorf("port plan for %d workers has %d conflict(s): %s", workerCount, len(conflicts), strings.Join(details, "; "))
+}
+
+// resolveWorkerBasePorts resolves the worker base ports
+// Order: explicit value, node config, qtools clustering config, default
+func resolveWorkerBasePorts(cfg *config.Config, nodeConfig *NodeConfig, baseP2P, baseStream int) (int, int) {
+	if baseP2P == 0 && nodeConfig != nil && nodeConfig.Engine != nil && nodeConfig.Engine.DataWorkerBaseP2PPort != nil {
+		baseP2P = *nodeConfig.Engine.DataWorkerBaseP2PPort
+	}
+	if baseStream == 0 && nodeConfig != nil && nodeConfig.Engine != nil && nodeConfig.Engine.DataWorkerBaseStreamPort != nil {
+		baseStream = *nodeConfig.Engine.DataWorkerBaseStreamPort
+	}
+
+	if cfg != nil && cfg.Service != nil && cfg.Service.Clustering != nil {
+		if baseP2P == 0 {
+			baseP2P = cfg.Service.Clustering.WorkerBaseP2PPort
+		}
+		if baseStream == 0 {
+			baseStream = cfg.Service.Clustering.WorkerBaseStreamPort
+		}
+	}
+
+	if baseP2P == 0 {
+		baseP2P = DefaultBaseP2PPort
+	}
+	if baseStream == 0 {
+		baseStream = DefaultBaseStreamPort
+	}
+
+	return baseP2P, baseStream
+}
+
+// conflictRank orders conflicts so configuration errors are listed first
+func conflictRank(kind string) int {
+	switch kind {
+	case ConflictRangeOverlap, ConflictOutOfRange:
+		return 0
+	case ConflictOverlap:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// portKey builds a map key for a protocol/port pair
+func portKey(proto string, port int) string {
+	return fmt.Sprintf("%s/%d", proto, port)
+}
+
+// splitPortKey splits a key built by portKey
+func splitPortKey(key string) (string, int) {
+	var port int
+	parts := strings.SplitN(key, "/", 2)
+	fmt.Sscanf(parts[1], "%d", &port)
+	return parts[0], port
+}
@@ -0,0 +1,215 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// intPtr returns a pointer to v
+func intPtr(v int) *int {
+	return &v
+}
+
+// conflictKinds returns the kinds of conflicts in order
+func conflictKinds(conflicts []PortConflict) []string {
+	var kinds []string
+	for _, conflict := range conflicts {
+		kinds = append(kinds, conflict.Kind)
+	}
+	return kinds
+}
+
+func TestPlanPorts(t *testing.T) {
+	cfg := config.GenerateDefaultConfig()
+	plan := PlanPorts(cfg, nil, 2)
+
+	want := []PortUse{
+		{Port: 8336, Protocol: "udp", Owner: "master p2p"},
+		{Port: 8337, Protocol: "tcp", Owner: "master grpc"},
+		{Port: 8338, Protocol: "tcp", Owner: "master rest"},
+		{Port: 8340, Protocol: "tcp", Owner: "master stream"},
+		{Port: 50000, Protocol: "tcp", Owner: "worker 1 p2p"},
+		{Port: 60000, Protocol: "tcp", Owner: "worker 1 stream"},
+		{Port: 50001, Protocol: "tcp", Owner: "worker 2 p2p"},
+		{Port: 60001, Protocol: "tcp", Owner: "worker 2 stream"},
+	}
+	if !reflect.DeepEqual(plan.Ports, want) {
+		t.Errorf("ports = %+v, want %+v", plan.Ports, want)
+	}
+	if start, end := plan.P2PRange(); start != 50000 || end != 50001 {
+		t.Errorf("P2P range = %d-%d", start, end)
+	}
+	if start, end := plan.StreamRange(); start != 60000 || end != 60001 {
+		t.Errorf("stream range = %d-%d", start, end)
+	}
+	if conflicts := plan.Overlaps(); len(conflicts) != 0 {
+		t.Errorf("default plan has conflicts: %+v", conflicts)
+	}
+}
+
+func TestPlanPortsFromNodeConfig(t *testing.T) {
+	cfg := config.GenerateDefaultConfig()
+	cfg.Manual.WorkerCount = 3
+	cfg.Service.Clustering.WorkerBaseP2PPort = 40000
+	cfg.Service.Clustering.WorkerBaseStreamPort = 45000
+
+	// The node config wins over the clustering base ports; unparsable multiaddrs keep the defaults
+	nodeConfig := &NodeConfig{
+		P2P: &P2PConfig{
+			ListenMultiaddr:       "/ip4/0.0.0.0/tcp/9336",
+			StreamListenMultiaddr: "/ip4/0.0.0.0/tcp/9340",
+		},
+		GRPC:   &GRPCConfig{ListenMultiaddr: "/ip4/127.0.0.1/tcp/9337"},
+		REST:   &RESTConfig{ListenMultiaddr: "not a multiaddr"},
+		Engine: &EngineConfig{DataWorkerBaseP2PPort: intPtr(51000)},
+	}
+
+	// A worker count of 0 uses manual.worker_count
+	plan := PlanPorts(cfg, nodeConfig, 0)
+	if plan.WorkerCount != 3 || plan.BaseP2PPort != 51000 || plan.BaseStreamPort != 45000 {
+		t.Fatalf("plan = %d workers, bases %d/%d", plan.WorkerCount, plan.BaseP2PPort, plan.BaseStreamPort)
+	}
+	wantMaster := []PortUse{
+		{Port: 9336, Protocol: "tcp", Owner: "master p2p"},
+		{Port: 9337, Protocol: "tcp", Owner: "master grpc"},
+		{Port: 8338, Protocol: "tcp", Owner: "master rest"},
+		{Port: 9340, Protocol: "tcp", Owner: "master stream"},
+	}
+	if !reflect.DeepEqual(plan.Ports[:4], wantMaster) {
+		t.Errorf("master ports = %+v, want %+v", plan.Ports[:4], wantMaster)
+	}
+	if len(plan.Ports) != 4+2*3 {
+		t.Errorf("planned %d ports, want %d", len(plan.Ports), 4+2*3)
+	}
+}
+
+func TestPortPlanOverlaps(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodeConfig *NodeConfig
+		workers    int
+		wantKinds  []string
+		wantDetail string
+	}{
+		{
+			name:       "ranges overlap",
+			nodeConfig: &NodeConfig{Engine: &EngineConfig{DataWorkerBaseP2PPort: intPtr(50000), DataWorkerBaseStreamPort: intPtr(50002)}},
+			workers:    4,
+			// Workers 3 and 4 share their p2p ports with the stream ports of workers 1 and 2
+			wantKinds:  []string{ConflictRangeOverlap, ConflictOverlap, ConflictOverlap},
+			wantDetail: "worker P2P range 50000-50003 overlaps worker stream range 50002-50005",
+		},
+		{
+			name:       "out of range",
+			nodeConfig: &NodeConfig{Engine: &EngineConfig{DataWorkerBaseP2PPort: intPtr(65500)}},
+			workers:    64,
+			wantKinds:  []string{ConflictOutOfRange},
+			wantDetail: "worker port ranges exceed 65535 with 64 workers",
+		},
+		{
+			name:       "master port",
+			nodeConfig: &NodeConfig{GRPC: &GRPCConfig{ListenMultiaddr: "/ip4/127.0.0.1/tcp/50001"}},
+			workers:    2,
+			wantKinds:  []string{ConflictOverlap},
+			wantDetail: "tcp/50001 is assigned to master grpc, worker 2 p2p",
+		},
+		{
+			// The master p2p port is udp, so it does not collide with a tcp worker port
+			name:       "different protocol",
+			nodeConfig: &NodeConfig{P2P: &P2PConfig{ListenMultiaddr: "/ip4/0.0.0.0/udp/50000/quic-v1"}},
+			workers:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		conflicts := PlanPorts(config.GenerateDefaultConfig(), tt.nodeConfig, tt.workers).Overlaps()
+		if got := conflictKinds(conflicts); !reflect.DeepEqual(got, tt.wantKinds) {
+			t.Errorf("%s: conflict kinds = %q, want %q (%+v)", tt.name, got, tt.wantKinds, conflicts)
+			continue
+		}
+		if tt.wantDetail != "" && conflicts[0].Detail != tt.wantDetail {
+			t.Errorf("%s: detail = %q, want %q", tt.name, conflicts[0].Detail, tt.wantDetail)
+		}
+	}
+}
+
+// writeQuilConfig points QUIL_CONFIG_FILE at a node config with the given content
+func writeQuilConfig(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("QUIL_CONFIG_FILE", path)
+}
+
+func TestCheckWorkerPorts(t *testing.T) {
+	writeQuilConfig(t, "engine:\n  dataWorkerBaseP2PPort: 65500\n")
+	cfg := config.GenerateDefaultConfig()
+
+	if err := CheckWorkerPorts(cfg, 8); err != nil {
+		t.Errorf("8 workers: %v", err)
+	}
+	err := CheckWorkerPorts(cfg, 64)
+	if err == nil || !strings.Contains(err.Error(), "exceed 65535") {
+		t.Errorf("64 workers: err = %v, want the ports to exceed 65535", err)
+	}
+}
+
+func TestSetWorkerCountChecksPorts(t *testing.T) {
+	writeQuilConfig(t, "engine:\n  dataWorkerBaseP2PPort: 65500\n")
+	configPath := filepath.Join(t.TempDir(), "qtools.yml")
+	t.Setenv("QTOOLS_CONFIG_FILE", configPath)
+	cfg := config.GenerateDefaultConfig()
+	opts := ConfigCommandOptions{Quiet: true}
+
+	if err := ExecuteConfigCommand(ConfigCommandSet, "manual.worker_count", "64", opts, cfg); err == nil {
+		t.Fatal("setting 64 workers succeeded, want a port conflict")
+	}
+	if cfg.Manual.WorkerCount != 0 {
+		t.Errorf("worker count = %d after a rejected set", cfg.Manual.WorkerCount)
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Errorf("config was saved after a rejected set (%v)", err)
+	}
+
+	if err := ExecuteConfigCommand(ConfigCommandSet, "manual.worker_count", "8", opts, cfg); err != nil {
+		t.Fatalf("setting 8 workers failed: %v", err)
+	}
+	saved, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Manual.WorkerCount != 8 || saved.Manual.WorkerCount != 8 {
+		t.Errorf("worker count = %d, saved %d, want 8", cfg.Manual.WorkerCount, saved.Manual.WorkerCount)
+	}
+}
+
+func TestToggleModeChecksPorts(t *testing.T) {
+	writeQuilConfig(t, "engine:\n  dataWorkerBaseP2PPort: 65535\n")
+	cfg := config.GenerateDefaultConfig()
+	cfg.Manual.Enabled = false
+
+	// Two or more workers no longer fit below 65535; one worker always does
+	if calculateDefaultWorkerCount() > 1 {
+		if err := ToggleMode(cfg); err == nil {
+			t.Fatal("ToggleMode succeeded, want a port conflict")
+		}
+		if cfg.Manual.Enabled || cfg.Manual.WorkerCount != 0 {
+			t.Errorf("manual = %+v after a rejected toggle", cfg.Manual)
+		}
+	}
+
+	writeQuilConfig(t, "")
+	if err := ToggleMode(cfg); err != nil {
+		t.Fatalf("ToggleMode failed: %v", err)
+	}
+	if !cfg.Manual.Enabled || cfg.Manual.WorkerCount != calculateDefaultWorkerCount() {
+		t.Errorf("manual = %+v, want enabled with the default worker count", cfg.Manual)
+	}
+}
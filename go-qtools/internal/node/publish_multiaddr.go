@@ -0,0 +1,159 @@
+package node
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// DirectPeerPublishOptions represents options for publishing this node's multiaddr
+type DirectPeerPublishOptions struct {
+	Target string // Local path, ssh://user@host/path or user@host:path (default from settings)
+	IP     string // Address other nodes reach this node on
+	PeerID string
+	DryRun bool
+}
+
+// DirectPeerPublishResult represents the outcome of publishing this node's multiaddr
+type DirectPeerPublishResult struct {
+	Multiaddr string   `json:"multiaddr"`
+	Added     bool     `json:"added"`
+	Removed   []string `json:"removed"` // Older entries for this peer ID or IP
+	Peers     []string `json:"peers"`
+}
+
+// Changed returns true if the published peer list was changed
+func (r *DirectPeerPublishResult) Changed() bool {
+	return r.Added || len(r.Removed) > 0
+}
+
+// BuildPublishMultiaddr builds the multiaddr other nodes dial this node on, using the
+// protocol and port of p2p.listenMultiaddr
+func BuildPublishMultiaddr(configPath, ip, peerID string) (string, error) {
+	port, proto := DefaultP2PPort, "udp"
+	if mgr, err := NewNodeConfigManager(configPath); err == nil {
+		if nodeConfig, err := mgr.Load(); err == nil && nodeConfig.P2P != nil && nodeConfig.P2P.ListenMultiaddr != "" {
+			if _, p, pr, err := ParseMultiaddr(nodeConfig.P2P.ListenMultiaddr); err == nil {
+				port, proto = p, pr
+			}
+		}
+	}
+
+	multiaddr := BuildDirectPeerMultiaddr(ip, port, proto, peerID)
+	if err := ValidateDirectPeerMultiaddr(multiaddr); err != nil {
+		return "", err
+	}
+	return multiaddr, nil
+}
+
+// PublishDirectPeer adds this node's multiaddr to the shared peer list that other nodes
+// sync their direct peers from, replacing older entries for the same peer ID or IP
+// The list is created when missing and its other keys are kept
+func PublishDirectPeer(configPath string, opts DirectPeerPublishOptions, cfg *config.Config) (*DirectPeerPublishResult, error) {
+	target := opts.Target
+	if target == "" {
+		target = defaultPeerListSource(cfg)
+		if target == "" {
+			return nil, fmt.Errorf("no peer list target given and settings.central_server is not configured")
+		}
+	}
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return nil, fmt.Errorf("cannot publish to %s: use a local path or an SSH location", target)
+	}
+
+	multiaddr, err := BuildPublishMultiaddr(configPath, opts.IP, opts.PeerID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readPeerListTarget(target, cfg)
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]interface{})
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse peer list %s: %w", target, err)
+		}
+	}
+	var peers []string
+	if list, ok := doc["directPeers"].([]interface{}); ok {
+		for _, entry := range list {
+			if peer, ok := entry.(string); ok {
+				peers = append(peers, peer)
+			}
+		}
+	}
+
+	result := &DirectPeerPublishResult{Multiaddr: multiaddr, Added: true}
+	for _, peer := range peers {
+		if peer == multiaddr {
+			result.Added = false
+		} else if ip, _, _, err := ParseMultiaddr(peer); PeerIDFromMultiaddr(peer) == opts.PeerID || (err == nil && ip == opts.IP) {
+			result.Removed = append(result.Removed, peer)
+			continue
+		}
+		result.Peers = append(result.Peers, peer)
+	}
+	if result.Added {
+		result.Peers = append(result.Peers, multiaddr)
+	}
+
+	if opts.DryRun || !result.Changed() {
+		return result, nil
+	}
+
+	doc["directPeers"] = result.Peers
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal peer list: %w", err)
+	}
+	if err := writePeerListTarget(target, out, cfg); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readPeerListTarget reads a local or SSH peer list, returning nothing when it does not exist
+func readPeerListTarget(target string, cfg *config.Config) ([]byte, error) {
+	if !strings.HasPrefix(target, "ssh://") && !isSCPStyleSource(target) {
+		data, err := os.ReadFile(expandHome(target))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read peer list: %w", err)
+		}
+		return data, nil
+	}
+
+	host, port, path := parseSSHSource(target)
+	if host == "" || path == "" {
+		return nil, fmt.Errorf("invalid SSH location: %s", target)
+	}
+	quoted := remoteShellPath(path)
+	output, err := sshCommand(host, port, cfg, fmt.Sprintf("if [ -f %s ]; then cat %s; fi", quoted, quoted)).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peer list from %s: %w", host, err)
+	}
+	return output, nil
+}
+
+// writePeerListTarget writes a local or SSH peer list
+func writePeerListTarget(target string, data []byte, cfg *config.Config) error {
+	if !strings.HasPrefix(target, "ssh://") && !isSCPStyleSource(target) {
+		if err := os.WriteFile(expandHome(target), data, 0644); err != nil {
+			return fmt.Errorf("failed to write peer list: %w", err)
+		}
+		return nil
+	}
+
+	host, port, path := parseSSHSource(target)
+	cmd := sshCommand(host, port, cfg, "cat > "+remoteShellPath(path))
+	cmd.Stdin = bytes.NewReader(data)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to publish peer list to %s: %w: %s", host, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
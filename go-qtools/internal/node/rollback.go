@@ -0,0 +1,93 @@
+package node
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// skipVersionPath is the qtools config key holding a node version auto-updates skip
+const skipVersionPath = "scheduled_tasks.updates.node.skip_version"
+
+// Rollback describes switching the node binary back to an older version
+type Rollback struct {
+	FromVersion string `json:"from_version"`
+	ToVersion   string `json:"to_version"`
+	Binary      string `json:"binary"`
+	Link        string `json:"link"`
+}
+
+// nodeSymlinkPath returns the symlink the services run the node through
+func nodeSymlinkPath(cfg *config.Config) string {
+	if cfg != nil && cfg.Service != nil && cfg.Service.LinkName != "" {
+		return cfg.Service.LinkName
+	}
+	return "/usr/local/bin/node"
+}
+
+// PreviousNodeBinary finds the newest node binary in the node directory older than the current version
+// Old binaries are only kept when updates run with --skip-clean
+func PreviousNodeBinary(cfg *config.Config) (*Rollback, error) {
+	current, err := GetCurrentNodeVersion(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	nodePath := config.GetNodePath()
+	files, err := os.ReadDir(nodePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", nodePath, err)
+	}
+
+	re := regexp.MustCompile(`^node-([0-9]+(?:\.[0-9]+)+)-` + regexp.QuoteMeta(getOSArch()) + `$`)
+	rollback := &Rollback{FromVersion: current, Link: nodeSymlinkPath(cfg)}
+	for _, file := range files {
+		matches := re.FindStringSubmatch(file.Name())
+		if file.IsDir() || matches == nil {
+			continue
+		}
+		version := matches[1]
+		if compareVersions(version, current) >= 0 {
+			continue
+		}
+		if rollback.ToVersion == "" || compareVersions(version, rollback.ToVersion) > 0 {
+			rollback.ToVersion = version
+			rollback.Binary = filepath.Join(nodePath, file.Name())
+		}
+	}
+
+	if rollback.Binary == "" {
+		return nil, fmt.Errorf("no node binary older than %s in %s", current, nodePath)
+	}
+	return rollback, nil
+}
+
+// RollbackNode points the node symlink at the previous binary and records the version
+// The version rolled back from is set as scheduled_tasks.updates.node.skip_version so auto-updates
+// do not reinstall it; the caller saves the config and restarts the services
+// privileged builds the ln command, e.g. with sudo when the node is installed system-wide
+func RollbackNode(cfg *config.Config, privileged func(name string, args ...string) *exec.Cmd) (*Rollback, error) {
+	rollback, err := PreviousNodeBinary(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if output, err := privileged("ln", "-sfn", rollback.Binary, rollback.Link).CombinedOutput(); err != nil {
+		return rollback, fmt.Errorf("failed to link %s to %s: %w\nOutput: %s", rollback.Link, rollback.Binary, err, string(output))
+	}
+
+	if err := SetCurrentNodeVersion(rollback.ToVersion, cfg); err != nil {
+		return rollback, err
+	}
+	if err := config.SetConfigValue(cfg, "current_node_version", rollback.ToVersion); err != nil {
+		return rollback, err
+	}
+	if err := config.SetConfigValue(cfg, skipVersionPath, rollback.FromVersion); err != nil {
+		return rollback, err
+	}
+	return rollback, nil
+}
@@ -0,0 +1,53 @@
+package node
+
+import (
+	"os/exec"
+	"sync"
+)
+
+// CommandRunner runs external commands (the node binary)
+// Tests swap it for a fake node with SetCommandRunner
+type CommandRunner interface {
+	// Output runs the command and returns its stdout; failures return *exec.ExitError carrying stderr
+	Output(name string, args ...string) ([]byte, error)
+	// CombinedOutput runs the command and returns stdout and stderr together
+	CombinedOutput(name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs commands with os/exec
+type ExecRunner struct{}
+
+// Output implements CommandRunner
+func (ExecRunner) Output(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// CombinedOutput implements CommandRunner
+func (ExecRunner) CombinedOutput(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+var (
+	runnerMu      sync.RWMutex
+	commandRunner CommandRunner = ExecRunner{}
+)
+
+// GetCommandRunner returns the runner used for node commands
+func GetCommandRunner() CommandRunner {
+	runnerMu.RLock()
+	defer runnerMu.RUnlock()
+	return commandRunner
+}
+
+// SetCommandRunner replaces the runner used for node commands and returns the previous one
+// Passing nil restores ExecRunner
+func SetCommandRunner(runner CommandRunner) CommandRunner {
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	runnerMu.Lock()
+	defer runnerMu.Unlock()
+	previous := commandRunner
+	commandRunner = runner
+	return previous
+}
@@ -82,6 +82,24 @@ func SetupManualMode(cfg *config.Config, workerCount int, opts SetupOptions) err
 		}
 	}
 
+	// Check the port plan before touching the node config
+	nodeConfig, err := mgr.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load node config: %w", err)
+	}
+	plan := planPorts(cfg, nodeConfig, workerCount, baseP2P, baseStream)
+	if overlaps := plan.Overlaps(); len(overlaps) > 0 {
+		for _, conflict := range overlaps {
+			fmt.Printf("Port conflict: %s\n", conflict.Detail)
+		}
+		return fmt.Errorf("port plan for %d workers has %d conflict(s)", workerCount, len(overlaps))
+	}
+	if live, err := plan.CheckLive(false); err == nil {
+		for _, conflict := range live {
+			fmt.Printf("Warning: %s\n", conflict.Detail)
+		}
+	}
+
 	// Set base ports in node config
 	if err := mgr.SetValue("engine.dataWorkerBaseP2PPort", baseP2P); err != nil {
 		return fmt.Errorf("failed to set base P2P port: %w", err)
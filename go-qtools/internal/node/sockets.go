@@ -0,0 +1,188 @@
+package node
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procNetFiles maps /proc/net socket tables to their protocol
+var procNetFiles = map[string]string{
+	"/proc/net/tcp":  "tcp",
+	"/proc/net/tcp6": "tcp",
+	"/proc/net/udp":  "udp",
+	"/proc/net/udp6": "udp",
+}
+
+// Socket states from include/net/tcp_states.h
+const (
+	tcpStateListen = "0A"
+	udpStateUnconn = "07"
+	udpStateEstabl = "01"
+)
+
+// BoundSocket represents a listening or bound socket found in /proc/net
+type BoundSocket struct {
+	Protocol    string `json:"protocol"`
+	Address     string `json:"address"`
+	Port        int    `json:"port"`
+	Inode       string `json:"inode"`
+	PID         int    `json:"pid,omitempty"`
+	ProcessName string `json:"process,omitempty"`
+}
+
+// IsNodeProcess checks if the socket is held by the node binary
+func (s BoundSocket) IsNodeProcess() bool {
+	return s.ProcessName == "node" || strings.HasPrefix(s.ProcessName, "node-")
+}
+
+// ListBoundSockets lists TCP listening sockets and bound UDP sockets
+// Owning processes are resolved on a best-effort basis (requires permission to read /proc/<pid>/fd)
+func ListBoundSockets() ([]BoundSocket, error) {
+	var sockets []BoundSocket
+	found := false
+
+	for path, proto := range procNetFiles {
+		file, err := os.Open(path)
+		if err != nil {
+			continue // tcp6/udp6 may be missing if IPv6 is disabled
+		}
+		found = true
+
+		parsed, err := parseProcNet(file, proto)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		sockets = append(sockets, parsed...)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no socket tables found in /proc/net")
+	}
+
+	owners := socketOwners()
+	for i := range sockets {
+		if owner, ok := owners[sockets[i].Inode]; ok {
+			sockets[i].PID = owner.pid
+			sockets[i].ProcessName = owner.name
+		}
+	}
+
+	return sockets, nil
+}
+
+// parseProcNet parses a /proc/net/{tcp,udp}[6] table
+func parseProcNet(r io.Reader, proto string) ([]BoundSocket, error) {
+	var sockets []BoundSocket
+	scanner := bufio.NewScanner(r)
+
+	// Skip header
+	scanner.Scan()
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		state := fields[3]
+		if proto == "tcp" && state != tcpStateListen {
+			continue
+		}
+		if proto == "udp" && state != udpStateUnconn && state != udpStateEstabl {
+			continue
+		}
+
+		addr, port, err := parseHexAddress(fields[1])
+		if err != nil {
+			continue
+		}
+
+		sockets = append(sockets, BoundSocket{
+			Protocol: proto,
+			Address:  addr,
+			Port:     port,
+			Inode:    fields[9],
+		})
+	}
+
+	return sockets, scanner.Err()
+}
+
+// parseHexAddress parses an address of the form "0100007F:1F90"
+func parseHexAddress(s string) (string, int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid address: %s", s)
+	}
+
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port: %s", parts[1])
+	}
+
+	// Addresses are stored as little-endian 32-bit words
+	hexIP := parts[0]
+	var octets []string
+	if len(hexIP) == 8 {
+		for i := 6; i >= 0; i -= 2 {
+			b, err := strconv.ParseUint(hexIP[i:i+2], 16, 8)
+			if err != nil {
+				return "", 0, err
+			}
+			octets = append(octets, strconv.FormatUint(b, 10))
+		}
+		return strings.Join(octets, "."), int(port), nil
+	}
+
+	return hexIP, int(port), nil
+}
+
+type socketOwner struct {
+	pid  int
+	name string
+}
+
+// socketOwners maps socket inodes to the process holding them
+func socketOwners() map[string]socketOwner {
+	owners := make(map[string]socketOwner)
+
+	procDirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return owners
+	}
+
+	for _, dir := range procDirs {
+		pid, err := strconv.Atoi(dir.Name())
+		if err != nil {
+			continue
+		}
+
+		fdDir := filepath.Join("/proc", dir.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // Permission denied for other users' processes
+		}
+
+		name := ""
+		if comm, err := os.ReadFile(filepath.Join("/proc", dir.Name(), "comm")); err == nil {
+			name = strings.TrimSpace(string(comm))
+		}
+
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")
+			owners[inode] = socketOwner{pid: pid, name: name}
+		}
+	}
+
+	return owners
+}
@@ -0,0 +1,102 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseProcNet(t *testing.T) {
+	tests := []struct {
+		file  string
+		proto string
+		want  []BoundSocket
+	}{
+		{
+			// Only listening sockets; TIME_WAIT and established connections are skipped
+			file:  "tcp",
+			proto: "tcp",
+			want: []BoundSocket{
+				{Protocol: "tcp", Address: "0.0.0.0", Port: 8337, Inode: "1001"},
+				{Protocol: "tcp", Address: "127.0.0.1", Port: 8338, Inode: "1002"},
+				{Protocol: "tcp", Address: "192.168.1.1", Port: 50000, Inode: "1003"},
+			},
+		},
+		{
+			// IPv6 addresses are kept in their hex form
+			file:  "tcp6",
+			proto: "tcp",
+			want: []BoundSocket{
+				{Protocol: "tcp", Address: "00000000000000000000000000000000", Port: 60000, Inode: "2001"},
+			},
+		},
+		{
+			// Unconnected and connected UDP sockets both hold their port; the malformed line is skipped
+			file:  "udp",
+			proto: "udp",
+			want: []BoundSocket{
+				{Protocol: "udp", Address: "0.0.0.0", Port: 8336, Inode: "3001"},
+				{Protocol: "udp", Address: "127.0.0.53", Port: 53, Inode: "3002"},
+				{Protocol: "udp", Address: "127.0.0.1", Port: 57763, Inode: "3003"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		file, err := os.Open(filepath.Join("testdata", "proc-net", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseProcNet(file, tt.proto)
+		file.Close()
+		if err != nil {
+			t.Fatalf("%s: parseProcNet failed: %v", tt.file, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: sockets = %+v, want %+v", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestParseHexAddress(t *testing.T) {
+	tests := []struct {
+		in       string
+		wantAddr string
+		wantPort int
+		wantErr  bool
+	}{
+		{in: "0100007F:1F90", wantAddr: "127.0.0.1", wantPort: 8080},
+		{in: "00000000:2090", wantAddr: "0.0.0.0", wantPort: 8336},
+		{in: "0100007F", wantErr: true},
+		{in: "0100007F:XYZ", wantErr: true},
+		{in: "0100007F:10000", wantErr: true}, // Port above 65535
+	}
+
+	for _, tt := range tests {
+		addr, port, err := parseHexAddress(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseHexAddress(%q) = %s:%d, want error", tt.in, addr, port)
+			}
+			continue
+		}
+		if err != nil || addr != tt.wantAddr || port != tt.wantPort {
+			t.Errorf("parseHexAddress(%q) = %s:%d (%v), want %s:%d", tt.in, addr, port, err, tt.wantAddr, tt.wantPort)
+		}
+	}
+}
+
+func TestBoundSocketIsNodeProcess(t *testing.T) {
+	for name, want := range map[string]bool{
+		"node":                      true,
+		"node-2.1.0.19-linux-amd64": true,
+		"qtools":                    false,
+		"nodejs":                    false,
+		"":                          false,
+	} {
+		if got := (BoundSocket{ProcessName: name}).IsNodeProcess(); got != want {
+			t.Errorf("IsNodeProcess(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
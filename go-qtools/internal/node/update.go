@@ -25,11 +25,10 @@ type UpdateOptions struct {
 func UpdateNode(opts UpdateOptions, cfg *config.Config) error {
 	// Check auto-update setting
 	if opts.Auto {
-		if cfg.ScheduledTasks == nil || cfg.ScheduledTasks.Updates == nil {
+		tasks := cfg.ScheduledTasks
+		if tasks == nil || tasks.Updates == nil || tasks.Updates.Node == nil || !tasks.Updates.Node.Enabled {
 			return fmt.Errorf("auto-update is disabled")
 		}
-		// Check if node auto-update is enabled
-		// This would need to be checked from config
 	}
 
 	// Get current version
@@ -273,13 +272,10 @@ func cleanOldNodeFiles(currentVersion string, cfg *config.Config) error {
 
 // getSkipVersion gets the skip version from config
 func getSkipVersion(cfg *config.Config) string {
-	if cfg == nil || cfg.ScheduledTasks == nil || cfg.ScheduledTasks.Updates == nil {
+	if cfg == nil || cfg.ScheduledTasks == nil || cfg.ScheduledTasks.Updates == nil || cfg.ScheduledTasks.Updates.Node == nil {
 		return ""
 	}
-
-	// This would need to be extracted from the nested map structure
-	// For now, return empty
-	return ""
+	return cfg.ScheduledTasks.Updates.Node.SkipVersion
 }
 
 // getOSArch gets the OS architecture string
@@ -0,0 +1,187 @@
+package publicip
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+	"github.com/tjsturos/qtools/go-qtools/internal/service"
+)
+
+// previousIPPath is the qtools config key holding the last seen public IP
+const previousIPPath = "scheduled_tasks.public_ip.previous_ip"
+
+// MonitorOptions represents options for a public IP check
+type MonitorOptions struct {
+	Providers      []Provider
+	Quorum         int
+	Timeout        time.Duration
+	RestartMaster  bool
+	DryRun         bool
+	ConfigPath     string // qtools config path, used to persist previous_ip
+	NodeConfigPath string // empty uses the default node config path
+	OnChange       func(ChangeEvent)
+}
+
+// ChangeEvent represents a public IP change detected by the monitor
+type ChangeEvent struct {
+	Time       time.Time                `json:"time"`
+	PreviousIP string                   `json:"previous_ip"`
+	CurrentIP  string                   `json:"current_ip"`
+	Announce   *node.AnnounceMultiaddrs `json:"announce,omitempty"`
+	Restarted  bool                     `json:"restarted"`
+	DryRun     bool                     `json:"dry_run,omitempty"`
+}
+
+// CheckResult represents the outcome of a single monitor check
+type CheckResult struct {
+	Lookup     *Result      `json:"lookup"`
+	PreviousIP string       `json:"previous_ip"`
+	FirstRun   bool         `json:"first_run"`
+	Changed    bool         `json:"changed"`
+	Event      *ChangeEvent `json:"event,omitempty"`
+}
+
+// LoadMonitorOptionsFromConfig loads monitor options from scheduled_tasks.public_ip
+func LoadMonitorOptionsFromConfig(cfg *config.Config) (*MonitorOptions, error) {
+	opts := &MonitorOptions{}
+
+	var task config.PublicIPTaskConfig
+	if cfg.ScheduledTasks != nil && cfg.ScheduledTasks.PublicIP != nil {
+		task = *cfg.ScheduledTasks.PublicIP
+	}
+
+	specs := DefaultProviders
+	if len(task.Providers) > 0 {
+		specs = task.Providers
+	}
+
+	providers, err := ParseProviders(specs)
+	if err != nil {
+		return nil, err
+	}
+	opts.Providers = providers
+
+	opts.Quorum = task.Quorum
+	opts.RestartMaster = task.RestartMaster
+
+	return opts, nil
+}
+
+// Check resolves the public IP and, when it changed since the last check, rewrites
+// the announce multiaddrs, records previous_ip and optionally restarts the master
+// The first check only records the current address (matches monitor-public-ip.sh)
+func Check(ctx context.Context, cfg *config.Config, opts *MonitorOptions) (*CheckResult, error) {
+	lookup, err := Resolve(ctx, opts.Providers, opts.Quorum, opts.Timeout)
+	if err != nil {
+		return &CheckResult{Lookup: lookup}, err
+	}
+
+	result := &CheckResult{Lookup: lookup}
+	if val, err := config.GetConfigValue(cfg, previousIPPath); err == nil {
+		result.PreviousIP, _ = val.(string)
+	}
+
+	if result.PreviousIP == "" {
+		result.FirstRun = true
+		if opts.DryRun {
+			return result, nil
+		}
+		return result, savePreviousIP(cfg, opts.ConfigPath, lookup.IP)
+	}
+
+	if result.PreviousIP == lookup.IP {
+		return result, nil
+	}
+
+	result.Changed = true
+	event := ChangeEvent{
+		Time:       time.Now(),
+		PreviousIP: result.PreviousIP,
+		CurrentIP:  lookup.IP,
+		DryRun:     opts.DryRun,
+	}
+	result.Event = &event
+
+	if opts.DryRun {
+		nodeConfig, err := loadNodeConfig(opts.NodeConfigPath)
+		if err != nil {
+			return result, err
+		}
+		if event.Announce, err = node.BuildAnnounceMultiaddrs(nodeConfig, cfg, lookup.IP, 0); err != nil {
+			return result, err
+		}
+		notify(opts, event)
+		return result, nil
+	}
+
+	if event.Announce, err = node.SetAnnounceMultiaddrs(opts.NodeConfigPath, cfg, lookup.IP, 0); err != nil {
+		return result, fmt.Errorf("failed to update announce multiaddrs: %w", err)
+	}
+
+	if err := savePreviousIP(cfg, opts.ConfigPath, lookup.IP); err != nil {
+		return result, err
+	}
+
+	if opts.RestartMaster {
+		if err := service.RestartService(service.RestartOptions{MasterOnly: true}, cfg); err != nil {
+			notify(opts, event)
+			return result, fmt.Errorf("failed to restart master: %w", err)
+		}
+		event.Restarted = true
+	}
+
+	notify(opts, event)
+	return result, nil
+}
+
+// Watch runs Check on an interval until the context is cancelled
+// Check errors are passed to onError and do not stop the loop
+func Watch(ctx context.Context, cfg *config.Config, opts *MonitorOptions, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := Check(ctx, cfg, opts); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// notify delivers a change event to the OnChange hook
+func notify(opts *MonitorOptions, event ChangeEvent) {
+	if opts.OnChange != nil {
+		opts.OnChange(event)
+	}
+}
+
+// savePreviousIP records the public IP in the qtools config
+func savePreviousIP(cfg *config.Config, configPath, ip string) error {
+	if err := config.SetConfigValue(cfg, previousIPPath, ip); err != nil {
+		return fmt.Errorf("failed to set previous_ip: %w", err)
+	}
+	if configPath == "" {
+		configPath = config.GetConfigPath()
+	}
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+// loadNodeConfig loads the node config from the given path
+func loadNodeConfig(path string) (*node.NodeConfig, error) {
+	mgr, err := node.NewNodeConfigManager(path)
+	if err != nil {
+		return nil, err
+	}
+	return mgr.Load()
+}
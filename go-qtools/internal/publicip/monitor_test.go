@@ -0,0 +1,249 @@
+package publicip
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+	"github.com/tjsturos/qtools/go-qtools/internal/service"
+)
+
+const monitorNodeConfig = `p2p:
+  listenMultiaddr: /ip4/0.0.0.0/udp/8336/quic-v1
+  streamListenMultiaddr: /ip4/0.0.0.0/tcp/8340
+  announceListenMultiaddr: /ip4/198.51.100.1/udp/8336/quic-v1
+  announceMultiaddrs:
+    - /ip4/198.51.100.1/tcp/8336
+engine:
+  dataWorkerP2PMultiaddrs:
+    - /ip4/0.0.0.0/tcp/50000
+    - /ip4/0.0.0.0/tcp/50001
+`
+
+// restartBackend records the services restarted through it
+type restartBackend struct {
+	service.ServiceBackend
+	restarted []string
+	err       error
+}
+
+func (b *restartBackend) RestartService(name string) error {
+	b.restarted = append(b.restarted, name)
+	return b.err
+}
+
+// announceConfig is the part of the node config Check rewrites
+type announceConfig struct {
+	P2P struct {
+		AnnounceListenMultiaddr       string   `yaml:"announceListenMultiaddr"`
+		AnnounceStreamListenMultiaddr string   `yaml:"announceStreamListenMultiaddr"`
+		AnnounceMultiaddrs            []string `yaml:"announceMultiaddrs"`
+	} `yaml:"p2p"`
+	Engine struct {
+		DataWorkerAnnounceP2PMultiaddrs    []string `yaml:"dataWorkerAnnounceP2PMultiaddrs"`
+		DataWorkerAnnounceStreamMultiaddrs []string `yaml:"dataWorkerAnnounceStreamMultiaddrs"`
+	} `yaml:"engine"`
+}
+
+// monitorTest holds the files and backend of a Check test
+type monitorTest struct {
+	cfg     *config.Config
+	opts    *MonitorOptions
+	backend *restartBackend
+	events  []ChangeEvent
+}
+
+// newMonitorTest sets up a node config, a qtools config with previous_ip and a static provider for ip
+func newMonitorTest(t *testing.T, previousIP, ip string) *monitorTest {
+	t.Helper()
+	dir := t.TempDir()
+	nodeConfigPath := filepath.Join(dir, "node.yml")
+	if err := os.WriteFile(nodeConfigPath, []byte(monitorNodeConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mt := &monitorTest{cfg: config.GenerateDefaultConfig(), backend: &restartBackend{}}
+	if err := config.SetConfigValue(mt.cfg, previousIPPath, previousIP); err != nil {
+		t.Fatal(err)
+	}
+	previous := service.SetServiceBackend(mt.backend)
+	t.Cleanup(func() { service.SetServiceBackend(previous) })
+
+	mt.opts = &MonitorOptions{
+		Providers:      providersFor(t, "static:"+ip),
+		Timeout:        testTimeout,
+		ConfigPath:     filepath.Join(dir, "config.yml"),
+		NodeConfigPath: nodeConfigPath,
+		OnChange:       func(event ChangeEvent) { mt.events = append(mt.events, event) },
+	}
+	return mt
+}
+
+// readAnnounce reads the announce multiaddrs from the node config
+func (mt *monitorTest) readAnnounce(t *testing.T) announceConfig {
+	t.Helper()
+	data, err := os.ReadFile(mt.opts.NodeConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var announce announceConfig
+	if err := yaml.Unmarshal(data, &announce); err != nil {
+		t.Fatal(err)
+	}
+	return announce
+}
+
+// savedPreviousIP returns previous_ip from the saved qtools config, or "" when it was not saved
+func (mt *monitorTest) savedPreviousIP(t *testing.T) string {
+	t.Helper()
+	if _, err := os.Stat(mt.opts.ConfigPath); os.IsNotExist(err) {
+		return ""
+	}
+	cfg, err := config.LoadConfig(mt.opts.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg.ScheduledTasks.PublicIP.PreviousIP
+}
+
+func TestCheckIPChanged(t *testing.T) {
+	mt := newMonitorTest(t, "198.51.100.1", "203.0.113.7")
+
+	result, err := Check(context.Background(), mt.cfg, mt.opts)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !result.Changed || result.FirstRun || result.PreviousIP != "198.51.100.1" || result.Lookup.IP != "203.0.113.7" {
+		t.Errorf("result = %+v", result)
+	}
+
+	want := &node.AnnounceMultiaddrs{
+		ListenMultiaddr:        "/ip4/203.0.113.7/udp/8336/quic-v1",
+		StreamListenMultiaddr:  "/ip4/203.0.113.7/tcp/8340",
+		WorkerP2PMultiaddrs:    []string{"/ip4/203.0.113.7/tcp/50000", "/ip4/203.0.113.7/tcp/50001"},
+		WorkerStreamMultiaddrs: []string{"/ip4/203.0.113.7/tcp/60000", "/ip4/203.0.113.7/tcp/60001"},
+		Multiaddrs:             []string{"/ip4/203.0.113.7/tcp/8336"},
+	}
+	if result.Event == nil || !reflect.DeepEqual(result.Event.Announce, want) {
+		t.Fatalf("event = %+v, want announce %+v", result.Event, want)
+	}
+
+	announce := mt.readAnnounce(t)
+	if announce.P2P.AnnounceListenMultiaddr != want.ListenMultiaddr ||
+		announce.P2P.AnnounceStreamListenMultiaddr != want.StreamListenMultiaddr ||
+		!reflect.DeepEqual(announce.P2P.AnnounceMultiaddrs, want.Multiaddrs) ||
+		!reflect.DeepEqual(announce.Engine.DataWorkerAnnounceP2PMultiaddrs, want.WorkerP2PMultiaddrs) ||
+		!reflect.DeepEqual(announce.Engine.DataWorkerAnnounceStreamMultiaddrs, want.WorkerStreamMultiaddrs) {
+		t.Errorf("node config announce = %+v", announce)
+	}
+
+	if got := mt.savedPreviousIP(t); got != "203.0.113.7" {
+		t.Errorf("saved previous_ip = %q, want 203.0.113.7", got)
+	}
+	if len(mt.events) != 1 || mt.events[0].PreviousIP != "198.51.100.1" || mt.events[0].CurrentIP != "203.0.113.7" || mt.events[0].Restarted {
+		t.Errorf("OnChange events = %+v", mt.events)
+	}
+	if len(mt.backend.restarted) != 0 {
+		t.Errorf("restarted %v without restart_master", mt.backend.restarted)
+	}
+
+	// The next check sees the new address and changes nothing
+	mt.events = nil
+	result, err = Check(context.Background(), mt.cfg, mt.opts)
+	if err != nil || result.Changed || result.PreviousIP != "203.0.113.7" || len(mt.events) != 0 {
+		t.Errorf("second check: result %+v, events %+v, err %v", result, mt.events, err)
+	}
+}
+
+func TestCheckFirstRun(t *testing.T) {
+	mt := newMonitorTest(t, "", "203.0.113.7")
+
+	result, err := Check(context.Background(), mt.cfg, mt.opts)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !result.FirstRun || result.Changed || result.Event != nil {
+		t.Errorf("result = %+v, want a first run without a change", result)
+	}
+	if got := mt.savedPreviousIP(t); got != "203.0.113.7" {
+		t.Errorf("saved previous_ip = %q, want 203.0.113.7", got)
+	}
+	data, err := os.ReadFile(mt.opts.NodeConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != monitorNodeConfig {
+		t.Errorf("node config rewritten on the first run:\n%s", data)
+	}
+	if len(mt.events) != 0 {
+		t.Errorf("OnChange events = %+v", mt.events)
+	}
+}
+
+func TestCheckDryRun(t *testing.T) {
+	for _, previousIP := range []string{"", "198.51.100.1"} {
+		mt := newMonitorTest(t, previousIP, "203.0.113.7")
+		mt.opts.DryRun = true
+		mt.opts.RestartMaster = true
+
+		result, err := Check(context.Background(), mt.cfg, mt.opts)
+		if err != nil {
+			t.Fatalf("previous %q: Check failed: %v", previousIP, err)
+		}
+		data, err := os.ReadFile(mt.opts.NodeConfigPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != monitorNodeConfig || mt.savedPreviousIP(t) != "" || len(mt.backend.restarted) != 0 {
+			t.Errorf("previous %q: dry run changed the configs or restarted %v", previousIP, mt.backend.restarted)
+		}
+
+		// A change is still reported, with the multiaddrs it would write
+		if previousIP == "" {
+			continue
+		}
+		if len(mt.events) != 1 || !mt.events[0].DryRun || mt.events[0].Announce == nil ||
+			mt.events[0].Announce.ListenMultiaddr != "/ip4/203.0.113.7/udp/8336/quic-v1" {
+			t.Errorf("dry run events = %+v", mt.events)
+		}
+		if !result.Changed {
+			t.Errorf("dry run result = %+v", result)
+		}
+	}
+}
+
+func TestCheckRestartMaster(t *testing.T) {
+	mt := newMonitorTest(t, "198.51.100.1", "203.0.113.7")
+	mt.opts.RestartMaster = true
+
+	if _, err := Check(context.Background(), mt.cfg, mt.opts); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !reflect.DeepEqual(mt.backend.restarted, []string{"ceremonyclient"}) {
+		t.Errorf("restarted %v, want the master", mt.backend.restarted)
+	}
+	if len(mt.events) != 1 || !mt.events[0].Restarted {
+		t.Errorf("OnChange events = %+v", mt.events)
+	}
+
+	// A failed restart is returned, and still notified with the new address saved
+	mt = newMonitorTest(t, "198.51.100.1", "203.0.113.7")
+	mt.opts.RestartMaster = true
+	mt.backend.err = errors.New("unit not found")
+	if _, err := Check(context.Background(), mt.cfg, mt.opts); err == nil || !errors.Is(err, mt.backend.err) {
+		t.Errorf("Check error = %v, want the restart failure", err)
+	}
+	if len(mt.events) != 1 || mt.events[0].Restarted {
+		t.Errorf("OnChange events after a failed restart = %+v", mt.events)
+	}
+	if got := mt.savedPreviousIP(t); got != "203.0.113.7" {
+		t.Errorf("saved previous_ip = %q, want 203.0.113.7", got)
+	}
+}
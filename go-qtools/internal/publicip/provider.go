@@ -0,0 +1,127 @@
+package publicip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultProviders are the providers queried when none are configured
+var DefaultProviders = []string{
+	"https://api.ipify.org",
+	"https://icanhazip.com",
+	"https://ifconfig.me",
+}
+
+// Provider looks up the public IP address of this machine
+type Provider interface {
+	Name() string
+	Lookup(ctx context.Context) (net.IP, error)
+}
+
+// HTTPProvider queries a web service that returns the caller's IP as plain text
+type HTTPProvider struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPProvider creates a new HTTP provider
+func NewHTTPProvider(url string) *HTTPProvider {
+	return &HTTPProvider{URL: url, Client: http.DefaultClient}
+}
+
+// Name returns the provider URL
+func (p *HTTPProvider) Name() string {
+	return p.URL
+}
+
+// Lookup queries the web service
+func (p *HTTPProvider) Lookup(ctx context.Context) (net.IP, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Some services (ifconfig.me) return HTML unless asked for plain text
+	req.Header.Set("Accept", "text/plain")
+	req.Header.Set("User-Agent", "curl/8.0")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", p.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query %s: status %d", p.URL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", p.URL, err)
+	}
+
+	return parseIP(strings.TrimSpace(string(body)))
+}
+
+// StaticProvider always returns the same address (for testing and manual overrides)
+type StaticProvider struct {
+	IP net.IP
+}
+
+// Name returns the provider name
+func (p *StaticProvider) Name() string {
+	return "static:" + p.IP.String()
+}
+
+// Lookup returns the static address
+func (p *StaticProvider) Lookup(ctx context.Context) (net.IP, error) {
+	return p.IP, nil
+}
+
+// ParseProvider creates a provider from a spec
+// Supported: http(s)://url, stun:host:port, static:ip
+func ParseProvider(spec string) (Provider, error) {
+	switch {
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return NewHTTPProvider(spec), nil
+	case strings.HasPrefix(spec, "stun:"):
+		addr := strings.TrimPrefix(spec, "stun:")
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "3478")
+		}
+		return NewSTUNProvider(addr), nil
+	case strings.HasPrefix(spec, "static:"):
+		ip, err := parseIP(strings.TrimPrefix(spec, "static:"))
+		if err != nil {
+			return nil, err
+		}
+		return &StaticProvider{IP: ip}, nil
+	default:
+		return nil, fmt.Errorf("unsupported provider %s (expected http(s)://, stun: or static:)", spec)
+	}
+}
+
+// ParseProviders creates providers from a list of specs
+func ParseProviders(specs []string) ([]Provider, error) {
+	var providers []Provider
+	for _, spec := range specs {
+		provider, err := ParseProvider(spec)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// parseIP parses an IPv4 address
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil || ip.To4() == nil {
+		return nil, fmt.Errorf("invalid IPv4 address: %q", s)
+	}
+	return ip.To4(), nil
+}
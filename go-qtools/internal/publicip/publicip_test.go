@@ -0,0 +1,297 @@
+package publicip
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTimeout bounds each provider lookup in the tests
+const testTimeout = 500 * time.Millisecond
+
+// stunMode selects how the test STUN server answers
+type stunMode int
+
+const (
+	stunXorMapped stunMode = iota // XOR-MAPPED-ADDRESS, as current servers send
+	stunMapped                    // MAPPED-ADDRESS only (RFC 3489 servers)
+	stunSilent                    // never answers
+	stunWrongID                   // answers with another transaction ID
+)
+
+// newSTUNServer starts a UDP STUN stand-in that maps every client to ip
+func newSTUNServer(t *testing.T, ip string, mode stunMode) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	mapped := net.ParseIP(ip).To4()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if mode == stunSilent || n < stunHeaderSize {
+				continue
+			}
+
+			attrType := uint16(stunAttrXorMapped)
+			address := binary.BigEndian.Uint32(mapped) ^ stunMagicCookie
+			if mode == stunMapped {
+				attrType = stunAttrMappedAddr
+				address = binary.BigEndian.Uint32(mapped)
+			}
+			attr := make([]byte, 12)
+			binary.BigEndian.PutUint16(attr[0:2], attrType)
+			binary.BigEndian.PutUint16(attr[2:4], 8)
+			attr[5] = stunFamilyIPv4
+			binary.BigEndian.PutUint16(attr[6:8], 4000)
+			binary.BigEndian.PutUint32(attr[8:12], address)
+
+			response := make([]byte, stunHeaderSize, stunHeaderSize+len(attr))
+			binary.BigEndian.PutUint16(response[0:2], stunBindingSuccess)
+			binary.BigEndian.PutUint16(response[2:4], uint16(len(attr)))
+			binary.BigEndian.PutUint32(response[4:8], stunMagicCookie)
+			copy(response[8:20], buf[8:20])
+			if mode == stunWrongID {
+				response[8] ^= 0xff
+			}
+			conn.WriteTo(append(response, attr...), addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// newHTTPServer starts a web service stand-in answering every request with status and body
+func newHTTPServer(t *testing.T, status int, body string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/plain" {
+			t.Errorf("request without Accept: text/plain")
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// providersFor parses provider specs, failing the test on error
+func providersFor(t *testing.T, specs ...string) []Provider {
+	t.Helper()
+	providers, err := ParseProviders(specs)
+	if err != nil {
+		t.Fatalf("ParseProviders failed: %v", err)
+	}
+	return providers
+}
+
+func TestSTUNProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    stunMode
+		want    string
+		wantErr string
+	}{
+		{name: "xor mapped", mode: stunXorMapped, want: "203.0.113.7"},
+		{name: "mapped", mode: stunMapped, want: "203.0.113.7"},
+		{name: "silent", mode: stunSilent, wantErr: "failed to read STUN response"},
+		{name: "wrong transaction", mode: stunWrongID, wantErr: "transaction ID mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewSTUNProvider(newSTUNServer(t, "203.0.113.7", tt.mode))
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+
+			ip, err := provider.Lookup(ctx)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Lookup() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lookup failed: %v", err)
+			}
+			if ip.String() != tt.want {
+				t.Errorf("Lookup() = %s, want %s", ip, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr string
+	}{
+		{name: "plain text", status: http.StatusOK, body: "198.51.100.4\n", want: "198.51.100.4"},
+		{name: "server error", status: http.StatusBadGateway, body: "bad gateway", wantErr: "status 502"},
+		{name: "html", status: http.StatusOK, body: "<html>198.51.100.4</html>", wantErr: "invalid IPv4 address"},
+		{name: "ipv6", status: http.StatusOK, body: "2001:db8::1", wantErr: "invalid IPv4 address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewHTTPProvider(newHTTPServer(t, tt.status, tt.body))
+			ip, err := provider.Lookup(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Lookup() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lookup failed: %v", err)
+			}
+			if ip.String() != tt.want {
+				t.Errorf("Lookup() = %s, want %s", ip, tt.want)
+			}
+		})
+	}
+}
+
+// TestResolveFirstFallbackOrder checks that providers are tried in order until one answers
+func TestResolveFirstFallbackOrder(t *testing.T) {
+	failingHTTP := newHTTPServer(t, http.StatusServiceUnavailable, "")
+	silentSTUN := "stun:" + newSTUNServer(t, "203.0.113.7", stunSilent)
+	httpA := newHTTPServer(t, http.StatusOK, "198.51.100.4")
+	stunB := "stun:" + newSTUNServer(t, "203.0.113.7", stunXorMapped)
+
+	tests := []struct {
+		name      string
+		providers []string
+		want      string
+		wantErrs  []string // In provider order
+	}{
+		{name: "first answers", providers: []string{httpA, stunB}, want: "198.51.100.4"},
+		{name: "falls back to http", providers: []string{failingHTTP, silentSTUN, httpA, stunB}, want: "198.51.100.4"},
+		{name: "falls back to stun", providers: []string{failingHTTP, stunB, httpA}, want: "203.0.113.7"},
+		{name: "static override", providers: []string{"static:192.0.2.1", httpA}, want: "192.0.2.1"},
+		{
+			name:      "all fail",
+			providers: []string{failingHTTP, silentSTUN},
+			wantErrs:  []string{"status 503", "failed to read STUN response"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := ResolveFirst(context.Background(), providersFor(t, tt.providers...), testTimeout)
+			if tt.wantErrs != nil {
+				if err == nil {
+					t.Fatalf("ResolveFirst() = %s, want an error", ip)
+				}
+				last := -1
+				for _, want := range tt.wantErrs {
+					i := strings.Index(err.Error(), want)
+					if i <= last {
+						t.Errorf("error %q does not report %q in provider order", err, want)
+					}
+					last = i
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveFirst failed: %v", err)
+			}
+			if ip.String() != tt.want {
+				t.Errorf("ResolveFirst() = %s, want %s", ip, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveQuorum(t *testing.T) {
+	httpA := newHTTPServer(t, http.StatusOK, "198.51.100.4")
+	httpA2 := newHTTPServer(t, http.StatusOK, "198.51.100.4")
+	stunA := "stun:" + newSTUNServer(t, "198.51.100.4", stunXorMapped)
+	stunB := "stun:" + newSTUNServer(t, "203.0.113.7", stunMapped)
+	failing := newHTTPServer(t, http.StatusInternalServerError, "")
+
+	tests := []struct {
+		name      string
+		providers []string
+		quorum    int
+		want      string
+		wantVotes int
+		wantErr   string
+	}{
+		{name: "unanimous", providers: []string{httpA, httpA2, stunA}, want: "198.51.100.4", wantVotes: 3},
+		{name: "majority", providers: []string{httpA, stunA, stunB}, want: "198.51.100.4", wantVotes: 2},
+		{name: "majority of answers", providers: []string{httpA, stunA, failing}, want: "198.51.100.4", wantVotes: 2},
+		{name: "tie", providers: []string{httpA, stunB}, wantErr: "did not reach quorum"},
+		{name: "explicit quorum not met", providers: []string{httpA, stunA, stunB}, quorum: 3, wantErr: "did not reach quorum of 3"},
+		{name: "quorum above providers", providers: []string{httpA}, quorum: 2, wantErr: "exceeds the number of providers"},
+		{name: "no answers", providers: []string{failing}, wantErr: "no provider returned a public IP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := providersFor(t, tt.providers...)
+			result, err := Resolve(context.Background(), providers, tt.quorum, testTimeout)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if result.IP != tt.want || result.Votes != tt.wantVotes {
+				t.Errorf("Resolve() = %s with %d votes, want %s with %d", result.IP, result.Votes, tt.want, tt.wantVotes)
+			}
+			if len(result.Answers) != len(providers) {
+				t.Fatalf("got %d answers, want one per provider", len(result.Answers))
+			}
+			for i, answer := range result.Answers {
+				if answer.Provider != providers[i].Name() {
+					t.Errorf("answer %d is from %s, want %s", i, answer.Provider, providers[i].Name())
+				}
+			}
+		})
+	}
+}
+
+func TestParseProvider(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "https://api.ipify.org", want: "https://api.ipify.org"},
+		{spec: "stun:stun.l.google.com:19302", want: "stun:stun.l.google.com:19302"},
+		{spec: "stun:stun.example.com", want: "stun:stun.example.com:3478"},
+		{spec: "static:192.0.2.1", want: "static:192.0.2.1"},
+		{spec: "static:2001:db8::1", wantErr: true},
+		{spec: "ftp://example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		provider, err := ParseProvider(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseProvider(%q) = %s, want an error", tt.spec, provider.Name())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseProvider(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if provider.Name() != tt.want {
+			t.Errorf("ParseProvider(%q).Name() = %q, want %q", tt.spec, provider.Name(), tt.want)
+		}
+	}
+}
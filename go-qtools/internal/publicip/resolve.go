@@ -0,0 +1,125 @@
+package publicip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLookupTimeout is the per-provider lookup timeout
+const DefaultLookupTimeout = 10 * time.Second
+
+// Answer represents a single provider's response
+type Answer struct {
+	Provider string `json:"provider"`
+	IP       string `json:"ip,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Result represents the outcome of a quorum lookup
+type Result struct {
+	IP      string   `json:"ip"`
+	Votes   int      `json:"votes"`
+	Quorum  int      `json:"quorum"`
+	Answers []Answer `json:"answers"`
+}
+
+// Resolve queries all providers in parallel and returns the address agreed on by at least quorum providers
+// A quorum of 0 requires a majority of the providers that answered
+func Resolve(ctx context.Context, providers []Provider, quorum int, timeout time.Duration) (*Result, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no public IP providers configured")
+	}
+	if quorum > len(providers) {
+		return nil, fmt.Errorf("quorum %d exceeds the number of providers (%d)", quorum, len(providers))
+	}
+	if timeout <= 0 {
+		timeout = DefaultLookupTimeout
+	}
+
+	answers := make([]Answer, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider Provider) {
+			defer wg.Done()
+			lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			answers[i].Provider = provider.Name()
+			ip, err := provider.Lookup(lookupCtx)
+			if err != nil {
+				answers[i].Error = err.Error()
+				return
+			}
+			answers[i].IP = ip.String()
+		}(i, provider)
+	}
+	wg.Wait()
+
+	votes := make(map[string]int)
+	answered := 0
+	for _, answer := range answers {
+		if answer.IP != "" {
+			votes[answer.IP]++
+			answered++
+		}
+	}
+
+	result := &Result{Quorum: quorum, Answers: answers}
+	if answered == 0 {
+		return result, fmt.Errorf("no provider returned a public IP")
+	}
+	if result.Quorum <= 0 {
+		result.Quorum = answered/2 + 1
+	}
+
+	// Pick the address with the most votes; ties are broken by address for stable output
+	ips := make([]string, 0, len(votes))
+	for ip := range votes {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		if votes[ips[i]] != votes[ips[j]] {
+			return votes[ips[i]] > votes[ips[j]]
+		}
+		return ips[i] < ips[j]
+	})
+
+	best := ips[0]
+	result.Votes = votes[best]
+	if result.Votes < result.Quorum || (len(ips) > 1 && votes[ips[1]] == result.Votes) {
+		var seen []string
+		for _, ip := range ips {
+			seen = append(seen, fmt.Sprintf("%s (%d)", ip, votes[ip]))
+		}
+		return result, fmt.Errorf("providers did not reach quorum of %d: %s", result.Quorum, strings.Join(seen, ", "))
+	}
+
+	result.IP = best
+	return result, nil
+}
+
+// ResolveFirst returns the first address any provider returns, in order
+func ResolveFirst(ctx context.Context, providers []Provider, timeout time.Duration) (net.IP, error) {
+	if timeout <= 0 {
+		timeout = DefaultLookupTimeout
+	}
+
+	var errs []string
+	for _, provider := range providers {
+		lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+		ip, err := provider.Lookup(lookupCtx)
+		cancel()
+		if err == nil {
+			return ip, nil
+		}
+		errs = append(errs, err.Error())
+	}
+
+	return nil, fmt.Errorf("failed to get public IP from any provider: %s", strings.Join(errs, "; "))
+}
@@ -0,0 +1,128 @@
+package publicip
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// STUN message constants from RFC 5389
+const (
+	stunBindingRequest  = 0x0001
+	stunBindingSuccess  = 0x0101
+	stunMagicCookie     = 0x2112A442
+	stunHeaderSize      = 20
+	stunAttrMappedAddr  = 0x0001
+	stunAttrXorMapped   = 0x0020
+	stunFamilyIPv4      = 0x01
+	stunDefaultDeadline = 5 * time.Second
+)
+
+// STUNProvider looks up the public IP with a STUN binding request over UDP
+type STUNProvider struct {
+	Address string // host:port
+}
+
+// NewSTUNProvider creates a new STUN provider
+func NewSTUNProvider(address string) *STUNProvider {
+	return &STUNProvider{Address: address}
+}
+
+// Name returns the provider name
+func (p *STUNProvider) Name() string {
+	return "stun:" + p.Address
+}
+
+// Lookup sends a binding request and returns the mapped address
+func (p *STUNProvider) Lookup(ctx context.Context) (net.IP, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp4", p.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to STUN server %s: %w", p.Address, err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(stunDefaultDeadline)
+	}
+	conn.SetDeadline(deadline)
+
+	request := make([]byte, stunHeaderSize)
+	binary.BigEndian.PutUint16(request[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint16(request[2:4], 0)
+	binary.BigEndian.PutUint32(request[4:8], stunMagicCookie)
+	if _, err := rand.Read(request[8:20]); err != nil {
+		return nil, fmt.Errorf("failed to generate transaction ID: %w", err)
+	}
+
+	if _, err := conn.Write(request); err != nil {
+		return nil, fmt.Errorf("failed to send STUN request: %w", err)
+	}
+
+	response := make([]byte, 1024)
+	n, err := conn.Read(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read STUN response: %w", err)
+	}
+
+	return parseSTUNResponse(response[:n], request[8:20])
+}
+
+// parseSTUNResponse extracts the mapped IPv4 address from a binding success response
+func parseSTUNResponse(msg []byte, transactionID []byte) (net.IP, error) {
+	if len(msg) < stunHeaderSize {
+		return nil, fmt.Errorf("STUN response too short")
+	}
+	if binary.BigEndian.Uint16(msg[0:2]) != stunBindingSuccess {
+		return nil, fmt.Errorf("unexpected STUN message type 0x%04x", binary.BigEndian.Uint16(msg[0:2]))
+	}
+	if binary.BigEndian.Uint32(msg[4:8]) != stunMagicCookie {
+		return nil, fmt.Errorf("invalid STUN magic cookie")
+	}
+	if string(msg[8:20]) != string(transactionID) {
+		return nil, fmt.Errorf("STUN transaction ID mismatch")
+	}
+
+	length := int(binary.BigEndian.Uint16(msg[2:4]))
+	if stunHeaderSize+length > len(msg) {
+		return nil, fmt.Errorf("truncated STUN response")
+	}
+
+	var mapped net.IP
+	attrs := msg[stunHeaderSize : stunHeaderSize+length]
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if 4+attrLen > len(attrs) {
+			break
+		}
+		value := attrs[4 : 4+attrLen]
+
+		if len(value) >= 8 && value[1] == stunFamilyIPv4 {
+			switch attrType {
+			case stunAttrXorMapped:
+				ip := make(net.IP, 4)
+				binary.BigEndian.PutUint32(ip, binary.BigEndian.Uint32(value[4:8])^stunMagicCookie)
+				return ip, nil
+			case stunAttrMappedAddr:
+				mapped = net.IP(append([]byte(nil), value[4:8]...))
+			}
+		}
+
+		// Attributes are padded to 4 bytes
+		next := 4 + (attrLen+3)&^3
+		if next > len(attrs) {
+			break
+		}
+		attrs = attrs[next:]
+	}
+
+	if mapped != nil {
+		return mapped, nil
+	}
+	return nil, fmt.Errorf("STUN response has no IPv4 mapped address")
+}
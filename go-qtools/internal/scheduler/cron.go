@@ -0,0 +1,288 @@
+// Package scheduler runs the scheduled_tasks from the qtools config on their cron schedules
+// (qtools daemon), replacing the crontab entries install-cron.sh used to write
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reboot is the expression for tasks run once when the daemon starts
+const Reboot = "@reboot"
+
+// cronMacros maps the @ shorthands to five-field expressions
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField describes the values one field of an expression can take
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Sunday is 0 or 7
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	expr    string
+	reboot  bool
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool // day of month is *, so only the day of week restricts days
+	dowStar bool // day of week is *, so only the day of month restricts days
+}
+
+// ParseSchedule parses a five-field cron expression (minute hour day-of-month month day-of-week)
+// Fields take *, values, ranges (1-5), steps (*/10, 0-30/5) and lists (1,15); months and days
+// of the week also take names (jan, mon). @hourly, @daily, @weekly, @monthly, @yearly and
+// @reboot are accepted too. Like cron, when both day fields are restricted either may match
+func ParseSchedule(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == Reboot {
+		return &Schedule{expr: expr, reboot: true}, nil
+	}
+	fields := strings.Fields(expr)
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+		macro, ok := cronMacros[strings.ToLower(fields[0])]
+		if !ok {
+			return nil, fmt.Errorf("invalid cron expression %q: unknown shorthand %s", expr, fields[0])
+		}
+		fields = strings.Fields(macro)
+	}
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	schedule := &Schedule{
+		expr:    expr,
+		domStar: strings.HasPrefix(fields[2], "*") || fields[2] == "?",
+		dowStar: strings.HasPrefix(fields[4], "*") || fields[4] == "?",
+	}
+	var err error
+	for _, f := range []struct {
+		bits  *uint64
+		value string
+		field cronField
+	}{
+		{&schedule.minute, fields[0], minuteField},
+		{&schedule.hour, fields[1], hourField},
+		{&schedule.dom, fields[2], domField},
+		{&schedule.month, fields[3], monthField},
+		{&schedule.dow, fields[4], dowField},
+	} {
+		if *f.bits, err = parseField(f.value, f.field); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+	}
+	// Sunday may be written as 7
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	return schedule, nil
+}
+
+// parseField parses one field into a bit set of its values
+func parseField(value string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, field.name)
+			}
+		}
+
+		start, end := field.min, field.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseValue(from, field); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(to, field); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, field.name)
+			}
+		default:
+			var err error
+			if start, err = parseValue(rangePart, field); err != nil {
+				return 0, err
+			}
+			// A single value with a step runs from the value to the maximum, e.g., 5/15
+			if !hasStep {
+				end = start
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseValue parses a number or name within a field's range
+func parseValue(value string, field cronField) (int, error) {
+	if n, ok := field.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", value, field.name)
+	}
+	if n < field.min || n > field.max {
+		return 0, fmt.Errorf("%s %d is out of range (%d-%d)", field.name, n, field.min, field.max)
+	}
+	return n, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// OnCalendar returns the schedule as systemd calendar events, or nil for @reboot
+// Restricting both day fields takes two events, since either day may match
+func (s *Schedule) OnCalendar() []string {
+	if s.reboot {
+		return nil
+	}
+	clock := fmt.Sprintf("%s:%s:00", calendarValues(s.hour, 0, 23), calendarValues(s.minute, 0, 59))
+	month := calendarValues(s.month, 1, 12)
+	dom := calendarValues(s.dom, 1, 31)
+	dow := ""
+	if days := calendarWeekdays(s.dow); days != "" {
+		dow = days + " "
+	}
+	if s.domStar || s.dowStar {
+		return []string{fmt.Sprintf("%s*-%s-%s %s", dow, month, dom, clock)}
+	}
+	return []string{
+		fmt.Sprintf("*-%s-%s %s", month, dom, clock),
+		fmt.Sprintf("%s*-%s-* %s", dow, month, clock),
+	}
+}
+
+// calendarValues lists a field's values for a systemd calendar event, or * for all of them
+func calendarValues(bits uint64, min, max int) string {
+	var values []string
+	for v := min; v <= max; v++ {
+		if bits&(1<<uint(v)) != 0 {
+			values = append(values, strconv.Itoa(v))
+		}
+	}
+	if len(values) == max-min+1 {
+		return "*"
+	}
+	return strings.Join(values, ",")
+}
+
+// calendarWeekdays lists the days of the week for a systemd calendar event, or "" for all of them
+func calendarWeekdays(bits uint64) string {
+	names := []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+	var days []string
+	for v, name := range names {
+		if bits&(1<<uint(v)) != 0 {
+			days = append(days, name)
+		}
+	}
+	if len(days) == len(names) {
+		return ""
+	}
+	return strings.Join(days, ",")
+}
+
+// IsReboot reports whether the schedule is @reboot, which only runs when the daemon starts
+func (s *Schedule) IsReboot() bool {
+	return s.reboot
+}
+
+// Matches reports whether the schedule fires in the minute containing t
+func (s *Schedule) Matches(t time.Time) bool {
+	if s.reboot {
+		return false
+	}
+	return s.minute&(1<<uint(t.Minute())) != 0 &&
+		s.hour&(1<<uint(t.Hour())) != 0 &&
+		s.month&(1<<uint(t.Month())) != 0 &&
+		s.dayMatches(t)
+}
+
+// dayMatches checks the day of month and day of week
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time after t the schedule fires, or zero for @reboot or a schedule
+// that never fires (e.g., 30 February)
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.reboot {
+		return time.Time{}
+	}
+
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Every combination repeats within a leap-year cycle
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = advance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+		case !s.dayMatches(t):
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// advance returns next, moved forward when it is not after t
+// time.Date maps a wall clock time skipped by a DST change to before the change, so the start
+// of the hour after 01:00 on a spring-forward night would be 01:00 again
+func advance(t, next time.Time) time.Time {
+	for !next.After(t) {
+		next = next.Add(time.Hour)
+	}
+	return next
+}
@@ -0,0 +1,186 @@
+package scheduler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // America/New_York for the DST cases
+)
+
+// at returns a UTC time on the given day and minute
+func at(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := map[string]string{
+		"":               "expected 5 fields, got 0",
+		"* * * *":        "expected 5 fields, got 4",
+		"* * * * * *":    "expected 5 fields, got 6",
+		"@fortnightly":   "unknown shorthand @fortnightly",
+		"60 * * * *":     "minute 60 is out of range (0-59)",
+		"* 24 * * *":     "hour 24 is out of range (0-23)",
+		"* * 0 * *":      "day of month 0 is out of range (1-31)",
+		"* * * 13 *":     "month 13 is out of range (1-12)",
+		"* * * * 8":      "day of week 8 is out of range (0-7)",
+		"*/0 * * * *":    `invalid step "0" in minute field`,
+		"*/x * * * *":    `invalid step "x" in minute field`,
+		"30-10 * * * *":  `invalid range "30-10" in minute field`,
+		"* * * foo *":    `invalid value "foo" in month field`,
+		"* * * * mon-xx": `invalid value "xx" in day of week field`,
+		"1,,2 * * * *":   `invalid value "" in minute field`,
+	}
+	for expr, want := range tests {
+		_, err := ParseSchedule(expr)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseSchedule(%q) error = %v, want %q", expr, err, want)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// Steps, ranges, lists and names
+		{"step", "*/15 * * * *", at(2024, 3, 6, 10, 7), at(2024, 3, 6, 10, 15)},
+		{"step wraps the hour", "*/15 * * * *", at(2024, 3, 6, 10, 45), at(2024, 3, 6, 11, 0)},
+		{"step from a value", "5/20 * * * *", at(2024, 3, 6, 10, 26), at(2024, 3, 6, 10, 45)},
+		{"stepped range", "0-30/10 9-17 * * mon-fri", at(2024, 3, 8, 17, 35), at(2024, 3, 11, 9, 0)},
+		{"list", "0 0 1,15 * *", at(2024, 1, 2, 0, 0), at(2024, 1, 15, 0, 0)},
+		{"month names", "0 12 * jan,JUL *", at(2024, 2, 10, 0, 0), at(2024, 7, 1, 12, 0)},
+		{"macro", "@daily", at(2024, 3, 6, 10, 0), at(2024, 3, 7, 0, 0)},
+		{"strictly after", "30 10 * * *", at(2024, 3, 6, 10, 30), at(2024, 3, 7, 10, 30)},
+		{"seconds ignored", "31 10 * * *", at(2024, 3, 6, 10, 30).Add(59 * time.Second), at(2024, 3, 6, 10, 31)},
+
+		// Sunday may be written as 0 or 7
+		{"sunday as 7", "0 9 * * 7", at(2024, 3, 6, 0, 0), at(2024, 3, 10, 9, 0)},
+		{"range ending in 7", "0 9 * * 5-7", at(2024, 3, 9, 10, 0), at(2024, 3, 10, 9, 0)},
+
+		// Both day fields restricted: either may match
+		{"dom or dow: friday", "0 0 13 * fri", at(2024, 1, 1, 0, 0), at(2024, 1, 5, 0, 0)},
+		{"dom or dow: 13th", "0 0 13 * fri", at(2024, 1, 12, 0, 0), at(2024, 1, 13, 0, 0)},
+		// A day field starting with * only narrows the other one, like cron
+		{"*/2 day of month", "0 0 */2 * mon", at(2024, 1, 1, 0, 0), at(2024, 1, 15, 0, 0)},
+		{"*/2 day of week", "0 0 1 * */2", at(2024, 1, 1, 0, 0), at(2024, 2, 1, 0, 0)},
+
+		// Month ends and leap days
+		{"end of month", "59 23 * * *", at(2024, 1, 31, 23, 59), at(2024, 2, 1, 23, 59)},
+		{"skips short months", "0 0 31 * *", at(2024, 4, 1, 0, 0), at(2024, 5, 31, 0, 0)},
+		{"end of year", "0 0 1 * *", at(2024, 12, 15, 0, 0), at(2025, 1, 1, 0, 0)},
+		{"leap day", "0 0 29 2 *", at(2024, 3, 1, 0, 0), at(2028, 2, 29, 0, 0)},
+		{"never fires", "0 0 30 2 *", at(2024, 1, 1, 0, 0), time.Time{}},
+		{"reboot", Reboot, at(2024, 1, 1, 0, 0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: ParseSchedule(%q) failed: %v", tt.name, tt.expr, err)
+		}
+		if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%s) of %q = %s, want %s", tt.name, tt.from, tt.expr, got, tt.want)
+		}
+		if !tt.want.IsZero() && !schedule.Matches(tt.want) {
+			t.Errorf("%s: %q does not match its next run %s", tt.name, tt.expr, tt.want)
+		}
+	}
+}
+
+func TestScheduleNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		want     time.Time
+		wantGap  time.Duration
+		wantZone string
+	}{
+		// 2024-03-10 02:00 EST jumps to 03:00 EDT
+		{"day before spring forward", "0 3 * * *", local(3, 9, 3, 0), local(3, 10, 3, 0), 23 * time.Hour, "EDT"},
+		// 02:30 does not exist that night, so the run moves to the next day
+		{"skipped hour", "30 2 * * *", local(3, 9, 3, 0), local(3, 11, 2, 30), 46*time.Hour + 30*time.Minute, "EDT"},
+		// 2024-11-03 02:00 EDT falls back to 01:00 EST
+		{"day before fall back", "0 3 * * *", local(11, 2, 3, 0), local(11, 3, 3, 0), 25 * time.Hour, "EST"},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := schedule.Next(tt.from)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%s) = %s, want %s", tt.name, tt.from, got, tt.want)
+			continue
+		}
+		if gap := got.Sub(tt.from); gap != tt.wantGap {
+			t.Errorf("%s: next run is %s later, want %s", tt.name, gap, tt.wantGap)
+		}
+		if zone, _ := got.Zone(); zone != tt.wantZone {
+			t.Errorf("%s: next run is in %s, want %s", tt.name, zone, tt.wantZone)
+		}
+	}
+}
+
+func TestScheduleMatches(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 13 * fri")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[time.Time]bool{
+		at(2024, 1, 5, 0, 0):  true,  // Friday
+		at(2024, 1, 13, 0, 0): true,  // Saturday the 13th
+		at(2024, 1, 14, 0, 0): false, // Sunday the 14th
+		at(2024, 1, 5, 0, 1):  false, // Wrong minute
+	}
+	for when, want := range tests {
+		if got := schedule.Matches(when); got != want {
+			t.Errorf("Matches(%s) = %t, want %t", when, got, want)
+		}
+	}
+
+	reboot, err := ParseSchedule(" @reboot ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reboot.IsReboot() || reboot.Matches(at(2024, 1, 1, 0, 0)) || reboot.String() != Reboot {
+		t.Errorf("@reboot schedule = %+v", reboot)
+	}
+}
+
+func TestScheduleOnCalendar(t *testing.T) {
+	tests := map[string][]string{
+		"*/15 * * * *":     {"*-*-* *:0,15,30,45:00"},
+		"30 2 * * mon-fri": {"Mon,Tue,Wed,Thu,Fri *-*-* 2:30:00"},
+		"0 9 1 jan,jul *":  {"*-1,7-1 9:0:00"},
+		"0 9 * * 7":        {"Sun *-*-* 9:0:00"},
+		"@weekly":          {"Sun *-*-* 0:0:00"},
+		// Either day may match, which takes two events
+		"0 0 13 * fri": {"*-*-13 0:0:00", "Fri *-*-* 0:0:00"},
+		// A day field starting with * narrows the other one, so one event has both
+		"0 0 */2 * mon": {"Mon *-*-1,3,5,7,9,11,13,15,17,19,21,23,25,27,29,31 0:0:00"},
+		Reboot:          nil,
+	}
+	for expr, want := range tests {
+		schedule, err := ParseSchedule(expr)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) failed: %v", expr, err)
+		}
+		if got := schedule.OnCalendar(); !reflect.DeepEqual(got, want) {
+			t.Errorf("OnCalendar(%q) = %q, want %q", expr, got, want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// DaemonServiceName is the service qtools daemon install creates
+const DaemonServiceName = "qtools-daemon"
+
+// Daemon runs the enabled scheduled tasks on their schedules
+// The config file is reloaded when it changes, so toggling a task needs no restart
+type Daemon struct {
+	ConfigPath string
+	Logf       func(format string, args ...interface{})
+
+	cfg     *config.Config
+	modTime time.Time
+	tasks   []TaskConfig
+	wg      sync.WaitGroup
+}
+
+// NewDaemon creates a daemon for the config file at path
+func NewDaemon(path string, logf func(format string, args ...interface{})) *Daemon {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	return &Daemon{ConfigPath: path, Logf: logf}
+}
+
+// Run runs tasks until ctx is done, then waits for running tasks to finish
+// Running tasks are not interrupted; each is bounded by its own timeout
+func (d *Daemon) Run(ctx context.Context) error {
+	if err := d.reload(true); err != nil {
+		return err
+	}
+
+	for _, tc := range d.tasks {
+		if tc.Enabled && tc.Schedule != nil && tc.Schedule.IsReboot() {
+			d.start(ctx, tc, TriggerReboot)
+		}
+	}
+
+	for {
+		next := time.Now().Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			d.Logf("Stopping; waiting for running tasks")
+			d.wg.Wait()
+			return nil
+		case <-timer.C:
+		}
+
+		if err := d.reload(false); err != nil {
+			d.Logf("Warning: %v; keeping the previous config", err)
+		}
+		for _, tc := range d.tasks {
+			if tc.Enabled && tc.Schedule != nil && tc.Schedule.Matches(next) {
+				d.start(ctx, tc, TriggerSchedule)
+			}
+		}
+	}
+}
+
+// reload reads the config when it changed since it was last read
+func (d *Daemon) reload(initial bool) error {
+	info, err := os.Stat(d.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if !initial && info.ModTime().Equal(d.modTime) {
+		return nil
+	}
+
+	cfg, err := config.LoadConfig(d.ConfigPath)
+	if err != nil {
+		return err
+	}
+	d.cfg = cfg
+	d.modTime = info.ModTime()
+	d.tasks = LoadTaskConfigs(cfg)
+
+	if !initial {
+		d.Logf("Reloaded %s", d.ConfigPath)
+	}
+	for _, tc := range d.tasks {
+		switch {
+		case !tc.Enabled:
+		case tc.Error != "":
+			d.Logf("Warning: %s is enabled but not scheduled: %s", tc.Name, tc.Error)
+		case initial:
+			d.Logf("Scheduled %s (%s)", tc.Name, tc.Expression)
+		}
+	}
+	for _, warning := range cfg.Warnings() {
+		d.Logf("Warning: %s", warning)
+	}
+	for _, name := range EnabledUnsupportedTasks(cfg) {
+		d.Logf("Warning: %s", UnsupportedTaskWarning(name))
+	}
+	return nil
+}
+
+// start runs a task in the background after a random delay of up to its jitter
+// Once started, a run is not tied to ctx, so stopping the daemon lets it finish
+func (d *Daemon) start(ctx context.Context, tc TaskConfig, trigger string) {
+	cfg := d.cfg
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if trigger == TriggerSchedule {
+			if err := Delay(ctx, tc); err != nil {
+				return
+			}
+		}
+
+		d.Logf("Running %s", tc.Name)
+		run, err := RunTask(context.Background(), cfg, tc, trigger)
+		switch run.Status {
+		case StatusSuccess:
+			d.Logf("✓ %s finished in %s", tc.Name, run.Duration.Round(time.Second))
+		case StatusSkipped:
+			d.Logf("Skipped %s: %s", tc.Name, run.Error)
+		default:
+			d.Logf("✗ %s %s after %s: %s", tc.Name, run.Status, run.Duration.Round(time.Second), run.Error)
+		}
+		if err != nil && run.Status != StatusFailed && run.Status != StatusTimeout {
+			d.Logf("Warning: %v", err)
+		}
+	}()
+}
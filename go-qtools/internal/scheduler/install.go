@@ -0,0 +1,273 @@
+package scheduler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/service"
+)
+
+// Ways to run the scheduled tasks (settings.scheduler.backend)
+const (
+	BackendDaemon       = "daemon"        // qtools daemon
+	BackendCron         = "cron"          // crontab entries
+	BackendSystemdTimer = "systemd-timer" // a .timer and .service per task
+)
+
+// timerPrefix starts the names of the timers qtools tasks install creates
+const timerPrefix = "qtools-task-"
+
+// The crontab entries qtools tasks install manages sit between these lines
+const (
+	crontabBegin = "# BEGIN qtools scheduled tasks (managed by qtools tasks install; do not edit)"
+	crontabEnd   = "# END qtools scheduled tasks"
+)
+
+// crontabTask finds the task an entry runs
+var crontabTask = regexp.MustCompile(`tasks run --scheduled (\S+)`)
+
+// InstallResult describes what installing the schedule changed
+type InstallResult struct {
+	Backend   string       `json:"backend"`
+	Installed []TaskConfig `json:"installed"`
+	Removed   []string     `json:"removed"` // Tasks (cron) or timers (systemd-timer) no longer scheduled
+	Invalid   []TaskConfig `json:"invalid"` // Enabled tasks with invalid cron expressions
+}
+
+// LoadBackend returns settings.scheduler.backend, defaulting to the daemon
+func LoadBackend(cfg *config.Config) string {
+	if cfg.Settings != nil && cfg.Settings.Scheduler != nil {
+		if backend := strings.TrimSpace(cfg.Settings.Scheduler.Backend); backend != "" {
+			return backend
+		}
+	}
+	return BackendDaemon
+}
+
+// ValidateBackend checks a backend qtools tasks install can write
+func ValidateBackend(backend string) error {
+	switch backend {
+	case BackendCron, BackendSystemdTimer:
+		return nil
+	default:
+		return fmt.Errorf("unknown backend %q (expected cron or systemd-timer)", backend)
+	}
+}
+
+// TimerName returns the systemd timer (and service) name for a task, e.g., qtools-task-updates-node
+func TimerName(task string) string {
+	return timerPrefix + strings.ReplaceAll(task, ".", "-")
+}
+
+// taskCommand returns the command cron or a timer runs for a task
+// qtools tasks run --scheduled applies the same jitter, lock, timeout and history as the daemon
+func taskCommand(tc TaskConfig) (*service.CommandService, error) {
+	return service.NewCommandService(TimerName(tc.Name), "qtools task "+tc.Name+": "+tc.Description, "tasks", "run", "--scheduled", tc.Name)
+}
+
+// InstallSchedule writes each enabled task to crontab entries or systemd timers and removes
+// those of tasks no longer enabled
+func InstallSchedule(cfg *config.Config, backend string) (*InstallResult, error) {
+	if err := ValidateBackend(backend); err != nil {
+		return nil, err
+	}
+	result := &InstallResult{Backend: backend}
+	for _, tc := range LoadTaskConfigs(cfg) {
+		switch {
+		case !tc.Enabled:
+		case tc.Error != "":
+			result.Invalid = append(result.Invalid, tc)
+		default:
+			result.Installed = append(result.Installed, tc)
+		}
+	}
+
+	var err error
+	if backend == BackendCron {
+		result.Removed, err = installCrontab(result.Installed)
+	} else {
+		result.Removed, err = installTimers(cfg, result.Installed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UninstallSchedule removes every crontab entry or systemd timer qtools tasks install wrote
+func UninstallSchedule(cfg *config.Config, backend string) ([]string, error) {
+	if err := ValidateBackend(backend); err != nil {
+		return nil, err
+	}
+	if backend == BackendCron {
+		return installCrontab(nil)
+	}
+	return installTimers(cfg, nil)
+}
+
+// SyncSchedule reinstalls the schedule for settings.scheduler.backend after a task changes
+// The daemon reloads the config itself, so nil is returned for it
+func SyncSchedule(cfg *config.Config) (*InstallResult, error) {
+	backend := LoadBackend(cfg)
+	if backend == BackendDaemon {
+		return nil, nil
+	}
+	return InstallSchedule(cfg, backend)
+}
+
+// installCrontab replaces the managed block of the user's crontab with entries for tasks
+// Entries outside the block are kept; the tasks whose entries were dropped are returned
+func installCrontab(tasks []TaskConfig) ([]string, error) {
+	current, err := readCrontab()
+	if err != nil {
+		return nil, err
+	}
+	kept, previous := splitCrontab(current)
+
+	var block []string
+	installed := make(map[string]bool)
+	for _, tc := range tasks {
+		svc, err := taskCommand(tc)
+		if err != nil {
+			return nil, err
+		}
+		// cron treats % as a newline, and does not accept ?
+		command := strings.ReplaceAll(svc.ShellCommand(), "%", `\%`)
+		block = append(block, fmt.Sprintf("%s %s >/dev/null 2>&1", crontabExpression(tc.Expression), command))
+		installed[tc.Name] = true
+	}
+
+	var removed []string
+	for _, name := range previous {
+		if !installed[name] {
+			removed = append(removed, name)
+		}
+	}
+
+	lines := kept
+	if len(block) > 0 {
+		lines = append(lines, crontabBegin)
+		lines = append(lines, block...)
+		lines = append(lines, crontabEnd)
+	}
+	content := ""
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	if content == current {
+		return removed, nil
+	}
+	return removed, writeCrontab(content)
+}
+
+// crontabExpression rewrites ? fields, which the parser accepts but cron does not
+func crontabExpression(expr string) string {
+	fields := strings.Fields(expr)
+	for i, field := range fields {
+		if field == "?" {
+			fields[i] = "*"
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// splitCrontab separates the managed block from the rest of a crontab, returning the other
+// lines and the tasks the block ran
+func splitCrontab(content string) ([]string, []string) {
+	var kept, tasks []string
+	inBlock := false
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# BEGIN qtools scheduled tasks"):
+			inBlock = true
+		case inBlock && line == crontabEnd:
+			inBlock = false
+		case inBlock:
+			if match := crontabTask.FindStringSubmatch(line); match != nil {
+				tasks = append(tasks, match[1])
+			}
+		case line != "" || len(kept) > 0:
+			kept = append(kept, line)
+		}
+	}
+	// Drop trailing blank lines left where the block was
+	for len(kept) > 0 && kept[len(kept)-1] == "" {
+		kept = kept[:len(kept)-1]
+	}
+	return kept, tasks
+}
+
+// readCrontab returns the user's crontab, or "" when there is none
+func readCrontab() (string, error) {
+	if _, err := exec.LookPath("crontab"); err != nil {
+		return "", fmt.Errorf("crontab not found; install cron or use --backend systemd-timer")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("crontab", "-l")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && strings.Contains(strings.ToLower(stderr.String()), "no crontab") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read crontab: %w\nOutput: %s", err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// writeCrontab replaces the user's crontab
+func writeCrontab(content string) error {
+	cmd := exec.Command("crontab", "-")
+	cmd.Stdin = strings.NewReader(content)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write crontab: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// installTimers installs a systemd timer for each task and removes the other qtools task timers
+func installTimers(cfg *config.Config, tasks []TaskConfig) ([]string, error) {
+	sb, err := service.GetTimerBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := sb.ListTimers(timerPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list timers: %w", err)
+	}
+
+	installed := make(map[string]bool)
+	for _, tc := range tasks {
+		svc, err := taskCommand(tc)
+		if err != nil {
+			return nil, err
+		}
+		timer := &service.TimerService{
+			CommandService: svc,
+			OnCalendar:     tc.Schedule.OnCalendar(),
+			OnBoot:         tc.Schedule.IsReboot(),
+		}
+		if err := sb.InstallTimer(timer); err != nil {
+			return nil, err
+		}
+		installed[svc.Name] = true
+	}
+
+	var removed []string
+	for _, name := range existing {
+		if installed[name] {
+			continue
+		}
+		if err := sb.RemoveTimer(name); err != nil {
+			return removed, err
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
@@ -0,0 +1,281 @@
+package scheduler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule" // the daemon, on the task's schedule
+	TriggerReboot   = "reboot"   // the daemon, at startup for @reboot tasks
+	TriggerManual   = "manual"   // qtools tasks run
+)
+
+// Run statuses
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	StatusTimeout = "timeout"
+	StatusSkipped = "skipped" // the previous run still held the lock
+)
+
+// outputTail bounds how much of a run's output is kept in the history
+const outputTail = 4096
+
+// stopWaitDelay is how long a timed-out task has to exit after SIGINT before it is killed
+const stopWaitDelay = 30 * time.Second
+
+// Run is one execution of a task
+type Run struct {
+	Task     string        `json:"task"`
+	Trigger  string        `json:"trigger"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration_ns"`
+	Status   string        `json:"status"`
+	ExitCode int           `json:"exit_code"`
+	Error    string        `json:"error,omitempty"`
+	Output   string        `json:"output,omitempty"` // End of the combined output
+}
+
+// schedulerDir returns where locks and the run history are kept
+func schedulerDir() string {
+	return filepath.Join(config.GetQtoolsPath(), "scheduler")
+}
+
+// HistoryPath returns the run history file
+func HistoryPath() string {
+	return filepath.Join(schedulerDir(), "history.jsonl")
+}
+
+// lockPath returns a task's lock file
+func lockPath(name string) string {
+	return filepath.Join(schedulerDir(), "locks", name+".lock")
+}
+
+// RunTask runs a task's qtools command with a timeout, unless another run of the task holds its lock
+// The run is appended to the history; the returned error is set when the run did not succeed
+func RunTask(ctx context.Context, cfg *config.Config, tc TaskConfig, trigger string) (Run, error) {
+	run := Run{Task: tc.Name, Trigger: trigger, Start: time.Now()}
+
+	unlock, err := lockTask(tc.Name)
+	if err != nil {
+		run.Status = StatusSkipped
+		run.Error = err.Error()
+		return run, recordRun(cfg, run)
+	}
+	defer unlock()
+
+	executable, err := os.Executable()
+	if err != nil {
+		run.Status = StatusFailed
+		run.Error = fmt.Sprintf("failed to find the qtools binary: %v", err)
+		return run, errors.Join(errors.New(run.Error), recordRun(cfg, run))
+	}
+
+	runCtx := ctx
+	if tc.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, tc.Timeout)
+		defer cancel()
+	}
+
+	// Interrupt rather than kill so updates can clean up, then kill after stopWaitDelay
+	output := &tailBuffer{limit: outputTail}
+	cmd := exec.CommandContext(runCtx, executable, tc.Args...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = stopWaitDelay
+
+	err = cmd.Run()
+	run.Duration = time.Since(run.Start)
+	run.Output = output.String()
+	run.ExitCode = cmd.ProcessState.ExitCode()
+	switch {
+	case err == nil:
+		run.Status = StatusSuccess
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		run.Status = StatusTimeout
+		run.Error = fmt.Sprintf("timed out after %s", tc.Timeout)
+	default:
+		run.Status = StatusFailed
+		run.Error = err.Error()
+	}
+
+	recordErr := recordRun(cfg, run)
+	if run.Status != StatusSuccess {
+		return run, errors.Join(fmt.Errorf("task %s %s: %s", run.Task, run.Status, run.Error), recordErr)
+	}
+	return run, recordErr
+}
+
+// Delay waits a random time of up to the task's jitter, so hosts sharing a schedule spread out
+// It returns early with ctx's error when ctx is done
+func Delay(ctx context.Context, tc TaskConfig) error {
+	if tc.Jitter <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(tc.Jitter))))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// lockTask takes a task's lock without waiting; the returned function releases it
+// The lock is held on an open file, so a run that dies never leaves it behind
+func lockTask(name string) (func(), error) {
+	path := lockPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock %s: %w", path, err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("a previous run of %s is still running", name)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	return func() { file.Close() }, nil
+}
+
+// recordRun appends a run to the history, keeping the last settings.scheduler.history_limit runs
+func recordRun(cfg *config.Config, run Run) error {
+	path := HistoryPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create scheduler directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open run history: %w", err)
+	}
+	defer file.Close()
+
+	// Runs finishing at the same time, e.g., from the daemon and qtools tasks run, take turns
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock run history: %w", err)
+	}
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode run: %w", err)
+	}
+	lines, err := readLines(file)
+	if err != nil {
+		return fmt.Errorf("failed to read run history: %w", err)
+	}
+	lines = append(lines, string(data))
+	if limit := historyLimit(cfg); limit > 0 && len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	if _, err := file.WriteAt([]byte(strings.Join(lines, "\n")+"\n"), 0); err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	return nil
+}
+
+// readLines reads the non-empty lines of a file from its start
+func readLines(file *os.File) ([]string, error) {
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// ReadHistory returns the most recent runs, oldest first, optionally only those of one task
+// A limit of 0 returns every run
+func ReadHistory(task string, limit int) ([]Run, error) {
+	file, err := os.Open(HistoryPath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open run history: %w", err)
+	}
+	defer file.Close()
+
+	lines, err := readLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	var runs []Run
+	for _, line := range lines {
+		var run Run
+		if err := json.Unmarshal([]byte(line), &run); err != nil {
+			continue
+		}
+		if task == "" || run.Task == task {
+			runs = append(runs, run)
+		}
+	}
+	if limit > 0 && len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	return runs, nil
+}
+
+// LastRuns returns each task's most recent run
+func LastRuns() (map[string]Run, error) {
+	runs, err := ReadHistory("", 0)
+	if err != nil {
+		return nil, err
+	}
+	last := make(map[string]Run)
+	for _, run := range runs {
+		last[run.Task] = run
+	}
+	return last, nil
+}
+
+// tailBuffer keeps the last limit bytes written to it
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+// Write implements io.Writer
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+	return len(p), nil
+}
+
+// String returns the kept output
+func (b *tailBuffer) String() string {
+	return strings.TrimSpace(string(b.buf))
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// historyConfig returns a config keeping limit runs, with the history under a temporary QTOOLS_PATH
+func historyConfig(t *testing.T, limit int) *config.Config {
+	t.Helper()
+	t.Setenv("QTOOLS_PATH", t.TempDir())
+	cfg := config.GenerateDefaultConfig()
+	if cfg.Settings == nil {
+		cfg.Settings = &config.SettingsConfig{}
+	}
+	cfg.Settings.Scheduler = &config.SchedulerConfig{HistoryLimit: limit}
+	return cfg
+}
+
+func TestRecordRunTrimsHistory(t *testing.T) {
+	cfg := historyConfig(t, 3)
+
+	// Lines that are not runs are skipped when reading, and dropped once trimmed
+	if err := os.MkdirAll(filepath.Dir(HistoryPath()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(HistoryPath(), []byte("not json\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 3, 6, 10, 0, 0, 0, time.UTC)
+	tasks := []string{"updates.node", "public_ip", "updates.node", "direct_peers", "public_ip"}
+	for i, task := range tasks {
+		run := Run{Task: task, Trigger: TriggerSchedule, Start: start.Add(time.Duration(i) * time.Minute), Status: StatusSuccess}
+		if err := recordRun(cfg, run); err != nil {
+			t.Fatalf("recordRun failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(HistoryPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 {
+		t.Fatalf("history has %d lines, want 3:\n%s", len(lines), data)
+	}
+
+	runs, err := ReadHistory("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 || runs[0].Task != "updates.node" || !runs[0].Start.Equal(start.Add(2*time.Minute)) || runs[2].Task != "public_ip" {
+		t.Fatalf("history = %+v, want the last 3 runs oldest first", runs)
+	}
+
+	if runs, _ := ReadHistory("public_ip", 0); len(runs) != 1 || !runs[0].Start.Equal(start.Add(4*time.Minute)) {
+		t.Errorf("public_ip history = %+v", runs)
+	}
+	if runs, _ := ReadHistory("", 2); len(runs) != 2 || runs[1].Task != "public_ip" {
+		t.Errorf("limited history = %+v", runs)
+	}
+
+	last, err := LastRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last) != 3 || !last["updates.node"].Start.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("last runs = %+v", last)
+	}
+}
+
+func TestReadHistoryMissing(t *testing.T) {
+	t.Setenv("QTOOLS_PATH", t.TempDir())
+	runs, err := ReadHistory("", 0)
+	if err != nil || runs != nil {
+		t.Errorf("ReadHistory = %+v, %v; want no runs and no error", runs, err)
+	}
+}
+
+func TestRunTaskSkipsWhileLocked(t *testing.T) {
+	cfg := historyConfig(t, 0)
+	task, err := FindTask("direct_peers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := LoadTaskConfig(cfg, task)
+
+	unlock, err := lockTask(tc.Name)
+	if err != nil {
+		t.Fatalf("lockTask failed: %v", err)
+	}
+	if _, err := lockTask(tc.Name); err == nil || !strings.Contains(err.Error(), "still running") {
+		t.Errorf("second lockTask error = %v, want the task to be running", err)
+	}
+
+	// The lock is taken before the command starts, so nothing is run here
+	run, err := RunTask(context.Background(), cfg, tc, TriggerManual)
+	if err != nil {
+		t.Fatalf("RunTask failed: %v", err)
+	}
+	if run.Status != StatusSkipped || !strings.Contains(run.Error, "still running") {
+		t.Errorf("run = %+v, want it skipped", run)
+	}
+	runs, err := ReadHistory(tc.Name, 0)
+	if err != nil || len(runs) != 1 || runs[0].Status != StatusSkipped || runs[0].Trigger != TriggerManual {
+		t.Errorf("history = %+v (%v), want the skipped run", runs, err)
+	}
+
+	// Releasing the lock, or the process holding it exiting, frees the task
+	unlock()
+	unlock, err = lockTask(tc.Name)
+	if err != nil {
+		t.Fatalf("lockTask after unlock failed: %v", err)
+	}
+	unlock()
+}
+
+func TestTailBuffer(t *testing.T) {
+	buf := &tailBuffer{limit: 8}
+	buf.Write([]byte("first line\n"))
+	buf.Write([]byte("end\n"))
+	if got := buf.String(); got != "ine\nend" {
+		t.Errorf("tail = %q, want %q", got, "ine\nend")
+	}
+}
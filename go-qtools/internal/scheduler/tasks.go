@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// Scheduler defaults, overridden by settings.scheduler and each task's timeout and jitter
+const (
+	DefaultTimeout      = 30 * time.Minute
+	DefaultJitter       = 30 * time.Second
+	DefaultHistoryLimit = 1000
+)
+
+// Task is a scheduled task: a qtools command run on the schedule in scheduled_tasks.<name>
+type Task struct {
+	Name            string   `json:"name"` // Path under scheduled_tasks, e.g., updates.node
+	Description     string   `json:"description"`
+	Args            []string `json:"args"`             // qtools arguments
+	DefaultSchedule string   `json:"default_schedule"` // Used when cron_expression is empty (matches install-cron.sh)
+}
+
+// Tasks lists the scheduled tasks qtools can run
+// Crash-loop remediation stops, disables or rolls back units, so it has its own enabled key
+// under diagnostics.crash_loop rather than following diagnostics.enabled
+var Tasks = []Task{
+	{Name: "updates.node", Description: "Update the node binary", Args: []string{"node", "update", "--auto"}, DefaultSchedule: "*/10 * * * *"},
+	{Name: "public_ip", Description: "Update announce addresses when the public IP changes", Args: []string{"util", "monitor-public-ip"}, DefaultSchedule: "*/5 * * * *"},
+	{Name: "diagnostics.crash_loop", Description: "Detect and remediate crash loops", Args: []string{"diagnostics", "check-crash-loops"}, DefaultSchedule: "*/10 * * * *"},
+	{Name: "cluster.memory_check", Description: "Restart workers when memory runs low", Args: []string{"diagnostics", "check-memory"}, DefaultSchedule: "0 * * * *"},
+	{Name: "direct_peers", Description: "Sync direct peers from the central server", Args: []string{"node", "config", "direct-peers", "sync"}, DefaultSchedule: "*/5 * * * *"},
+}
+
+// UnsupportedTasks are the scheduled_tasks the bash scripts ran that have no working qtools command yet
+var UnsupportedTasks = []string{
+	"updates.qtools",
+	"updates.system",
+	"backup",
+	"diagnostics",
+	"logs",
+	"statistics",
+	"cluster.auto_reconnect",
+}
+
+// EnabledUnsupportedTasks returns the unsupported tasks enabled in the config, which are not run
+func EnabledUnsupportedTasks(cfg *config.Config) []string {
+	var enabled []string
+	for _, name := range UnsupportedTasks {
+		if schedule := cfg.ScheduledTasks.Task(name); schedule != nil && schedule.Enabled {
+			enabled = append(enabled, name)
+		}
+	}
+	return enabled
+}
+
+// UnsupportedTaskWarning describes an enabled task that qtools does not run
+func UnsupportedTaskWarning(name string) string {
+	return fmt.Sprintf("scheduled_tasks.%s is enabled, but qtools has no command for it yet; it will not run", name)
+}
+
+// FindTask returns the task with the given name
+func FindTask(name string) (Task, error) {
+	for _, task := range Tasks {
+		if task.Name == name {
+			return task, nil
+		}
+	}
+	names := make([]string, len(Tasks))
+	for i, task := range Tasks {
+		names[i] = task.Name
+	}
+	return Task{}, fmt.Errorf("unknown task %q (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// TaskConfig is a task with its settings from the qtools config
+type TaskConfig struct {
+	Task
+	Enabled    bool          `json:"enabled"`
+	Expression string        `json:"cron_expression"`
+	Schedule   *Schedule     `json:"-"`
+	Timeout    time.Duration `json:"timeout_ns"`
+	Jitter     time.Duration `json:"jitter_ns"`
+	Error      string        `json:"error,omitempty"` // Invalid cron expression
+}
+
+// LoadTaskConfig reads a task's settings from scheduled_tasks.<name>
+// An empty cron_expression uses the task's default schedule
+func LoadTaskConfig(cfg *config.Config, task Task) TaskConfig {
+	var schedule config.TaskSchedule
+	if s := cfg.ScheduledTasks.Task(task.Name); s != nil {
+		schedule = *s
+	}
+	var settings config.SchedulerConfig
+	if cfg.Settings != nil && cfg.Settings.Scheduler != nil {
+		settings = *cfg.Settings.Scheduler
+	}
+
+	tc := TaskConfig{
+		Task:       task,
+		Enabled:    schedule.Enabled,
+		Expression: strings.TrimSpace(schedule.CronExpression),
+		Timeout:    config.DurationOr(schedule.Timeout, config.DurationOr(settings.Timeout, DefaultTimeout)),
+		Jitter:     config.DurationOr(schedule.Jitter, config.DurationOr(settings.Jitter, DefaultJitter)),
+	}
+	if tc.Expression == "" {
+		tc.Expression = task.DefaultSchedule
+	}
+	parsed, err := ParseSchedule(tc.Expression)
+	if err != nil {
+		tc.Error = err.Error()
+	} else {
+		tc.Schedule = parsed
+	}
+	return tc
+}
+
+// LoadTaskConfigs reads every task's settings
+func LoadTaskConfigs(cfg *config.Config) []TaskConfig {
+	configs := make([]TaskConfig, len(Tasks))
+	for i, task := range Tasks {
+		configs[i] = LoadTaskConfig(cfg, task)
+	}
+	return configs
+}
+
+// historyLimit returns settings.scheduler.history_limit; 0 or unset uses the default
+func historyLimit(cfg *config.Config) int {
+	if cfg.Settings != nil && cfg.Settings.Scheduler != nil && cfg.Settings.Scheduler.HistoryLimit > 0 {
+		return cfg.Settings.Scheduler.HistoryLimit
+	}
+	return DefaultHistoryLimit
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// Service backends selectable with service.backend
+const (
+	BackendAuto        = "auto"
+	BackendSystemd     = "systemd"
+	BackendSystemdUser = "systemd-user"
+	BackendLaunchd     = "launchd"
+	BackendOpenRC      = "openrc"
+	BackendRunit       = "runit"
+	BackendSupervisor  = "supervisor"
+)
+
+var (
+	sudoOnce      sync.Once
+	sudoAvailable bool
+
+	backendMu       sync.RWMutex
+	backendOverride ServiceBackend
+)
+
+// SetServiceBackend makes GetServiceBackend return backend whatever service.backend says, and
+// returns the previous override; tests use it to run service operations against a fake
+// Passing nil restores the configured backend
+func SetServiceBackend(backend ServiceBackend) ServiceBackend {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	previous := backendOverride
+	backendOverride = backend
+	return previous
+}
+
+// GetServiceBackend returns the service backend from service.backend, detecting one for
+// the platform when it is unset or auto
+func GetServiceBackend(cfg *config.Config) (ServiceBackend, error) {
+	backendMu.RLock()
+	override := backendOverride
+	backendMu.RUnlock()
+	if override != nil {
+		return override, nil
+	}
+
+	backend := ""
+	if cfg != nil && cfg.Service != nil {
+		backend = strings.TrimSpace(cfg.Service.Backend)
+	}
+
+	switch backend {
+	case "", BackendAuto:
+		return detectServiceBackend(cfg)
+	case BackendSystemd:
+		return NewSystemdBackend(), nil
+	case BackendSystemdUser:
+		return NewSystemdUserBackend(), nil
+	case BackendLaunchd:
+		return NewLaunchdBackend(), nil
+	case BackendOpenRC:
+		return NewOpenRCBackend(), nil
+	case BackendRunit:
+		return NewRunitBackend(), nil
+	case BackendSupervisor:
+		return NewSupervisorBackend(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown service.backend %q (expected auto, systemd, systemd-user, launchd, openrc, runit or supervisor)", backend)
+	}
+}
+
+// detectServiceBackend picks the backend for the running init system
+// Without one (e.g., containers) the built-in supervisor is used; systemd units go to the
+// user's systemd instance when sudo is not available
+func detectServiceBackend(cfg *config.Config) (ServiceBackend, error) {
+	switch DetectInitSystem() {
+	case InitSystemd:
+		if !canSudo() {
+			return NewSystemdUserBackend(), nil
+		}
+		return NewSystemdBackend(), nil
+	case InitOpenRC:
+		return NewOpenRCBackend(), nil
+	case InitRunit:
+		return NewRunitBackend(), nil
+	case InitLaunchd:
+		return NewLaunchdBackend(), nil
+	}
+
+	if platform := DetectPlatform(); platform != PlatformLinux {
+		return nil, fmt.Errorf("unsupported platform: %s", platform)
+	}
+	return NewSupervisorBackend(cfg), nil
+}
+
+// canSudo reports whether system units can be managed: running as root or passwordless sudo
+// The result is cached, since sudo -n may be slow to fail (e.g., with an LDAP sudoers source)
+func canSudo() bool {
+	sudoOnce.Do(func() {
+		if os.Geteuid() == 0 {
+			sudoAvailable = true
+			return
+		}
+		if _, err := exec.LookPath("sudo"); err != nil {
+			return
+		}
+		sudoAvailable = exec.Command("sudo", "-n", "true").Run() == nil
+	})
+	return sudoAvailable
+}
+
+// sudoCommand builds a command run with sudo
+func sudoCommand(name string, args ...string) *exec.Cmd {
+	return exec.Command("sudo", append([]string{name}, args...)...)
+}
+
+// PrivilegedCommand returns how the service backend runs commands that modify the node
+// installation: without sudo for user-level backends (systemd --user, launchd agents, the
+// built-in supervisor) or when running as root, with sudo otherwise
+func PrivilegedCommand(cfg *config.Config) func(name string, args ...string) *exec.Cmd {
+	backend, err := GetServiceBackend(cfg)
+	if err == nil {
+		switch b := backend.(type) {
+		case *SystemdBackend:
+			return b.privileged
+		case *LaunchdBackend, *SupervisorBackend:
+			return exec.Command
+		}
+	}
+	if os.Geteuid() == 0 {
+		return exec.Command
+	}
+	return sudoCommand
+}
+
+// installFile copies content to path with the given mode, creating the directory
+// privileged builds the commands, e.g. with sudo for system directories
+func installFile(privileged func(name string, args ...string) *exec.Cmd, path string, content []byte, mode string) error {
+	tmpFile, err := os.CreateTemp("", "qtools-unit-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	tmpFile.Close()
+
+	if output, err := privileged("mkdir", "-p", filepath.Dir(path)).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create %s: %w\nOutput: %s", filepath.Dir(path), err, string(output))
+	}
+	if output, err := privileged("install", "-m", mode, tmpFile.Name(), path).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to install %s: %w\nOutput: %s", path, err, string(output))
+	}
+	return nil
+}
+
+// LingerEnabled reports whether the current user's systemd instance runs without a login session
+// Without lingering, user units stop at logout and do not start on boot
+func LingerEnabled() (bool, string, error) {
+	current, err := user.Current()
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	if _, err := os.Stat(filepath.Join("/var/lib/systemd/linger", current.Username)); err == nil {
+		return true, current.Username, nil
+	}
+
+	output, err := exec.Command("loginctl", "show-user", current.Username, "-p", "Linger", "--value").Output()
+	if err != nil {
+		// No session or no logind: the linger file above is authoritative
+		return false, current.Username, nil
+	}
+	return strings.TrimSpace(string(output)) == "yes", current.Username, nil
+}
+
+// LingerWarning returns a warning when services run in the user's systemd instance without
+// lingering enabled, or "" otherwise
+func LingerWarning(cfg *config.Config) string {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return ""
+	}
+	if sb, ok := backend.(*SystemdBackend); !ok || !sb.IsUser() {
+		return ""
+	}
+
+	enabled, username, err := LingerEnabled()
+	if err != nil || enabled {
+		return ""
+	}
+	return fmt.Sprintf("lingering is not enabled for %s; services will stop at logout and not start on boot (run: sudo loginctl enable-linger %s)", username, username)
+}
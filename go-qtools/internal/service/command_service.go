@@ -0,0 +1,393 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/supervisor"
+)
+
+// Command service restart delay and stop timeout, in seconds
+// Stopping waits for running work, e.g., a scheduled node update, up to the stop timeout
+const (
+	commandServiceRestartDelay = 10
+	commandServiceStopTimeout  = 3600
+)
+
+// commandServiceEnv lists the environment passed on to command services so they use the same
+// qtools and node paths as the command that installed them
+var commandServiceEnv = []string{"QTOOLS_PATH", "QTOOLS_CONFIG_FILE", "QUIL_NODE_PATH", "QUIL_CLIENT_PATH", "QUIL_CONFIG_FILE", "PATH"}
+
+// CommandService is a long-running qtools command run as a service, e.g., the scheduler daemon
+// Unlike the node services it runs as the service manager's user (root for system services)
+type CommandService struct {
+	Name        string
+	Description string
+	Command     []string
+	WorkingDir  string
+	Env         []string // KEY=VALUE
+}
+
+// NewCommandService creates a service running qtools with the given arguments
+func NewCommandService(name, description string, args ...string) (*CommandService, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the qtools binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	svc := &CommandService{
+		Name:        name,
+		Description: description,
+		Command:     append([]string{executable}, args...),
+		WorkingDir:  config.GetQtoolsPath(),
+	}
+	if !dirExists(svc.WorkingDir) {
+		svc.WorkingDir = "/"
+	}
+	for _, key := range commandServiceEnv {
+		if value := os.Getenv(key); value != "" {
+			svc.Env = append(svc.Env, key+"="+value)
+		}
+	}
+	sort.Strings(svc.Env)
+	return svc, nil
+}
+
+// CommandServiceBackend is implemented by backends that can run a qtools command as a service
+type CommandServiceBackend interface {
+	RenderCommandService(svc *CommandService) ([]byte, error)
+	InstallCommandService(svc *CommandService) error
+	RemoveCommandService(name string) error
+}
+
+// InstallCommandService installs, enables and (re)starts a command service
+func InstallCommandService(cfg *config.Config, svc *CommandService, start bool) error {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return err
+	}
+	cb, ok := backend.(CommandServiceBackend)
+	if !ok {
+		return fmt.Errorf("this service backend cannot run qtools commands as services")
+	}
+
+	if err := cb.InstallCommandService(svc); err != nil {
+		return err
+	}
+	if err := backend.EnableService(svc.Name); err != nil {
+		return err
+	}
+	if !start {
+		return nil
+	}
+	return backend.RestartService(svc.Name)
+}
+
+// RemoveCommandService stops, disables and removes a command service
+func RemoveCommandService(cfg *config.Config, name string) error {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return err
+	}
+	cb, ok := backend.(CommandServiceBackend)
+	if !ok {
+		return fmt.Errorf("this service backend cannot run qtools commands as services")
+	}
+
+	// The service may already be stopped or disabled
+	backend.StopService(name)
+	backend.DisableService(name)
+	return cb.RemoveCommandService(name)
+}
+
+// CommandServiceFilePath returns where a command service's file is installed
+func CommandServiceFilePath(cfg *config.Config, name string) (string, error) {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return "", err
+	}
+	return backend.ServiceFilePath(name), nil
+}
+
+// renderCommandTemplate executes a command service template
+func renderCommandTemplate(name, text string, data interface{}) ([]byte, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to generate service file content: %w", err)
+	}
+	return []byte(buf.String()), nil
+}
+
+// removePath deletes a file or directory, ignoring one that does not exist
+func removePath(privileged bool, path string) error {
+	if !privileged {
+		if err := os.RemoveAll(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+	if output, err := sudoCommand("rm", "-rf", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to remove %s: %w\nOutput: %s", path, err, string(output))
+	}
+	return nil
+}
+
+// RenderCommandService returns the unit file for a command service
+func (sb *SystemdBackend) RenderCommandService(svc *CommandService) ([]byte, error) {
+	wantedBy := "multi-user.target"
+	if sb.user {
+		wantedBy = "default.target"
+	}
+	return renderCommandTemplate("systemd-command", systemdCommandServiceTemplate, struct {
+		*CommandService
+		ExecStart    string
+		RestartDelay int
+		StopTimeout  int
+		WantedBy     string
+	}{svc, shellJoin(svc.Command), commandServiceRestartDelay, commandServiceStopTimeout, wantedBy})
+}
+
+// InstallCommandService writes a command service's unit file
+func (sb *SystemdBackend) InstallCommandService(svc *CommandService) error {
+	content, err := sb.RenderCommandService(svc)
+	if err != nil {
+		return err
+	}
+	if err := installFile(sb.privileged, sb.ServiceFilePath(svc.Name), content, "0644"); err != nil {
+		return err
+	}
+	return sb.daemonReload()
+}
+
+// RemoveCommandService deletes a command service's unit file
+func (sb *SystemdBackend) RemoveCommandService(name string) error {
+	if err := removePath(!sb.user, sb.ServiceFilePath(name)); err != nil {
+		return err
+	}
+	return sb.daemonReload()
+}
+
+// RenderCommandService returns the init.d script for a command service
+func (ob *OpenRCBackend) RenderCommandService(svc *CommandService) ([]byte, error) {
+	var env []string
+	for _, entry := range svc.Env {
+		key, value, _ := strings.Cut(entry, "=")
+		env = append(env, key+"="+shellQuote(value))
+	}
+	return renderCommandTemplate("openrc-command", openrcCommandServiceTemplate, struct {
+		*CommandService
+		Header       string
+		CommandPath  string
+		CommandArgs  string
+		LogFile      string
+		RestartDelay int
+		StopTimeout  int
+		EnvVars      []string
+	}{svc, generatedScriptHeader, svc.Command[0], shellJoin(svc.Command[1:]), ob.LogFile(svc.Name), commandServiceRestartDelay, commandServiceStopTimeout, env})
+}
+
+// InstallCommandService writes a command service's init.d script
+func (ob *OpenRCBackend) InstallCommandService(svc *CommandService) error {
+	content, err := ob.RenderCommandService(svc)
+	if err != nil {
+		return err
+	}
+	return installFile(sudoCommand, ob.ServiceFilePath(svc.Name), content, "0755")
+}
+
+// RemoveCommandService deletes a command service's init.d script
+func (ob *OpenRCBackend) RemoveCommandService(name string) error {
+	return removePath(true, ob.ServiceFilePath(name))
+}
+
+// RenderCommandService returns the run script for a command service
+func (rb *RunitBackend) RenderCommandService(svc *CommandService) ([]byte, error) {
+	var env []string
+	for _, entry := range svc.Env {
+		key, value, _ := strings.Cut(entry, "=")
+		env = append(env, key+"="+shellQuote(value))
+	}
+	return renderCommandTemplate("runit-command", runitCommandServiceTemplate, struct {
+		*CommandService
+		Header  string
+		Exec    string
+		EnvVars []string
+	}{svc, generatedScriptHeader, shellJoin(svc.Command), env})
+}
+
+// InstallCommandService writes a command service's scripts and links it into the runsvdir
+func (rb *RunitBackend) InstallCommandService(svc *CommandService) error {
+	run, err := rb.RenderCommandService(svc)
+	if err != nil {
+		return err
+	}
+
+	dir := rb.serviceDir(svc.Name)
+	if !dirExists(dir) {
+		if err := installFile(sudoCommand, filepath.Join(dir, "down"), nil, "0644"); err != nil {
+			return err
+		}
+	}
+	scripts := map[string]string{
+		"run":     string(run),
+		"finish":  fmt.Sprintf(runitFinishTemplate, commandServiceRestartDelay),
+		"log/run": fmt.Sprintf(runitLogTemplate, filepath.Join("/var/log", svc.Name), filepath.Join("/var/log", svc.Name)),
+	}
+	for _, file := range sortedKeys(scripts) {
+		if err := installFile(sudoCommand, filepath.Join(dir, file), []byte(scripts[file]), "0755"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Lstat(rb.linkPath(svc.Name)); err != nil {
+		output, err := sudoCommand("ln", "-sfn", dir, rb.linkPath(svc.Name)).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to link %s into %s: %w\nOutput: %s", dir, runitRunsvDir(), err, string(output))
+		}
+	}
+	return nil
+}
+
+// RemoveCommandService unlinks a command service from the runsvdir and deletes its directory
+func (rb *RunitBackend) RemoveCommandService(name string) error {
+	if err := removePath(true, rb.linkPath(name)); err != nil {
+		return err
+	}
+	return removePath(true, rb.serviceDir(name))
+}
+
+// RenderCommandService returns the plist for a command service
+func (lb *LaunchdBackend) RenderCommandService(svc *CommandService) ([]byte, error) {
+	env := make(map[string]string, len(svc.Env))
+	for _, entry := range svc.Env {
+		key, value, _ := strings.Cut(entry, "=")
+		env[key] = value
+	}
+	logFile := filepath.Join(config.GetQtoolsPath(), svc.Name+".log")
+	return generatePlistXML(&PlistConfig{
+		Label:                fmt.Sprintf("com.quilibrium.%s", svc.Name),
+		ProgramArguments:     svc.Command,
+		RunAtLoad:            true,
+		KeepAlive:            true,
+		WorkingDirectory:     svc.WorkingDir,
+		StandardOutPath:      logFile,
+		StandardErrorPath:    logFile,
+		EnvironmentVariables: env,
+		ThrottleInterval:     commandServiceRestartDelay,
+	})
+}
+
+// InstallCommandService writes a command service's plist
+func (lb *LaunchdBackend) InstallCommandService(svc *CommandService) error {
+	content, err := lb.RenderCommandService(svc)
+	if err != nil {
+		return err
+	}
+	path := lb.getPlistPath(svc.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create plist directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write plist file: %w", err)
+	}
+	return nil
+}
+
+// RemoveCommandService deletes a command service's plist
+func (lb *LaunchdBackend) RemoveCommandService(name string) error {
+	return removePath(false, lb.getPlistPath(name))
+}
+
+// RenderCommandService returns the supervisor spec for a command service
+func (b *SupervisorBackend) RenderCommandService(svc *CommandService) ([]byte, error) {
+	return supervisor.RenderSpec(&supervisor.Spec{
+		Name:         svc.Name,
+		Command:      svc.Command,
+		WorkingDir:   svc.WorkingDir,
+		Env:          svc.Env,
+		Restart:      supervisor.RestartAlways,
+		RestartDelay: fmt.Sprintf("%ds", commandServiceRestartDelay),
+	})
+}
+
+// InstallCommandService writes a command service's supervisor spec
+func (b *SupervisorBackend) InstallCommandService(svc *CommandService) error {
+	content, err := b.RenderCommandService(svc)
+	if err != nil {
+		return err
+	}
+	return b.paths.WriteSpec(svc.Name, content)
+}
+
+// RemoveCommandService deletes a command service's supervisor spec
+func (b *SupervisorBackend) RemoveCommandService(name string) error {
+	return removePath(false, b.paths.SpecPath(name))
+}
+
+// systemdCommandServiceTemplate runs a qtools command
+// Only the main process gets SIGINT (KillMode=mixed), so it can let running work finish
+const systemdCommandServiceTemplate = `[Unit]
+Description={{.Description}}
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+WorkingDirectory={{.WorkingDir}}
+{{- range .Env}}
+Environment="{{.}}"
+{{- end}}
+ExecStart={{.ExecStart}}
+Restart=always
+RestartSec={{.RestartDelay}}
+KillSignal=SIGINT
+KillMode=mixed
+TimeoutStopSec={{.StopTimeout}}
+
+[Install]
+WantedBy={{.WantedBy}}
+`
+
+// openrcCommandServiceTemplate runs a qtools command under supervise-daemon
+const openrcCommandServiceTemplate = `#!/sbin/openrc-run
+{{.Header}}
+description="{{.Description}}"
+
+supervisor=supervise-daemon
+command={{.CommandPath}}
+command_args="{{.CommandArgs}}"
+directory={{.WorkingDir}}
+output_log={{.LogFile}}
+error_log={{.LogFile}}
+respawn_delay={{.RestartDelay}}
+respawn_max=0
+retry="SIGINT/{{.StopTimeout}}/SIGKILL/5"
+{{- range .EnvVars}}
+export {{.}}
+{{- end}}
+
+depend() {
+	need net
+}
+`
+
+// runitCommandServiceTemplate runs a qtools command
+const runitCommandServiceTemplate = `#!/bin/sh
+{{.Header}}exec 2>&1
+cd {{.WorkingDir}} || exit 1
+exec {{if .EnvVars}}env{{range .EnvVars}} {{.}}{{end}} {{end}}{{.Exec}}
+`
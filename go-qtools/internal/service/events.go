@@ -0,0 +1,460 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// Service event types
+const (
+	EventStart   = "start"
+	EventStop    = "stop"
+	EventExit    = "exit"    // Exited with status 0 without a stop request
+	EventCrash   = "crash"   // Exited non-zero or was killed by a signal without a stop request
+	EventOOM     = "oom"     // A process of the unit was killed by the OOM killer
+	EventRestart = "restart" // The service manager scheduled a restart
+)
+
+// systemd journal message IDs (see systemd's catalog/systemd.catalog)
+const (
+	messageUnitStarted          = "39f53479d3a045ac8e11786248231fbf"
+	messageUnitStopping         = "de5b426a63be47a7b6ac3eaac82e2f6f"
+	messageUnitStopped          = "9d1aaa27d60140bd96365438aad20286"
+	messageUnitProcessExit      = "98e322203f7a4ed290d09fe03c09fe15"
+	messageUnitFailureResult    = "d9b373ed55a64feb8242e02dbe79a49c"
+	messageUnitRestartScheduled = "5eb03494b6584870a536b337290809b3"
+	messageUnitOutOfMemory      = "fe6faa94e7774663a0da52717891d8ef"
+)
+
+var (
+	// processExitPattern matches "Main process exited, code=killed, status=9/KILL"
+	processExitPattern = regexp.MustCompile(`code=(\w+), status=(\d+)(?:/(\w+))?`)
+	// failureResultPattern matches "Failed with result 'oom-kill'."
+	failureResultPattern = regexp.MustCompile(`Failed with result '([\w-]+)'`)
+	// restartCounterPattern matches "Scheduled restart job, restart counter is at 5."
+	restartCounterPattern = regexp.MustCompile(`restart counter is at (\d+)`)
+)
+
+// ServiceEvent is a lifecycle event of the master or a worker, extracted from the journal
+type ServiceEvent struct {
+	Time        time.Time `json:"time"`
+	Unit        string    `json:"unit"`
+	WorkerIndex int       `json:"worker_index"` // 0 for the master
+	Type        string    `json:"type"`
+	ExitCode    int       `json:"exit_code,omitempty"`
+	Signal      string    `json:"signal,omitempty"`
+	Result      string    `json:"result,omitempty"`  // Unit result after a crash, e.g., exit-code, signal or oom-kill
+	Restart     int       `json:"restart,omitempty"` // Restart counter: of a restart event, or of the restart that followed a crash or exit
+	Message     string    `json:"message"`
+}
+
+// Detail describes the event for display, e.g., "exit 3 (exit-code), restart #4"
+func (e *ServiceEvent) Detail() string {
+	var parts []string
+	switch e.Type {
+	case EventCrash:
+		detail := fmt.Sprintf("exit %d", e.ExitCode)
+		if e.Signal != "" {
+			detail = "signal " + e.Signal
+		}
+		if e.Result != "" {
+			detail += " (" + e.Result + ")"
+		}
+		parts = append(parts, detail)
+	case EventOOM:
+		parts = append(parts, "killed by the OOM killer")
+	}
+	if e.Restart > 0 {
+		parts = append(parts, fmt.Sprintf("restart #%d", e.Restart))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// EventSummary counts a service's events
+type EventSummary struct {
+	Unit        string    `json:"unit"`
+	WorkerIndex int       `json:"worker_index"`
+	Starts      int       `json:"starts"`
+	Stops       int       `json:"stops"`
+	Exits       int       `json:"exits"`
+	Crashes     int       `json:"crashes"`
+	OOMKills    int       `json:"oom_kills"`
+	Restarts    int       `json:"restarts"`
+	LastCrash   time.Time `json:"last_crash,omitempty"`
+}
+
+// EventOptions selects the events to load
+type EventOptions struct {
+	WorkerIndex int       // Only this worker; 0 for the master and every worker
+	Since       time.Time // Zero for the whole journal
+	File        string    // Read journalctl -o json output from this file instead of the journal
+}
+
+// EventSource returns journal entries, oldest first
+type EventSource interface {
+	// Entries returns the entries about units (glob patterns such as ceremonyclient-worker@*.service) since the given time
+	Entries(units []string, since time.Time) ([]JournalEntry, error)
+}
+
+// JournalEntry is one entry of journalctl -o json output
+type JournalEntry map[string]interface{}
+
+// Field returns a field as a string; journald exports non-UTF-8 values as byte arrays
+func (e JournalEntry) Field(name string) string {
+	switch value := e[name].(type) {
+	case string:
+		return value
+	case []interface{}:
+		b := make([]byte, 0, len(value))
+		for _, v := range value {
+			if n, ok := v.(float64); ok {
+				b = append(b, byte(n))
+			}
+		}
+		return string(b)
+	}
+	return ""
+}
+
+// Time returns the entry's realtime timestamp
+func (e JournalEntry) Time() time.Time {
+	usec, err := strconv.ParseInt(e.Field("__REALTIME_TIMESTAMP"), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMicro(usec)
+}
+
+// Unit returns the unit a service manager message is about, or "" for other messages
+func (e JournalEntry) Unit() string {
+	if unit := e.Field("UNIT"); unit != "" {
+		return unit
+	}
+	return e.Field("USER_UNIT")
+}
+
+// JournalSource reads entries with journalctl
+type JournalSource struct {
+	User bool // Read the user's journal (systemd-user backend)
+}
+
+// Entries runs journalctl -o json for the service manager's messages about the units
+func (s *JournalSource) Entries(units []string, since time.Time) ([]JournalEntry, error) {
+	args := []string{"-o", "json", "--no-pager", "-q"}
+	if s.User {
+		args = append(args, "--user")
+	}
+	args = append(args, journalMatches(units, s.User)...)
+	if !since.IsZero() {
+		args = append(args, "--since", since.Format("2006-01-02 15:04:05"))
+	}
+
+	output, err := exec.Command("journalctl", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("failed to read the journal: %w\nOutput: %s", err, string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("failed to read the journal: %w", err)
+	}
+	return parseJournalEntries(bytes.NewReader(output), units, since)
+}
+
+// journalMatches returns journalctl matches selecting the service manager's messages about units
+// Unlike -u, they skip the node's own output, which is logged under _SYSTEMD_UNIT and would
+// otherwise be exported in full. Matches cannot be globs, so with a glob pattern only the
+// manager's messages are selected and parseJournalEntries picks the units
+func journalMatches(units []string, user bool) []string {
+	manager, field := "_PID=1", "UNIT"
+	if user {
+		manager, field = "_COMM=systemd", "USER_UNIT"
+	}
+	matches := []string{manager}
+	for _, unit := range units {
+		if strings.ContainsAny(unit, "*?[") {
+			return []string{manager}
+		}
+		matches = append(matches, field+"="+unit)
+	}
+	return matches
+}
+
+// FileSource reads entries saved with journalctl -o json, e.g., from another host or for testing
+type FileSource struct {
+	Path string
+}
+
+// Entries reads the file, keeping entries about the units since the given time
+func (s *FileSource) Entries(units []string, since time.Time) ([]JournalEntry, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", s.Path, err)
+	}
+	defer file.Close()
+	return parseJournalEntries(file, units, since)
+}
+
+// parseJournalEntries decodes journalctl -o json lines, keeping service manager messages about the units
+func parseJournalEntries(r io.Reader, units []string, since time.Time) ([]JournalEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var entries []JournalEntry
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("invalid journal entry on line %d: %w", line, err)
+		}
+		if !since.IsZero() && entry.Time().Before(since) {
+			continue
+		}
+		if matchUnit(entry.Unit(), units) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal entries: %w", err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time().Before(entries[j].Time())
+	})
+	return entries, nil
+}
+
+// matchUnit reports whether unit matches one of the patterns
+func matchUnit(unit string, patterns []string) bool {
+	if unit == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, unit); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// eventUnits returns the unit patterns for the master and workers, or for one worker
+func eventUnits(serviceName string, workerIndex int) []string {
+	if workerIndex > 0 {
+		return []string{fmt.Sprintf("%s-worker@%d.service", serviceName, workerIndex)}
+	}
+	return []string{serviceName + ".service", serviceName + "-worker@*.service"}
+}
+
+// eventSource returns the journal of the configured backend, or the file from opts
+func eventSource(cfg *config.Config, opts EventOptions) (EventSource, error) {
+	if opts.File != "" {
+		return &FileSource{Path: opts.File}, nil
+	}
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sb, ok := backend.(*SystemdBackend)
+	if !ok {
+		return nil, fmt.Errorf("service events are read from the systemd journal, which this service backend does not use (pass --file with journalctl -o json output)")
+	}
+	return &JournalSource{User: sb.IsUser()}, nil
+}
+
+// LoadServiceEvents loads the master's and workers' lifecycle events, oldest first
+func LoadServiceEvents(cfg *config.Config, opts EventOptions) ([]ServiceEvent, error) {
+	source, err := eventSource(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	serviceName := getServiceName(cfg)
+	entries, err := source.Entries(eventUnits(serviceName, opts.WorkerIndex), opts.Since)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractServiceEvents(serviceName, entries), nil
+}
+
+// ExtractServiceEvents turns service manager messages into events
+// Exits while a stop is in progress are part of the stop; a crash or exit is linked to
+// the restart scheduled after it, and an oom-kill result is recorded on the crash
+func ExtractServiceEvents(serviceName string, entries []JournalEntry) []ServiceEvent {
+	var events []ServiceEvent
+	stopping := make(map[string]bool)
+	lastExit := make(map[string]int) // Index in events of the unit's last crash or exit not yet restarted
+
+	for _, entry := range entries {
+		unit := entry.Unit()
+		message := entry.Field("MESSAGE")
+		event := ServiceEvent{
+			Time:    entry.Time(),
+			Unit:    unit,
+			Message: message,
+		}
+		if index, ok := workerInstanceIndex(serviceName, unit); ok {
+			event.WorkerIndex = index
+		}
+
+		switch classifyEntry(entry.Field("MESSAGE_ID"), entry.Field("JOB_TYPE"), message) {
+		case messageUnitStarted:
+			stopping[unit] = false
+			delete(lastExit, unit)
+			event.Type = EventStart
+		case messageUnitStopping:
+			stopping[unit] = true
+			continue
+		case messageUnitStopped:
+			stopping[unit] = false
+			event.Type = EventStop
+		case messageUnitProcessExit:
+			match := processExitPattern.FindStringSubmatch(message)
+			if match == nil || stopping[unit] {
+				continue
+			}
+			code, _ := strconv.Atoi(match[2])
+			if match[1] == "exited" {
+				event.ExitCode = code
+				event.Type = EventExit
+				if code != 0 {
+					event.Type = EventCrash
+				}
+			} else {
+				// killed or dumped: status is the signal
+				event.Type = EventCrash
+				event.ExitCode = 128 + code
+				event.Signal = match[3]
+				if event.Signal == "" {
+					event.Signal = match[2]
+				}
+			}
+			lastExit[unit] = len(events)
+		case messageUnitFailureResult:
+			if i, ok := lastExit[unit]; ok {
+				if match := failureResultPattern.FindStringSubmatch(message); match != nil {
+					events[i].Result = match[1]
+				} else {
+					events[i].Result = entry.Field("UNIT_RESULT")
+				}
+			}
+			continue
+		case messageUnitOutOfMemory:
+			event.Type = EventOOM
+		case messageUnitRestartScheduled:
+			event.Type = EventRestart
+			event.Restart, _ = strconv.Atoi(entry.Field("N_RESTARTS"))
+			if match := restartCounterPattern.FindStringSubmatch(message); match != nil && event.Restart == 0 {
+				event.Restart, _ = strconv.Atoi(match[1])
+			}
+			if i, ok := lastExit[unit]; ok {
+				events[i].Restart = event.Restart
+				delete(lastExit, unit)
+			}
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// classifyEntry returns the message ID for a service manager message, falling back to
+// the message text for journals exported without MESSAGE_ID
+func classifyEntry(messageID, jobType, message string) string {
+	switch messageID {
+	case messageUnitStarted:
+		// Older systemd versions log both job completions with this ID
+		if jobType == "stop" {
+			return messageUnitStopped
+		}
+		return messageID
+	case messageUnitStopping, messageUnitStopped, messageUnitProcessExit, messageUnitFailureResult,
+		messageUnitRestartScheduled, messageUnitOutOfMemory:
+		return messageID
+	}
+
+	switch {
+	case strings.HasPrefix(message, "Started "):
+		return messageUnitStarted
+	case strings.HasPrefix(message, "Stopping "):
+		return messageUnitStopping
+	case strings.HasPrefix(message, "Stopped "):
+		return messageUnitStopped
+	case strings.Contains(message, "Main process exited, code="):
+		return messageUnitProcessExit
+	case strings.Contains(message, "Failed with result '"):
+		return messageUnitFailureResult
+	case strings.Contains(message, "Scheduled restart job"):
+		return messageUnitRestartScheduled
+	case strings.Contains(message, "killed by the OOM killer"):
+		return messageUnitOutOfMemory
+	}
+	return ""
+}
+
+// SummarizeEvents counts events per service, master first, then workers by index
+func SummarizeEvents(events []ServiceEvent) []EventSummary {
+	byUnit := make(map[string]*EventSummary)
+	for _, event := range events {
+		summary := byUnit[event.Unit]
+		if summary == nil {
+			summary = &EventSummary{Unit: event.Unit, WorkerIndex: event.WorkerIndex}
+			byUnit[event.Unit] = summary
+		}
+		switch event.Type {
+		case EventStart:
+			summary.Starts++
+		case EventStop:
+			summary.Stops++
+		case EventExit:
+			summary.Exits++
+		case EventCrash:
+			summary.Crashes++
+			summary.LastCrash = event.Time
+		case EventOOM:
+			summary.OOMKills++
+		case EventRestart:
+			summary.Restarts++
+		}
+	}
+
+	summaries := make([]EventSummary, 0, len(byUnit))
+	for _, summary := range byUnit {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].WorkerIndex != summaries[j].WorkerIndex {
+			return summaries[i].WorkerIndex < summaries[j].WorkerIndex
+		}
+		return summaries[i].Unit < summaries[j].Unit
+	})
+	return summaries
+}
+
+// WorkerCrashCounts returns crashes per worker index since the given time
+func WorkerCrashCounts(cfg *config.Config, since time.Time) (map[int]int, error) {
+	events, err := LoadServiceEvents(cfg, EventOptions{Since: since})
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int)
+	for _, event := range events {
+		if event.Type == EventCrash && event.WorkerIndex > 0 {
+			counts[event.WorkerIndex]++
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// journalFixture is journalctl -o json output for the master and two workers, with node
+// output and another unit's messages mixed in
+const journalFixture = "testdata/journal.json"
+
+// journalStart is the time of the master's start in the fixture
+var journalStart = time.UnixMicro(1760000000000000)
+
+func TestFileSourceEntries(t *testing.T) {
+	source := &FileSource{Path: journalFixture}
+
+	tests := []struct {
+		name  string
+		units []string
+		since time.Time
+		want  int
+	}{
+		{name: "master and workers", units: eventUnits("ceremonyclient", 0), want: 15},
+		{name: "one worker", units: eventUnits("ceremonyclient", 2), want: 4},
+		{name: "since", units: eventUnits("ceremonyclient", 0), since: journalStart.Add(20 * time.Second), want: 8},
+		{name: "other service", units: eventUnits("qtest", 0), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := source.Entries(tt.units, tt.since)
+			if err != nil {
+				t.Fatalf("Entries failed: %v", err)
+			}
+			if len(entries) != tt.want {
+				t.Fatalf("got %d entries, want %d", len(entries), tt.want)
+			}
+			for i, entry := range entries {
+				if entry.Field("_COMM") != "systemd" {
+					t.Errorf("entry %d is not a service manager message: %v", i, entry)
+				}
+				if i > 0 && entry.Time().Before(entries[i-1].Time()) {
+					t.Errorf("entry %d is out of order", i)
+				}
+				if !tt.since.IsZero() && entry.Time().Before(tt.since) {
+					t.Errorf("entry %d is before %s", i, tt.since)
+				}
+			}
+		})
+	}
+}
+
+func TestFileSourceMissingFile(t *testing.T) {
+	source := &FileSource{Path: "testdata/missing.json"}
+	if _, err := source.Entries(eventUnits("ceremonyclient", 0), time.Time{}); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+func TestLoadServiceEventsFromFile(t *testing.T) {
+	cfg := &config.Config{Service: &config.ServiceConfig{FileName: "ceremonyclient"}}
+	events, err := LoadServiceEvents(cfg, EventOptions{File: journalFixture, Since: journalStart})
+	if err != nil {
+		t.Fatalf("LoadServiceEvents failed: %v", err)
+	}
+
+	type eventKey struct {
+		Offset      time.Duration
+		WorkerIndex int
+		Type        string
+		ExitCode    int
+		Signal      string
+		Result      string
+		Restart     int
+	}
+	want := []eventKey{
+		{Offset: 0, WorkerIndex: 0, Type: EventStart},
+		{Offset: 2 * time.Second, WorkerIndex: 1, Type: EventStart},
+		{Offset: 10 * time.Second, WorkerIndex: 1, Type: EventCrash, ExitCode: 1, Result: "exit-code", Restart: 1},
+		{Offset: 15 * time.Second, WorkerIndex: 1, Type: EventRestart, Restart: 1},
+		{Offset: 15 * time.Second, WorkerIndex: 1, Type: EventStart},
+		{Offset: 20 * time.Second, WorkerIndex: 2, Type: EventOOM},
+		{Offset: 20 * time.Second, WorkerIndex: 2, Type: EventCrash, ExitCode: 137, Signal: "KILL", Result: "oom-kill", Restart: 3},
+		{Offset: 25 * time.Second, WorkerIndex: 2, Type: EventRestart, Restart: 3},
+		{Offset: 31 * time.Second, WorkerIndex: 1, Type: EventStop},
+		{Offset: 40 * time.Second, WorkerIndex: 0, Type: EventStop},
+	}
+	got := make([]eventKey, len(events))
+	for i, event := range events {
+		got[i] = eventKey{
+			Offset:      event.Time.Sub(journalStart),
+			WorkerIndex: event.WorkerIndex,
+			Type:        event.Type,
+			ExitCode:    event.ExitCode,
+			Signal:      event.Signal,
+			Result:      event.Result,
+			Restart:     event.Restart,
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	if detail := events[6].Detail(); detail != "signal KILL (oom-kill), restart #3" {
+		t.Errorf("Detail() = %q", detail)
+	}
+	if message := events[9].Message; message != "Stopped Quilibrium Ceremony Client Service." {
+		t.Errorf("byte array message decoded as %q", message)
+	}
+}
+
+func TestSummarizeEvents(t *testing.T) {
+	cfg := &config.Config{}
+	events, err := LoadServiceEvents(cfg, EventOptions{File: journalFixture})
+	if err != nil {
+		t.Fatalf("LoadServiceEvents failed: %v", err)
+	}
+
+	summaries := SummarizeEvents(events)
+	want := []EventSummary{
+		{Unit: "ceremonyclient.service", WorkerIndex: 0, Starts: 2, Stops: 1},
+		{Unit: "ceremonyclient-worker@1.service", WorkerIndex: 1, Starts: 2, Stops: 1, Crashes: 1, Restarts: 1, LastCrash: journalStart.Add(10 * time.Second)},
+		{Unit: "ceremonyclient-worker@2.service", WorkerIndex: 2, Crashes: 1, OOMKills: 1, Restarts: 1, LastCrash: journalStart.Add(20 * time.Second)},
+	}
+	if !reflect.DeepEqual(summaries, want) {
+		t.Errorf("summaries:\ngot  %+v\nwant %+v", summaries, want)
+	}
+}
+
+func TestJournalMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		units []string
+		user  bool
+		want  []string
+	}{
+		{
+			name:  "system units",
+			units: []string{"ceremonyclient.service", "ceremonyclient-worker@2.service"},
+			want:  []string{"_PID=1", "UNIT=ceremonyclient.service", "UNIT=ceremonyclient-worker@2.service"},
+		},
+		{
+			name:  "user unit",
+			units: []string{"ceremonyclient-worker@2.service"},
+			user:  true,
+			want:  []string{"_COMM=systemd", "USER_UNIT=ceremonyclient-worker@2.service"},
+		},
+		{
+			name:  "glob",
+			units: eventUnits("ceremonyclient", 0),
+			want:  []string{"_PID=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := journalMatches(tt.units, tt.user); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("journalMatches() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
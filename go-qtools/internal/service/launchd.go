@@ -103,9 +103,9 @@ func (lb *LaunchdBackend) CreateServiceFile(name string, config *ServiceConfig)
 func (lb *LaunchdBackend) UpdateServiceFile(name string, config *ServiceConfig) error {
 	plistPath := lb.getPlistPath(name)
 
-	content, err := GeneratePlist(config)
+	content, err := lb.RenderServiceFile(config)
 	if err != nil {
-		return fmt.Errorf("failed to generate plist: %w", err)
+		return err
 	}
 
 	// Ensure directory exists
@@ -122,6 +122,20 @@ func (lb *LaunchdBackend) UpdateServiceFile(name string, config *ServiceConfig)
 	return nil
 }
 
+// RenderServiceFile returns the plist content for a service
+func (lb *LaunchdBackend) RenderServiceFile(config *ServiceConfig) ([]byte, error) {
+	content, err := GeneratePlist(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate plist: %w", err)
+	}
+	return content, nil
+}
+
+// ServiceFilePath returns the plist path for a service
+func (lb *LaunchdBackend) ServiceFilePath(name string) string {
+	return lb.getPlistPath(name)
+}
+
 // getPlistPath gets the plist file path for a service
 func (lb *LaunchdBackend) getPlistPath(name string) string {
 	// Use user LaunchAgents directory
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// logPollInterval is how often a followed log file is checked for new output
+const logPollInterval = 500 * time.Millisecond
+
+// logTailChunk bounds how much of the end of a log file is read for its last lines
+const logTailChunk = 8 * 1024 * 1024
+
+// LogFileBackend is implemented by backends that write service output to a file instead of the journal
+type LogFileBackend interface {
+	LogFile(name string) string
+}
+
+// LogFile returns the file OpenRC's supervise-daemon writes the service output to
+func (ob *OpenRCBackend) LogFile(name string) string {
+	return filepath.Join("/var/log", name+".log")
+}
+
+// LogFile returns the current file of the service's svlogd directory
+func (rb *RunitBackend) LogFile(name string) string {
+	return filepath.Join("/var/log", name, "current")
+}
+
+// LogFile returns the file the supervisor writes the service output to
+func (b *SupervisorBackend) LogFile(name string) string {
+	return b.paths.LogPath(name)
+}
+
+// MasterLog returns the master's output, starting with its last lines
+// With follow, the reader keeps returning new output until it is closed or ctx is cancelled.
+// systemd services are read from the journal; other backends from their log file
+func MasterLog(ctx context.Context, cfg *config.Config, lines int, follow bool) (io.ReadCloser, error) {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	name := getServiceName(cfg)
+
+	switch b := backend.(type) {
+	case *SystemdBackend:
+		args := []string{"-u", name + ".service", "-o", "cat", "--no-pager", "-q", "-n", strconv.Itoa(lines)}
+		if b.IsUser() {
+			args = append([]string{"--user"}, args...)
+		}
+		if follow {
+			args = append(args, "-f")
+		}
+		return commandOutput(ctx, exec.CommandContext(ctx, "journalctl", args...))
+	case LogFileBackend:
+		return OpenLogFile(ctx, b.LogFile(name), lines, follow)
+	}
+	return nil, fmt.Errorf("this service backend does not keep a log qtools can read (pass a log file)")
+}
+
+// commandOutput starts a command and returns its stdout; closing it stops the command
+func commandOutput(ctx context.Context, cmd *exec.Cmd) (io.ReadCloser, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", cmd.Path, err)
+	}
+	return &commandReader{ReadCloser: stdout, cmd: cmd}, nil
+}
+
+// commandReader stops its command when closed
+type commandReader struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+// Close stops the command and waits for it
+func (r *commandReader) Close() error {
+	if r.cmd.Process != nil {
+		r.cmd.Process.Kill()
+	}
+	r.ReadCloser.Close()
+	r.cmd.Wait()
+	return nil
+}
+
+// OpenLogFile returns the last lines of a log file and, with follow, the output appended after them
+// A followed file that is truncated or replaced (log rotation) is read again from its start
+func OpenLogFile(ctx context.Context, path string, lines int, follow bool) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log %s: %w", path, err)
+	}
+
+	offset, err := lastLinesOffset(file, lines)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read log %s: %w", path, err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read log %s: %w", path, err)
+	}
+	if !follow {
+		return file, nil
+	}
+
+	reader, writer := io.Pipe()
+	go followFile(ctx, path, file, writer)
+	return reader, nil
+}
+
+// lastLinesOffset returns the offset of the last lines of a file, looking at most logTailChunk back
+func lastLinesOffset(file *os.File, lines int) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	start := size - logTailChunk
+	if start < 0 {
+		start = 0
+	}
+	buf := make([]byte, size-start)
+	if _, err := file.ReadAt(buf, start); err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	// Skip a trailing newline so it does not count as a line
+	end := len(buf)
+	if end > 0 && buf[end-1] == '\n' {
+		end--
+	}
+	for i := 0; i < lines; i++ {
+		idx := bytes.LastIndexByte(buf[:end], '\n')
+		if idx < 0 {
+			return start, nil
+		}
+		end = idx
+	}
+	return start + int64(end) + 1, nil
+}
+
+// followFile copies a file to the pipe, polling for new output until the pipe is closed or ctx is done
+func followFile(ctx context.Context, path string, file *os.File, writer *io.PipeWriter) {
+	defer func() { file.Close() }()
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			if _, err := writer.Write(buf[:n]); err != nil {
+				return
+			}
+			continue
+		}
+		if err != nil && err != io.EOF {
+			writer.CloseWithError(err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			writer.CloseWithError(ctx.Err())
+			return
+		case <-time.After(logPollInterval):
+		}
+
+		// Reopen the file when it was rotated or truncated
+		current, statErr := file.Stat()
+		latest, pathErr := os.Stat(path)
+		offset, _ := file.Seek(0, io.SeekCurrent)
+		if statErr != nil || pathErr != nil {
+			continue
+		}
+		if !os.SameFile(current, latest) || latest.Size() < offset {
+			reopened, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			file.Close()
+			file = reopened
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/tjsturos/qtools/go-qtools/internal/config"
 	"github.com/tjsturos/qtools/go-qtools/internal/node"
@@ -9,6 +11,7 @@ import (
 
 // StartOptions represents options for starting services
 type StartOptions struct {
+	WorkerOptions
 	MasterOnly bool
 	CoreIndex   int
 	Cores       string // e.g., "1-4,6,8"
@@ -16,14 +19,18 @@ type StartOptions struct {
 
 // StopOptions represents options for stopping services
 type StopOptions struct {
+	WorkerOptions
 	MasterOnly bool
 	CoreIndex   int
 	Cores       string
-	Kill        bool
+	Kill        bool              // Send SIGKILL instead of waiting for the node to shut down
+	GracePeriod time.Duration     // Wait after SIGINT before escalating (0: service.stop_grace_period)
+	OnSignal    func(Termination) // Called when a process is sent SIGTERM or SIGKILL
 }
 
 // RestartOptions represents options for restarting services
 type RestartOptions struct {
+	WorkerOptions
 	MasterOnly bool
 	CoreIndex   int
 	Cores       string
@@ -37,7 +44,7 @@ type StatusOptions struct {
 
 // StartService starts the service(s) based on options
 func StartService(opts StartOptions, cfg *config.Config) error {
-	backend, err := GetServiceBackend()
+	backend, err := GetServiceBackend(cfg)
 	if err != nil {
 		return err
 	}
@@ -58,7 +65,7 @@ func StartService(opts StartOptions, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
-		return StartWorkersByCores(cores, cfg)
+		return RunWorkerAction(WorkerActionStart, cores, opts.WorkerOptions, cfg)
 	}
 
 	// Start all (master + workers in manual mode)
@@ -70,7 +77,7 @@ func StartService(opts StartOptions, cfg *config.Config) error {
 
 		// Start all workers
 		workerCount := node.GetWorkerCount(cfg)
-		return StartWorkers(workerCount, cfg)
+		return RunWorkerAction(WorkerActionStart, workerRange(workerCount), opts.WorkerOptions, cfg)
 	}
 
 	// Automatic mode - just start master
@@ -78,8 +85,65 @@ func StartService(opts StartOptions, cfg *config.Config) error {
 }
 
 // StopService stops the service(s) based on options
+// The service manager sends SIGINT; processes still running after the grace period are sent
+// SIGTERM, then SIGKILL. Orphaned node processes for the stopped cores are stopped the same way
 func StopService(opts StopOptions, cfg *config.Config) error {
-	backend, err := GetServiceBackend()
+	grace := opts.GracePeriod
+	if grace <= 0 {
+		var err error
+		if grace, err = LoadStopGracePeriod(cfg); err != nil {
+			return err
+		}
+	}
+
+	// Escalation is best effort; without a process list the services are still stopped
+	processes, err := ListNodeProcesses(cfg)
+	if err == nil {
+		processes, err = stoppedProcesses(processes, opts)
+	}
+	if err != nil || len(processes) == 0 {
+		return stopServices(opts, cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() {
+		err := stopServices(opts, cfg)
+		if err != nil {
+			cancel() // leave the processes alone when the service manager could not stop them
+		}
+		stopped <- err
+	}()
+
+	TerminateProcesses(ctx, processes, TerminateOptions{
+		GracePeriod: grace,
+		Force:       opts.Kill,
+		OnSignal:    opts.OnSignal,
+	})
+	return <-stopped
+}
+
+// stoppedProcesses returns the node processes a stop with the options covers
+func stoppedProcesses(processes []NodeProcess, opts StopOptions) ([]NodeProcess, error) {
+	switch {
+	case opts.MasterOnly:
+		return filterCores(processes, []int{0}), nil
+	case opts.CoreIndex > 0:
+		return filterCores(processes, []int{opts.CoreIndex}), nil
+	case opts.Cores != "":
+		cores, err := ParseCoreNumbers(opts.Cores)
+		if err != nil {
+			return nil, err
+		}
+		return filterCores(processes, cores), nil
+	}
+	return processes, nil
+}
+
+// stopServices asks the service manager to stop the service(s)
+func stopServices(opts StopOptions, cfg *config.Config) error {
+	backend, err := GetServiceBackend(cfg)
 	if err != nil {
 		return err
 	}
@@ -100,19 +164,23 @@ func StopService(opts StopOptions, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
-		return StopWorkersByCores(cores, cfg)
+		return RunWorkerAction(WorkerActionStop, cores, opts.WorkerOptions, cfg)
 	}
 
 	// Stop all (master + workers in manual mode)
 	if node.IsManualMode(cfg) {
 		// Stop all workers first
 		workerCount := node.GetWorkerCount(cfg)
-		if err := StopWorkers(workerCount, cfg); err != nil {
-			return fmt.Errorf("failed to stop workers: %w", err)
+		workerErr := RunWorkerAction(WorkerActionStop, workerRange(workerCount), opts.WorkerOptions, cfg)
+		if workerErr != nil && !opts.ContinueOnError {
+			return fmt.Errorf("failed to stop workers: %w", workerErr)
 		}
 
 		// Stop master
-		return backend.StopService(serviceName)
+		if err := backend.StopService(serviceName); err != nil {
+			return err
+		}
+		return workerErr
 	}
 
 	// Automatic mode - just stop master
@@ -121,7 +189,7 @@ func StopService(opts StopOptions, cfg *config.Config) error {
 
 // RestartService restarts the service(s) based on options
 func RestartService(opts RestartOptions, cfg *config.Config) error {
-	backend, err := GetServiceBackend()
+	backend, err := GetServiceBackend(cfg)
 	if err != nil {
 		return err
 	}
@@ -142,19 +210,23 @@ func RestartService(opts RestartOptions, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
-		return RestartWorkersByCores(cores, cfg)
+		return RunWorkerAction(WorkerActionRestart, cores, opts.WorkerOptions, cfg)
 	}
 
 	// Restart all (master + workers in manual mode)
 	if node.IsManualMode(cfg) {
 		// Restart all workers first
 		workerCount := node.GetWorkerCount(cfg)
-		if err := RestartWorkers(workerCount, cfg); err != nil {
-			return fmt.Errorf("failed to restart workers: %w", err)
+		workerErr := RunWorkerAction(WorkerActionRestart, workerRange(workerCount), opts.WorkerOptions, cfg)
+		if workerErr != nil && !opts.ContinueOnError {
+			return fmt.Errorf("failed to restart workers: %w", workerErr)
 		}
 
 		// Restart master
-		return backend.RestartService(serviceName)
+		if err := backend.RestartService(serviceName); err != nil {
+			return err
+		}
+		return workerErr
 	}
 
 	// Automatic mode - just restart master
@@ -163,7 +235,7 @@ func RestartService(opts RestartOptions, cfg *config.Config) error {
 
 // GetStatus gets the status of services
 func GetStatus(opts StatusOptions, cfg *config.Config) (*Status, error) {
-	backend, err := GetServiceBackend()
+	backend, err := GetServiceBackend(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -174,7 +246,7 @@ func GetStatus(opts StatusOptions, cfg *config.Config) (*Status, error) {
 		Workers: make(map[int]*ServiceStatus),
 	}
 
-	// Get master status
+	// Get master status; when it cannot be read, Master is left empty so worker statuses still show
 	masterStatus, err := backend.GetStatus(serviceName)
 	if err == nil {
 		status.Master = masterStatus
@@ -200,8 +272,71 @@ func GetStatus(opts StatusOptions, cfg *config.Config) (*Status, error) {
 
 // Status represents the status of master and workers
 type Status struct {
-	Master  *ServiceStatus
-	Workers map[int]*ServiceStatus
+	Master  *ServiceStatus         `json:"master"`
+	Workers map[int]*ServiceStatus `json:"workers"`
+}
+
+// EnableServices enables the master on boot and, in manual mode, exactly the configured workers
+// Worker instances beyond the configured count are disabled
+func EnableServices(opts WorkerOptions, cfg *config.Config) error {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return err
+	}
+
+	serviceName := getServiceName(cfg)
+	if err := backend.EnableService(serviceName); err != nil {
+		return err
+	}
+	if !node.IsManualMode(cfg) {
+		return nil
+	}
+
+	workerCount := node.GetWorkerCount(cfg)
+	if templates, ok := backend.(WorkerTemplateBackend); ok {
+		enabled, err := templates.EnabledWorkers(serviceName)
+		if err != nil {
+			return err
+		}
+		var extra []int
+		for _, index := range enabled {
+			if index > workerCount {
+				extra = append(extra, index)
+			}
+		}
+		if len(extra) > 0 {
+			if err := RunWorkerAction(WorkerActionDisable, extra, opts, cfg); err != nil {
+				return err
+			}
+		}
+	}
+
+	return RunWorkerAction(WorkerActionEnable, workerRange(workerCount), opts, cfg)
+}
+
+// DisableServices disables the master and every worker on boot
+func DisableServices(opts WorkerOptions, cfg *config.Config) error {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return err
+	}
+
+	serviceName := getServiceName(cfg)
+	var workers []int
+	if templates, ok := backend.(WorkerTemplateBackend); ok {
+		if workers, err = templates.EnabledWorkers(serviceName); err != nil {
+			return err
+		}
+	} else if node.IsManualMode(cfg) {
+		workers = workerRange(node.GetWorkerCount(cfg))
+	}
+
+	if len(workers) > 0 {
+		if err := RunWorkerAction(WorkerActionDisable, workers, opts, cfg); err != nil {
+			return err
+		}
+	}
+	return backend.DisableService(serviceName)
 }
 
 // getServiceName gets the service name from config
@@ -213,19 +348,19 @@ func getServiceName(cfg *config.Config) string {
 }
 
 // CreateServiceFile creates a service file
-func CreateServiceFile(name string, config *ServiceConfig) error {
-	backend, err := GetServiceBackend()
+func CreateServiceFile(name string, serviceConfig *ServiceConfig, cfg *config.Config) error {
+	backend, err := GetServiceBackend(cfg)
 	if err != nil {
 		return err
 	}
-	return backend.CreateServiceFile(name, config)
+	return backend.CreateServiceFile(name, serviceConfig)
 }
 
 // UpdateServiceFile updates a service file
-func UpdateServiceFile(name string, config *ServiceConfig) error {
-	backend, err := GetServiceBackend()
+func UpdateServiceFile(name string, serviceConfig *ServiceConfig, cfg *config.Config) error {
+	backend, err := GetServiceBackend(cfg)
 	if err != nil {
 		return err
 	}
-	return backend.UpdateServiceFile(name, config)
+	return backend.UpdateServiceFile(name, serviceConfig)
 }
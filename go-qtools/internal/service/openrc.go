@@ -0,0 +1,248 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/supervisor"
+)
+
+// OpenRC directories
+const (
+	openrcInitDir    = "/etc/init.d"
+	openrcRunlevel   = "default"
+	openrcOptionsDir = "/run/openrc/options" // Values stored by supervise-daemon, e.g., child_pid
+)
+
+// generatedScriptHeader marks init scripts as generated
+const generatedScriptHeader = "# Generated by qtools from config.yml.\n# Edit the config and run `qtools service update` instead of changing this file.\n"
+
+// OpenRCBackend implements ServiceBackend for OpenRC (e.g., Alpine)
+// Services are init.d scripts run under supervise-daemon, which respawns them like systemd's Restart=
+type OpenRCBackend struct{}
+
+// NewOpenRCBackend creates a new OpenRC backend
+func NewOpenRCBackend() *OpenRCBackend {
+	return &OpenRCBackend{}
+}
+
+// rcService runs rc-service with sudo
+func (ob *OpenRCBackend) rcService(name, action string) error {
+	output, err := sudoCommand("rc-service", name, action).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to %s service %s: %w\nOutput: %s", action, name, err, string(output))
+	}
+	return nil
+}
+
+// StartService starts an OpenRC service
+func (ob *OpenRCBackend) StartService(name string) error {
+	return ob.rcService(name, "start")
+}
+
+// StopService stops an OpenRC service
+func (ob *OpenRCBackend) StopService(name string) error {
+	return ob.rcService(name, "stop")
+}
+
+// RestartService restarts an OpenRC service
+func (ob *OpenRCBackend) RestartService(name string) error {
+	return ob.rcService(name, "restart")
+}
+
+// GetStatus gets the status of an OpenRC service from rc-service status
+// and the child PID recorded by supervise-daemon
+func (ob *OpenRCBackend) GetStatus(name string) (*ServiceStatus, error) {
+	status := &ServiceStatus{
+		Name:      name,
+		LoadState: "loaded",
+		Enabled:   fileExists(filepath.Join("/etc/runlevels", openrcRunlevel, name)),
+	}
+	if !fileExists(ob.ServiceFilePath(name)) {
+		status.LoadState = "not-found"
+	}
+
+	// rc-service exits non-zero for stopped and crashed services, so only the output is used
+	output, _ := exec.Command("rc-service", name, "status").CombinedOutput()
+	state := parseOpenRCStatus(string(output))
+	status.StatusText = strings.TrimSpace(string(output))
+
+	switch state {
+	case "started":
+		status.ActiveState, status.SubState = "active", "running"
+	case "starting":
+		status.ActiveState, status.SubState = "activating", "start"
+	case "stopping":
+		status.ActiveState, status.SubState = "deactivating", "stop-sigint"
+	case "crashed":
+		status.ActiveState, status.SubState = "failed", "dead"
+		status.Result = "exit-code"
+	default:
+		status.ActiveState, status.SubState = "inactive", "dead"
+	}
+	status.Active = status.ActiveState == "active"
+	status.Running = status.SubState == "running"
+
+	if status.Running {
+		data, _ := os.ReadFile(filepath.Join(openrcOptionsDir, name, "child_pid"))
+		status.PID, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+		if status.PID > 0 {
+			status.MemoryBytes = supervisor.ProcessMemory(status.PID)
+		}
+	}
+	return status, nil
+}
+
+// parseOpenRCStatus returns the state from rc-service status output (" * status: started")
+func parseOpenRCStatus(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if _, state, ok := strings.Cut(line, "status:"); ok {
+			return strings.TrimSpace(state)
+		}
+	}
+	return ""
+}
+
+// EnableService adds an OpenRC service to the default runlevel
+func (ob *OpenRCBackend) EnableService(name string) error {
+	output, err := sudoCommand("rc-update", "add", name, openrcRunlevel).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to enable service %s: %w\nOutput: %s", name, err, string(output))
+	}
+	return nil
+}
+
+// DisableService removes an OpenRC service from the default runlevel
+func (ob *OpenRCBackend) DisableService(name string) error {
+	if !fileExists(filepath.Join("/etc/runlevels", openrcRunlevel, name)) {
+		return nil
+	}
+	output, err := sudoCommand("rc-update", "del", name, openrcRunlevel).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to disable service %s: %w\nOutput: %s", name, err, string(output))
+	}
+	return nil
+}
+
+// CreateServiceFile creates an init.d script
+func (ob *OpenRCBackend) CreateServiceFile(name string, config *ServiceConfig) error {
+	return ob.UpdateServiceFile(name, config)
+}
+
+// UpdateServiceFile writes an init.d script; running services pick it up when restarted
+func (ob *OpenRCBackend) UpdateServiceFile(name string, config *ServiceConfig) error {
+	content, err := ob.RenderServiceFile(config)
+	if err != nil {
+		return err
+	}
+	return installFile(sudoCommand, ob.ServiceFilePath(name), content, "0755")
+}
+
+// RenderServiceFile returns the init.d script for a service
+func (ob *OpenRCBackend) RenderServiceFile(config *ServiceConfig) ([]byte, error) {
+	opts := config.ServiceOptions
+	if opts == nil {
+		return nil, fmt.Errorf("service options are required")
+	}
+
+	args := buildProgramArguments(config)
+	name := config.ServiceName
+	data := struct {
+		ServiceConfig
+		Header       string
+		Description  string
+		Command      string
+		CommandArgs  string
+		LogFile      string
+		RestartDelay int
+		EnvVars      []string
+	}{
+		ServiceConfig: *config,
+		Header:        generatedScriptHeader,
+		Description:   "Quilibrium Ceremony Client Service",
+		Command:       args[0],
+		CommandArgs:   shellJoin(args[1:]),
+		RestartDelay:  parseRestartTime(opts.RestartTime),
+	}
+	if config.IsWorker {
+		name = fmt.Sprintf("%s-worker@%d", config.ServiceName, config.WorkerIndex)
+		data.Description = fmt.Sprintf("Quilibrium Worker Service %d", config.WorkerIndex)
+		data.RestartDelay = parseRestartTime(opts.WorkerRestartTime)
+	}
+	data.LogFile = filepath.Join("/var/log", name+".log")
+
+	env := buildEnvironmentMap(config)
+	for _, key := range sortedKeys(env) {
+		data.EnvVars = append(data.EnvVars, key+"="+shellQuote(env[key]))
+	}
+
+	tmpl, err := template.New("openrc").Parse(openrcScriptTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to generate service file content: %w", err)
+	}
+	return []byte(buf.String()), nil
+}
+
+// ServiceFilePath returns the init.d script path for a service
+func (ob *OpenRCBackend) ServiceFilePath(name string) string {
+	return filepath.Join(openrcInitDir, name)
+}
+
+// shellJoin quotes args for a POSIX shell
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote single-quotes s unless it only contains characters safe in a shell word
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=./:,@%+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// openrcScriptTemplate runs the node under supervise-daemon
+// supervise-daemon respawns on any exit, so workers also restart after a clean exit
+// SIGINT stops the node gracefully; SIGKILL follows after 240s like TimeoutStopSec
+const openrcScriptTemplate = `#!/sbin/openrc-run
+{{.Header}}
+description="{{.Description}}"
+
+supervisor=supervise-daemon
+command={{.Command}}
+command_args="{{.CommandArgs}}"
+{{- if .User}}
+command_user={{.User}}:{{.Group}}
+{{- end}}
+directory={{.WorkingDir}}
+output_log={{.LogFile}}
+error_log={{.LogFile}}
+respawn_delay={{.RestartDelay}}
+respawn_max=0
+retry="SIGINT/240/SIGKILL/5"
+{{- range .EnvVars}}
+export {{.}}
+{{- end}}
+
+depend() {
+	need net
+	after firewall
+}
+
+start_pre() {
+	checkpath --file{{if .User}} --owner {{.User}}:{{.Group}}{{end}} "$output_log"
+}
+`
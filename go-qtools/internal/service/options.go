@@ -23,6 +23,10 @@ type ServiceOptions struct {
 	EnableService        bool
 	RestartService       bool
 	MasterOnly           bool
+	Args                 string // Extra arguments for the master (service.args)
+	MaxThreads           int    // CPU threads for the master (service.max_threads); 0 for no limit
+	MasterOverrides      *config.UnitOverrides
+	WorkerOverrides      *config.UnitOverrides
 }
 
 // ParseServiceOptions parses command-line arguments into ServiceOptions
@@ -121,6 +125,18 @@ func LoadServiceOptionsFromConfig(cfg *config.Config) (*ServiceOptions, error) {
 		opts.RestartTime = "60s"
 	}
 
+	opts.Args = strings.TrimSpace(cfg.Service.Args)
+	maxThreads, err := parseMaxThreads(cfg.Service.MaxThreads)
+	if err != nil {
+		return nil, err
+	}
+	opts.MaxThreads = maxThreads
+
+	if cfg.Service.Overrides != nil {
+		opts.MasterOverrides = cfg.Service.Overrides.Master
+		opts.WorkerOverrides = cfg.Service.Overrides.Worker
+	}
+
 	// Load worker service options
 	if cfg.Service.WorkerService != nil {
 		opts.WorkerRestartTime = normalizeRestartTime(cfg.Service.WorkerService.RestartTime)
@@ -146,6 +162,30 @@ func LoadServiceOptionsFromConfig(cfg *config.Config) (*ServiceOptions, error) {
 	return opts, nil
 }
 
+// parseMaxThreads reads service.max_threads: false (or unset) for no limit, or a thread count
+func parseMaxThreads(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case bool:
+		if !v {
+			return 0, nil
+		}
+	case int:
+		if v >= 0 {
+			return v, nil
+		}
+	case string:
+		if v == "" || v == "false" {
+			return 0, nil
+		}
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid service.max_threads %v: expected false or a number of threads", value)
+}
+
 // ApplyServiceOptions saves options to config
 func ApplyServiceOptions(opts *ServiceOptions, cfg *config.Config) error {
 	if cfg.Service == nil {
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// Worker actions
+const (
+	WorkerActionStart   = "start"
+	WorkerActionStop    = "stop"
+	WorkerActionRestart = "restart"
+	WorkerActionEnable  = "enable"
+	WorkerActionDisable = "disable"
+)
+
+// DefaultWorkerConcurrency is used when neither the options nor service.worker_service.concurrency set a limit
+const DefaultWorkerConcurrency = 8
+
+// errWorkerSkipped marks workers not attempted after a failure without ContinueOnError
+var errWorkerSkipped = errors.New("skipped after an earlier failure")
+
+// WorkerOptions controls how worker operations run
+type WorkerOptions struct {
+	Concurrency     int               // Maximum workers acted on at once (0: config or DefaultWorkerConcurrency)
+	ContinueOnError bool              // Keep going after a worker fails
+	Progress        func(WorkerEvent) // Called as each worker finishes; may be called concurrently
+}
+
+// WorkerEvent reports the result of one worker operation
+type WorkerEvent struct {
+	Action   string
+	Index    int
+	Service  string
+	Err      error
+	Skipped  bool
+	Duration time.Duration
+	Done     int // Workers finished so far, including this one
+	Total    int
+}
+
+// WorkerErrors is returned when one or more worker operations fail
+type WorkerErrors struct {
+	Action  string
+	Total   int
+	Results []WorkerEvent // Failed and skipped workers, ordered by index
+}
+
+// Error implements error
+func (e *WorkerErrors) Error() string {
+	failed, skipped := e.counts()
+	var parts []string
+	for _, result := range e.Results {
+		if !result.Skipped {
+			parts = append(parts, fmt.Sprintf("worker %d: %v", result.Index, result.Err))
+		}
+	}
+	msg := fmt.Sprintf("failed to %s %d of %d workers", e.Action, failed, e.Total)
+	if skipped > 0 {
+		msg += fmt.Sprintf(" (%d skipped)", skipped)
+	}
+	return msg + ": " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the per-worker errors
+func (e *WorkerErrors) Unwrap() []error {
+	var errs []error
+	for _, result := range e.Results {
+		if !result.Skipped {
+			errs = append(errs, result.Err)
+		}
+	}
+	return errs
+}
+
+// Summary renders the failed and skipped workers as a table
+func (e *WorkerErrors) Summary() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKER\tSERVICE\tRESULT")
+	for _, result := range e.Results {
+		outcome := fmt.Sprintf("failed: %v", result.Err)
+		if result.Skipped {
+			outcome = "skipped"
+		}
+		// Keep multi-line systemctl output on one row
+		outcome = strings.Join(strings.Fields(outcome), " ")
+		fmt.Fprintf(w, "%d\t%s\t%s\n", result.Index, result.Service, outcome)
+	}
+	w.Flush()
+	failed, skipped := e.counts()
+	fmt.Fprintf(&b, "%d failed, %d skipped, %d succeeded\n", failed, skipped, e.Total-failed-skipped)
+	return b.String()
+}
+
+// counts returns the number of failed and skipped workers
+func (e *WorkerErrors) counts() (failed int, skipped int) {
+	for _, result := range e.Results {
+		if result.Skipped {
+			skipped++
+		} else {
+			failed++
+		}
+	}
+	return failed, skipped
+}
+
+// RunWorkerAction starts, stops, restarts, enables or disables workers in parallel
+// At most opts.Concurrency workers are acted on at once; without ContinueOnError no new
+// workers are started after the first failure. Failures are returned as *WorkerErrors
+func RunWorkerAction(action string, indexes []int, opts WorkerOptions, cfg *config.Config) error {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return err
+	}
+
+	var run func(name string) error
+	switch action {
+	case WorkerActionStart:
+		run = backend.StartService
+	case WorkerActionStop:
+		run = backend.StopService
+	case WorkerActionRestart:
+		run = backend.RestartService
+	case WorkerActionEnable:
+		run = backend.EnableService
+	case WorkerActionDisable:
+		run = backend.DisableService
+	default:
+		return fmt.Errorf("unknown worker action %q", action)
+	}
+
+	return runWorkers(action, indexes, workerConcurrency(opts, cfg), opts, getServiceName(cfg), run)
+}
+
+// runWorkers runs fn for each worker with bounded concurrency
+func runWorkers(action string, indexes []int, concurrency int, opts WorkerOptions, serviceName string, fn func(name string) error) error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		done    int
+		failed  bool
+		results []WorkerEvent
+	)
+
+	report := func(event WorkerEvent) {
+		mu.Lock()
+		done++
+		event.Done = done
+		if event.Err != nil {
+			if !event.Skipped {
+				failed = true
+			}
+			results = append(results, event)
+		}
+		mu.Unlock()
+		if opts.Progress != nil {
+			opts.Progress(event)
+		}
+	}
+
+	sem := make(chan struct{}, concurrency)
+	for _, index := range indexes {
+		event := WorkerEvent{
+			Action:  action,
+			Index:   index,
+			Service: fmt.Sprintf("%s-worker@%d", serviceName, index),
+			Total:   len(indexes),
+		}
+
+		sem <- struct{}{}
+		mu.Lock()
+		stop := failed && !opts.ContinueOnError
+		mu.Unlock()
+		if stop {
+			<-sem
+			event.Err = errWorkerSkipped
+			event.Skipped = true
+			report(event)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			start := time.Now()
+			event.Err = fn(event.Service)
+			event.Duration = time.Since(start)
+			report(event)
+		}()
+	}
+	wg.Wait()
+
+	if len(results) == 0 {
+		return nil
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+	return &WorkerErrors{Action: action, Total: len(indexes), Results: results}
+}
+
+// workerConcurrency resolves the concurrency limit
+func workerConcurrency(opts WorkerOptions, cfg *config.Config) int {
+	if opts.Concurrency > 0 {
+		return opts.Concurrency
+	}
+	if cfg != nil && cfg.Service != nil && cfg.Service.WorkerService != nil && cfg.Service.WorkerService.Concurrency > 0 {
+		return cfg.Service.WorkerService.Concurrency
+	}
+	return DefaultWorkerConcurrency
+}
+
+// workerRange returns worker indexes 1..count
+func workerRange(count int) []int {
+	indexes := make([]int, count)
+	for i := range indexes {
+		indexes[i] = i + 1
+	}
+	return indexes
+}
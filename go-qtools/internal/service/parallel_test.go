@@ -0,0 +1,153 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunWorkersConcurrency(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		active    int
+		maxActive int
+		done      []int
+		names     []string
+	)
+	fn := func(name string) error {
+		mu.Lock()
+		active++
+		maxActive = max(maxActive, active)
+		names = append(names, name)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return nil
+	}
+	opts := WorkerOptions{Progress: func(event WorkerEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if event.Total != 12 || event.Err != nil {
+			t.Errorf("progress event = %+v", event)
+		}
+		done = append(done, event.Done)
+	}}
+
+	if err := runWorkers(WorkerActionRestart, workerRange(12), 3, opts, "quilibrium", fn); err != nil {
+		t.Fatalf("runWorkers failed: %v", err)
+	}
+	if maxActive != 3 {
+		t.Errorf("at most %d workers ran at once, want 3", maxActive)
+	}
+	var want []string
+	for _, index := range workerRange(12) {
+		want = append(want, fmt.Sprintf("quilibrium-worker@%d", index))
+	}
+	slices.Sort(names)
+	slices.Sort(want)
+	if !slices.Equal(names, want) {
+		t.Errorf("ran %v, want each worker once", names)
+	}
+	// Each worker finishing counts once; progress may be reported out of order
+	slices.Sort(done)
+	for i, n := range done {
+		if n != i+1 {
+			t.Errorf("progress done = %v, want 1 to 12", done)
+			break
+		}
+	}
+	if len(done) != 12 {
+		t.Errorf("progress reported %d workers, want 12", len(done))
+	}
+}
+
+func TestRunWorkersFailure(t *testing.T) {
+	errBusy := errors.New("unit is busy")
+	tests := []struct {
+		name            string
+		continueOnError bool
+		wantRan         []string
+		wantErr         string
+		wantSkipped     []int
+	}{
+		{
+			name:        "skips after a failure",
+			wantRan:     []string{"node-worker@1", "node-worker@2"},
+			wantErr:     "failed to stop 1 of 5 workers (3 skipped): worker 2: unit is busy",
+			wantSkipped: []int{3, 4, 5},
+		},
+		{
+			name:            "continues on error",
+			continueOnError: true,
+			wantRan:         []string{"node-worker@1", "node-worker@2", "node-worker@3", "node-worker@4", "node-worker@5"},
+			wantErr:         "failed to stop 2 of 5 workers: worker 2: unit is busy; worker 4: unit is busy",
+		},
+	}
+
+	for _, tt := range tests {
+		var ran []string
+		var skipped []int
+		fn := func(name string) error {
+			ran = append(ran, name)
+			if name == "node-worker@2" || name == "node-worker@4" {
+				return errBusy
+			}
+			return nil
+		}
+		opts := WorkerOptions{ContinueOnError: tt.continueOnError, Progress: func(event WorkerEvent) {
+			if event.Skipped {
+				skipped = append(skipped, event.Index)
+			}
+		}}
+
+		// One at a time, so the failure is seen before the next worker starts
+		err := runWorkers(WorkerActionStop, workerRange(5), 1, opts, "node", fn)
+		if !reflect.DeepEqual(ran, tt.wantRan) {
+			t.Errorf("%s: ran %v, want %v", tt.name, ran, tt.wantRan)
+		}
+		if !reflect.DeepEqual(skipped, tt.wantSkipped) {
+			t.Errorf("%s: skipped %v, want %v", tt.name, skipped, tt.wantSkipped)
+		}
+
+		var workerErrs *WorkerErrors
+		if !errors.As(err, &workerErrs) {
+			t.Fatalf("%s: err = %v, want *WorkerErrors", tt.name, err)
+		}
+		if err.Error() != tt.wantErr {
+			t.Errorf("%s: error = %q, want %q", tt.name, err.Error(), tt.wantErr)
+		}
+		if !errors.Is(err, errBusy) || errors.Is(err, errWorkerSkipped) {
+			t.Errorf("%s: error does not unwrap to only the worker failures", tt.name)
+		}
+		if workerErrs.Action != WorkerActionStop || workerErrs.Total != 5 {
+			t.Errorf("%s: WorkerErrors = %+v", tt.name, workerErrs)
+		}
+	}
+}
+
+func TestWorkerErrorsSummary(t *testing.T) {
+	err := &WorkerErrors{
+		Action: WorkerActionRestart,
+		Total:  6,
+		Results: []WorkerEvent{
+			{Index: 2, Service: "quilibrium-worker@2", Err: fmt.Errorf("failed to restart service:\nJob for quilibrium-worker@2.service failed.\n")},
+			{Index: 10, Service: "quilibrium-worker@10", Err: errWorkerSkipped, Skipped: true},
+		},
+	}
+
+	want := "WORKER  SERVICE               RESULT\n" +
+		"2       quilibrium-worker@2   failed: failed to restart service: Job for quilibrium-worker@2.service failed.\n" +
+		"10      quilibrium-worker@10  skipped\n" +
+		"1 failed, 1 skipped, 4 succeeded\n"
+	if got := err.Summary(); got != want {
+		t.Errorf("Summary() =\n%s\nwant\n%s", got, want)
+	}
+}
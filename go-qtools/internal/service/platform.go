@@ -2,7 +2,11 @@ package service
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 )
 
 // Platform represents the operating system platform
@@ -26,14 +30,138 @@ func DetectPlatform() Platform {
 	}
 }
 
+// InitSystem is the init system managing services on the host
+type InitSystem string
+
+const (
+	InitSystemd InitSystem = "systemd"
+	InitOpenRC  InitSystem = "openrc"
+	InitRunit   InitSystem = "runit"
+	InitLaunchd InitSystem = "launchd"
+	InitNone    InitSystem = "none" // No service manager (e.g., containers)
+)
+
+// DetectInitSystem detects the running init system
+// On Linux, PID 1 and the init systems' runtime directories are checked rather than which
+// tools are installed: Alpine boots busybox init with OpenRC and many images ship systemctl
+func DetectInitSystem() InitSystem {
+	switch DetectPlatform() {
+	case PlatformDarwin:
+		return InitLaunchd
+	case PlatformLinux:
+	default:
+		return InitNone
+	}
+
+	if dirExists("/run/systemd/system") {
+		return InitSystemd
+	}
+
+	comm, _ := os.ReadFile("/proc/1/comm")
+	exe, _ := os.Readlink("/proc/1/exe")
+	pid1 := []string{strings.TrimSpace(string(comm)), filepath.Base(exe)}
+	for _, name := range pid1 {
+		switch name {
+		case "openrc-init":
+			return InitOpenRC
+		case "runit", "runit-init":
+			return InitRunit
+		}
+	}
+	if fileExists("/run/openrc/softlevel") {
+		return InitOpenRC
+	}
+	if dirExists("/run/runit") {
+		return InitRunit
+	}
+	return InitNone
+}
+
+// dirExists reports whether path is a directory
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 // ServiceStatus represents the status of a service
 type ServiceStatus struct {
-	Name        string
-	Active      bool
-	Running     bool
-	Enabled     bool
-	PID         int
-	StatusText  string
+	Name        string `json:"name"`
+	Active      bool   `json:"active"`
+	Running     bool   `json:"running"`
+	Enabled     bool   `json:"enabled"`
+	PID         int    `json:"pid"`
+	StatusText  string `json:"status_text"`
+
+	// Unit properties (systemd, OpenRC, runit and the built-in supervisor; zero when unavailable)
+	LoadState   string        `json:"load_state,omitempty"`   // e.g., "loaded", "not-found"
+	ActiveState string        `json:"active_state,omitempty"` // e.g., "active", "activating", "failed"
+	SubState    string        `json:"sub_state,omitempty"`    // e.g., "running", "auto-restart"
+	StartedAt   time.Time     `json:"started_at"`             // ExecMainStartTimestamp
+	Restarts    int           `json:"restarts"`               // NRestarts
+	MemoryBytes uint64        `json:"memory_bytes"`           // MemoryCurrent
+	CPUUsage    time.Duration `json:"cpu_usage_ns"`           // CPUUsageNSec
+	Result      string        `json:"result,omitempty"`       // e.g., "success", "exit-code", "signal"
+	ExitCode    int           `json:"exit_code"`              // ExecMainStatus
+}
+
+// Uptime returns how long the main process has been running
+func (s *ServiceStatus) Uptime() time.Duration {
+	if !s.Running || s.StartedAt.IsZero() {
+		return 0
+	}
+	return time.Since(s.StartedAt)
+}
+
+// State returns "active (running)" style state text
+func (s *ServiceStatus) State() string {
+	state := s.ActiveState
+	if state == "" {
+		state = "inactive"
+		if s.Active {
+			state = "active"
+		}
+	}
+	if s.SubState != "" {
+		state += " (" + s.SubState + ")"
+	}
+	return state
+}
+
+// FormatBytes formats a byte count as B, KiB, MiB or GiB
+func FormatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n) / unit
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		if value < unit || suffix == "GiB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// FormatDuration formats a duration as e.g. "3d4h", "2h15m" or "42s"
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
 }
 
 // ServiceBackend is the interface for platform-specific service management
@@ -46,6 +174,30 @@ type ServiceBackend interface {
 	DisableService(name string) error
 	CreateServiceFile(name string, config *ServiceConfig) error
 	UpdateServiceFile(name string, config *ServiceConfig) error
+	RenderServiceFile(config *ServiceConfig) ([]byte, error)
+	ServiceFilePath(name string) string
+}
+
+// DropInBackend is implemented by backends that keep local settings in a drop-in file
+// next to the generated service file (e.g., systemd's <name>.service.d/override.conf)
+type DropInBackend interface {
+	// RenderDropIn returns the drop-in content, or nil when there is nothing to override
+	RenderDropIn(config *ServiceConfig) ([]byte, error)
+	DropInPath(name string) string
+	// UpdateDropIn writes the drop-in, removing it when there is nothing to override
+	UpdateDropIn(name string, config *ServiceConfig) error
+}
+
+// WorkerTemplateBackend is implemented by backends that run workers as instances of one
+// template service (e.g., systemd's ceremonyclient-worker@.service) instead of a file per worker
+type WorkerTemplateBackend interface {
+	// WorkerTemplateName returns the template service name (e.g., ceremonyclient-worker@)
+	WorkerTemplateName(serviceName string) string
+	// EnabledWorkers returns the indexes of worker instances enabled on boot
+	EnabledWorkers(serviceName string) ([]int, error)
+	// MigrateWorkerFiles replaces per-worker files left by older installs with template
+	// instances, keeping enabled workers enabled; it returns the removed paths
+	MigrateWorkerFiles(serviceName string) ([]string, error)
 }
 
 // ServiceConfig represents service configuration for file generation
@@ -59,17 +211,3 @@ type ServiceConfig struct {
 	IsWorker       bool
 	WorkerIndex    int // For worker services
 }
-
-// GetServiceBackend returns the appropriate service backend for the platform
-func GetServiceBackend() (ServiceBackend, error) {
-	platform := DetectPlatform()
-
-	switch platform {
-	case PlatformLinux:
-		return NewSystemdBackend(), nil
-	case PlatformDarwin:
-		return NewLaunchdBackend(), nil
-	default:
-		return nil, fmt.Errorf("unsupported platform: %s", platform)
-	}
-}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -38,6 +39,8 @@ func GeneratePlist(config *ServiceConfig) ([]byte, error) {
 
 	// Set KeepAlive based on service type
 	if config.IsWorker {
+		// Each worker is its own job, labeled like its plist file
+		plistConfig.Label = fmt.Sprintf("com.quilibrium.%s-worker@%d", config.ServiceName, config.WorkerIndex)
 		// Workers restart on failure
 		plistConfig.KeepAlive = map[string]bool{
 			"SuccessfulExit": false,
@@ -80,7 +83,10 @@ func buildProgramArguments(config *ServiceConfig) []string {
 		args = append(args, "--signature-check=false")
 	}
 	if config.IsWorker {
-		args = append(args, "--core", fmt.Sprintf("%%i"))
+		// launchd has no instance specifier like systemd's %i
+		args = append(args, "--core", strconv.Itoa(config.WorkerIndex))
+	} else if config.ServiceOptions.Args != "" {
+		args = append(args, strings.Fields(config.ServiceOptions.Args)...)
 	}
 
 	return args
@@ -147,9 +153,9 @@ func generatePlistXML(config *PlistConfig) ([]byte, error) {
 	buf.WriteString("    <key>KeepAlive</key>\n")
 	if keepAliveDict, ok := config.KeepAlive.(map[string]bool); ok {
 		buf.WriteString("    <dict>\n")
-		for k, v := range keepAliveDict {
+		for _, k := range sortedKeys(keepAliveDict) {
 			buf.WriteString(fmt.Sprintf("        <key>%s</key>\n", escapeXML(k)))
-			buf.WriteString(fmt.Sprintf("        <%t/>\n", v))
+			buf.WriteString(fmt.Sprintf("        <%t/>\n", keepAliveDict[k]))
 		}
 		buf.WriteString("    </dict>\n")
 	} else if keepAliveBool, ok := config.KeepAlive.(bool); ok {
@@ -168,9 +174,9 @@ func generatePlistXML(config *PlistConfig) ([]byte, error) {
 	if len(config.EnvironmentVariables) > 0 {
 		buf.WriteString("    <key>EnvironmentVariables</key>\n")
 		buf.WriteString("    <dict>\n")
-		for k, v := range config.EnvironmentVariables {
+		for _, k := range sortedKeys(config.EnvironmentVariables) {
 			buf.WriteString(fmt.Sprintf("        <key>%s</key>\n", escapeXML(k)))
-			buf.WriteString(fmt.Sprintf("        <string>%s</string>\n", escapeXML(v)))
+			buf.WriteString(fmt.Sprintf("        <string>%s</string>\n", escapeXML(config.EnvironmentVariables[k])))
 		}
 		buf.WriteString("    </dict>\n")
 	}
@@ -181,6 +187,16 @@ func generatePlistXML(config *PlistConfig) ([]byte, error) {
 	return []byte(buf.String()), nil
 }
 
+// sortedKeys returns map keys in order so generated plists are stable
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // writePlistKeyValue writes a key-value pair to the plist
 func writePlistKeyValue(buf *strings.Builder, key string, value interface{}) {
 	buf.WriteString(fmt.Sprintf("    <key>%s</key>\n", escapeXML(key)))
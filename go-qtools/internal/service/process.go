@@ -0,0 +1,279 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// versionedNodeBinary matches the node binaries installed in the node directory, e.g., node-2.1.0-linux-amd64
+var versionedNodeBinary = regexp.MustCompile(`^node-[0-9]+(\.[0-9]+)+-[a-z0-9]+-[a-z0-9]+$`)
+
+// NodeProcess represents a running node process
+type NodeProcess struct {
+	PID      int      `json:"pid"`
+	PPID     int      `json:"ppid"`
+	Core     int      `json:"core"`           // 0 for the master
+	Unit     string   `json:"unit,omitempty"` // Service whose process (or one of its parents) this is
+	Orphaned bool     `json:"orphaned"`       // No service tracks the process
+	Command  []string `json:"command"`
+}
+
+// Label returns "master" or "worker N"
+func (p NodeProcess) Label() string {
+	if p.Core == 0 {
+		return "master"
+	}
+	return fmt.Sprintf("worker %d", p.Core)
+}
+
+// processEntry is one row of the process table
+type processEntry struct {
+	pid    int
+	ppid   int
+	zombie bool
+	args   []string
+}
+
+// ListNodeProcesses finds the running node processes and the services they belong to
+// A process is the node when it is a service's main process, runs the service binary or a
+// versioned node binary, or was started by one (workers spawned by the master in automatic mode).
+// Processes no running service leads back to, e.g., a worker left behind by a crashed master, are orphaned
+func ListNodeProcesses(cfg *config.Config) ([]NodeProcess, error) {
+	table, err := readProcessTable()
+	if err != nil {
+		return nil, err
+	}
+
+	// Main processes of the running services; a status error leaves every process orphaned
+	units := make(map[int]string)
+	unitCores := make(map[int]int)
+	if status, err := GetStatus(StatusOptions{}, cfg); err == nil {
+		if status.Master != nil && status.Master.PID > 0 {
+			units[status.Master.PID] = status.Master.Name
+		}
+		for index, worker := range status.Workers {
+			if worker.PID > 0 {
+				units[worker.PID] = worker.Name
+				unitCores[worker.PID] = index
+			}
+		}
+	}
+
+	binaries := nodeBinaryPaths(cfg)
+	var processes []NodeProcess
+	for _, entry := range table {
+		_, isUnit := units[entry.pid]
+		if entry.zombie || (!isUnit && !isNodeCommand(entry.args, binaries)) {
+			continue
+		}
+
+		process := NodeProcess{PID: entry.pid, PPID: entry.ppid, Command: entry.args}
+		if core, ok := unitCores[entry.pid]; ok {
+			process.Core = core
+		} else {
+			process.Core = parseCoreArg(entry.args)
+		}
+
+		// Walk up the parents until a service's main process is found
+		for pid, seen := entry.pid, 0; pid > 1 && seen < len(table); seen++ {
+			if unit, ok := units[pid]; ok {
+				process.Unit = unit
+				break
+			}
+			parent, ok := table[pid]
+			if !ok {
+				break
+			}
+			pid = parent.ppid
+		}
+		process.Orphaned = process.Unit == ""
+		processes = append(processes, process)
+	}
+
+	sort.Slice(processes, func(i, j int) bool {
+		if processes[i].Core != processes[j].Core {
+			return processes[i].Core < processes[j].Core
+		}
+		return processes[i].PID < processes[j].PID
+	})
+	return processes, nil
+}
+
+// FindNodeProcesses returns the node processes for the given cores (0 for the master)
+func FindNodeProcesses(cores []int, cfg *config.Config) ([]NodeProcess, error) {
+	processes, err := ListNodeProcesses(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return filterCores(processes, cores), nil
+}
+
+// filterCores keeps the processes running one of the cores
+func filterCores(processes []NodeProcess, cores []int) []NodeProcess {
+	wanted := make(map[int]bool, len(cores))
+	for _, core := range cores {
+		wanted[core] = true
+	}
+	var matched []NodeProcess
+	for _, process := range processes {
+		if wanted[process.Core] {
+			matched = append(matched, process)
+		}
+	}
+	return matched
+}
+
+// nodeBinaryPaths returns the paths services start the node with
+func nodeBinaryPaths(cfg *config.Config) map[string]bool {
+	paths := map[string]bool{DefaultServiceBinaryPath: true}
+	if cfg != nil && cfg.Service != nil && filepath.IsAbs(cfg.Service.LinkName) {
+		paths[cfg.Service.LinkName] = true
+	}
+	return paths
+}
+
+// isNodeCommand reports whether a command runs the node
+// The second argument is checked too, for a binary started through an interpreter
+// A bare "node" is not enough, since that is also Node.js
+func isNodeCommand(args []string, binaries map[string]bool) bool {
+	for i := 0; i < len(args) && i < 2; i++ {
+		if binaries[args[i]] || versionedNodeBinary.MatchString(filepath.Base(args[i])) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCoreArg returns the core from --core N, --core=N or core=N, or 0 for the master
+func parseCoreArg(args []string) int {
+	for i, arg := range args {
+		value := ""
+		switch {
+		case arg == "--core" && i+1 < len(args):
+			value = args[i+1]
+		case strings.HasPrefix(arg, "--core="):
+			value = strings.TrimPrefix(arg, "--core=")
+		case strings.HasPrefix(arg, "core="):
+			value = strings.TrimPrefix(arg, "core=")
+		default:
+			continue
+		}
+		if core, err := strconv.Atoi(value); err == nil && core > 0 {
+			return core
+		}
+	}
+	return 0
+}
+
+// readProcessTable lists every process from /proc, or from ps where there is no /proc (macOS)
+func readProcessTable() (map[int]*processEntry, error) {
+	if _, err := os.Stat("/proc/self/stat"); err == nil {
+		return readProcTable()
+	}
+	return readPsTable()
+}
+
+// readProcTable lists processes from /proc
+func readProcTable() (map[int]*processEntry, error) {
+	dirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	table := make(map[int]*processEntry)
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(dir.Name())
+		if err != nil {
+			continue
+		}
+		entry, ok := readProcEntry(pid)
+		if !ok {
+			continue // exited while listing
+		}
+		table[pid] = entry
+	}
+	return table, nil
+}
+
+// readProcEntry reads a process's parent, state and arguments from /proc
+func readProcEntry(pid int) (*processEntry, bool) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, false
+	}
+	// The command name in parentheses may contain spaces; the fields after it are state and ppid
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return nil, false
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 2 {
+		return nil, false
+	}
+	entry := &processEntry{pid: pid, zombie: fields[0] == "Z"}
+	entry.ppid, _ = strconv.Atoi(fields[1])
+
+	if cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid)); err == nil {
+		for _, arg := range strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00") {
+			if arg != "" {
+				entry.args = append(entry.args, arg)
+			}
+		}
+	}
+	return entry, true
+}
+
+// readPsTable lists processes with ps
+// Arguments are split on whitespace, so paths with spaces are not matched
+func readPsTable() (map[int]*processEntry, error) {
+	output, err := exec.Command("ps", "-axo", "pid=,ppid=,state=,command=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	table := make(map[int]*processEntry)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		table[pid] = &processEntry{pid: pid, ppid: ppid, zombie: strings.HasPrefix(fields[2], "Z"), args: fields[3:]}
+	}
+	return table, nil
+}
+
+// runningProcesses returns which of the processes are still running (zombies have exited)
+func runningProcesses(pids []int) map[int]bool {
+	running := make(map[int]bool, len(pids))
+	if _, err := os.Stat("/proc/self/stat"); err == nil {
+		for _, pid := range pids {
+			if entry, ok := readProcEntry(pid); ok && !entry.zombie {
+				running[pid] = true
+			}
+		}
+		return running
+	}
+
+	table, err := readPsTable()
+	for _, pid := range pids {
+		if err != nil {
+			running[pid] = signalProcess(pid, 0) == nil
+		} else if entry, ok := table[pid]; ok && !entry.zombie {
+			running[pid] = true
+		}
+	}
+	return running
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// updateGolden rewrites the golden files from the current templates: go test ./internal/service -update
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// goldenServiceConfig returns the master or a worker's service config used for the golden files
+func goldenServiceConfig(worker bool) *ServiceConfig {
+	nice := 5
+	cfg := &ServiceConfig{
+		ServiceOptions: &ServiceOptions{
+			Debug:               true,
+			IPFSDebug:           true,
+			RestartTime:         "5s",
+			WorkerRestartTime:   "2s",
+			GOGC:                "200",
+			GOMEMLimit:          "8GiB",
+			EnableCPUScheduling: true,
+			DataWorkerPriority:  90,
+			Args:                "--dht-only",
+			MaxThreads:          4,
+			MasterOverrides: &config.UnitOverrides{
+				MemoryMax:   "16G",
+				LimitNOFILE: 65536,
+				Hardening:   true,
+			},
+			WorkerOverrides: &config.UnitOverrides{
+				CPUQuota:          "100%",
+				Nice:              &nice,
+				IOSchedulingClass: "best-effort",
+				Extra:             map[string]string{"TasksMax": "4096"},
+			},
+		},
+		ServiceName: "ceremonyclient",
+		WorkingDir:  "/home/quilibrium/ceremonyclient/node",
+		BinaryPath:  "/usr/local/bin/node",
+		User:        "quilibrium",
+		Group:       "qtools",
+	}
+	if worker {
+		cfg.IsWorker = true
+		cfg.WorkerIndex = 3
+	}
+	return cfg
+}
+
+// checkGolden compares content to testdata/units/name, or rewrites the file with -update
+func checkGolden(t *testing.T, name string, content []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "units", name)
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run go test -update to create it): %v", err)
+	}
+	if string(content) != string(want) {
+		t.Errorf("%s does not match the rendered unit:\n%s", path, UnifiedDiff(path, "rendered", string(want), string(content)))
+	}
+}
+
+func TestRenderServiceFileGolden(t *testing.T) {
+	backends := []struct {
+		name    string
+		backend ServiceBackend
+	}{
+		{name: "systemd", backend: NewSystemdBackend()},
+		{name: "systemd-user", backend: NewSystemdUserBackend()},
+		{name: "launchd", backend: NewLaunchdBackend()},
+		{name: "openrc", backend: NewOpenRCBackend()},
+		{name: "runit", backend: NewRunitBackend()},
+		{name: "supervisor", backend: NewSupervisorBackend(nil)},
+	}
+	for _, b := range backends {
+		for _, unit := range []string{"master", "worker"} {
+			t.Run(b.name+"/"+unit, func(t *testing.T) {
+				content, err := b.backend.RenderServiceFile(goldenServiceConfig(unit == "worker"))
+				if err != nil {
+					t.Fatalf("RenderServiceFile failed: %v", err)
+				}
+				checkGolden(t, b.name+"-"+unit+".golden", content)
+			})
+		}
+	}
+}
+
+func TestRenderDropInGolden(t *testing.T) {
+	sb := NewSystemdBackend()
+	for _, unit := range []string{"master", "worker"} {
+		t.Run(unit, func(t *testing.T) {
+			content, err := sb.RenderDropIn(goldenServiceConfig(unit == "worker"))
+			if err != nil {
+				t.Fatalf("RenderDropIn failed: %v", err)
+			}
+			checkGolden(t, "systemd-"+unit+"-override.conf.golden", content)
+		})
+	}
+}
+
+func TestRenderTimerGolden(t *testing.T) {
+	svc := &CommandService{
+		Name:        "qtools-task-updates-node",
+		Description: "qtools task updates.node: Update the node binary",
+		Command:     []string{"/usr/local/bin/qtools", "tasks", "run", "--scheduled", "updates.node"},
+		WorkingDir:  "/home/quilibrium/qtools",
+		Env:         []string{"QTOOLS_CONFIG_FILE=/home/quilibrium/qtools/config.yml"},
+	}
+	unit, timer, err := NewSystemdBackend().RenderTimer(&TimerService{
+		CommandService: svc,
+		OnCalendar:     []string{"*-*-* *:00/10:00"},
+		OnBoot:         true,
+	})
+	if err != nil {
+		t.Fatalf("RenderTimer failed: %v", err)
+	}
+	checkGolden(t, "systemd-timer.service.golden", unit)
+	checkGolden(t, "systemd-timer.timer.golden", timer)
+}
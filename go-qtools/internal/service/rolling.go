@@ -0,0 +1,242 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+)
+
+// Rolling restart defaults
+const (
+	DefaultRollingBatchSize = 4
+	DefaultRollingPause     = 10 * time.Second
+	DefaultRollingTimeout   = 2 * time.Minute
+
+	rollingPollInterval = 2 * time.Second
+)
+
+// Rolling restart phases reported in RollingEvent
+const (
+	RollingPhaseRestarting = "restarting"
+	RollingPhaseWaiting    = "waiting"
+	RollingPhaseHealthy    = "healthy"
+	RollingPhaseFailed     = "failed"
+	RollingPhaseMaster     = "master"
+)
+
+// RollingOptions controls a rolling restart
+type RollingOptions struct {
+	BatchSize  int           // Workers restarted per batch (default DefaultRollingBatchSize)
+	Pause      time.Duration // Pause after each healthy batch
+	Timeout    time.Duration // How long a batch may take to become healthy (default DefaultRollingTimeout)
+	Cores      string        // Restrict to these workers (e.g., "1-8"); the master is then left alone
+	SkipMaster bool          // Do not restart the master after the workers
+	Progress   func(RollingEvent)
+}
+
+// RollingEvent reports the progress of a rolling restart
+type RollingEvent struct {
+	Batch    int // 1-based; 0 for the master
+	Batches  int
+	Workers  []int
+	Phase    string
+	Err      error
+	Duration time.Duration
+}
+
+// RollingRestart restarts data workers in batches, waiting after each batch until the
+// workers are active and their stream ports are listening, then restarts the master
+// It stops at the first batch that fails or does not become healthy
+// Workers must run as separate services (manual mode)
+func RollingRestart(opts RollingOptions, cfg *config.Config) error {
+	if !node.IsManualMode(cfg) {
+		return fmt.Errorf("rolling restart requires manual mode (workers running as separate services)")
+	}
+
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return err
+	}
+
+	workerCount := node.GetWorkerCount(cfg)
+	workers := workerRange(workerCount)
+	if opts.Cores != "" {
+		workers, err = ParseCoreNumbers(opts.Cores)
+		if err != nil {
+			return err
+		}
+	}
+	if len(workers) == 0 {
+		return fmt.Errorf("no workers to restart")
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultRollingBatchSize
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRollingTimeout
+	}
+	report := func(event RollingEvent) {
+		if opts.Progress != nil {
+			opts.Progress(event)
+		}
+	}
+
+	streamAddrs := workerStreamAddrs(cfg, workerCount)
+	serviceName := getServiceName(cfg)
+	batches := (len(workers) + batchSize - 1) / batchSize
+
+	for b := 0; b < batches; b++ {
+		batch := workers[b*batchSize : min((b+1)*batchSize, len(workers))]
+		event := RollingEvent{Batch: b + 1, Batches: batches, Workers: batch}
+		start := time.Now()
+
+		event.Phase = RollingPhaseRestarting
+		report(event)
+		err := RunWorkerAction(WorkerActionRestart, batch, WorkerOptions{
+			Concurrency:     len(batch),
+			ContinueOnError: true,
+		}, cfg)
+		if err == nil {
+			event.Phase = RollingPhaseWaiting
+			report(event)
+			err = waitForWorkers(backend, serviceName, batch, streamAddrs, timeout)
+		}
+
+		event.Duration = time.Since(start)
+		if err != nil {
+			event.Phase = RollingPhaseFailed
+			event.Err = err
+			report(event)
+			return fmt.Errorf("rolling restart stopped at batch %d/%d (workers %s): %w",
+				b+1, batches, FormatWorkerList(batch), err)
+		}
+
+		event.Phase = RollingPhaseHealthy
+		report(event)
+
+		if opts.Pause > 0 && b < batches-1 {
+			time.Sleep(opts.Pause)
+		}
+	}
+
+	if opts.Cores != "" || opts.SkipMaster {
+		return nil
+	}
+
+	start := time.Now()
+	report(RollingEvent{Batches: batches, Phase: RollingPhaseMaster})
+	if err := backend.RestartService(serviceName); err != nil {
+		return fmt.Errorf("failed to restart master: %w", err)
+	}
+	report(RollingEvent{Batches: batches, Phase: RollingPhaseHealthy, Duration: time.Since(start)})
+
+	return nil
+}
+
+// waitForWorkers waits until every worker is active and listening on its stream address
+func waitForWorkers(backend ServiceBackend, serviceName string, workers []int, streamAddrs map[int]string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var pending []string
+		for _, index := range workers {
+			if reason := workerHealth(backend, fmt.Sprintf("%s-worker@%d", serviceName, index), streamAddrs[index]); reason != "" {
+				pending = append(pending, fmt.Sprintf("worker %d %s", index, reason))
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("workers not healthy after %s: %s", timeout, strings.Join(pending, "; "))
+		}
+		time.Sleep(rollingPollInterval)
+	}
+}
+
+// workerHealth returns why a worker is not healthy yet, or "" when it is
+func workerHealth(backend ServiceBackend, name string, streamAddr string) string {
+	status, err := backend.GetStatus(name)
+	if err != nil {
+		return fmt.Sprintf("status unavailable (%v)", err)
+	}
+	if !status.Active {
+		state := status.ActiveState
+		if state == "" {
+			state = "inactive"
+		}
+		return state
+	}
+	if streamAddr != "" {
+		conn, err := net.DialTimeout("tcp", streamAddr, time.Second)
+		if err != nil {
+			return fmt.Sprintf("not listening on %s", streamAddr)
+		}
+		conn.Close()
+	}
+	return ""
+}
+
+// workerStreamAddrs maps worker index to the host:port its stream listener is dialed on
+func workerStreamAddrs(cfg *config.Config, workerCount int) map[int]string {
+	var nodeConfig *node.NodeConfig
+	if mgr, err := node.NewNodeConfigManager(""); err == nil {
+		nodeConfig, _ = mgr.Load()
+	}
+	return streamAddrsFor(cfg, nodeConfig, workerCount)
+}
+
+// streamAddrsFor uses the workers' stream multiaddrs from the node config, falling back to
+// the planned stream ports on loopback for workers without one
+func streamAddrsFor(cfg *config.Config, nodeConfig *node.NodeConfig, workerCount int) map[int]string {
+	var multiaddrs []string
+	if nodeConfig != nil && nodeConfig.Engine != nil {
+		multiaddrs = nodeConfig.Engine.DataWorkerStreamMultiaddrs
+	}
+
+	plan := node.PlanPorts(cfg, nodeConfig, workerCount)
+	addrs := make(map[int]string, plan.WorkerCount)
+	for i := 1; i <= plan.WorkerCount; i++ {
+		host, port := "127.0.0.1", plan.BaseStreamPort+i-1
+		if i <= len(multiaddrs) {
+			if ip, p, _, err := node.ParseMultiaddr(multiaddrs[i-1]); err == nil {
+				port = p
+				// A wildcard listener is reachable on loopback
+				if parsed := net.ParseIP(ip); parsed != nil && !parsed.IsUnspecified() {
+					host = ip
+				}
+			}
+		}
+		addrs[i] = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+	return addrs
+}
+
+// FormatWorkerList formats worker indexes compactly, e.g. "1-4,7"
+func FormatWorkerList(workers []int) string {
+	sorted := append([]int(nil), workers...)
+	sort.Ints(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, fmt.Sprintf("%d", sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
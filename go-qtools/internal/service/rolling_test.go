@@ -0,0 +1,77 @@
+package service
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+)
+
+// activeBackend reports every service as active
+type activeBackend struct {
+	ServiceBackend
+}
+
+func (activeBackend) GetStatus(name string) (*ServiceStatus, error) {
+	return &ServiceStatus{Name: name, Active: true, ActiveState: "active"}, nil
+}
+
+// nonLoopbackIPv4 returns an IPv4 address of a local interface other than loopback
+func nonLoopbackIPv4(t *testing.T) string {
+	t.Helper()
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		t.Skipf("cannot list interface addresses: %v", err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+	}
+	t.Skip("no non-loopback IPv4 address")
+	return ""
+}
+
+// TestWorkerHealthConfiguredStreamAddress checks that a worker listening only on the
+// address of its stream multiaddr is found healthy
+func TestWorkerHealthConfiguredStreamAddress(t *testing.T) {
+	ip := nonLoopbackIPv4(t)
+	listener, err := net.Listen("tcp4", net.JoinHostPort(ip, "0"))
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", ip, err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	nodeConfig := &node.NodeConfig{Engine: &node.EngineConfig{
+		DataWorkerStreamMultiaddrs: []string{
+			"/ip4/0.0.0.0/tcp/60000",
+			node.BuildMultiaddr(ip, port, "tcp"),
+		},
+	}}
+	addrs := streamAddrsFor(&config.Config{}, nodeConfig, 3)
+
+	want := map[int]string{
+		1: "127.0.0.1:60000",
+		2: net.JoinHostPort(ip, strconv.Itoa(port)),
+		3: "127.0.0.1:" + strconv.Itoa(node.DefaultBaseStreamPort+2),
+	}
+	for index, addr := range want {
+		if addrs[index] != addr {
+			t.Errorf("worker %d stream address = %q, want %q", index, addrs[index], addr)
+		}
+	}
+
+	if reason := workerHealth(activeBackend{}, "ceremonyclient-worker@2", addrs[2]); reason != "" {
+		t.Errorf("worker listening on %s reported as %q", addrs[2], reason)
+	}
+
+	listener.Close()
+	reason := workerHealth(activeBackend{}, "ceremonyclient-worker@2", addrs[2])
+	if !strings.Contains(reason, "not listening on "+addrs[2]) {
+		t.Errorf("closed listener reported as %q", reason)
+	}
+}
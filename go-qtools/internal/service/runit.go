@@ -0,0 +1,273 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/supervisor"
+)
+
+// runitSvDir holds runit service directories; they are linked into the runsvdir to be supervised
+const runitSvDir = "/etc/sv"
+
+// runitStopTimeout matches the systemd units' TimeoutStopSec before the node is killed
+const runitStopTimeout = 240
+
+// runitStatusPattern matches sv status output, e.g., "run: /var/service/ceremonyclient: (pid 123) 45s"
+var runitStatusPattern = regexp.MustCompile(`^(\w+): [^:]+: (?:\(pid (\d+)\) )?(\d+)s`)
+
+// RunitBackend implements ServiceBackend for runit (e.g., Void)
+// Each service is a directory with run, finish and log/run scripts. A down file keeps it from
+// starting when the runsvdir starts, which is how services are disabled. runsv has no
+// on-failure policy, so workers also restart after a clean exit
+type RunitBackend struct{}
+
+// NewRunitBackend creates a new runit backend
+func NewRunitBackend() *RunitBackend {
+	return &RunitBackend{}
+}
+
+// serviceDir returns the service's directory under /etc/sv
+func (rb *RunitBackend) serviceDir(name string) string {
+	return filepath.Join(runitSvDir, name)
+}
+
+// linkPath returns where the service directory is linked into the runsvdir
+func (rb *RunitBackend) linkPath(name string) string {
+	return filepath.Join(runitRunsvDir(), name)
+}
+
+// runitRunsvDir returns the directory runsvdir watches: $SVDIR, /var/service (Void) or /etc/service
+func runitRunsvDir() string {
+	if dir := os.Getenv("SVDIR"); dir != "" {
+		return dir
+	}
+	for _, dir := range []string{"/var/service", "/etc/service", "/service"} {
+		if dirExists(dir) {
+			return dir
+		}
+	}
+	return "/var/service"
+}
+
+// sv runs sv with sudo against the linked service
+func (rb *RunitBackend) sv(name string, args ...string) error {
+	action := args[len(args)-1]
+	output, err := sudoCommand("sv", append(args, rb.linkPath(name))...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to %s service %s: %w\nOutput: %s", action, name, err, string(output))
+	}
+	return nil
+}
+
+// StartService starts a runit service
+func (rb *RunitBackend) StartService(name string) error {
+	return rb.sv(name, "start")
+}
+
+// StopService stops a runit service, killing it when it has not exited after the stop timeout
+func (rb *RunitBackend) StopService(name string) error {
+	return rb.sv(name, "-w", strconv.Itoa(runitStopTimeout), "force-stop")
+}
+
+// RestartService restarts a runit service
+// The restart is a stop and a start so the finish script's restart delay is skipped
+func (rb *RunitBackend) RestartService(name string) error {
+	if err := rb.StopService(name); err != nil {
+		return err
+	}
+	return rb.StartService(name)
+}
+
+// GetStatus gets the status of a runit service from sv status
+func (rb *RunitBackend) GetStatus(name string) (*ServiceStatus, error) {
+	status := &ServiceStatus{
+		Name:        name,
+		LoadState:   "loaded",
+		Enabled:     !fileExists(filepath.Join(rb.serviceDir(name), "down")),
+		ActiveState: "inactive",
+		SubState:    "dead",
+	}
+	if !fileExists(rb.ServiceFilePath(name)) {
+		status.LoadState = "not-found"
+		status.Enabled = false
+		return status, nil
+	}
+
+	// sv exits non-zero for services that are down or not supervised, so only the output is used
+	output, _ := sudoCommand("sv", "status", rb.linkPath(name)).CombinedOutput()
+	status.StatusText = strings.TrimSpace(string(output))
+	state, pid, since := parseRunitStatus(string(output))
+
+	switch state {
+	case "run":
+		status.ActiveState, status.SubState = "active", "running"
+		status.PID = pid
+		status.StartedAt = time.Now().Add(-since)
+		status.MemoryBytes = supervisor.ProcessMemory(pid)
+	case "finish":
+		status.ActiveState, status.SubState = "activating", "auto-restart"
+	}
+	status.Active = status.ActiveState == "active"
+	status.Running = status.SubState == "running"
+	return status, nil
+}
+
+// parseRunitStatus returns the state (run, down or finish), PID and time in state from sv status output
+func parseRunitStatus(output string) (string, int, time.Duration) {
+	// The log service's status follows the first ";"
+	service, _, _ := strings.Cut(strings.TrimSpace(output), ";")
+	match := runitStatusPattern.FindStringSubmatch(service)
+	if match == nil {
+		return "", 0, 0
+	}
+	pid, _ := strconv.Atoi(match[2])
+	seconds, _ := strconv.Atoi(match[3])
+	return match[1], pid, time.Duration(seconds) * time.Second
+}
+
+// EnableService starts a runit service when the runsvdir starts by removing its down file
+func (rb *RunitBackend) EnableService(name string) error {
+	output, err := sudoCommand("rm", "-f", filepath.Join(rb.serviceDir(name), "down")).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to enable service %s: %w\nOutput: %s", name, err, string(output))
+	}
+	return nil
+}
+
+// DisableService keeps a runit service from starting with the runsvdir by creating its down file
+func (rb *RunitBackend) DisableService(name string) error {
+	if !dirExists(rb.serviceDir(name)) {
+		return nil
+	}
+	output, err := sudoCommand("touch", filepath.Join(rb.serviceDir(name), "down")).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to disable service %s: %w\nOutput: %s", name, err, string(output))
+	}
+	return nil
+}
+
+// CreateServiceFile creates a runit service directory
+func (rb *RunitBackend) CreateServiceFile(name string, config *ServiceConfig) error {
+	return rb.UpdateServiceFile(name, config)
+}
+
+// UpdateServiceFile writes the service's scripts and links it into the runsvdir
+// New services get a down file so they are not started until enabled, like the other backends
+func (rb *RunitBackend) UpdateServiceFile(name string, config *ServiceConfig) error {
+	run, err := rb.RenderServiceFile(config)
+	if err != nil {
+		return err
+	}
+
+	dir := rb.serviceDir(name)
+	if !dirExists(dir) {
+		if err := installFile(sudoCommand, filepath.Join(dir, "down"), nil, "0644"); err != nil {
+			return err
+		}
+	}
+
+	delay := parseRestartTime(config.ServiceOptions.RestartTime)
+	if config.IsWorker {
+		delay = parseRestartTime(config.ServiceOptions.WorkerRestartTime)
+	}
+	scripts := map[string]string{
+		"run":       string(run),
+		"finish":    fmt.Sprintf(runitFinishTemplate, delay),
+		"control/d": runitControlScript,
+		"control/t": runitControlScript,
+		"log/run":   fmt.Sprintf(runitLogTemplate, filepath.Join("/var/log", name), filepath.Join("/var/log", name)),
+	}
+	for _, file := range sortedKeys(scripts) {
+		if err := installFile(sudoCommand, filepath.Join(dir, file), []byte(scripts[file]), "0755"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Lstat(rb.linkPath(name)); err != nil {
+		output, err := sudoCommand("ln", "-sfn", dir, rb.linkPath(name)).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to link %s into %s: %w\nOutput: %s", dir, runitRunsvDir(), err, string(output))
+		}
+	}
+	return nil
+}
+
+// RenderServiceFile returns the run script for a service
+func (rb *RunitBackend) RenderServiceFile(config *ServiceConfig) ([]byte, error) {
+	opts := config.ServiceOptions
+	if opts == nil {
+		return nil, fmt.Errorf("service options are required")
+	}
+
+	data := struct {
+		ServiceConfig
+		Header       string
+		Command      string
+		EnvVars      []string
+		RestartDelay int
+	}{
+		ServiceConfig: *config,
+		Header:        generatedScriptHeader,
+		Command:       shellJoin(buildProgramArguments(config)),
+		RestartDelay:  parseRestartTime(opts.RestartTime),
+	}
+	if config.IsWorker {
+		data.RestartDelay = parseRestartTime(opts.WorkerRestartTime)
+	}
+
+	env := buildEnvironmentMap(config)
+	for _, key := range sortedKeys(env) {
+		data.EnvVars = append(data.EnvVars, key+"="+shellQuote(env[key]))
+	}
+
+	tmpl, err := template.New("runit").Parse(runitRunTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to generate service file content: %w", err)
+	}
+	return []byte(buf.String()), nil
+}
+
+// ServiceFilePath returns the run script path for a service
+func (rb *RunitBackend) ServiceFilePath(name string) string {
+	return filepath.Join(rb.serviceDir(name), "run")
+}
+
+// runitRunTemplate runs the node as the service user
+// The restart delay is repeated here so a change to it shows up in service diff and update
+const runitRunTemplate = `#!/bin/sh
+{{.Header}}# Restarts after {{.RestartDelay}}s (see finish)
+exec 2>&1
+cd {{.WorkingDir}} || exit 1
+exec {{if .User}}chpst -u {{.User}}:{{.Group}} {{end}}{{if .EnvVars}}env{{range .EnvVars}} {{.}}{{end}} {{end}}{{.Command}}
+`
+
+// runitFinishTemplate delays restarts like RestartSec, except when the service is being stopped
+const runitFinishTemplate = `#!/bin/sh
+case "$(cat supervise/stat 2>/dev/null)" in
+*"want down"*|*"want exit"*) exit 0 ;;
+esac
+exec sleep %d
+`
+
+// runitControlScript replaces the TERM that sv down and sv term send with SIGINT, which
+// stops the node gracefully
+const runitControlScript = `#!/bin/sh
+exec kill -INT "$(cat supervise/pid)"
+`
+
+// runitLogTemplate writes the service output to a timestamped svlogd directory
+const runitLogTemplate = `#!/bin/sh
+mkdir -p %s
+exec svlogd -tt %s
+`
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+)
+
+// Service file defaults
+const (
+	DefaultServiceBinaryPath = "/usr/local/bin/node"
+	DefaultServiceUser       = "quilibrium"
+	DefaultServiceGroup      = "qtools"
+)
+
+// ServiceFile is a generated service file alongside the one currently installed
+type ServiceFile struct {
+	Name      string // Service name (e.g., ceremonyclient, ceremonyclient-worker@)
+	Path      string // Installed location
+	DropIn    bool   // A drop-in override file rather than the service file itself
+	Rendered  []byte // Content generated from the current config; nil when the file should not exist
+	Installed []byte // Content on disk; nil when not installed
+	Exists    bool
+
+	write func() error
+}
+
+// Changed reports whether the installed file differs from the generated one
+func (f *ServiceFile) Changed() bool {
+	return string(f.Installed) != string(f.Rendered)
+}
+
+// Diff returns a unified diff from the installed file to the generated one, or "" when unchanged
+func (f *ServiceFile) Diff() string {
+	from, to := f.Path, f.Path+" (generated)"
+	if !f.Exists {
+		from = "/dev/null"
+	}
+	if f.Rendered == nil {
+		to = "/dev/null"
+	}
+	return UnifiedDiff(from, to, string(f.Installed), string(f.Rendered))
+}
+
+// readInstalled fills in the installed content
+func (f *ServiceFile) readInstalled() error {
+	installed, err := os.ReadFile(f.Path)
+	switch {
+	case err == nil:
+		f.Installed = installed
+		f.Exists = true
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("failed to read %s: %w", f.Path, err)
+	}
+	return nil
+}
+
+// BuildServiceConfig builds the service file configuration for the master (workerIndex 0) or a worker
+func BuildServiceConfig(cfg *config.Config, workerIndex int) (*ServiceConfig, error) {
+	serviceOpts, err := LoadServiceOptionsFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service options: %w", err)
+	}
+
+	return &ServiceConfig{
+		ServiceOptions: serviceOpts,
+		ServiceName:    getServiceName(cfg),
+		WorkingDir:     config.GetNodePath(),
+		BinaryPath:     DefaultServiceBinaryPath,
+		User:           DefaultServiceUser,
+		Group:          DefaultServiceGroup,
+		IsWorker:       workerIndex > 0,
+		WorkerIndex:    workerIndex,
+	}, nil
+}
+
+// serviceFileName returns the service defined by the master (workerIndex 0) or a worker's file
+// On backends with a worker template every worker shares the template
+func serviceFileName(backend ServiceBackend, cfg *config.Config, workerIndex int) string {
+	if workerIndex <= 0 {
+		return getServiceName(cfg)
+	}
+	if templates, ok := backend.(WorkerTemplateBackend); ok {
+		return templates.WorkerTemplateName(getServiceName(cfg))
+	}
+	return fmt.Sprintf("%s-worker@%d", getServiceName(cfg), workerIndex)
+}
+
+// LoadServiceFilesFor renders the service file for the master (workerIndex 0) or a worker,
+// followed by its drop-in on backends that use them, and reads the installed copies
+func LoadServiceFilesFor(cfg *config.Config, workerIndex int) ([]*ServiceFile, error) {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return loadServiceFiles(backend, cfg, workerIndex)
+}
+
+// loadServiceFiles renders a service file and its drop-in with the given backend
+func loadServiceFiles(backend ServiceBackend, cfg *config.Config, workerIndex int) ([]*ServiceFile, error) {
+	serviceConfig, err := BuildServiceConfig(cfg, workerIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	name := serviceFileName(backend, cfg, workerIndex)
+	rendered, err := backend.RenderServiceFile(serviceConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []*ServiceFile{{
+		Name:     name,
+		Path:     backend.ServiceFilePath(name),
+		Rendered: rendered,
+		write:    func() error { return backend.UpdateServiceFile(name, serviceConfig) },
+	}}
+
+	if dropIns, ok := backend.(DropInBackend); ok {
+		rendered, err := dropIns.RenderDropIn(serviceConfig)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &ServiceFile{
+			Name:     name,
+			Path:     dropIns.DropInPath(name),
+			DropIn:   true,
+			Rendered: rendered,
+			write:    func() error { return dropIns.UpdateDropIn(name, serviceConfig) },
+		})
+	}
+
+	for _, file := range files {
+		if err := file.readInstalled(); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// LoadServiceFiles loads every managed service file: the master and, in manual mode, the
+// worker template or one file per worker, each followed by its drop-in
+func LoadServiceFiles(cfg *config.Config) ([]*ServiceFile, error) {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := []int{0}
+	if node.IsManualMode(cfg) {
+		if _, ok := backend.(WorkerTemplateBackend); ok {
+			indexes = append(indexes, 1)
+		} else {
+			indexes = append(indexes, workerRange(node.GetWorkerCount(cfg))...)
+		}
+	}
+
+	var files []*ServiceFile
+	for _, index := range indexes {
+		loaded, err := loadServiceFiles(backend, cfg, index)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, loaded...)
+	}
+	return files, nil
+}
+
+// UpdateServiceFiles writes every managed service file and drop-in whose content changed
+// On backends with a worker template, per-worker files from older installs are then
+// migrated away. It returns the files written and the paths removed
+func UpdateServiceFiles(cfg *config.Config) (updated []*ServiceFile, removed []string, err error) {
+	files, err := LoadServiceFiles(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, file := range files {
+		if !file.Changed() {
+			continue
+		}
+		if err := file.write(); err != nil {
+			return updated, nil, fmt.Errorf("failed to update %s: %w", file.Path, err)
+		}
+		updated = append(updated, file)
+	}
+
+	if !node.IsManualMode(cfg) {
+		return updated, nil, nil
+	}
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return updated, nil, err
+	}
+	if templates, ok := backend.(WorkerTemplateBackend); ok {
+		removed, err = templates.MigrateWorkerFiles(getServiceName(cfg))
+		if err != nil {
+			return updated, removed, fmt.Errorf("failed to migrate worker service files: %w", err)
+		}
+	}
+	return updated, removed, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/supervisor"
+)
+
+// supervisorCallTimeout bounds control requests other than stops
+const supervisorCallTimeout = 30 * time.Second
+
+// SupervisorBackend implements ServiceBackend with the built-in supervisor (qtools supervise)
+// Service files are JSON specs the supervisor reads when it starts a service
+type SupervisorBackend struct {
+	paths       supervisor.Paths
+	stopTimeout time.Duration
+}
+
+// NewSupervisorBackend creates a supervisor backend from service.supervisor
+func NewSupervisorBackend(cfg *config.Config) *SupervisorBackend {
+	opts, _ := supervisor.OptionsFromConfig(cfg)
+	return &SupervisorBackend{
+		paths:       supervisor.PathsFromConfig(cfg),
+		stopTimeout: opts.StopTimeout,
+	}
+}
+
+// call sends a control request to the supervisor, wrapping errors as "failed to <action> service <name>"
+func (b *SupervisorBackend) call(action, name string, timeout time.Duration) (*supervisor.Response, error) {
+	resp, err := supervisor.Call(b.paths.Socket, supervisor.Request{Action: action, Name: name}, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s service %s: %w", action, name, err)
+	}
+	return resp, nil
+}
+
+// StartService starts a supervised service
+func (b *SupervisorBackend) StartService(name string) error {
+	_, err := b.call(supervisor.ActionStart, name, supervisorCallTimeout)
+	return err
+}
+
+// StopService stops a supervised service, waiting for the graceful stop timeout
+func (b *SupervisorBackend) StopService(name string) error {
+	_, err := b.call(supervisor.ActionStop, name, b.stopTimeout+supervisorCallTimeout)
+	return err
+}
+
+// RestartService restarts a supervised service
+func (b *SupervisorBackend) RestartService(name string) error {
+	_, err := b.call(supervisor.ActionRestart, name, b.stopTimeout+supervisorCallTimeout)
+	return err
+}
+
+// GetStatus gets the status of a supervised service
+func (b *SupervisorBackend) GetStatus(name string) (*ServiceStatus, error) {
+	resp, err := supervisor.Call(b.paths.Socket, supervisor.Request{Action: supervisor.ActionStatus, Name: name}, supervisorCallTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, fmt.Errorf("supervisor returned no status for %s", name)
+	}
+	return statusFromProcessStatus(resp.Status), nil
+}
+
+// statusFromProcessStatus converts a supervisor process status
+func statusFromProcessStatus(ps *supervisor.ProcessStatus) *ServiceStatus {
+	status := &ServiceStatus{
+		Name:        ps.Name,
+		Active:      ps.ActiveState == supervisor.StateActive,
+		Running:     ps.SubState == supervisor.SubStateRunning,
+		Enabled:     ps.Enabled,
+		PID:         ps.PID,
+		LoadState:   ps.LoadState,
+		ActiveState: ps.ActiveState,
+		SubState:    ps.SubState,
+		StartedAt:   ps.StartedAt,
+		Restarts:    ps.Restarts,
+		MemoryBytes: ps.MemoryBytes,
+		Result:      ps.Result,
+		ExitCode:    ps.ExitCode,
+	}
+	status.StatusText = fmt.Sprintf("ActiveState=%s SubState=%s MainPID=%d", status.ActiveState, status.SubState, status.PID)
+	return status
+}
+
+// EnableService starts the service whenever the supervisor starts
+func (b *SupervisorBackend) EnableService(name string) error {
+	return b.paths.SetEnabled(name, true)
+}
+
+// DisableService stops starting the service with the supervisor
+func (b *SupervisorBackend) DisableService(name string) error {
+	return b.paths.SetEnabled(name, false)
+}
+
+// CreateServiceFile creates a supervisor spec
+func (b *SupervisorBackend) CreateServiceFile(name string, config *ServiceConfig) error {
+	return b.UpdateServiceFile(name, config)
+}
+
+// UpdateServiceFile writes a supervisor spec; running services pick it up when restarted
+func (b *SupervisorBackend) UpdateServiceFile(name string, config *ServiceConfig) error {
+	content, err := b.RenderServiceFile(config)
+	if err != nil {
+		return err
+	}
+	return b.paths.WriteSpec(name, content)
+}
+
+// RenderServiceFile returns the spec for a service, mirroring the systemd units
+func (b *SupervisorBackend) RenderServiceFile(config *ServiceConfig) ([]byte, error) {
+	opts := config.ServiceOptions
+	if opts == nil {
+		return nil, fmt.Errorf("service options are required")
+	}
+
+	spec := &supervisor.Spec{
+		Name:         config.ServiceName,
+		Command:      buildProgramArguments(config),
+		WorkingDir:   config.WorkingDir,
+		Restart:      supervisor.RestartAlways,
+		RestartDelay: opts.RestartTime,
+	}
+	if config.IsWorker {
+		spec.Name = fmt.Sprintf("%s-worker@%d", config.ServiceName, config.WorkerIndex)
+		spec.Restart = supervisor.RestartOnFailure
+		spec.RestartDelay = opts.WorkerRestartTime
+	}
+
+	env := buildEnvironmentMap(config)
+	for _, key := range sortedKeys(env) {
+		spec.Env = append(spec.Env, key+"="+env[key])
+	}
+
+	return supervisor.RenderSpec(spec)
+}
+
+// ServiceFilePath returns the spec path for a service
+func (b *SupervisorBackend) ServiceFilePath(name string) string {
+	return b.paths.SpecPath(name)
+}
@@ -9,17 +9,69 @@ import (
 	"text/template"
 )
 
+// systemdUnitDir is where system unit files are installed
+const systemdUnitDir = "/etc/systemd/system"
+
 // SystemdBackend implements ServiceBackend for Linux systemd
-type SystemdBackend struct{}
+type SystemdBackend struct {
+	user bool // Manage units in the user's systemd instance (systemctl --user) without sudo
+}
 
-// NewSystemdBackend creates a new systemd backend
+// NewSystemdBackend creates a new systemd backend for system-wide units
 func NewSystemdBackend() *SystemdBackend {
 	return &SystemdBackend{}
 }
 
+// NewSystemdUserBackend creates a systemd backend for units in ~/.config/systemd/user
+func NewSystemdUserBackend() *SystemdBackend {
+	return &SystemdBackend{user: true}
+}
+
+// IsUser reports whether the backend manages the user's systemd instance
+func (sb *SystemdBackend) IsUser() bool {
+	return sb.user
+}
+
+// systemctl builds a systemctl command: sudo systemctl for system units, systemctl --user otherwise
+func (sb *SystemdBackend) systemctl(args ...string) *exec.Cmd {
+	if sb.user {
+		return exec.Command("systemctl", append([]string{"--user"}, args...)...)
+	}
+	return exec.Command("sudo", append([]string{"systemctl"}, args...)...)
+}
+
+// privileged builds a command that modifies unit files, using sudo for system units
+func (sb *SystemdBackend) privileged(name string, args ...string) *exec.Cmd {
+	if sb.user {
+		return exec.Command(name, args...)
+	}
+	return exec.Command("sudo", append([]string{name}, args...)...)
+}
+
+// unitDir returns where unit files are installed
+func (sb *SystemdBackend) unitDir() string {
+	if !sb.user {
+		return systemdUnitDir
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		homeDir, _ := os.UserHomeDir()
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, "systemd", "user")
+}
+
+// daemonReload reloads unit files
+func (sb *SystemdBackend) daemonReload() error {
+	if output, err := sb.systemctl("daemon-reload").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to reload systemd: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
 // StartService starts a systemd service
 func (sb *SystemdBackend) StartService(name string) error {
-	cmd := exec.Command("sudo", "systemctl", "start", name)
+	cmd := sb.systemctl("start", name)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to start service %s: %w\nOutput: %s", name, err, string(output))
@@ -29,7 +81,7 @@ func (sb *SystemdBackend) StartService(name string) error {
 
 // StopService stops a systemd service
 func (sb *SystemdBackend) StopService(name string) error {
-	cmd := exec.Command("sudo", "systemctl", "stop", name)
+	cmd := sb.systemctl("stop", name)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to stop service %s: %w\nOutput: %s", name, err, string(output))
@@ -39,7 +91,7 @@ func (sb *SystemdBackend) StopService(name string) error {
 
 // RestartService restarts a systemd service
 func (sb *SystemdBackend) RestartService(name string) error {
-	cmd := exec.Command("sudo", "systemctl", "restart", name)
+	cmd := sb.systemctl("restart", name)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to restart service %s: %w\nOutput: %s", name, err, string(output))
@@ -48,33 +100,18 @@ func (sb *SystemdBackend) RestartService(name string) error {
 }
 
 // GetStatus gets the status of a systemd service
+// Properties are read over D-Bus, falling back to systemctl show
 func (sb *SystemdBackend) GetStatus(name string) (*ServiceStatus, error) {
-	cmd := exec.Command("systemctl", "is-active", name)
-	activeOutput, _ := cmd.Output()
-	active := strings.TrimSpace(string(activeOutput)) == "active"
-
-	cmd = exec.Command("systemctl", "is-enabled", name)
-	enabledOutput, _ := cmd.Output()
-	enabled := strings.TrimSpace(string(enabledOutput)) == "enabled"
-
-	cmd = exec.Command("systemctl", "show", name, "--property=MainPID,ActiveState,SubState")
-	statusOutput, _ := cmd.Output()
-	statusText := strings.TrimSpace(string(statusOutput))
-
-	status := &ServiceStatus{
-		Name:       name,
-		Active:     active,
-		Running:    active,
-		Enabled:    enabled,
-		StatusText: statusText,
+	props, err := getSystemdProperties(name, sb.user)
+	if err != nil {
+		return nil, err
 	}
-
-	return status, nil
+	return statusFromSystemdProperties(name, props), nil
 }
 
 // EnableService enables a systemd service
 func (sb *SystemdBackend) EnableService(name string) error {
-	cmd := exec.Command("sudo", "systemctl", "enable", name)
+	cmd := sb.systemctl("enable", name)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to enable service %s: %w\nOutput: %s", name, err, string(output))
@@ -84,7 +121,7 @@ func (sb *SystemdBackend) EnableService(name string) error {
 
 // DisableService disables a systemd service
 func (sb *SystemdBackend) DisableService(name string) error {
-	cmd := exec.Command("sudo", "systemctl", "disable", name)
+	cmd := sb.systemctl("disable", name)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to disable service %s: %w\nOutput: %s", name, err, string(output))
@@ -99,32 +136,31 @@ func (sb *SystemdBackend) CreateServiceFile(name string, config *ServiceConfig)
 
 // UpdateServiceFile updates a systemd service file
 func (sb *SystemdBackend) UpdateServiceFile(name string, config *ServiceConfig) error {
-	serviceFilePath := filepath.Join("/etc/systemd/system", name+".service")
+	serviceFilePath := sb.ServiceFilePath(name)
 
-	content, err := sb.generateSystemdServiceFile(config)
+	content, err := sb.RenderServiceFile(config)
 	if err != nil {
-		return fmt.Errorf("failed to generate service file content: %w", err)
+		return err
 	}
 
-	// Write to temp file first
-	tmpFile := serviceFilePath + ".tmp"
-	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to write temp service file: %w", err)
-	}
-
-	// Move to final location with sudo
-	cmd := exec.Command("sudo", "mv", tmpFile, serviceFilePath)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to move service file: %w", err)
+	if err := installFile(sb.privileged, serviceFilePath, content, "0644"); err != nil {
+		return err
 	}
+	return sb.daemonReload()
+}
 
-	// Reload systemd
-	cmd = exec.Command("sudo", "systemctl", "daemon-reload")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to reload systemd: %w", err)
+// RenderServiceFile returns the unit file content for a service
+func (sb *SystemdBackend) RenderServiceFile(config *ServiceConfig) ([]byte, error) {
+	content, err := sb.generateSystemdServiceFile(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate service file content: %w", err)
 	}
+	return []byte(content), nil
+}
 
-	return nil
+// ServiceFilePath returns the unit file path for a service
+func (sb *SystemdBackend) ServiceFilePath(name string) string {
+	return filepath.Join(sb.unitDir(), name+".service")
 }
 
 // generateSystemdServiceFile generates the systemd service file content
@@ -147,6 +183,9 @@ func (sb *SystemdBackend) generateSystemdServiceFile(config *ServiceConfig) (str
 		ExecStart  string
 		ExecReload string
 		EnvVars    []string
+		CPUQuota   string
+		UserUnit   bool // User units run as the calling user; User= and Group= are not allowed
+		WantedBy   string
 	}
 
 	data := templateData{
@@ -154,6 +193,12 @@ func (sb *SystemdBackend) generateSystemdServiceFile(config *ServiceConfig) (str
 		ExecStart:     execStart,
 		ExecReload:    execReload,
 		EnvVars:       envVars,
+		CPUQuota:      fmt.Sprintf("%d%%", opts.MaxThreads*100),
+		UserUnit:      sb.user,
+		WantedBy:      "multi-user.target",
+	}
+	if sb.user {
+		data.WantedBy = "default.target"
 	}
 
 	var tmpl *template.Template
@@ -193,6 +238,8 @@ func (sb *SystemdBackend) buildExecStart(config *ServiceConfig) string {
 	}
 	if config.IsWorker {
 		parts = append(parts, "--core", "%i")
+	} else if config.ServiceOptions.Args != "" {
+		parts = append(parts, config.ServiceOptions.Args)
 	}
 
 	return strings.Join(parts, " ")
@@ -223,12 +270,17 @@ Description=Quilibrium Ceremony Client Service
 Type=simple
 Restart=always
 RestartSec={{.ServiceOptions.RestartTime}}
+{{- if not .UserUnit}}
 User={{.User}}
 Group={{.Group}}
+{{- end}}
 WorkingDirectory={{.WorkingDir}}
-{{range .EnvVars}}
+{{- range .EnvVars}}
 Environment={{.}}
-{{end}}
+{{- end}}
+{{- if .ServiceOptions.MaxThreads}}
+CPUQuota={{.CPUQuota}}
+{{- end}}
 ExecStart={{.ExecStart}}
 ExecStop=/bin/kill -s SIGINT $MAINPID
 ExecReload={{.ExecReload}}
@@ -238,7 +290,7 @@ FinalKillSignal=SIGKILL
 TimeoutStopSec=240
 
 [Install]
-WantedBy=multi-user.target
+WantedBy={{.WantedBy}}
 `
 
 const systemdWorkerServiceTemplate = `[Unit]
@@ -253,15 +305,17 @@ WorkingDirectory={{.WorkingDir}}
 Restart=on-failure
 RestartSec={{.ServiceOptions.WorkerRestartTime}}
 StartLimitBurst=5
+{{- if not .UserUnit}}
 User={{.User}}
 Group={{.Group}}
-{{range .EnvVars}}
+{{- end}}
+{{- range .EnvVars}}
 Environment={{.}}
-{{end}}
-{{if .ServiceOptions.EnableCPUScheduling}}
+{{- end}}
+{{- if .ServiceOptions.EnableCPUScheduling}}
 CPUSchedulingPolicy=rr
 CPUSchedulingPriority={{.ServiceOptions.DataWorkerPriority}}
-{{end}}
+{{- end}}
 ExecStart={{.ExecStart}}
 ExecStop=/bin/kill -s SIGINT $MAINPID
 ExecReload={{.ExecReload}}
@@ -271,5 +325,5 @@ FinalKillSignal=SIGKILL
 TimeoutStopSec=240
 
 [Install]
-WantedBy=multi-user.target
+WantedBy={{.WantedBy}}
 `
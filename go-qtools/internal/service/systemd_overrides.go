@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// dropInHeader marks override.conf files as generated
+const dropInHeader = "# Generated by qtools from service.overrides in config.yml.\n# Edit the config and run `qtools service update` instead of changing this file.\n"
+
+// ioSchedulingClasses are the values accepted for IOSchedulingClass
+var ioSchedulingClasses = map[string]bool{
+	"realtime":    true,
+	"best-effort": true,
+	"idle":        true,
+	"none":        true,
+}
+
+// RenderDropIn returns the override.conf content for the unit's service.overrides, or nil when none are set
+func (sb *SystemdBackend) RenderDropIn(config *ServiceConfig) ([]byte, error) {
+	if config.ServiceOptions == nil {
+		return nil, fmt.Errorf("service options are required")
+	}
+
+	overrides := config.ServiceOptions.MasterOverrides
+	section := "service.overrides.master"
+	if config.IsWorker {
+		overrides = config.ServiceOptions.WorkerOverrides
+		section = "service.overrides.worker"
+	}
+
+	lines, err := dropInSettings(overrides, config.WorkingDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", section, err)
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(dropInHeader)
+	b.WriteString("[Service]\n")
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return []byte(b.String()), nil
+}
+
+// dropInSettings validates overrides and returns them as Key=Value lines
+func dropInSettings(o *config.UnitOverrides, workingDir string) ([]string, error) {
+	if o == nil {
+		return nil, nil
+	}
+
+	var lines []string
+	if o.MemoryMax != "" {
+		lines = append(lines, "MemoryMax="+o.MemoryMax)
+	}
+	if o.CPUQuota != "" {
+		if !strings.HasSuffix(o.CPUQuota, "%") {
+			return nil, fmt.Errorf("cpu_quota must be a percentage (e.g., 400%%), got %q", o.CPUQuota)
+		}
+		lines = append(lines, "CPUQuota="+o.CPUQuota)
+	}
+	if o.Nice != nil {
+		if *o.Nice < -20 || *o.Nice > 19 {
+			return nil, fmt.Errorf("nice must be between -20 and 19, got %d", *o.Nice)
+		}
+		lines = append(lines, fmt.Sprintf("Nice=%d", *o.Nice))
+	}
+	if o.IOSchedulingClass != "" {
+		if !ioSchedulingClasses[o.IOSchedulingClass] {
+			return nil, fmt.Errorf("io_scheduling_class must be realtime, best-effort, idle or none, got %q", o.IOSchedulingClass)
+		}
+		lines = append(lines, "IOSchedulingClass="+o.IOSchedulingClass)
+	}
+	if o.LimitNOFILE < 0 {
+		return nil, fmt.Errorf("limit_nofile must not be negative")
+	}
+	if o.LimitNOFILE > 0 {
+		lines = append(lines, fmt.Sprintf("LimitNOFILE=%d", o.LimitNOFILE))
+	}
+
+	if o.Hardening {
+		lines = append(lines,
+			"NoNewPrivileges=yes",
+			"ProtectSystem=full",
+			"ProtectHome=read-only",
+			"PrivateTmp=yes",
+		)
+		// The node keeps its store under the working directory
+		if workingDir != "" {
+			lines = append(lines, "ReadWritePaths="+workingDir)
+		}
+	}
+
+	for _, key := range sortedKeys(o.Extra) {
+		value := o.Extra[key]
+		if key == "" || strings.ContainsAny(key, "=\n ") || strings.Contains(value, "\n") {
+			return nil, fmt.Errorf("invalid extra setting %q=%q", key, value)
+		}
+		lines = append(lines, key+"="+value)
+	}
+
+	return lines, nil
+}
+
+// DropInPath returns the override.conf path for a unit
+func (sb *SystemdBackend) DropInPath(name string) string {
+	return filepath.Join(sb.unitDir(), name+".service.d", "override.conf")
+}
+
+// UpdateDropIn writes the unit's override.conf, or removes it when no overrides are set
+func (sb *SystemdBackend) UpdateDropIn(name string, config *ServiceConfig) error {
+	content, err := sb.RenderDropIn(config)
+	if err != nil {
+		return err
+	}
+
+	path := sb.DropInPath(name)
+	if content == nil {
+		if output, err := sb.privileged("rm", "-f", path).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to remove %s: %w\nOutput: %s", path, err, string(output))
+		}
+	} else if err := installFile(sb.privileged, path, content, "0644"); err != nil {
+		return err
+	}
+
+	return sb.daemonReload()
+}
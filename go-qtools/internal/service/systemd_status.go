@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdbus "github.com/coreos/go-systemd/v22/dbus"
+)
+
+// systemdStatusProperties are the unit properties read for ServiceStatus
+var systemdStatusProperties = []string{
+	"LoadState",
+	"ActiveState",
+	"SubState",
+	"UnitFileState",
+	"MainPID",
+	"ExecMainStartTimestamp",
+	"ExecMainStatus",
+	"NRestarts",
+	"MemoryCurrent",
+	"CPUUsageNSec",
+	"Result",
+}
+
+// systemdTimestampLayout is the layout of timestamps printed by systemctl show
+const systemdTimestampLayout = "Mon 2006-01-02 15:04:05 MST"
+
+// dbusTimeout bounds each D-Bus property call
+const dbusTimeout = 5 * time.Second
+
+// dbusManager is a shared connection to a systemd manager
+type dbusManager struct {
+	once sync.Once
+	conn *sdbus.Conn
+	err  error
+}
+
+var systemDBus, userDBus dbusManager
+
+// systemdDBus returns a shared connection to the system manager, or the user's manager
+func systemdDBus(user bool) (*sdbus.Conn, error) {
+	m := &systemDBus
+	if user {
+		m = &userDBus
+	}
+	m.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), dbusTimeout)
+		defer cancel()
+		if user {
+			m.conn, m.err = sdbus.NewUserConnectionContext(ctx)
+		} else {
+			m.conn, m.err = sdbus.NewSystemConnectionContext(ctx)
+		}
+	})
+	return m.conn, m.err
+}
+
+// systemdUnitName appends .service to names without a unit suffix
+func systemdUnitName(name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return name + ".service"
+}
+
+// getSystemdProperties reads unit properties over D-Bus, falling back to systemctl show
+func getSystemdProperties(name string, user bool) (map[string]string, error) {
+	if props, err := getSystemdPropertiesDBus(name, user); err == nil {
+		return props, nil
+	}
+	return getSystemdPropertiesShow(name, user)
+}
+
+// getSystemdPropertiesDBus reads unit and service properties from org.freedesktop.systemd1
+func getSystemdPropertiesDBus(name string, user bool) (map[string]string, error) {
+	conn, err := systemdDBus(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to systemd over D-Bus: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbusTimeout)
+	defer cancel()
+
+	unit := systemdUnitName(name)
+	unitProps, err := conn.GetUnitPropertiesContext(ctx, unit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit properties for %s: %w", unit, err)
+	}
+	serviceProps, err := conn.GetUnitTypePropertiesContext(ctx, unit, "Service")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service properties for %s: %w", unit, err)
+	}
+
+	props := make(map[string]string, len(systemdStatusProperties))
+	for _, key := range systemdStatusProperties {
+		value, ok := serviceProps[key]
+		if !ok {
+			value, ok = unitProps[key]
+		}
+		if ok {
+			props[key] = fmt.Sprint(value)
+		}
+	}
+
+	// D-Bus timestamps are microseconds since the epoch; use systemctl's "@<seconds>" form
+	if usec, ok := serviceProps["ExecMainStartTimestamp"].(uint64); ok {
+		props["ExecMainStartTimestamp"] = ""
+		if usec > 0 {
+			props["ExecMainStartTimestamp"] = fmt.Sprintf("@%d", usec/1000000)
+		}
+	}
+
+	return props, nil
+}
+
+// getSystemdPropertiesShow reads unit properties with systemctl show -p
+func getSystemdPropertiesShow(name string, user bool) (map[string]string, error) {
+	args := []string{"show", name, "-p", strings.Join(systemdStatusProperties, ",")}
+	if user {
+		args = append([]string{"--user"}, args...)
+	}
+	cmd := exec.Command("systemctl", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("failed to show service %s: %s", name, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to show service %s: %w", name, err)
+	}
+	return parseSystemctlShow(string(output)), nil
+}
+
+// parseSystemctlShow parses Key=Value lines from systemctl show
+func parseSystemctlShow(output string) map[string]string {
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			props[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return props
+}
+
+// statusFromSystemdProperties builds a ServiceStatus from unit properties
+func statusFromSystemdProperties(name string, props map[string]string) *ServiceStatus {
+	status := &ServiceStatus{
+		Name:        name,
+		LoadState:   props["LoadState"],
+		ActiveState: props["ActiveState"],
+		SubState:    props["SubState"],
+		Result:      props["Result"],
+	}
+
+	status.Active = status.ActiveState == "active"
+	status.Running = status.Active && status.SubState == "running"
+	status.Enabled = props["UnitFileState"] == "enabled"
+	status.PID, _ = strconv.Atoi(props["MainPID"])
+	status.ExitCode, _ = strconv.Atoi(props["ExecMainStatus"])
+	status.Restarts, _ = strconv.Atoi(props["NRestarts"])
+	status.MemoryBytes = parseSystemdUint(props["MemoryCurrent"])
+	status.CPUUsage = time.Duration(parseSystemdUint(props["CPUUsageNSec"]))
+
+	status.StartedAt = parseSystemdTimestamp(props["ExecMainStartTimestamp"])
+
+	status.StatusText = fmt.Sprintf("ActiveState=%s SubState=%s MainPID=%d", status.ActiveState, status.SubState, status.PID)
+
+	return status
+}
+
+// parseSystemdUint parses a counter; "[not set]" and UINT64_MAX mean unavailable
+func parseSystemdUint(value string) uint64 {
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || v == math.MaxUint64 {
+		return 0
+	}
+	return v
+}
+
+// parseSystemdTimestamp parses "@<unix seconds>" or "Mon 2006-01-02 15:04:05 MST"; empty and n/a give the zero time
+func parseSystemdTimestamp(value string) time.Time {
+	if strings.HasPrefix(value, "@") {
+		if secs, err := strconv.ParseInt(value[1:], 10, 64); err == nil {
+			return time.Unix(secs, 0)
+		}
+		return time.Time{}
+	}
+	if t, err := time.Parse(systemdTimestampLayout, value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata" // America/New_York for the systemctl timestamps
+)
+
+// useLocalZone sets time.Local for the rest of the test
+func useLocalZone(t *testing.T, name string) {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = local })
+}
+
+func TestParseSystemctlShow(t *testing.T) {
+	output := "LoadState=loaded\n" +
+		"\n" +
+		"Environment=QUIL_CONFIG=a=b\n" +
+		"  SubState = running \n" +
+		"not a property\n" +
+		"UnitFileState=\n"
+	want := map[string]string{
+		"LoadState":     "loaded",
+		"Environment":   "QUIL_CONFIG=a=b",
+		"SubState":      "running",
+		"UnitFileState": "",
+	}
+	if got := parseSystemctlShow(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSystemctlShow = %v, want %v", got, want)
+	}
+}
+
+func TestStatusFromSystemctlShow(t *testing.T) {
+	// systemctl show prints timestamps in the host's zone, whose abbreviations time.Parse resolves
+	useLocalZone(t, "America/New_York")
+
+	tests := []struct {
+		fixture string
+		want    ServiceStatus
+	}{
+		{
+			fixture: "active.txt",
+			want: ServiceStatus{
+				Name: "quilibrium", Active: true, Running: true, Enabled: true, PID: 2113,
+				StatusText: "ActiveState=active SubState=running MainPID=2113",
+				LoadState:  "loaded", ActiveState: "active", SubState: "running",
+				StartedAt: time.Date(2024, 6, 3, 16, 33, 30, 0, time.UTC), Restarts: 2,
+				MemoryBytes: 8 << 30, CPUUsage: 90*time.Minute + 23*time.Second + 187*time.Millisecond,
+				Result: "success",
+			},
+		},
+		{
+			// Restarted too often: systemd gave up, and the counters are unset
+			fixture: "failed.txt",
+			want: ServiceStatus{
+				Name: "quilibrium", Enabled: true,
+				StatusText: "ActiveState=failed SubState=failed MainPID=0",
+				LoadState:  "loaded", ActiveState: "failed", SubState: "failed",
+				StartedAt: time.Date(2024, 6, 3, 16, 40, 2, 0, time.UTC), Restarts: 5,
+				Result: "start-limit-hit", ExitCode: 1,
+			},
+		},
+		{
+			// Older systemd prints unset counters as UINT64_MAX
+			fixture: "not-found.txt",
+			want: ServiceStatus{
+				Name:       "quilibrium",
+				StatusText: "ActiveState=inactive SubState=dead MainPID=0",
+				LoadState:  "not-found", ActiveState: "inactive", SubState: "dead",
+				Result: "success",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		output, err := os.ReadFile(filepath.Join("testdata", "systemctl-show", tt.fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		props := parseSystemctlShow(string(output))
+		for _, key := range systemdStatusProperties {
+			if _, ok := props[key]; !ok {
+				t.Errorf("%s: %s missing", tt.fixture, key)
+			}
+		}
+
+		got := statusFromSystemdProperties("quilibrium", props)
+		if !got.StartedAt.Equal(tt.want.StartedAt) {
+			t.Errorf("%s: started at %s, want %s", tt.fixture, got.StartedAt, tt.want.StartedAt)
+		}
+		got.StartedAt, tt.want.StartedAt = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: status = %+v\nwant %+v", tt.fixture, *got, tt.want)
+		}
+	}
+}
+
+func TestParseSystemdTimestamp(t *testing.T) {
+	useLocalZone(t, "America/New_York")
+
+	tests := map[string]time.Time{
+		// D-Bus timestamps are converted to seconds since the epoch
+		"@1717432410": time.Date(2024, 6, 3, 16, 33, 30, 0, time.UTC),
+		// The local zone's abbreviations carry its offsets, in and out of DST
+		"Mon 2024-06-03 12:33:30 EDT": time.Date(2024, 6, 3, 16, 33, 30, 0, time.UTC),
+		"Fri 2024-01-05 09:00:00 EST": time.Date(2024, 1, 5, 14, 0, 0, 0, time.UTC),
+		"Mon 2024-06-03 16:33:30 UTC": time.Date(2024, 6, 3, 16, 33, 30, 0, time.UTC),
+		"":                            {},
+		"n/a":                         {},
+		"@soon":                       {},
+		"2024-06-03 16:33:30":         {},
+	}
+	for value, want := range tests {
+		if got := parseSystemdTimestamp(value); !got.Equal(want) {
+			t.Errorf("parseSystemdTimestamp(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
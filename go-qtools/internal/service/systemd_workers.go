@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WorkerTemplateName returns the worker template unit name (e.g., ceremonyclient-worker@)
+// Workers run as instances of it, so ceremonyclient-worker@3 runs with --core 3
+func (sb *SystemdBackend) WorkerTemplateName(serviceName string) string {
+	return serviceName + "-worker@"
+}
+
+// EnabledWorkers returns the worker instances linked into a .wants directory
+func (sb *SystemdBackend) EnabledWorkers(serviceName string) ([]int, error) {
+	links, err := filepath.Glob(filepath.Join(sb.unitDir(), "*.wants", serviceName+"-worker@*.service"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list enabled workers: %w", err)
+	}
+
+	seen := make(map[int]bool)
+	var workers []int
+	for _, link := range links {
+		index, ok := workerInstanceIndex(serviceName, filepath.Base(link))
+		if ok && !seen[index] {
+			seen[index] = true
+			workers = append(workers, index)
+		}
+	}
+	sort.Ints(workers)
+	return workers, nil
+}
+
+// MigrateWorkerFiles removes per-index worker unit files (ceremonyclient-worker@N.service)
+// written by older versions so the instances fall back to the template unit
+// Workers that were enabled are disabled before removal and re-enabled from the template
+func (sb *SystemdBackend) MigrateWorkerFiles(serviceName string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(sb.unitDir(), serviceName+"-worker@*.service"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worker unit files: %w", err)
+	}
+
+	enabled, err := sb.EnabledWorkers(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	wasEnabled := make(map[int]bool, len(enabled))
+	for _, index := range enabled {
+		wasEnabled[index] = true
+	}
+
+	var removed []string
+	var reenable []string
+	for _, file := range files {
+		index, ok := workerInstanceIndex(serviceName, filepath.Base(file))
+		if !ok {
+			continue // The template itself
+		}
+		if info, err := os.Lstat(file); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		name := strings.TrimSuffix(filepath.Base(file), ".service")
+		if wasEnabled[index] {
+			// Drop the .wants links to the old file; they are recreated against the template
+			if err := sb.DisableService(name); err != nil {
+				return removed, err
+			}
+			reenable = append(reenable, name)
+		}
+		if output, err := sb.privileged("rm", "-f", file).CombinedOutput(); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w\nOutput: %s", file, err, string(output))
+		}
+		removed = append(removed, file)
+	}
+
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if err := sb.daemonReload(); err != nil {
+		return removed, err
+	}
+	for _, name := range reenable {
+		if err := sb.EnableService(name); err != nil {
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
+
+// workerInstanceIndex parses the index from a worker instance unit name (e.g., ceremonyclient-worker@3.service)
+func workerInstanceIndex(serviceName, unit string) (int, bool) {
+	instance, ok := strings.CutPrefix(strings.TrimSuffix(unit, ".service"), serviceName+"-worker@")
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.Atoi(instance)
+	if err != nil || index <= 0 {
+		return 0, false
+	}
+	return index, true
+}
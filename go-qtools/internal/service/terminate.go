@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// Stop escalation defaults
+// SIGKILL is sent after DefaultStopGracePeriod + StopTermTimeout, before the service managers'
+// own 240s stop timeout, so qtools can report which processes needed it
+const (
+	DefaultStopGracePeriod = 180 * time.Second
+	DefaultKillGracePeriod = 10 * time.Second
+	StopTermTimeout        = 30 * time.Second
+)
+
+// terminatePollInterval is how often signalled processes are checked for exit
+const terminatePollInterval = 250 * time.Millisecond
+
+// Signals sent while stopping node processes
+const (
+	SignalInterrupt = "SIGINT"
+	SignalTerminate = "SIGTERM"
+	SignalKill      = "SIGKILL"
+)
+
+// signalNumbers maps signal names to numbers
+var signalNumbers = map[string]syscall.Signal{
+	SignalInterrupt: syscall.SIGINT,
+	SignalTerminate: syscall.SIGTERM,
+	SignalKill:      syscall.SIGKILL,
+}
+
+// TerminateOptions controls how node processes are stopped
+type TerminateOptions struct {
+	GracePeriod      time.Duration     // Wait after SIGINT before SIGTERM
+	TermTimeout      time.Duration     // Wait after SIGTERM before SIGKILL (0: StopTermTimeout)
+	Force            bool              // Send SIGKILL straight away
+	InterruptTracked bool              // Also send SIGINT to processes a service tracks (stop leaves that to the service manager)
+	OnSignal         func(Termination) // Called when a process is sent SIGTERM or SIGKILL
+}
+
+// Termination reports how a node process stopped
+type Termination struct {
+	NodeProcess
+	Signal   string        `json:"signal,omitempty"` // Last signal qtools sent, if any
+	Stopped  bool          `json:"stopped"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Forced reports whether the process had to be sent SIGTERM or SIGKILL
+func (t Termination) Forced() bool {
+	return t.Signal == SignalTerminate || t.Signal == SignalKill
+}
+
+// LoadStopGracePeriod returns service.stop_grace_period, or DefaultStopGracePeriod when unset
+func LoadStopGracePeriod(cfg *config.Config) (time.Duration, error) {
+	if cfg == nil || cfg.Service == nil || cfg.Service.StopGracePeriod == "" {
+		return DefaultStopGracePeriod, nil
+	}
+	grace, err := time.ParseDuration(cfg.Service.StopGracePeriod)
+	if err != nil {
+		return 0, fmt.Errorf("invalid service.stop_grace_period: %w", err)
+	}
+	return grace, nil
+}
+
+// TerminateProcesses stops node processes, escalating from SIGINT to SIGTERM after the grace
+// period and to SIGKILL after the term timeout. It returns once every process has exited, SIGKILL
+// has gone unanswered for the term timeout, or ctx is done
+func TerminateProcesses(ctx context.Context, processes []NodeProcess, opts TerminateOptions) []Termination {
+	if opts.TermTimeout <= 0 {
+		opts.TermTimeout = StopTermTimeout
+	}
+
+	start := time.Now()
+	results := make([]Termination, len(processes))
+	pids := make([]int, len(processes))
+	for i, process := range processes {
+		results[i] = Termination{NodeProcess: process}
+		pids[i] = process.PID
+	}
+
+	send := func(signal string, forced bool) {
+		for i := range results {
+			result := &results[i]
+			if result.Stopped || (signal == SignalInterrupt && !result.Orphaned && !opts.InterruptTracked) {
+				continue
+			}
+			result.Signal = signal
+			if err := signalProcess(result.PID, signalNumbers[signal]); err != nil {
+				result.Error = err.Error()
+			}
+			if forced && opts.OnSignal != nil {
+				opts.OnSignal(*result)
+			}
+		}
+	}
+
+	// wait polls until every process exited or the timeout passed, and reports whether all did
+	wait := func(timeout time.Duration) bool {
+		deadline := time.Now().Add(timeout)
+		for {
+			running := runningProcesses(pids)
+			remaining := 0
+			for i := range results {
+				if results[i].Stopped {
+					continue
+				}
+				if running[results[i].PID] {
+					remaining++
+					continue
+				}
+				results[i].Stopped = true
+				results[i].Duration = time.Since(start)
+			}
+			if remaining == 0 {
+				return true
+			}
+			if !time.Now().Before(deadline) {
+				return false
+			}
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(terminatePollInterval):
+			}
+		}
+	}
+
+	steps := []struct {
+		signal  string
+		timeout time.Duration
+	}{
+		{SignalInterrupt, opts.GracePeriod},
+		{SignalTerminate, opts.TermTimeout},
+		{SignalKill, opts.TermTimeout},
+	}
+	if opts.Force {
+		steps = steps[2:]
+	}
+	for _, step := range steps {
+		send(step.signal, step.signal != SignalInterrupt)
+		if wait(step.timeout) || ctx.Err() != nil {
+			break
+		}
+	}
+	return results
+}
+
+// signalProcess sends a signal to a process, through sudo when it belongs to another user
+// A process that has already exited is not an error
+func signalProcess(pid int, signal syscall.Signal) error {
+	err := syscall.Kill(pid, signal)
+	if err == nil || errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	if !errors.Is(err, syscall.EPERM) {
+		return fmt.Errorf("failed to signal PID %d: %w", pid, err)
+	}
+
+	args := []string{"-0", strconv.Itoa(pid)}
+	if signal != 0 {
+		args = []string{"-s", signalName(signal), strconv.Itoa(pid)}
+	}
+	if output, err := sudoCommand("kill", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to signal PID %d: %w\nOutput: %s", pid, err, output)
+	}
+	return nil
+}
+
+// signalName returns the name kill -s takes for a signal
+func signalName(signal syscall.Signal) string {
+	for name, number := range signalNumbers {
+		if number == signal {
+			return name[len("SIG"):]
+		}
+	}
+	return strconv.Itoa(int(signal))
+}
+
+// KillWorkers stops stuck worker processes by core, whether or not their services still respond
+// Each process is sent SIGINT, then SIGTERM after the grace period and SIGKILL after the term timeout
+func KillWorkers(ctx context.Context, cores []int, opts TerminateOptions, cfg *config.Config) ([]Termination, error) {
+	for _, core := range cores {
+		if core <= 0 {
+			return nil, fmt.Errorf("core index 0 is reserved for the master; use qtools service stop --master")
+		}
+	}
+	processes, err := FindNodeProcesses(cores, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(processes) == 0 {
+		return nil, fmt.Errorf("no worker processes found for cores %v", cores)
+	}
+
+	opts.InterruptTracked = true
+	return TerminateProcesses(ctx, processes, opts), nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// timerBootDelay is how long after boot a timer with OnBoot set fires
+const timerBootDelay = "1min"
+
+// TimerService is a qtools command run by a systemd timer instead of a long-running service
+type TimerService struct {
+	*CommandService
+	OnCalendar []string // systemd calendar events; several fire the command on any of them
+	OnBoot     bool     // fire once after boot
+}
+
+// ShellCommand returns the service's command line for a shell, e.g., a crontab entry
+// The environment is set inline, since a crontab runs commands with a minimal one
+func (svc *CommandService) ShellCommand() string {
+	var b strings.Builder
+	if svc.WorkingDir != "" && svc.WorkingDir != "/" {
+		b.WriteString("cd " + shellQuote(svc.WorkingDir) + " && ")
+	}
+	for _, env := range svc.Env {
+		key, value, _ := strings.Cut(env, "=")
+		b.WriteString(key + "=" + shellQuote(value) + " ")
+	}
+	b.WriteString(shellJoin(svc.Command))
+	return b.String()
+}
+
+// GetTimerBackend returns the systemd backend for timers, which need systemd
+func GetTimerBackend(cfg *config.Config) (*SystemdBackend, error) {
+	backend, err := GetServiceBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sb, ok := backend.(*SystemdBackend)
+	if !ok {
+		return nil, fmt.Errorf("systemd timers need the systemd or systemd-user service backend")
+	}
+	return sb, nil
+}
+
+// TimerFilePath returns where a timer's .timer unit is installed; its .service is at ServiceFilePath
+func (sb *SystemdBackend) TimerFilePath(name string) string {
+	return filepath.Join(sb.unitDir(), name+".timer")
+}
+
+// RenderTimer returns the .service and .timer units for a timer service
+func (sb *SystemdBackend) RenderTimer(t *TimerService) ([]byte, []byte, error) {
+	unit, err := renderCommandTemplate("systemd-timer-service", systemdTimerServiceTemplate, struct {
+		*CommandService
+		ExecStart string
+	}{t.CommandService, shellJoin(t.Command)})
+	if err != nil {
+		return nil, nil, err
+	}
+	timer, err := renderCommandTemplate("systemd-timer", systemdTimerTemplate, struct {
+		*TimerService
+		BootDelay string
+	}{t, timerBootDelay})
+	if err != nil {
+		return nil, nil, err
+	}
+	return unit, timer, nil
+}
+
+// InstallTimer writes a timer's units and enables and starts the timer
+func (sb *SystemdBackend) InstallTimer(t *TimerService) error {
+	unit, timer, err := sb.RenderTimer(t)
+	if err != nil {
+		return err
+	}
+	if err := installFile(sb.privileged, sb.ServiceFilePath(t.Name), unit, "0644"); err != nil {
+		return err
+	}
+	if err := installFile(sb.privileged, sb.TimerFilePath(t.Name), timer, "0644"); err != nil {
+		return err
+	}
+	if err := sb.daemonReload(); err != nil {
+		return err
+	}
+	if output, err := sb.systemctl("enable", "--now", t.Name+".timer").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to enable timer %s: %w\nOutput: %s", t.Name, err, string(output))
+	}
+	return nil
+}
+
+// RemoveTimer stops and disables a timer and deletes its units
+// A run in progress is left to finish
+func (sb *SystemdBackend) RemoveTimer(name string) error {
+	// The timer may already be stopped or disabled
+	sb.systemctl("disable", "--now", name+".timer").Run()
+	if err := removePath(!sb.user, sb.TimerFilePath(name)); err != nil {
+		return err
+	}
+	if err := removePath(!sb.user, sb.ServiceFilePath(name)); err != nil {
+		return err
+	}
+	return sb.daemonReload()
+}
+
+// ListTimers returns the names of the installed timers starting with prefix
+func (sb *SystemdBackend) ListTimers(prefix string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(sb.unitDir(), prefix+"*.timer"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(match), ".timer"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// systemdTimerServiceTemplate runs a qtools command once per timer event
+// Only the main process gets SIGINT (KillMode=mixed), so it can stop the command it runs
+const systemdTimerServiceTemplate = `[Unit]
+Description={{.Description}}
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=oneshot
+WorkingDirectory={{.WorkingDir}}
+{{- range .Env}}
+Environment="{{.}}"
+{{- end}}
+ExecStart={{.ExecStart}}
+KillSignal=SIGINT
+KillMode=mixed
+`
+
+// systemdTimerTemplate starts the service of the same name on its calendar events
+const systemdTimerTemplate = `[Unit]
+Description={{.Description}}
+
+[Timer]
+{{- range .OnCalendar}}
+OnCalendar={{.}}
+{{- end}}
+{{- if .OnBoot}}
+OnBootSec={{.BootDelay}}
+{{- end}}
+Unit={{.Name}}.service
+
+[Install]
+WantedBy=timers.target
+`
@@ -10,26 +10,14 @@ import (
 	"github.com/tjsturos/qtools/go-qtools/internal/node"
 )
 
-// StartWorkers starts all workers
+// StartWorkers starts all workers in parallel
 func StartWorkers(count int, cfg *config.Config) error {
-	backend, err := GetServiceBackend()
-	if err != nil {
-		return err
-	}
-
-	serviceName := getServiceName(cfg)
-	for i := 1; i <= count; i++ {
-		workerName := fmt.Sprintf("%s-worker@%d", serviceName, i)
-		if err := backend.StartService(workerName); err != nil {
-			return fmt.Errorf("failed to start worker %d: %w", i, err)
-		}
-	}
-	return nil
+	return RunWorkerAction(WorkerActionStart, workerRange(count), WorkerOptions{}, cfg)
 }
 
 // StartWorker starts a specific worker by core index
 func StartWorker(coreIndex int, cfg *config.Config) error {
-	backend, err := GetServiceBackend()
+	backend, err := GetServiceBackend(cfg)
 	if err != nil {
 		return err
 	}
@@ -39,36 +27,19 @@ func StartWorker(coreIndex int, cfg *config.Config) error {
 	return backend.StartService(workerName)
 }
 
-// StartWorkersByCores starts specific workers by core numbers
+// StartWorkersByCores starts specific workers by core numbers in parallel
 func StartWorkersByCores(coreNumbers []int, cfg *config.Config) error {
-	for _, coreNum := range coreNumbers {
-		if err := StartWorker(coreNum, cfg); err != nil {
-			return fmt.Errorf("failed to start worker %d: %w", coreNum, err)
-		}
-	}
-	return nil
+	return RunWorkerAction(WorkerActionStart, coreNumbers, WorkerOptions{}, cfg)
 }
 
-// StopWorkers stops all workers
+// StopWorkers stops all workers in parallel
 func StopWorkers(count int, cfg *config.Config) error {
-	backend, err := GetServiceBackend()
-	if err != nil {
-		return err
-	}
-
-	serviceName := getServiceName(cfg)
-	for i := 1; i <= count; i++ {
-		workerName := fmt.Sprintf("%s-worker@%d", serviceName, i)
-		if err := backend.StopService(workerName); err != nil {
-			return fmt.Errorf("failed to stop worker %d: %w", i, err)
-		}
-	}
-	return nil
+	return RunWorkerAction(WorkerActionStop, workerRange(count), WorkerOptions{}, cfg)
 }
 
 // StopWorker stops a specific worker by core index
 func StopWorker(coreIndex int, cfg *config.Config) error {
-	backend, err := GetServiceBackend()
+	backend, err := GetServiceBackend(cfg)
 	if err != nil {
 		return err
 	}
@@ -78,36 +49,19 @@ func StopWorker(coreIndex int, cfg *config.Config) error {
 	return backend.StopService(workerName)
 }
 
-// StopWorkersByCores stops specific workers by core numbers
+// StopWorkersByCores stops specific workers by core numbers in parallel
 func StopWorkersByCores(coreNumbers []int, cfg *config.Config) error {
-	for _, coreNum := range coreNumbers {
-		if err := StopWorker(coreNum, cfg); err != nil {
-			return fmt.Errorf("failed to stop worker %d: %w", coreNum, err)
-		}
-	}
-	return nil
+	return RunWorkerAction(WorkerActionStop, coreNumbers, WorkerOptions{}, cfg)
 }
 
-// RestartWorkers restarts all workers
+// RestartWorkers restarts all workers in parallel
 func RestartWorkers(count int, cfg *config.Config) error {
-	backend, err := GetServiceBackend()
-	if err != nil {
-		return err
-	}
-
-	serviceName := getServiceName(cfg)
-	for i := 1; i <= count; i++ {
-		workerName := fmt.Sprintf("%s-worker@%d", serviceName, i)
-		if err := backend.RestartService(workerName); err != nil {
-			return fmt.Errorf("failed to restart worker %d: %w", i, err)
-		}
-	}
-	return nil
+	return RunWorkerAction(WorkerActionRestart, workerRange(count), WorkerOptions{}, cfg)
 }
 
 // RestartWorker restarts a specific worker by core index
 func RestartWorker(coreIndex int, cfg *config.Config) error {
-	backend, err := GetServiceBackend()
+	backend, err := GetServiceBackend(cfg)
 	if err != nil {
 		return err
 	}
@@ -117,14 +71,9 @@ func RestartWorker(coreIndex int, cfg *config.Config) error {
 	return backend.RestartService(workerName)
 }
 
-// RestartWorkersByCores restarts specific workers by core numbers
+// RestartWorkersByCores restarts specific workers by core numbers in parallel
 func RestartWorkersByCores(coreNumbers []int, cfg *config.Config) error {
-	for _, coreNum := range coreNumbers {
-		if err := RestartWorker(coreNum, cfg); err != nil {
-			return fmt.Errorf("failed to restart worker %d: %w", coreNum, err)
-		}
-	}
-	return nil
+	return RunWorkerAction(WorkerActionRestart, coreNumbers, WorkerOptions{}, cfg)
 }
 
 // ParseCoreNumbers parses core number input into a slice of integers
@@ -195,7 +144,7 @@ func ParseCoreNumbers(input string) ([]int, error) {
 
 // GetWorkerStatus gets the status of a specific worker
 func GetWorkerStatus(workerIndex int, cfg *config.Config) (*ServiceStatus, error) {
-	backend, err := GetServiceBackend()
+	backend, err := GetServiceBackend(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,151 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Control actions
+const (
+	ActionStart   = "start"
+	ActionStop    = "stop"
+	ActionRestart = "restart"
+	ActionStatus  = "status"
+	ActionList    = "list"
+)
+
+// Request is a control request, sent as one JSON object per connection
+type Request struct {
+	Action string `json:"action"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Response answers a Request
+type Response struct {
+	Error    string          `json:"error,omitempty"`
+	Status   *ProcessStatus  `json:"status,omitempty"`
+	Statuses []ProcessStatus `json:"statuses,omitempty"`
+}
+
+// ErrNotRunning is returned by Call when no supervisor is listening on the socket
+var ErrNotRunning = errors.New("supervisor is not running (start it with qtools supervise)")
+
+// Run starts enabled services and serves control requests on the socket until ctx is done,
+// then stops every service before returning
+func (s *Supervisor) Run(ctx context.Context) error {
+	listener, err := listenSocket(s.paths.Socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(s.paths.Socket)
+
+	s.opts.Logf("listening on %s", s.paths.Socket)
+	s.StartEnabled()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	<-ctx.Done()
+	s.opts.Logf("stopping services")
+
+	listener.Close()
+	s.Shutdown()
+	return nil
+}
+
+// listenSocket listens on a unix socket, replacing a stale socket file
+func listenSocket(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a supervisor is already listening on %s", path)
+	}
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0660); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// serve handles one control connection
+func (s *Supervisor) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(Response{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	json.NewEncoder(conn).Encode(s.handle(req))
+}
+
+// handle dispatches a control request
+func (s *Supervisor) handle(req Request) Response {
+	if req.Action != ActionList && req.Name == "" {
+		return Response{Error: "service name is required"}
+	}
+
+	var err error
+	switch req.Action {
+	case ActionStart:
+		err = s.Start(req.Name)
+	case ActionStop:
+		err = s.Stop(req.Name)
+	case ActionRestart:
+		err = s.Restart(req.Name)
+	case ActionStatus:
+	case ActionList:
+		return Response{Statuses: s.List()}
+	default:
+		return Response{Error: fmt.Sprintf("unknown action %q", req.Action)}
+	}
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	status := s.Status(req.Name)
+	return Response{Status: &status}
+}
+
+// Call sends a control request to the supervisor listening on socket
+// timeout bounds the whole exchange; stops can take up to the supervisor's stop timeout
+func Call(socket string, req Request, timeout time.Duration) (*Response, error) {
+	conn, err := net.DialTimeout("unix", socket, 5*time.Second)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", req.Action, err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", req.Action, err)
+	}
+	if resp.Error != "" {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
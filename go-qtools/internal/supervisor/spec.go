@@ -0,0 +1,225 @@
+// Package supervisor implements qtools supervise, a process supervisor for hosts without an
+// init system (containers, minimal distros). It runs the master and worker processes from
+// spec files, restarts them like the systemd units would, writes their output to rotating
+// log files and is controlled over a unix socket
+package supervisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// Defaults for supervisor settings
+const (
+	DefaultLogMaxSizeMB = 100
+	DefaultLogMaxFiles  = 5
+	DefaultStopTimeout  = 240 * time.Second
+)
+
+// Restart policies
+const (
+	RestartAlways    = "always"
+	RestartOnFailure = "on-failure"
+	RestartNo        = "no"
+)
+
+// Spec describes a supervised process; it is the supervisor's equivalent of a unit file
+type Spec struct {
+	Name         string   `json:"name"`
+	Command      []string `json:"command"`
+	WorkingDir   string   `json:"working_dir,omitempty"`
+	Env          []string `json:"env,omitempty"` // KEY=VALUE, added to the supervisor's environment
+	Restart      string   `json:"restart"`
+	RestartDelay string   `json:"restart_delay"` // e.g., "5s"
+}
+
+// restartDelay parses RestartDelay; plain numbers are seconds
+func (s *Spec) restartDelay() time.Duration {
+	delay := strings.TrimSpace(s.RestartDelay)
+	if delay == "" {
+		return time.Second
+	}
+	if d, err := time.ParseDuration(delay); err == nil {
+		return d
+	}
+	if d, err := time.ParseDuration(delay + "s"); err == nil {
+		return d
+	}
+	return time.Second
+}
+
+// Paths locates the supervisor's socket, spec files and logs
+type Paths struct {
+	Socket  string
+	SpecDir string // <name>.json specs; enabled/<name> marks services started with the supervisor
+	LogDir  string
+}
+
+// Options holds the supervisor's runtime settings
+type Options struct {
+	StopTimeout time.Duration
+	LogMaxSize  int64
+	LogMaxFiles int
+	Logf        func(format string, args ...interface{}) // Supervisor events; nil discards them
+}
+
+// PathsFromConfig returns the supervisor paths from service.supervisor, defaulting under the qtools path
+func PathsFromConfig(cfg *config.Config) Paths {
+	base := filepath.Join(config.GetQtoolsPath(), "supervise")
+	paths := Paths{
+		Socket:  filepath.Join(base, "supervise.sock"),
+		SpecDir: filepath.Join(base, "services"),
+		LogDir:  filepath.Join(base, "logs"),
+	}
+	if sc := supervisorConfig(cfg); sc != nil {
+		if sc.Socket != "" {
+			paths.Socket = sc.Socket
+		}
+		if sc.LogDir != "" {
+			paths.LogDir = sc.LogDir
+		}
+	}
+	return paths
+}
+
+// OptionsFromConfig returns the supervisor options from service.supervisor
+func OptionsFromConfig(cfg *config.Config) (Options, error) {
+	opts := Options{
+		StopTimeout: DefaultStopTimeout,
+		LogMaxSize:  DefaultLogMaxSizeMB << 20,
+		LogMaxFiles: DefaultLogMaxFiles,
+	}
+	sc := supervisorConfig(cfg)
+	if sc == nil {
+		return opts, nil
+	}
+	if sc.StopTimeout != "" {
+		timeout, err := time.ParseDuration(sc.StopTimeout)
+		if err != nil {
+			return opts, fmt.Errorf("invalid service.supervisor.stop_timeout: %w", err)
+		}
+		opts.StopTimeout = timeout
+	}
+	if sc.LogMaxSizeMB > 0 {
+		opts.LogMaxSize = int64(sc.LogMaxSizeMB) << 20
+	}
+	if sc.LogMaxFiles > 0 {
+		opts.LogMaxFiles = sc.LogMaxFiles
+	}
+	return opts, nil
+}
+
+// supervisorConfig returns service.supervisor, or nil when unset
+func supervisorConfig(cfg *config.Config) *config.SupervisorConfig {
+	if cfg == nil || cfg.Service == nil {
+		return nil
+	}
+	return cfg.Service.Supervisor
+}
+
+// SpecPath returns the spec file path for a service
+func (p Paths) SpecPath(name string) string {
+	return filepath.Join(p.SpecDir, name+".json")
+}
+
+// LogPath returns the log file path for a service
+func (p Paths) LogPath(name string) string {
+	return filepath.Join(p.LogDir, name+".log")
+}
+
+// enabledPath returns the marker file for a service started with the supervisor
+func (p Paths) enabledPath(name string) string {
+	return filepath.Join(p.SpecDir, "enabled", name)
+}
+
+// RenderSpec returns the spec file content
+func RenderSpec(spec *Spec) ([]byte, error) {
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode spec: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// LoadSpec reads a service's spec file
+func (p Paths) LoadSpec(name string) (*Spec, error) {
+	data, err := os.ReadFile(p.SpecPath(name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("service %s not found (run qtools service update)", name)
+		}
+		return nil, fmt.Errorf("failed to read spec for %s: %w", name, err)
+	}
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", p.SpecPath(name), err)
+	}
+	if len(spec.Command) == 0 {
+		return nil, fmt.Errorf("spec for %s has no command", name)
+	}
+	spec.Name = name
+	return &spec, nil
+}
+
+// WriteSpec writes a service's spec file
+func (p Paths) WriteSpec(name string, content []byte) error {
+	if err := os.MkdirAll(p.SpecDir, 0755); err != nil {
+		return fmt.Errorf("failed to create spec directory: %w", err)
+	}
+	path := p.SpecPath(name)
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, content, 0644); err != nil {
+		return fmt.Errorf("failed to write spec: %w", err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		return fmt.Errorf("failed to install spec: %w", err)
+	}
+	return nil
+}
+
+// SpecNames lists the services with spec files
+func (p Paths) SpecNames() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(p.SpecDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(match), ".json"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// IsEnabled reports whether a service starts with the supervisor
+func (p Paths) IsEnabled(name string) bool {
+	_, err := os.Stat(p.enabledPath(name))
+	return err == nil
+}
+
+// SetEnabled marks or unmarks a service to start with the supervisor
+func (p Paths) SetEnabled(name string, enabled bool) error {
+	path := p.enabledPath(name)
+	if !enabled {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to disable %s: %w", name, err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to enable %s: %w", name, err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return fmt.Errorf("failed to enable %s: %w", name, err)
+	}
+	return nil
+}
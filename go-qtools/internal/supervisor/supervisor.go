@@ -0,0 +1,398 @@
+package supervisor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Process states, named after systemd's ActiveState and SubState
+const (
+	StateActive     = "active"
+	StateInactive   = "inactive"
+	StateActivating = "activating"
+	StateFailed     = "failed"
+
+	SubStateRunning     = "running"
+	SubStateDead        = "dead"
+	SubStateAutoRestart = "auto-restart"
+	SubStateStopping    = "stop-sigint"
+)
+
+// Exit results
+const (
+	ResultSuccess  = "success"
+	ResultExitCode = "exit-code"
+	ResultSignal   = "signal"
+	ResultTimeout  = "timeout" // Killed after the stop timeout
+)
+
+// ProcessStatus reports the state of a supervised process
+type ProcessStatus struct {
+	Name        string    `json:"name"`
+	LoadState   string    `json:"load_state"` // loaded or not-found
+	ActiveState string    `json:"active_state"`
+	SubState    string    `json:"sub_state"`
+	Enabled     bool      `json:"enabled"`
+	PID         int       `json:"pid,omitempty"`
+	StartedAt   time.Time `json:"started_at,omitempty"`
+	Restarts    int       `json:"restarts"`
+	Result      string    `json:"result,omitempty"`
+	ExitCode    int       `json:"exit_code"`
+	MemoryBytes uint64    `json:"memory_bytes,omitempty"`
+}
+
+// process is one supervised service
+type process struct {
+	name   string
+	spec   *Spec
+	cmd    *exec.Cmd
+	log    *rotatingFile
+	exited chan struct{} // Closed when cmd exits
+
+	want        bool // Should be running
+	stopping    bool
+	timedOut    bool
+	activeState string
+	subState    string
+	startedAt   time.Time
+	restarts    int
+	result      string
+	exitCode    int
+	timer       *time.Timer // Pending restart
+}
+
+// Supervisor runs and restarts processes from spec files
+type Supervisor struct {
+	paths Paths
+	opts  Options
+
+	mu    sync.Mutex
+	procs map[string]*process
+}
+
+// New creates a supervisor
+func New(paths Paths, opts Options) *Supervisor {
+	if opts.StopTimeout <= 0 {
+		opts.StopTimeout = DefaultStopTimeout
+	}
+	if opts.Logf == nil {
+		opts.Logf = func(string, ...interface{}) {}
+	}
+	return &Supervisor{paths: paths, opts: opts, procs: make(map[string]*process)}
+}
+
+// StartEnabled starts every service marked enabled
+func (s *Supervisor) StartEnabled() {
+	names, err := s.paths.SpecNames()
+	if err != nil {
+		s.opts.Logf("failed to list services: %v", err)
+		return
+	}
+	for _, name := range names {
+		if !s.paths.IsEnabled(name) {
+			continue
+		}
+		if err := s.Start(name); err != nil {
+			s.opts.Logf("failed to start %s: %v", name, err)
+		}
+	}
+}
+
+// Start starts a service, reloading its spec; starting a running service does nothing
+func (s *Supervisor) Start(name string) error {
+	spec, err := s.paths.LoadSpec(name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.procs[name]
+	if p == nil {
+		p = &process{name: name, activeState: StateInactive, subState: SubStateDead}
+		s.procs[name] = p
+	}
+	p.want = true
+	if p.cmd != nil {
+		return nil
+	}
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.spec = spec
+	return s.spawn(p)
+}
+
+// spawn starts the process; s.mu must be held
+func (s *Supervisor) spawn(p *process) error {
+	if p.log == nil {
+		log, err := openRotatingFile(s.paths.LogPath(p.name), s.opts.LogMaxSize, s.opts.LogMaxFiles)
+		if err != nil {
+			return err
+		}
+		p.log = log
+	}
+
+	cmd := exec.Command(p.spec.Command[0], p.spec.Command[1:]...)
+	cmd.Dir = p.spec.WorkingDir
+	cmd.Env = append(os.Environ(), p.spec.Env...)
+	cmd.Stdout = p.log
+	cmd.Stderr = p.log
+	// Own process group, so signals reach the node's child processes and not the supervisor's terminal
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		p.activeState = StateFailed
+		p.subState = SubStateDead
+		p.result = ResultExitCode
+		return fmt.Errorf("failed to start %s: %w", p.name, err)
+	}
+
+	p.cmd = cmd
+	p.exited = make(chan struct{})
+	p.stopping = false
+	p.timedOut = false
+	p.activeState = StateActive
+	p.subState = SubStateRunning
+	p.startedAt = time.Now()
+	s.opts.Logf("started %s (pid %d)", p.name, cmd.Process.Pid)
+
+	go s.wait(p, cmd, p.exited)
+	return nil
+}
+
+// wait reaps the process and schedules a restart according to the spec
+func (s *Supervisor) wait(p *process, cmd *exec.Cmd, exited chan struct{}) {
+	err := cmd.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer close(exited)
+
+	p.cmd = nil
+	p.exitCode = 0
+	p.result = ResultSuccess
+	failed := false
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		failed = true
+		p.exitCode = exitErr.ExitCode()
+		p.result = ResultExitCode
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			p.exitCode = 128 + int(status.Signal())
+			p.result = ResultSignal
+		}
+	default:
+		failed = true
+		p.result = ResultExitCode
+	}
+	if p.timedOut {
+		p.result = ResultTimeout
+	}
+
+	if p.stopping || !p.want {
+		// A requested stop: SIGINT is a clean shutdown for the node
+		p.activeState = StateInactive
+		if p.result == ResultTimeout {
+			p.activeState = StateFailed
+		}
+		p.subState = SubStateDead
+		s.opts.Logf("stopped %s (%s)", p.name, p.result)
+		return
+	}
+
+	restart := p.spec.Restart == RestartAlways || (p.spec.Restart == RestartOnFailure && failed)
+	if !restart {
+		p.activeState = StateInactive
+		if failed {
+			p.activeState = StateFailed
+		}
+		p.subState = SubStateDead
+		s.opts.Logf("%s exited (%s, code %d)", p.name, p.result, p.exitCode)
+		return
+	}
+
+	delay := p.spec.restartDelay()
+	p.activeState = StateActivating
+	p.subState = SubStateAutoRestart
+	s.opts.Logf("%s exited (%s, code %d); restarting in %s", p.name, p.result, p.exitCode, delay)
+	p.timer = time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !p.want || p.cmd != nil {
+			return
+		}
+		p.timer = nil
+		p.restarts++
+		if err := s.spawn(p); err != nil {
+			s.opts.Logf("%v", err)
+		}
+	})
+}
+
+// Stop stops a service: SIGINT to its process group, then SIGKILL after the stop timeout
+func (s *Supervisor) Stop(name string) error {
+	s.mu.Lock()
+	p := s.procs[name]
+	if p == nil {
+		s.mu.Unlock()
+		if _, err := s.paths.LoadSpec(name); err != nil {
+			return err
+		}
+		return nil
+	}
+	p.want = false
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+		p.activeState = StateInactive
+		p.subState = SubStateDead
+	}
+	if p.cmd == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	p.stopping = true
+	p.subState = SubStateStopping
+	pid := p.cmd.Process.Pid
+	exited := p.exited
+	s.mu.Unlock()
+
+	syscall.Kill(-pid, syscall.SIGINT)
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(s.opts.StopTimeout):
+	}
+
+	s.mu.Lock()
+	p.timedOut = true
+	s.mu.Unlock()
+	s.opts.Logf("%s did not stop within %s; sending SIGKILL", name, s.opts.StopTimeout)
+	syscall.Kill(-pid, syscall.SIGKILL)
+	<-exited
+	return nil
+}
+
+// Restart stops and starts a service
+func (s *Supervisor) Restart(name string) error {
+	if err := s.Stop(name); err != nil {
+		return err
+	}
+	return s.Start(name)
+}
+
+// Status returns a service's status; services without a spec report LoadState not-found
+func (s *Supervisor) Status(name string) ProcessStatus {
+	status := ProcessStatus{
+		Name:        name,
+		LoadState:   "loaded",
+		ActiveState: StateInactive,
+		SubState:    SubStateDead,
+		Enabled:     s.paths.IsEnabled(name),
+	}
+	if _, err := os.Stat(s.paths.SpecPath(name)); err != nil {
+		status.LoadState = "not-found"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.procs[name]
+	if p == nil {
+		return status
+	}
+
+	status.ActiveState = p.activeState
+	status.SubState = p.subState
+	status.Restarts = p.restarts
+	status.Result = p.result
+	status.ExitCode = p.exitCode
+	if p.cmd != nil {
+		status.PID = p.cmd.Process.Pid
+		status.StartedAt = p.startedAt
+		status.MemoryBytes = ProcessMemory(status.PID)
+	}
+	return status
+}
+
+// List returns the status of every service with a spec or a process
+func (s *Supervisor) List() []ProcessStatus {
+	names, _ := s.paths.SpecNames()
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	s.mu.Lock()
+	for name := range s.procs {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	s.mu.Unlock()
+
+	statuses := make([]ProcessStatus, 0, len(names))
+	for _, name := range names {
+		statuses = append(statuses, s.Status(name))
+	}
+	return statuses
+}
+
+// Shutdown stops every service in parallel and closes the logs
+func (s *Supervisor) Shutdown() {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.procs))
+	for name := range s.procs {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			s.Stop(name)
+		}(name)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.procs {
+		if p.log != nil {
+			p.log.Close()
+			p.log = nil
+		}
+	}
+}
+
+// ProcessMemory returns the resident memory of a process from /proc, or 0 when unavailable
+func ProcessMemory(pid int) uint64 {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "VmRSS:" {
+			kb, _ := strconv.ParseUint(fields[1], 10, 64)
+			return kb * 1024
+		}
+	}
+	return 0
+}
@@ -0,0 +1,299 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPaths returns supervisor paths in temporary directories
+// The socket gets a short directory of its own, since unix socket paths are limited to ~100 bytes
+func testPaths(t *testing.T) Paths {
+	t.Helper()
+	dir := t.TempDir()
+	socketDir, err := os.MkdirTemp("", "supervise")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(socketDir) })
+	return Paths{
+		Socket:  filepath.Join(socketDir, "s.sock"),
+		SpecDir: filepath.Join(dir, "services"),
+		LogDir:  filepath.Join(dir, "logs"),
+	}
+}
+
+// newTestSupervisor creates a supervisor that stops its services when the test ends
+func newTestSupervisor(t *testing.T, paths Paths, stopTimeout time.Duration) *Supervisor {
+	t.Helper()
+	s := New(paths, Options{StopTimeout: stopTimeout, LogMaxSize: 1 << 20, LogMaxFiles: 2, Logf: t.Logf})
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+// writeSpec writes a spec running command through sh
+func writeSpec(t *testing.T, paths Paths, name, restart, delay, command string) {
+	t.Helper()
+	content, err := RenderSpec(&Spec{
+		Name:         name,
+		Command:      []string{"sh", "-c", command},
+		WorkingDir:   paths.SpecDir,
+		Restart:      restart,
+		RestartDelay: delay,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := paths.WriteSpec(name, content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForStatus polls a service's status until cond holds, failing the test after 5s
+func waitForStatus(t *testing.T, s *Supervisor, name string, what string, cond func(ProcessStatus) bool) ProcessStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status := s.Status(name)
+		if cond(status) {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: timed out waiting for %s (status %+v)", name, what, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRestartPolicies(t *testing.T) {
+	paths := testPaths(t)
+	s := newTestSupervisor(t, paths, time.Second)
+
+	writeSpec(t, paths, "fails-on-failure", RestartOnFailure, "20ms", "exit 3")
+	writeSpec(t, paths, "succeeds-on-failure", RestartOnFailure, "20ms", "exit 0")
+	writeSpec(t, paths, "succeeds-always", RestartAlways, "20ms", "exit 0")
+	writeSpec(t, paths, "fails-no", RestartNo, "20ms", "exit 3")
+	for _, name := range []string{"fails-on-failure", "succeeds-on-failure", "succeeds-always", "fails-no"} {
+		if err := s.Start(name); err != nil {
+			t.Fatalf("Start(%s) failed: %v", name, err)
+		}
+	}
+
+	// on-failure restarts a failing process, always restarts one that exits cleanly
+	status := waitForStatus(t, s, "fails-on-failure", "restarts", func(st ProcessStatus) bool { return st.Restarts >= 2 })
+	if status.Result != ResultExitCode || status.ExitCode != 3 {
+		t.Errorf("fails-on-failure: result %s, exit code %d", status.Result, status.ExitCode)
+	}
+	waitForStatus(t, s, "succeeds-always", "restarts", func(st ProcessStatus) bool { return st.Restarts >= 2 })
+
+	// Neither a clean exit under on-failure nor any exit under no is restarted
+	status = waitForStatus(t, s, "succeeds-on-failure", "exit", func(st ProcessStatus) bool { return st.SubState == SubStateDead })
+	if status.ActiveState != StateInactive || status.Result != ResultSuccess || status.Restarts != 0 {
+		t.Errorf("succeeds-on-failure: %+v, want inactive after a clean exit", status)
+	}
+	status = waitForStatus(t, s, "fails-no", "exit", func(st ProcessStatus) bool { return st.SubState == SubStateDead })
+	if status.ActiveState != StateFailed || status.ExitCode != 3 || status.Restarts != 0 {
+		t.Errorf("fails-no: %+v, want failed without restarts", status)
+	}
+
+	// Give a wrongly scheduled restart time to happen
+	time.Sleep(100 * time.Millisecond)
+	for _, name := range []string{"succeeds-on-failure", "fails-no"} {
+		if status := s.Status(name); status.Restarts != 0 || status.PID != 0 {
+			t.Errorf("%s was restarted: %+v", name, status)
+		}
+	}
+}
+
+func TestStopDuringRestartDelay(t *testing.T) {
+	paths := testPaths(t)
+	s := newTestSupervisor(t, paths, time.Second)
+	writeSpec(t, paths, "worker-1", RestartOnFailure, "300ms", "exit 1")
+
+	if err := s.Start("worker-1"); err != nil {
+		t.Fatal(err)
+	}
+	waitForStatus(t, s, "worker-1", "the restart delay", func(st ProcessStatus) bool {
+		return st.ActiveState == StateActivating && st.SubState == SubStateAutoRestart
+	})
+
+	if err := s.Stop("worker-1"); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	status := s.Status("worker-1")
+	if status.ActiveState != StateInactive || status.SubState != SubStateDead {
+		t.Errorf("status after stop = %+v, want inactive", status)
+	}
+
+	// The pending restart must not fire
+	time.Sleep(500 * time.Millisecond)
+	if status := s.Status("worker-1"); status.Restarts != 0 || status.PID != 0 || status.ActiveState != StateInactive {
+		t.Errorf("restarted after stop: %+v", status)
+	}
+}
+
+func TestStopSignals(t *testing.T) {
+	paths := testPaths(t)
+	s := newTestSupervisor(t, paths, 300*time.Millisecond)
+
+	// SIGINT ends a process that does not catch it
+	writeSpec(t, paths, "sleeper", RestartAlways, "20ms", "exec sleep 30")
+	if err := s.Start("sleeper"); err != nil {
+		t.Fatal(err)
+	}
+	waitForStatus(t, s, "sleeper", "start", func(st ProcessStatus) bool { return st.PID > 0 })
+	start := time.Now()
+	if err := s.Stop("sleeper"); err != nil {
+		t.Fatal(err)
+	}
+	status := s.Status("sleeper")
+	if status.ActiveState != StateInactive || status.Result != ResultSignal || status.ExitCode != 130 {
+		t.Errorf("sleeper after stop = %+v, want inactive after SIGINT", status)
+	}
+	if elapsed := time.Since(start); elapsed >= 300*time.Millisecond {
+		t.Errorf("stopping sleeper took %s, want it to exit on SIGINT", elapsed)
+	}
+
+	// A process ignoring SIGINT is killed after the stop timeout; the ignored signal is
+	// inherited by its children in the same process group
+	ready := filepath.Join(paths.SpecDir, "ready")
+	writeSpec(t, paths, "stubborn", RestartAlways, "20ms", "trap '' INT; touch "+ready+"; while :; do sleep 0.05; done")
+	if err := s.Start("stubborn"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(ready); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stubborn did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	start = time.Now()
+	if err := s.Stop("stubborn"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("stubborn stopped after %s, before the stop timeout", elapsed)
+	}
+	status = s.Status("stubborn")
+	if status.ActiveState != StateFailed || status.Result != ResultTimeout || status.ExitCode != 137 {
+		t.Errorf("stubborn after stop = %+v, want failed after SIGKILL", status)
+	}
+	if status.Restarts != 0 {
+		t.Errorf("stubborn was restarted after stop: %+v", status)
+	}
+}
+
+func TestControlSocket(t *testing.T) {
+	paths := testPaths(t)
+	s := newTestSupervisor(t, paths, time.Second)
+	writeSpec(t, paths, "master", RestartAlways, "1s", "exec sleep 30")
+	writeSpec(t, paths, "worker-1", RestartAlways, "1s", "exec sleep 30")
+	if err := paths.SetEnabled("master", true); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	call := func(req Request) (*Response, error) {
+		return Call(paths.Socket, req, 5*time.Second)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := call(Request{Action: ActionList}); !errors.Is(err, ErrNotRunning) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("supervisor did not start listening")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Enabled services start with the supervisor
+	resp, err := call(Request{Action: ActionStatus, Name: "master"})
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if resp.Status == nil || resp.Status.ActiveState != StateActive || resp.Status.PID == 0 || !resp.Status.Enabled {
+		t.Errorf("master status = %+v, want running and enabled", resp.Status)
+	}
+
+	resp, err = call(Request{Action: ActionStart, Name: "worker-1"})
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if resp.Status == nil || resp.Status.SubState != SubStateRunning || resp.Status.Enabled {
+		t.Errorf("worker-1 status after start = %+v", resp.Status)
+	}
+	pid := resp.Status.PID
+
+	resp, err = call(Request{Action: ActionRestart, Name: "worker-1"})
+	if err != nil {
+		t.Fatalf("restart failed: %v", err)
+	}
+	if resp.Status.PID == 0 || resp.Status.PID == pid {
+		t.Errorf("worker-1 pid after restart = %d, was %d", resp.Status.PID, pid)
+	}
+
+	resp, err = call(Request{Action: ActionList})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(resp.Statuses) != 2 || resp.Statuses[0].Name != "master" || resp.Statuses[1].Name != "worker-1" {
+		t.Errorf("list = %+v", resp.Statuses)
+	}
+
+	resp, err = call(Request{Action: ActionStop, Name: "worker-1"})
+	if err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if resp.Status.ActiveState != StateInactive || resp.Status.PID != 0 {
+		t.Errorf("worker-1 status after stop = %+v", resp.Status)
+	}
+
+	for _, tt := range []struct {
+		req  Request
+		want string
+	}{
+		{Request{Action: ActionStart}, "service name is required"},
+		{Request{Action: "reload", Name: "master"}, `unknown action "reload"`},
+		{Request{Action: ActionStart, Name: "worker-9"}, "service worker-9 not found"},
+	} {
+		if _, err := call(tt.req); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%+v: err = %v, want %q", tt.req, err, tt.want)
+		}
+	}
+	if status := s.Status("worker-9"); status.LoadState != "not-found" {
+		t.Errorf("worker-9 load state = %s", status.LoadState)
+	}
+
+	// Stopping the supervisor stops its services and removes the socket
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run returned %v", err)
+	}
+	done <- nil
+	if status := s.Status("master"); status.ActiveState != StateInactive {
+		t.Errorf("master after shutdown = %+v", status)
+	}
+	if _, err := os.Stat(paths.Socket); !os.IsNotExist(err) {
+		t.Errorf("socket left behind (%v)", err)
+	}
+	if _, err := call(Request{Action: ActionList}); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("call after shutdown: err = %v, want ErrNotRunning", err)
+	}
+}
@@ -3,6 +3,7 @@ package views
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -18,6 +19,8 @@ type ServiceControlView struct {
 	coreInput       *components.CoreInput
 	selectedAction  string // "start", "stop", "restart"
 	status          *service.Status
+	workerResults   map[int]service.WorkerEvent // Results of the running/last worker action
+	crashes         map[int]int                 // Crashes per worker in the last 24 hours, from the journal
 	err             error
 }
 
@@ -69,11 +72,19 @@ func (sv *ServiceControlView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case serviceControlStatusUpdateMsg:
 		sv.status = msg.status
+		sv.crashes = msg.crashes
 		return sv, nil
 
 	case serviceControlErrorMsg:
 		sv.err = msg.err
-		return sv, nil
+		return sv, sv.refreshStatus()
+
+	case workerProgressMsg:
+		sv.workerResults[msg.event.Index] = msg.event
+		return sv, waitForWorkerEvent(msg.events)
+
+	case refreshStatusMsg:
+		return sv, sv.refreshStatus()
 	}
 
 	// Update core input if advanced mode
@@ -178,13 +189,32 @@ func (sv *ServiceControlView) renderStatus() string {
 			} else {
 				status = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("●")
 			}
-			b.WriteString(fmt.Sprintf("    Worker %d: %s\n", i, status))
+			b.WriteString(fmt.Sprintf("    Worker %d: %s", i, status))
+			if crashes := sv.crashes[i]; crashes > 0 {
+				b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render(fmt.Sprintf("  %d crashes (24h)", crashes)))
+			}
+			if result, ok := sv.workerResults[i]; ok {
+				b.WriteString("  " + renderWorkerResult(result))
+			}
+			b.WriteString("\n")
 		}
 	}
 
 	return b.String()
 }
 
+// renderWorkerResult renders the outcome of a worker action
+func renderWorkerResult(event service.WorkerEvent) string {
+	switch {
+	case event.Skipped:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(event.Action + " skipped")
+	case event.Err != nil:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(event.Action + " failed")
+	default:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Render(fmt.Sprintf("%s ok (%s)", event.Action, event.Duration.Round(time.Millisecond)))
+	}
+}
+
 // refreshStatus refreshes the service status
 func (sv *ServiceControlView) refreshStatus() tea.Cmd {
 	return func() tea.Msg {
@@ -192,45 +222,75 @@ func (sv *ServiceControlView) refreshStatus() tea.Cmd {
 		if err != nil {
 			return serviceControlErrorMsg{err: err}
 		}
-		return serviceControlStatusUpdateMsg{status: status}
+		// Crash counts are best effort: the journal may be unavailable or unreadable
+		crashes, _ := service.WorkerCrashCounts(sv.config, time.Now().Add(-24*time.Hour))
+		return serviceControlStatusUpdateMsg{status: status, crashes: crashes}
 	}
 }
 
 // startAll starts all services
 func (sv *ServiceControlView) startAll() tea.Cmd {
-	return func() tea.Msg {
-		err := service.StartService(service.StartOptions{}, sv.config)
-		if err != nil {
-			return serviceControlErrorMsg{err: err}
-		}
-		return refreshStatusMsg{}
-	}
+	return sv.runWorkerAction(func(opts service.WorkerOptions) error {
+		return service.StartService(service.StartOptions{WorkerOptions: opts}, sv.config)
+	})
 }
 
 // stopAll stops all services
 func (sv *ServiceControlView) stopAll() tea.Cmd {
-	return func() tea.Msg {
-		err := service.StopService(service.StopOptions{}, sv.config)
+	return sv.runWorkerAction(func(opts service.WorkerOptions) error {
+		return service.StopService(service.StopOptions{WorkerOptions: opts}, sv.config)
+	})
+}
+
+// restartAll restarts all services
+func (sv *ServiceControlView) restartAll() tea.Cmd {
+	return sv.runWorkerAction(func(opts service.WorkerOptions) error {
+		return service.RestartService(service.RestartOptions{WorkerOptions: opts}, sv.config)
+	})
+}
+
+// runWorkerAction runs a service action, streaming per-worker results into the view
+func (sv *ServiceControlView) runWorkerAction(action func(opts service.WorkerOptions) error) tea.Cmd {
+	events := make(chan service.WorkerEvent)
+	sv.workerResults = make(map[int]service.WorkerEvent)
+	sv.err = nil
+
+	run := func() tea.Msg {
+		err := action(service.WorkerOptions{
+			ContinueOnError: true,
+			Progress: func(event service.WorkerEvent) {
+				events <- event
+			},
+		})
+		close(events)
 		if err != nil {
 			return serviceControlErrorMsg{err: err}
 		}
 		return refreshStatusMsg{}
 	}
+
+	return tea.Batch(run, waitForWorkerEvent(events))
 }
 
-// restartAll restarts all services
-func (sv *ServiceControlView) restartAll() tea.Cmd {
+// waitForWorkerEvent waits for the next worker result
+func waitForWorkerEvent(events chan service.WorkerEvent) tea.Cmd {
 	return func() tea.Msg {
-		err := service.RestartService(service.RestartOptions{}, sv.config)
-		if err != nil {
-			return serviceControlErrorMsg{err: err}
+		event, ok := <-events
+		if !ok {
+			return nil
 		}
-		return refreshStatusMsg{}
+		return workerProgressMsg{event: event, events: events}
 	}
 }
 
+type workerProgressMsg struct {
+	event  service.WorkerEvent
+	events chan service.WorkerEvent
+}
+
 type serviceControlStatusUpdateMsg struct {
-	status *service.Status
+	status  *service.Status
+	crashes map[int]int
 }
 
 type serviceControlErrorMsg struct {
@@ -7,21 +7,31 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/tjsturos/qtools/go-qtools/internal/client"
 	"github.com/tjsturos/qtools/go-qtools/internal/config"
 	"github.com/tjsturos/qtools/go-qtools/internal/service"
 )
 
 // StatusView represents the status view
 type StatusView struct {
-	config *config.Config
-	status *service.Status
-	err    error
+	config     *config.Config
+	nodeClient client.NodeAPI
+	status     *service.Status
+	err        error
+	node       *client.NodeInfo
+	nodeErr    error
 }
 
 // NewStatusView creates a new status view
 func NewStatusView(cfg *config.Config) *StatusView {
+	return NewStatusViewWithClient(cfg, client.NewNodeClient(cfg))
+}
+
+// NewStatusViewWithClient creates a status view that queries the node through nc
+func NewStatusViewWithClient(cfg *config.Config, nc client.NodeAPI) *StatusView {
 	return &StatusView{
-		config: cfg,
+		config:     cfg,
+		nodeClient: nc,
 	}
 }
 
@@ -30,6 +40,7 @@ func (sv *StatusView) Init() tea.Cmd {
 	return tea.Batch(
 		sv.refreshStatus(),
 		sv.autoRefresh(),
+		sv.refreshNode(),
 	)
 }
 
@@ -39,7 +50,7 @@ func (sv *StatusView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "r":
-			return sv, sv.refreshStatus()
+			return sv, tea.Batch(sv.refreshStatus(), sv.refreshNode())
 		}
 
 	case statusUpdateMsg:
@@ -50,6 +61,11 @@ func (sv *StatusView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case statusErrorMsg:
 		sv.err = msg.err
 		return sv, sv.autoRefresh()
+
+	case nodeUpdateMsg:
+		sv.node = msg.info
+		sv.nodeErr = msg.err
+		return sv, sv.autoRefreshNode()
 	}
 
 	return sv, nil
@@ -91,6 +107,16 @@ func (sv *StatusView) View() string {
 		if sv.status.Master.PID > 0 {
 			b.WriteString(fmt.Sprintf("  PID: %d\n", sv.status.Master.PID))
 		}
+		if uptime := sv.status.Master.Uptime(); uptime > 0 {
+			b.WriteString(fmt.Sprintf("  Uptime: %s\n", service.FormatDuration(uptime)))
+		}
+		b.WriteString(fmt.Sprintf("  Restarts: %d\n", sv.status.Master.Restarts))
+		if sv.status.Master.MemoryBytes > 0 {
+			b.WriteString(fmt.Sprintf("  Memory: %s\n", service.FormatBytes(sv.status.Master.MemoryBytes)))
+		}
+		if !sv.status.Master.Running && sv.status.Master.Result != "" && sv.status.Master.Result != "success" {
+			b.WriteString(fmt.Sprintf("  Result: %s (exit %d)\n", sv.status.Master.Result, sv.status.Master.ExitCode))
+		}
 	}
 	b.WriteString("\n")
 
@@ -109,11 +135,43 @@ func (sv *StatusView) View() string {
 				if workerStatus.PID > 0 {
 					b.WriteString(fmt.Sprintf(" (PID: %d)", workerStatus.PID))
 				}
+				if uptime := workerStatus.Uptime(); uptime > 0 {
+					b.WriteString(fmt.Sprintf("  up %s", service.FormatDuration(uptime)))
+				}
+				if workerStatus.Restarts > 0 {
+					b.WriteString(fmt.Sprintf("  restarts %d", workerStatus.Restarts))
+				}
+				if workerStatus.MemoryBytes > 0 {
+					b.WriteString(fmt.Sprintf("  mem %s", service.FormatBytes(workerStatus.MemoryBytes)))
+				}
 				b.WriteString("\n")
 			}
 		}
 	}
 
+	// Node status as reported by the node itself
+	b.WriteString("\nNode:\n")
+	switch {
+	case sv.nodeErr != nil:
+		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("  Not answering: %v", sv.nodeErr)))
+		b.WriteString("\n")
+	case sv.node == nil:
+		b.WriteString("  Querying node...\n")
+	default:
+		b.WriteString(fmt.Sprintf("  Peer ID: %s\n", sv.node.PeerID))
+		b.WriteString(fmt.Sprintf("  Version: %s\n", sv.node.Version))
+		b.WriteString(fmt.Sprintf("  Max Frame: %d\n", sv.node.MaxFrame))
+		if sv.node.ProverRing >= 0 {
+			b.WriteString(fmt.Sprintf("  Prover Ring: %d\n", sv.node.ProverRing))
+		}
+		b.WriteString(fmt.Sprintf("  Seniority: %s\n", sv.node.Seniority))
+		b.WriteString(fmt.Sprintf("  Active Workers: %d\n", sv.node.ActiveWorkers))
+		if sv.node.Balance != "" {
+			b.WriteString(fmt.Sprintf("  Balance: %s %s\n", sv.node.Balance, sv.node.BalanceUnit))
+		}
+		b.WriteString(fmt.Sprintf("  Via: %s\n", sv.node.Transport))
+	}
+
 	b.WriteString("\n")
 	b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("Press 'r' to refresh, Esc to go back"))
 
@@ -142,6 +200,26 @@ func (sv *StatusView) autoRefresh() tea.Cmd {
 	})
 }
 
+// refreshNode queries the node
+func (sv *StatusView) refreshNode() tea.Cmd {
+	return func() tea.Msg {
+		return sv.queryNode()
+	}
+}
+
+// autoRefreshNode queries the node again after the refresh interval
+func (sv *StatusView) autoRefreshNode() tea.Cmd {
+	return tea.Tick(5*time.Second, func(time.Time) tea.Msg {
+		return sv.queryNode()
+	})
+}
+
+// queryNode gets node info over the configured transports
+func (sv *StatusView) queryNode() tea.Msg {
+	info, err := sv.nodeClient.GetNodeInfoHybrid()
+	return nodeUpdateMsg{info: info, err: err}
+}
+
 type statusUpdateMsg struct {
 	status *service.Status
 }
@@ -149,3 +227,8 @@ type statusUpdateMsg struct {
 type statusErrorMsg struct {
 	err error
 }
+
+type nodeUpdateMsg struct {
+	info *client.NodeInfo
+	err  error
+}
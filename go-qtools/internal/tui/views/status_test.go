@@ -0,0 +1,66 @@
+package views
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/client"
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/fakenode"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+	"github.com/tjsturos/qtools/go-qtools/internal/service"
+)
+
+func TestStatusViewNode(t *testing.T) {
+	t.Setenv("QUIL_CONFIG_FILE", filepath.Join(t.TempDir(), "config.yml"))
+	fake := fakenode.New()
+	fake.MaxFrame = 4242
+	fake.ProverRing = 1
+	defer fake.Close()
+	previous := node.SetCommandRunner(fake.Runner())
+	defer node.SetCommandRunner(previous)
+
+	cfg := &config.Config{}
+	nc := client.NewNodeClientWithQueriers(cfg, client.NewGRPCQuerier(fake.StartGRPC()), client.NewRESTQuerier(fake.Start()))
+	sv := NewStatusViewWithClient(cfg, nc)
+	sv.Update(statusUpdateMsg{status: &service.Status{Master: &service.ServiceStatus{Name: "ceremonyclient"}}})
+
+	if view := sv.View(); !strings.Contains(view, "Querying node...") {
+		t.Errorf("view before the first node query:\n%s", view)
+	}
+
+	sv.Update(sv.refreshNode()())
+	view := sv.View()
+	for _, want := range []string{"Peer ID: " + fake.PeerID, "Max Frame: 4242", "Prover Ring: 1", "Balance: 1 QUIL", "Via: grpc"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view does not contain %q:\n%s", want, view)
+		}
+	}
+
+	// A stopped service is still described by the binary
+	fake.Update(func(n *fakenode.Node) { n.Stopped = true })
+	sv.Update(sv.refreshNode()())
+	if view := sv.View(); !strings.Contains(view, "Via: binary") {
+		t.Errorf("view for a stopped node:\n%s", view)
+	}
+
+	// A node that answers on no transport is reported as not answering
+	nc = client.NewNodeClientWithQueriers(cfg, client.NewGRPCQuerier(fake.StartGRPC()), client.NewRESTQuerier(fake.Start()), failingQuerier{})
+	sv = NewStatusViewWithClient(cfg, nc)
+	sv.Update(statusUpdateMsg{status: &service.Status{}})
+	sv.Update(sv.refreshNode()())
+	if view := sv.View(); !strings.Contains(view, "Not answering: all node transports failed") {
+		t.Errorf("view for an unreachable node:\n%s", view)
+	}
+}
+
+// failingQuerier stands in for a node binary that cannot be run
+type failingQuerier struct{}
+
+func (failingQuerier) Transport() string { return client.TransportBinary }
+
+func (failingQuerier) GetNodeInfo() (*client.NodeInfo, error) {
+	return nil, errors.New("node binary not found")
+}
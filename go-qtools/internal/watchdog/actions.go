@@ -0,0 +1,166 @@
+package watchdog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// Action represents a remediation taken (or, in a dry run, planned) by a watchdog
+type Action struct {
+	Time     time.Time `json:"time"`
+	Watchdog string    `json:"watchdog"` // e.g., "crash_loop"
+	Unit     string    `json:"unit"`     // "master", "worker-N", "host" or "node"
+	Action   string    `json:"action"`   // e.g., "backoff", "resume", "disable", "rollback", "notify"
+	Reason   string    `json:"reason"`
+	Detail   string    `json:"detail,omitempty"`
+	DryRun   bool      `json:"dry_run,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Recorder appends watchdog actions to the action log and sends notifications
+// Notifications go to settings.notifications.command, which receives the action as JSON on
+// stdin, and settings.notifications.webhook_url, which receives it as a JSON POST
+type Recorder struct {
+	LogPath    string
+	Command    string
+	WebhookURL string
+	OnAction   func(Action)
+}
+
+// NewRecorder creates a recorder writing to the default action log with notifications from the config
+func NewRecorder(cfg *config.Config) *Recorder {
+	recorder := &Recorder{LogPath: ActionLogPath()}
+	if cfg.Settings != nil && cfg.Settings.Notifications != nil {
+		recorder.Command = cfg.Settings.Notifications.Command
+		recorder.WebhookURL = cfg.Settings.Notifications.WebhookURL
+	}
+	return recorder
+}
+
+// ActionLogPath returns the watchdog action log, one JSON action per line
+func ActionLogPath() string {
+	return filepath.Join(config.GetQtoolsPath(), "watchdog", "actions.jsonl")
+}
+
+// Record logs an action and notifies about it
+// Dry-run actions are only passed to OnAction; they are neither logged nor sent
+func (r *Recorder) Record(action Action) error {
+	if action.Time.IsZero() {
+		action.Time = time.Now()
+	}
+	if r.OnAction != nil {
+		r.OnAction(action)
+	}
+	if action.DryRun {
+		return nil
+	}
+
+	data, err := json.Marshal(action)
+	if err != nil {
+		return fmt.Errorf("failed to marshal action: %w", err)
+	}
+
+	var errs []error
+	if r.LogPath != "" {
+		if err := appendLine(r.LogPath, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.Command != "" {
+		cmd := exec.Command("sh", "-c", r.Command)
+		cmd.Stdin = bytes.NewReader(data)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			errs = append(errs, fmt.Errorf("notification command failed: %w\nOutput: %s", err, string(output)))
+		}
+	}
+	if r.WebhookURL != "" {
+		if err := postWebhook(r.WebhookURL, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recordAction stamps an action from a watchdog and passes it to the recorder
+// Recorder errors are kept on the action so the remediation still shows up
+func recordAction(recorder *Recorder, watchdog string, dryRun bool, action Action) Action {
+	action.Time = time.Now()
+	action.Watchdog = watchdog
+	action.DryRun = dryRun
+	if recorder != nil {
+		if err := recorder.Record(action); err != nil && action.Error == "" {
+			action.Error = err.Error()
+		}
+	}
+	return action
+}
+
+// appendLine appends a line to a file, creating it and its directory as needed
+func appendLine(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// postWebhook posts an action to the notification webhook
+func postWebhook(url string, data []byte) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("notification webhook failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// ReadActions reads the action log, returning at most the last limit actions (0 = all)
+func ReadActions(path string, limit int) ([]Action, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var actions []Action
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var action Action
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+			continue
+		}
+		actions = append(actions, action)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if limit > 0 && len(actions) > limit {
+		actions = actions[len(actions)-limit:]
+	}
+	return actions, nil
+}
@@ -0,0 +1,500 @@
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+	"github.com/tjsturos/qtools/go-qtools/internal/service"
+)
+
+// Crash-loop remediations
+const (
+	RemediationNotify   = "notify"   // only record and notify
+	RemediationBackoff  = "backoff"  // stop the unit and start it again after an increasing delay
+	RemediationDisable  = "disable"  // stop and disable a worker and drop it from the worker count
+	RemediationRollback = "rollback" // switch to the previous node binary and restart all units
+)
+
+// crashLoopStateName is the crash-loop watchdog's state file name
+const crashLoopStateName = "crash_loop"
+
+// CrashLoopOptions represents options for a crash-loop check
+type CrashLoopOptions struct {
+	Window             time.Duration // restarts are counted within this window
+	MaxRestarts        int           // restarts within Window that count as a crash loop
+	MaxRestartsPerHour int           // restarts within an hour that count as a crash loop
+	Remediation        string
+	Backoff            time.Duration // first backoff; each further backoff doubles it
+	MaxBackoff         time.Duration
+	DryRun             bool
+	ConfigPath         string // qtools config path, used to save the worker count and rollback version
+	Recorder           *Recorder
+
+	now func() time.Time // Replaces time.Now in tests
+}
+
+// UnitReport represents the crash-loop state of a unit after a check
+type UnitReport struct {
+	Unit             string     `json:"unit"`
+	ActiveState      string     `json:"active_state"`
+	Restarts         int        `json:"restarts"`
+	RestartsInWindow int        `json:"restarts_in_window"`
+	RestartsLastHour int        `json:"restarts_last_hour"`
+	CrashLooping     bool       `json:"crash_looping"`
+	BackoffUntil     *time.Time `json:"backoff_until,omitempty"`
+	Disabled         bool       `json:"disabled,omitempty"`
+}
+
+// CrashLoopResult represents the outcome of a crash-loop check
+type CrashLoopResult struct {
+	Units   []UnitReport `json:"units"`
+	Actions []Action     `json:"actions,omitempty"`
+}
+
+// crashLoopState is kept between checks to count restarts and track remediations
+type crashLoopState struct {
+	Units        map[string]*unitState `json:"units"`
+	RolledBackTo string                `json:"rolled_back_to,omitempty"`
+}
+
+// unitState tracks a unit's restart counter and the remediations applied to it
+type unitState struct {
+	Restarts     int             `json:"restarts"` // restart counter at the last check
+	Samples      []restartSample `json:"samples,omitempty"`
+	BackoffUntil time.Time       `json:"backoff_until"`
+	Backoffs     int             `json:"backoffs,omitempty"`
+	LastAction   time.Time       `json:"last_action"`
+	Disabled     bool            `json:"disabled,omitempty"`
+	// Entries trimmed from the data worker lists when disabling the worker lowered the worker count to TrimmedTo
+	Trimmed   node.DataWorkerEntries `json:"trimmed,omitempty"`
+	TrimmedTo int                    `json:"trimmed_to,omitempty"`
+}
+
+// restartSample records restarts seen by a check
+type restartSample struct {
+	Time  time.Time `json:"time"`
+	Count int       `json:"count"`
+}
+
+// LoadCrashLoopOptionsFromConfig loads crash-loop options from scheduled_tasks.diagnostics.crash_loop
+// Unset (or 0) limits use the defaults
+func LoadCrashLoopOptionsFromConfig(cfg *config.Config) (*CrashLoopOptions, error) {
+	var crashLoop config.CrashLoopConfig
+	if cfg.ScheduledTasks != nil && cfg.ScheduledTasks.Diagnostics != nil && cfg.ScheduledTasks.Diagnostics.CrashLoop != nil {
+		crashLoop = *cfg.ScheduledTasks.Diagnostics.CrashLoop
+	}
+
+	opts := &CrashLoopOptions{
+		Window:             config.DurationOr(crashLoop.Window, 10*time.Minute),
+		MaxRestarts:        5,
+		MaxRestartsPerHour: 20,
+		Remediation:        RemediationBackoff,
+		Backoff:            config.DurationOr(crashLoop.Backoff, 5*time.Minute),
+		MaxBackoff:         config.DurationOr(crashLoop.MaxBackoff, time.Hour),
+		Recorder:           NewRecorder(cfg),
+	}
+	if crashLoop.MaxRestarts > 0 {
+		opts.MaxRestarts = crashLoop.MaxRestarts
+	}
+	if crashLoop.MaxRestartsPerHour > 0 {
+		opts.MaxRestartsPerHour = crashLoop.MaxRestartsPerHour
+	}
+	if crashLoop.Remediation != "" {
+		opts.Remediation = crashLoop.Remediation
+	}
+
+	if err := ValidateRemediation(opts.Remediation); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// ValidateRemediation checks that a crash-loop remediation is known
+func ValidateRemediation(remediation string) error {
+	switch remediation {
+	case RemediationNotify, RemediationBackoff, RemediationDisable, RemediationRollback:
+		return nil
+	}
+	return fmt.Errorf("unknown crash-loop remediation %q (expected notify, backoff, disable or rollback)", remediation)
+}
+
+// CheckCrashLoops samples the restart counters of the master and workers and remediates crash loops
+// A unit is crash looping when it restarted MaxRestarts times within Window, MaxRestartsPerHour
+// times within an hour, or systemd gave up on it (start-limit-hit). Restarts are counted from the
+// backend's restart counter, so OpenRC and runit units, which have none, are not checked. Units in
+// backoff are started again once it expires; workers the watchdog disabled are skipped until they
+// are enabled again. A dry run records restart samples but takes no action
+func CheckCrashLoops(ctx context.Context, cfg *config.Config, opts *CrashLoopOptions) (*CrashLoopResult, error) {
+	status, err := service.GetStatus(service.StatusOptions{}, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &crashLoopState{}
+	if err := loadState(crashLoopStateName, state); err != nil {
+		return nil, err
+	}
+	if state.Units == nil {
+		state.Units = make(map[string]*unitState)
+	}
+
+	units := map[int]*service.ServiceStatus{0: status.Master}
+	indexes := []int{0}
+	for idx, worker := range status.Workers {
+		units[idx] = worker
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+
+	result := &CrashLoopResult{}
+	now := opts.currentTime()
+	for _, idx := range indexes {
+		if ctx.Err() != nil {
+			break
+		}
+		unit := units[idx]
+		if unit == nil || unit.LoadState == "not-found" {
+			continue
+		}
+
+		name := unitName(idx)
+		st, seen := state.Units[name]
+		if !seen {
+			// The first sample only sets the baseline for the restart counter
+			st = &unitState{Restarts: unit.Restarts}
+			state.Units[name] = st
+		}
+		report := UnitReport{Unit: name, ActiveState: unit.ActiveState, Restarts: unit.Restarts}
+
+		// Re-enabling a worker the watchdog disabled hands it back to the watchdog
+		if st.Disabled && unit.Enabled {
+			if len(st.Trimmed) > 0 {
+				action := Action{Unit: name, Action: "resume", Reason: "worker re-enabled",
+					Detail: fmt.Sprintf("restored the data worker entries after worker %d", st.TrimmedTo)}
+				if !opts.DryRun {
+					if err := node.RestoreDataWorkerMultiaddrs("", st.TrimmedTo, st.Trimmed); err != nil {
+						action.Error = err.Error()
+					}
+					st.Trimmed = nil
+					st.TrimmedTo = 0
+				}
+				result.Actions = append(result.Actions, opts.record(action))
+			}
+			st.Disabled = false
+			st.Restarts = unit.Restarts
+			st.Samples = nil
+		}
+		if st.Disabled {
+			report.Disabled = true
+			result.Units = append(result.Units, report)
+			continue
+		}
+
+		if !st.BackoffUntil.IsZero() {
+			if now.Before(st.BackoffUntil) {
+				until := st.BackoffUntil
+				report.BackoffUntil = &until
+				result.Units = append(result.Units, report)
+				continue
+			}
+			action := Action{Unit: name, Action: "resume", Reason: "backoff expired"}
+			if !opts.DryRun {
+				if err := startUnit(idx, cfg); err != nil {
+					action.Error = err.Error()
+				}
+				st.BackoffUntil = time.Time{}
+				st.Samples = nil
+			}
+			result.Actions = append(result.Actions, opts.record(action))
+			st.Restarts = unit.Restarts
+			result.Units = append(result.Units, report)
+			continue
+		}
+
+		// Forget past backoffs once the unit has stayed up for an hour
+		if st.Backoffs > 0 && now.Sub(st.LastAction) > time.Hour && len(st.Samples) == 0 {
+			st.Backoffs = 0
+		}
+
+		delta := unit.Restarts - st.Restarts
+		if delta < 0 {
+			// The counter resets when the unit is started by hand
+			delta = unit.Restarts
+		}
+		st.Restarts = unit.Restarts
+		if delta > 0 {
+			st.Samples = append(st.Samples, restartSample{Time: now, Count: delta})
+		}
+		st.Samples = pruneSamples(st.Samples, now.Add(-time.Hour))
+		report.RestartsInWindow = countSamples(st.Samples, now.Add(-opts.Window))
+		report.RestartsLastHour = countSamples(st.Samples, time.Time{})
+
+		reason := ""
+		switch {
+		case opts.MaxRestarts > 0 && report.RestartsInWindow >= opts.MaxRestarts:
+			reason = fmt.Sprintf("%d restarts in %s", report.RestartsInWindow, opts.Window)
+		case opts.MaxRestartsPerHour > 0 && report.RestartsLastHour >= opts.MaxRestartsPerHour:
+			reason = fmt.Sprintf("%d restarts in the last hour", report.RestartsLastHour)
+		case unit.ActiveState == "failed" && unit.Result == "start-limit-hit":
+			reason = "systemd start limit hit"
+		}
+		if reason == "" {
+			result.Units = append(result.Units, report)
+			continue
+		}
+
+		report.CrashLooping = true
+		action := remediate(idx, reason, cfg, opts, state, st)
+		result.Actions = append(result.Actions, opts.record(action))
+		if !opts.DryRun {
+			// Restarts that led to this action are not counted again
+			st.Samples = nil
+			st.LastAction = now
+			if !st.BackoffUntil.IsZero() {
+				until := st.BackoffUntil
+				report.BackoffUntil = &until
+			}
+			report.Disabled = st.Disabled
+		}
+		result.Units = append(result.Units, report)
+
+		// A rollback restarts every unit, so their counters are read again on the next check
+		if action.Action == RemediationRollback && action.Error == "" && !opts.DryRun {
+			break
+		}
+	}
+
+	if err := saveState(crashLoopStateName, state); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// WatchCrashLoops runs CheckCrashLoops on an interval until the context is cancelled
+// Check errors are passed to onError and do not stop the loop
+func WatchCrashLoops(ctx context.Context, cfg *config.Config, opts *CrashLoopOptions, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := CheckCrashLoops(ctx, cfg, opts); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// remediate applies the configured remediation to a crash-looping unit
+// Disabling only applies to workers and rollbacks need an older binary; otherwise the unit backs off
+func remediate(idx int, reason string, cfg *config.Config, opts *CrashLoopOptions, state *crashLoopState, st *unitState) Action {
+	action := Action{Unit: unitName(idx), Action: opts.Remediation, Reason: reason, DryRun: opts.DryRun}
+
+	switch opts.Remediation {
+	case RemediationNotify:
+		return action
+
+	case RemediationDisable:
+		if idx == 0 {
+			action.Detail = "the master cannot be disabled; backing off instead"
+			return backoff(idx, action, cfg, opts, st)
+		}
+		return disableWorker(idx, action, cfg, opts, state, st)
+
+	case RemediationRollback:
+		rollback, err := node.PreviousNodeBinary(cfg)
+		switch {
+		case err != nil:
+			action.Detail = fmt.Sprintf("cannot roll back (%v); backing off instead", err)
+			return backoff(idx, action, cfg, opts, st)
+		case state.RolledBackTo != "" && state.RolledBackTo == rollback.FromVersion:
+			action.Detail = fmt.Sprintf("already rolled back to %s; backing off instead", rollback.FromVersion)
+			return backoff(idx, action, cfg, opts, st)
+		}
+		action.Detail = fmt.Sprintf("node %s -> %s", rollback.FromVersion, rollback.ToVersion)
+		if opts.DryRun {
+			return action
+		}
+		return rollbackNode(action, cfg, opts, state)
+	}
+
+	return backoff(idx, action, cfg, opts, st)
+}
+
+// backoff stops a unit until an exponentially increasing delay has passed
+func backoff(idx int, action Action, cfg *config.Config, opts *CrashLoopOptions, st *unitState) Action {
+	delay := opts.Backoff
+	for i := 0; i < st.Backoffs && delay < opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if opts.MaxBackoff > 0 && delay > opts.MaxBackoff {
+		delay = opts.MaxBackoff
+	}
+
+	action.Action = RemediationBackoff
+	detail := fmt.Sprintf("stopped for %s", delay)
+	if action.Detail != "" {
+		detail = action.Detail + "; " + detail
+	}
+	action.Detail = detail
+	if opts.DryRun {
+		return action
+	}
+
+	st.Backoffs++
+	st.BackoffUntil = opts.currentTime().Add(delay)
+	if err := stopUnit(idx, cfg); err != nil {
+		action.Error = err.Error()
+	}
+	return action
+}
+
+// disableWorker stops and disables a worker and, when it is the highest, lowers manual.worker_count
+// and trims the data worker lists to match. Lower workers stay in the count and keep their list
+// entries, since worker N uses entry N-1 and the remaining workers must keep their ports and cores
+func disableWorker(idx int, action Action, cfg *config.Config, opts *CrashLoopOptions, state *crashLoopState, st *unitState) Action {
+	count := node.GetWorkerCount(cfg)
+	newCount := count
+	if node.IsManualMode(cfg) && idx == count {
+		newCount = idx - 1
+		for newCount > 0 && state.Units[unitName(newCount)] != nil && state.Units[unitName(newCount)].Disabled {
+			newCount--
+		}
+		action.Detail = fmt.Sprintf("worker_count %d -> %d", count, newCount)
+	} else {
+		action.Detail = "worker_count and data worker lists unchanged; only the highest worker is dropped from the count"
+	}
+	if opts.DryRun {
+		return action
+	}
+
+	st.Disabled = true
+	if err := service.RunWorkerAction(service.WorkerActionStop, []int{idx}, service.WorkerOptions{}, cfg); err != nil {
+		action.Error = err.Error()
+		return action
+	}
+	if err := service.RunWorkerAction(service.WorkerActionDisable, []int{idx}, service.WorkerOptions{}, cfg); err != nil {
+		action.Error = err.Error()
+		return action
+	}
+
+	if newCount != count {
+		trimmed, err := node.TrimDataWorkerMultiaddrs("", newCount)
+		if err != nil {
+			action.Error = fmt.Sprintf("failed to trim the data worker lists: %v", err)
+			return action
+		}
+		if len(trimmed) > 0 {
+			st.Trimmed = trimmed
+			st.TrimmedTo = newCount
+			action.Detail += fmt.Sprintf("; data worker lists trimmed to %d entries", newCount)
+		}
+
+		cfg.Manual.WorkerCount = newCount
+		if err := config.SetConfigValue(cfg, "manual.worker_count", newCount); err != nil {
+			action.Error = err.Error()
+			return action
+		}
+		if err := saveConfig(cfg, opts.ConfigPath); err != nil {
+			action.Error = err.Error()
+		}
+	}
+	return action
+}
+
+// rollbackNode switches to the previous node binary and restarts the master and workers
+func rollbackNode(action Action, cfg *config.Config, opts *CrashLoopOptions, state *crashLoopState) Action {
+	rollback, err := node.RollbackNode(cfg, service.PrivilegedCommand(cfg))
+	if err != nil {
+		action.Error = err.Error()
+		return action
+	}
+	state.RolledBackTo = rollback.ToVersion
+
+	if err := saveConfig(cfg, opts.ConfigPath); err != nil {
+		action.Error = err.Error()
+		return action
+	}
+	if err := service.RestartService(service.RestartOptions{WorkerOptions: service.WorkerOptions{ContinueOnError: true}}, cfg); err != nil {
+		action.Error = fmt.Sprintf("failed to restart services: %v", err)
+	}
+	return action
+}
+
+// currentTime returns the time a check runs at
+func (opts *CrashLoopOptions) currentTime() time.Time {
+	if opts.now != nil {
+		return opts.now()
+	}
+	return time.Now()
+}
+
+// record stamps an action and passes it to the recorder
+func (opts *CrashLoopOptions) record(action Action) Action {
+	return recordAction(opts.Recorder, crashLoopStateName, opts.DryRun, action)
+}
+
+// unitName returns the watchdog's name for the master (index 0) or a worker
+func unitName(idx int) string {
+	if idx == 0 {
+		return "master"
+	}
+	return fmt.Sprintf("worker-%d", idx)
+}
+
+// stopUnit stops the master (index 0) or a worker
+func stopUnit(idx int, cfg *config.Config) error {
+	if idx == 0 {
+		return service.StopService(service.StopOptions{MasterOnly: true}, cfg)
+	}
+	return service.RunWorkerAction(service.WorkerActionStop, []int{idx}, service.WorkerOptions{}, cfg)
+}
+
+// startUnit starts the master (index 0) or a worker
+func startUnit(idx int, cfg *config.Config) error {
+	if idx == 0 {
+		return service.StartService(service.StartOptions{MasterOnly: true}, cfg)
+	}
+	return service.RunWorkerAction(service.WorkerActionStart, []int{idx}, service.WorkerOptions{}, cfg)
+}
+
+// saveConfig saves the qtools config
+func saveConfig(cfg *config.Config, configPath string) error {
+	if configPath == "" {
+		configPath = config.GetConfigPath()
+	}
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+// pruneSamples drops samples older than since
+func pruneSamples(samples []restartSample, since time.Time) []restartSample {
+	kept := samples[:0]
+	for _, sample := range samples {
+		if sample.Time.After(since) {
+			kept = append(kept, sample)
+		}
+	}
+	return kept
+}
+
+// countSamples sums the restarts recorded after since
+func countSamples(samples []restartSample, since time.Time) int {
+	total := 0
+	for _, sample := range samples {
+		if sample.Time.After(since) {
+			total += sample.Count
+		}
+	}
+	return total
+}
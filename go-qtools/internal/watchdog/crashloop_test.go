@@ -0,0 +1,335 @@
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/node"
+	"github.com/tjsturos/qtools/go-qtools/internal/service"
+)
+
+// fakeBackend keeps unit statuses in memory and records the operations run on them
+type fakeBackend struct {
+	service.ServiceBackend
+
+	mu    sync.Mutex
+	units map[string]*service.ServiceStatus
+	ops   []string
+}
+
+func (b *fakeBackend) GetStatus(name string) (*service.ServiceStatus, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st, ok := b.units[name]
+	if !ok {
+		return &service.ServiceStatus{Name: name, LoadState: "not-found"}, nil
+	}
+	status := *st
+	return &status, nil
+}
+
+func (b *fakeBackend) StartService(name string) error {
+	return b.apply("start", name, func(st *service.ServiceStatus) { st.ActiveState = "active" })
+}
+
+func (b *fakeBackend) StopService(name string) error {
+	return b.apply("stop", name, func(st *service.ServiceStatus) { st.ActiveState = "inactive" })
+}
+
+func (b *fakeBackend) DisableService(name string) error {
+	return b.apply("disable", name, func(st *service.ServiceStatus) { st.Enabled = false })
+}
+
+func (b *fakeBackend) EnableService(name string) error {
+	return b.apply("enable", name, func(st *service.ServiceStatus) { st.Enabled = true })
+}
+
+// apply records an operation and changes the unit's status
+func (b *fakeBackend) apply(op, name string, fn func(st *service.ServiceStatus)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ops = append(b.ops, op+" "+name)
+	if st, ok := b.units[name]; ok {
+		fn(st)
+	}
+	return nil
+}
+
+// setRestarts sets a unit's restart counter
+func (b *fakeBackend) setRestarts(name string, restarts int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.units[name].Restarts = restarts
+}
+
+// takeOps returns and clears the recorded operations
+func (b *fakeBackend) takeOps() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ops := b.ops
+	b.ops = nil
+	return ops
+}
+
+// crashLoopTest runs CheckCrashLoops against a fake backend with a master and workers
+type crashLoopTest struct {
+	t       *testing.T
+	cfg     *config.Config
+	backend *fakeBackend
+	opts    *CrashLoopOptions
+	now     time.Time
+}
+
+func newCrashLoopTest(t *testing.T, workers int, remediation string) *crashLoopTest {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("QTOOLS_PATH", dir)
+	t.Setenv("QUIL_CONFIG_FILE", filepath.Join(dir, "node", "config.yml"))
+
+	cfg := config.GenerateDefaultConfig()
+	cfg.Manual.WorkerCount = workers
+	if err := config.SetConfigValue(cfg, "manual.worker_count", workers); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &fakeBackend{units: map[string]*service.ServiceStatus{}}
+	for i := 0; i <= workers; i++ {
+		name := "ceremonyclient"
+		if i > 0 {
+			name = fmt.Sprintf("ceremonyclient-worker@%d", i)
+		}
+		backend.units[name] = &service.ServiceStatus{Name: name, LoadState: "loaded", ActiveState: "active", Enabled: true}
+	}
+	previous := service.SetServiceBackend(backend)
+	t.Cleanup(func() { service.SetServiceBackend(previous) })
+
+	ct := &crashLoopTest{
+		t:       t,
+		cfg:     cfg,
+		backend: backend,
+		now:     time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	ct.opts = &CrashLoopOptions{
+		Window:      10 * time.Minute,
+		MaxRestarts: 3,
+		Remediation: remediation,
+		Backoff:     5 * time.Minute,
+		MaxBackoff:  time.Hour,
+		ConfigPath:  filepath.Join(dir, "config.yml"),
+		now:         func() time.Time { return ct.now },
+	}
+	return ct
+}
+
+// check advances the clock and runs a check
+func (ct *crashLoopTest) check(advance time.Duration) *CrashLoopResult {
+	ct.t.Helper()
+	ct.now = ct.now.Add(advance)
+	result, err := CheckCrashLoops(context.Background(), ct.cfg, ct.opts)
+	if err != nil {
+		ct.t.Fatalf("CheckCrashLoops failed: %v", err)
+	}
+	return result
+}
+
+// unit returns a unit's report from a check
+func (ct *crashLoopTest) unit(result *CrashLoopResult, name string) UnitReport {
+	ct.t.Helper()
+	for _, report := range result.Units {
+		if report.Unit == name {
+			return report
+		}
+	}
+	ct.t.Fatalf("no report for %s in %+v", name, result.Units)
+	return UnitReport{}
+}
+
+// actions returns the "unit action" pairs from a check
+func actions(result *CrashLoopResult) []string {
+	var got []string
+	for _, action := range result.Actions {
+		got = append(got, action.Unit+" "+action.Action)
+	}
+	return got
+}
+
+// TestCheckCrashLoopsBackoff steps one worker through the baseline, the restart window,
+// a backoff and its expiry, and a second, longer backoff
+func TestCheckCrashLoopsBackoff(t *testing.T) {
+	ct := newCrashLoopTest(t, 2, RemediationBackoff)
+	worker := "ceremonyclient-worker@1"
+
+	// The first check only records the counter, however high it is
+	ct.backend.setRestarts(worker, 50)
+	if result := ct.check(0); len(result.Actions) > 0 || ct.unit(result, "worker-1").RestartsInWindow != 0 {
+		t.Fatalf("baseline check = %+v", result)
+	}
+
+	steps := []struct {
+		advance     time.Duration
+		restarts    int
+		wantWindow  int
+		wantActions []string
+		wantOps     []string
+		backoff     time.Duration // expected remaining backoff, 0 for none
+	}{
+		// Restarts are counted within the window
+		{advance: time.Minute, restarts: 52, wantWindow: 2},
+		// Restarts older than the window no longer count
+		{advance: 10 * time.Minute, restarts: 52, wantWindow: 0},
+		{advance: time.Minute, restarts: 54, wantWindow: 2},
+		// The third restart within the window is a crash loop: the worker is stopped
+		{advance: time.Minute, restarts: 55, wantWindow: 3, wantActions: []string{"worker-1 backoff"},
+			wantOps: []string{"stop " + worker}, backoff: 5 * time.Minute},
+		// Restarts during the backoff are not counted
+		{advance: 2 * time.Minute, restarts: 60, backoff: 3 * time.Minute},
+		// Once it expires the worker is started again
+		{advance: 3 * time.Minute, restarts: 60, wantActions: []string{"worker-1 resume"}, wantOps: []string{"start " + worker}},
+		// A second crash loop backs off twice as long
+		{advance: time.Minute, restarts: 63, wantWindow: 3, wantActions: []string{"worker-1 backoff"},
+			wantOps: []string{"stop " + worker}, backoff: 10 * time.Minute},
+	}
+	for i, step := range steps {
+		ct.backend.setRestarts(worker, step.restarts)
+		result := ct.check(step.advance)
+		report := ct.unit(result, "worker-1")
+
+		if got := actions(result); !reflect.DeepEqual(got, step.wantActions) {
+			t.Errorf("step %d: actions = %q, want %q", i, got, step.wantActions)
+		}
+		if got := ct.backend.takeOps(); !reflect.DeepEqual(got, step.wantOps) {
+			t.Errorf("step %d: operations = %q, want %q", i, got, step.wantOps)
+		}
+		if report.RestartsInWindow != step.wantWindow {
+			t.Errorf("step %d: restarts in window = %d, want %d", i, report.RestartsInWindow, step.wantWindow)
+		}
+		switch {
+		case step.backoff == 0 && report.BackoffUntil != nil:
+			t.Errorf("step %d: backing off until %s", i, report.BackoffUntil)
+		case step.backoff > 0 && (report.BackoffUntil == nil || report.BackoffUntil.Sub(ct.now) != step.backoff):
+			t.Errorf("step %d: backoff until %v, want %s from now", i, report.BackoffUntil, step.backoff)
+		}
+	}
+
+	// Other units were left alone
+	if report := ct.unit(ct.check(time.Minute), "worker-2"); report.CrashLooping || report.RestartsLastHour != 0 {
+		t.Errorf("worker-2 = %+v", report)
+	}
+}
+
+// TestCheckCrashLoopsDryRun checks that a dry run reports a crash loop without acting on it
+func TestCheckCrashLoopsDryRun(t *testing.T) {
+	ct := newCrashLoopTest(t, 1, RemediationBackoff)
+	ct.opts.DryRun = true
+	worker := "ceremonyclient-worker@1"
+
+	ct.check(0)
+	ct.backend.setRestarts(worker, 3)
+	result := ct.check(time.Minute)
+	if len(result.Actions) != 1 || !result.Actions[0].DryRun || !ct.unit(result, "worker-1").CrashLooping {
+		t.Errorf("dry run result = %+v", result)
+	}
+	if ops := ct.backend.takeOps(); len(ops) > 0 {
+		t.Errorf("dry run ran %q", ops)
+	}
+}
+
+// TestCheckCrashLoopsDisable disables a middle worker and then the highest one, and checks
+// that only the highest lowers worker_count and trims the data worker lists, and that
+// re-enabling it restores them
+func TestCheckCrashLoopsDisable(t *testing.T) {
+	ct := newCrashLoopTest(t, 3, RemediationDisable)
+	lists := map[string][]string{
+		"engine.dataWorkerMultiaddrs":       {"/ip4/127.0.0.1/tcp/40001", "/ip4/127.0.0.1/tcp/40002", "/ip4/127.0.0.1/tcp/40003"},
+		"engine.dataWorkerP2PMultiaddrs":    {"/ip4/0.0.0.0/tcp/50001", "/ip4/0.0.0.0/tcp/50002", "/ip4/0.0.0.0/tcp/50003"},
+		"engine.dataWorkerStreamMultiaddrs": {"/ip4/0.0.0.0/tcp/60001", "/ip4/0.0.0.0/tcp/60002", "/ip4/0.0.0.0/tcp/60003"},
+	}
+	if err := node.SetDataWorkerMultiaddrs("", lists["engine.dataWorkerMultiaddrs"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.SetDataWorkerP2PMultiaddrs("", lists["engine.dataWorkerP2PMultiaddrs"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.SetDataWorkerStreamMultiaddrs("", lists["engine.dataWorkerStreamMultiaddrs"]); err != nil {
+		t.Fatal(err)
+	}
+	current := func() map[string][]string {
+		t.Helper()
+		mgr, err := node.NewNodeConfigManager("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodeConfig, err := mgr.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return map[string][]string{
+			"engine.dataWorkerMultiaddrs":       nodeConfig.Engine.DataWorkerMultiaddrs,
+			"engine.dataWorkerP2PMultiaddrs":    nodeConfig.Engine.DataWorkerP2PMultiaddrs,
+			"engine.dataWorkerStreamMultiaddrs": nodeConfig.Engine.DataWorkerStreamMultiaddrs,
+		}
+	}
+	firstEntries := func(n int) map[string][]string {
+		trimmed := make(map[string][]string)
+		for path, list := range lists {
+			trimmed[path] = list[:n]
+		}
+		return trimmed
+	}
+
+	ct.check(0)
+
+	// A middle worker keeps its entries so the workers after it keep theirs
+	ct.backend.setRestarts("ceremonyclient-worker@2", 3)
+	result := ct.check(time.Minute)
+	if got := actions(result); !reflect.DeepEqual(got, []string{"worker-2 disable"}) {
+		t.Fatalf("actions = %q", got)
+	}
+	if got := ct.backend.takeOps(); !reflect.DeepEqual(got, []string{"stop ceremonyclient-worker@2", "disable ceremonyclient-worker@2"}) {
+		t.Errorf("operations = %q", got)
+	}
+	if got := current(); !reflect.DeepEqual(got, lists) || ct.cfg.Manual.WorkerCount != 3 {
+		t.Errorf("after disabling worker 2: worker_count %d, lists %q", ct.cfg.Manual.WorkerCount, got)
+	}
+	if report := ct.unit(ct.check(time.Minute), "worker-2"); !report.Disabled {
+		t.Errorf("worker-2 = %+v, want disabled", report)
+	}
+
+	// The highest worker drops out of the count together with the disabled worker below it
+	ct.backend.setRestarts("ceremonyclient-worker@3", 3)
+	ct.check(time.Minute)
+	ct.backend.takeOps()
+	if got := current(); !reflect.DeepEqual(got, firstEntries(1)) || ct.cfg.Manual.WorkerCount != 1 {
+		t.Errorf("after disabling worker 3: worker_count %d, lists %q", ct.cfg.Manual.WorkerCount, got)
+	}
+	saved, err := config.LoadConfig(ct.opts.ConfigPath)
+	if err != nil || saved.Manual.WorkerCount != 1 {
+		t.Errorf("saved worker_count = %+v, %v", saved.Manual, err)
+	}
+
+	// Raising the count and enabling worker 3 again hands it back and restores the lists
+	ct.cfg.Manual.WorkerCount = 3
+	if err := config.SetConfigValue(ct.cfg, "manual.worker_count", 3); err != nil {
+		t.Fatal(err)
+	}
+	ct.backend.EnableService("ceremonyclient-worker@3")
+	result = ct.check(time.Minute)
+	if got := actions(result); !reflect.DeepEqual(got, []string{"worker-3 resume"}) || result.Actions[0].Error != "" {
+		t.Errorf("re-enable actions = %+v", result.Actions)
+	}
+	if got := current(); !reflect.DeepEqual(got, lists) {
+		t.Errorf("after re-enabling worker 3: lists %q", got)
+	}
+	if report := ct.unit(result, "worker-3"); report.Disabled || report.CrashLooping {
+		t.Errorf("worker-3 = %+v", report)
+	}
+	if report := ct.unit(result, "worker-2"); !report.Disabled {
+		t.Errorf("worker-2 = %+v, want still disabled", report)
+	}
+}
@@ -0,0 +1,319 @@
+package watchdog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/service"
+	"github.com/tjsturos/qtools/go-qtools/internal/supervisor"
+)
+
+// memoryStateName is the memory watchdog's state file name
+const memoryStateName = "memory"
+
+// MemoryOptions represents options for a memory check
+type MemoryOptions struct {
+	Threshold       float64       // host memory use, in percent, that triggers restarts
+	WorkerThreshold uint64        // worker memory, in bytes, that triggers a restart of that worker (0 = off)
+	RestartWorkers  bool          // restart the heaviest workers when host memory crosses Threshold
+	RestartMaster   bool          // restart the master when restarting workers is not enough
+	Cooldown        time.Duration // a unit is not restarted again within this time
+	DryRun          bool
+	Recorder        *Recorder
+}
+
+// HostMemory represents the host's memory from /proc/meminfo
+type HostMemory struct {
+	TotalBytes     uint64  `json:"total_bytes"`
+	AvailableBytes uint64  `json:"available_bytes"`
+	UsedPercent    float64 `json:"used_percent"`
+}
+
+// UnitMemory represents a unit's memory use
+type UnitMemory struct {
+	Unit        string    `json:"unit"`
+	PID         int       `json:"pid"`
+	Bytes       uint64    `json:"bytes"`
+	Percent     float64   `json:"percent"` // of host memory
+	LastRestart time.Time `json:"last_restart"`
+	Restarted   bool      `json:"restarted,omitempty"`
+}
+
+// MemoryResult represents the outcome of a memory check
+type MemoryResult struct {
+	Host    HostMemory   `json:"host"`
+	Units   []UnitMemory `json:"units"`
+	Actions []Action     `json:"actions,omitempty"`
+}
+
+// memoryState is kept between checks for restart cooldowns
+type memoryState struct {
+	LastRestart map[string]time.Time `json:"last_restart"`
+	LastNotify  time.Time            `json:"last_notify"`
+}
+
+// LoadMemoryOptionsFromConfig loads memory options from scheduled_tasks.cluster.memory_check
+// An unset (or 0) memory_threshold is 80%
+func LoadMemoryOptionsFromConfig(cfg *config.Config) *MemoryOptions {
+	var check config.MemoryCheckTaskConfig
+	if cfg.ScheduledTasks != nil && cfg.ScheduledTasks.Cluster != nil && cfg.ScheduledTasks.Cluster.MemoryCheck != nil {
+		check = *cfg.ScheduledTasks.Cluster.MemoryCheck
+	}
+
+	opts := &MemoryOptions{
+		Threshold:       80,
+		WorkerThreshold: uint64(check.WorkerThresholdMB) * 1024 * 1024,
+		RestartWorkers:  check.RestartWorkers == nil || *check.RestartWorkers,
+		RestartMaster:   check.RestartMaster,
+		Cooldown:        config.DurationOr(check.Cooldown, 10*time.Minute),
+		Recorder:        NewRecorder(cfg),
+	}
+	if check.MemoryThreshold > 0 {
+		opts.Threshold = float64(check.MemoryThreshold)
+	}
+	return opts
+}
+
+// CheckMemory compares the memory of the master and workers to host memory and restarts units
+// Workers above WorkerThreshold are always restarted. When host memory use is at or above
+// Threshold, the heaviest workers are restarted first until the memory they hold would bring use
+// back under it; the master is only restarted, last, with RestartMaster. Units restarted within
+// Cooldown are skipped. Unit memory is the cgroup's (systemd) or the main process's RSS (/proc)
+func CheckMemory(ctx context.Context, cfg *config.Config, opts *MemoryOptions) (*MemoryResult, error) {
+	host, err := ReadHostMemory()
+	if err != nil {
+		return nil, err
+	}
+	status, err := service.GetStatus(service.StatusOptions{}, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &memoryState{}
+	if err := loadState(memoryStateName, state); err != nil {
+		return nil, err
+	}
+	if state.LastRestart == nil {
+		state.LastRestart = make(map[string]time.Time)
+	}
+
+	result := &MemoryResult{Host: *host}
+	var master *UnitMemory
+	var workers []*UnitMemory
+	indexes := make(map[*UnitMemory]int)
+	add := func(idx int, unit *service.ServiceStatus) *UnitMemory {
+		usage := &UnitMemory{Unit: unitName(idx), PID: unit.PID, Bytes: unitMemory(unit), LastRestart: state.LastRestart[unitName(idx)]}
+		usage.Percent = 100 * float64(usage.Bytes) / float64(host.TotalBytes)
+		indexes[usage] = idx
+		return usage
+	}
+	if status.Master != nil && status.Master.Running {
+		master = add(0, status.Master)
+	}
+	for idx, worker := range status.Workers {
+		if worker != nil && worker.Running {
+			workers = append(workers, add(idx, worker))
+		}
+	}
+
+	now := time.Now()
+	plan := planMemoryRestarts(*host, master, workers, opts, state.LastNotify, now)
+	for _, selected := range plan.Restarts {
+		if ctx.Err() != nil {
+			break
+		}
+		usage := selected.Unit
+		action := Action{
+			Unit:   usage.Unit,
+			Action: "restart",
+			Reason: selected.Reason,
+			Detail: fmt.Sprintf("using %s (%.1f%% of host memory)", service.FormatBytes(usage.Bytes), usage.Percent),
+		}
+		if !opts.DryRun {
+			if err := restartUnit(indexes[usage], cfg); err != nil {
+				action.Error = err.Error()
+			}
+			state.LastRestart[usage.Unit] = now
+			usage.LastRestart = now
+		}
+		usage.Restarted = true
+		result.Actions = append(result.Actions, opts.record(action))
+	}
+	if plan.Notify != "" {
+		action := Action{Unit: "host", Action: RemediationNotify, Reason: plan.Notify, Detail: "no unit left to restart"}
+		if !opts.DryRun {
+			state.LastNotify = now
+		}
+		result.Actions = append(result.Actions, opts.record(action))
+	}
+
+	if master != nil {
+		result.Units = append(result.Units, *master)
+	}
+	for _, usage := range workers {
+		result.Units = append(result.Units, *usage)
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+	if err := saveState(memoryStateName, state); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// memoryRestart represents a unit a memory check restarts, and why
+type memoryRestart struct {
+	Unit   *UnitMemory
+	Reason string
+}
+
+// memoryPlan represents what a memory check does
+type memoryPlan struct {
+	Restarts []memoryRestart // in the order they run
+	Notify   string          // the reason to notify when nothing is left to restart
+}
+
+// planMemoryRestarts selects the units a memory check restarts from the running master (or nil)
+// and workers, in worker order; it follows the rules described on CheckMemory
+// lastNotify is when host memory was last reported with nothing left to restart
+func planMemoryRestarts(host HostMemory, master *UnitMemory, workers []*UnitMemory, opts *MemoryOptions, lastNotify, now time.Time) memoryPlan {
+	var plan memoryPlan
+	restarted := make(map[*UnitMemory]bool)
+	coolingDown := func(usage *UnitMemory) bool {
+		return opts.Cooldown > 0 && now.Sub(usage.LastRestart) < opts.Cooldown
+	}
+	restart := func(usage *UnitMemory, reason string) {
+		plan.Restarts = append(plan.Restarts, memoryRestart{Unit: usage, Reason: reason})
+		restarted[usage] = true
+	}
+
+	// Heaviest workers first; equal ones keep their order
+	heaviest := slices.Clone(workers)
+	sort.SliceStable(heaviest, func(i, j int) bool { return heaviest[i].Bytes > heaviest[j].Bytes })
+
+	// Workers above the per-worker limit are restarted regardless of host memory
+	if opts.WorkerThreshold > 0 {
+		for _, usage := range heaviest {
+			if usage.Bytes >= opts.WorkerThreshold && !coolingDown(usage) {
+				restart(usage, fmt.Sprintf("worker memory above %s", service.FormatBytes(opts.WorkerThreshold)))
+			}
+		}
+	}
+
+	if host.UsedPercent < opts.Threshold {
+		return plan
+	}
+	reason := fmt.Sprintf("host memory at %.1f%% (threshold %.0f%%)", host.UsedPercent, opts.Threshold)
+	projected := host.UsedPercent
+	for _, usage := range heaviest {
+		if restarted[usage] {
+			projected -= usage.Percent
+		}
+	}
+
+	if opts.RestartWorkers {
+		for _, usage := range heaviest {
+			if projected < opts.Threshold {
+				break
+			}
+			if restarted[usage] || coolingDown(usage) {
+				continue
+			}
+			restart(usage, reason)
+			projected -= usage.Percent
+		}
+	}
+	if projected >= opts.Threshold && opts.RestartMaster && master != nil && !coolingDown(master) {
+		restart(master, reason)
+		projected -= master.Percent
+	}
+
+	// Nothing left to restart: notify, at most once per cooldown
+	if projected >= opts.Threshold && len(plan.Restarts) == 0 && (opts.Cooldown == 0 || now.Sub(lastNotify) >= opts.Cooldown) {
+		plan.Notify = reason
+	}
+	return plan
+}
+
+// WatchMemory runs CheckMemory on an interval until the context is cancelled
+// Check errors are passed to onError and do not stop the loop
+func WatchMemory(ctx context.Context, cfg *config.Config, opts *MemoryOptions, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := CheckMemory(ctx, cfg, opts); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// record stamps an action and passes it to the recorder
+func (opts *MemoryOptions) record(action Action) Action {
+	return recordAction(opts.Recorder, memoryStateName, opts.DryRun, action)
+}
+
+// unitMemory returns a unit's memory: the backend's figure, or the main process's RSS when it has none
+func unitMemory(unit *service.ServiceStatus) uint64 {
+	if unit.MemoryBytes > 0 {
+		return unit.MemoryBytes
+	}
+	if unit.PID > 0 {
+		return supervisor.ProcessMemory(unit.PID)
+	}
+	return 0
+}
+
+// restartUnit restarts the master (index 0) or a worker
+func restartUnit(idx int, cfg *config.Config) error {
+	if idx == 0 {
+		return service.RestartService(service.RestartOptions{MasterOnly: true}, cfg)
+	}
+	return service.RunWorkerAction(service.WorkerActionRestart, []int{idx}, service.WorkerOptions{}, cfg)
+}
+
+// ReadHostMemory reads total and available memory from /proc/meminfo
+func ReadHostMemory() (*HostMemory, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read host memory: %w", err)
+	}
+	defer file.Close()
+
+	host := &HostMemory{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		kb, _ := strconv.ParseUint(fields[1], 10, 64)
+		switch fields[0] {
+		case "MemTotal:":
+			host.TotalBytes = kb * 1024
+		case "MemAvailable:":
+			host.AvailableBytes = kb * 1024
+		}
+	}
+	if host.TotalBytes == 0 {
+		return nil, fmt.Errorf("failed to read host memory: no MemTotal in /proc/meminfo")
+	}
+	host.UsedPercent = 100 * float64(host.TotalBytes-host.AvailableBytes) / float64(host.TotalBytes)
+	return host, nil
+}
@@ -0,0 +1,207 @@
+package watchdog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const gib = 1 << 30
+
+func TestPlanMemoryRestarts(t *testing.T) {
+	now := time.Date(2024, 6, 3, 16, 0, 0, 0, time.UTC)
+	recently := now.Add(-5 * time.Minute)
+	longAgo := now.Add(-11 * time.Minute)
+
+	// Host memory is 100 GiB, so a unit's GiB are its percent of host memory
+	type unit struct {
+		gib         uint64
+		lastRestart time.Time
+	}
+	hostReason := "host memory at 90.0% (threshold 80%)"
+
+	tests := []struct {
+		name       string
+		used       float64 // host memory use, in percent
+		master     *unit
+		workers    []unit // worker-1, worker-2, ...
+		opts       MemoryOptions
+		lastNotify time.Time
+		want       []string // restarted units, in order
+		wantReason string   // of the first restart, when set
+		wantNotify bool
+	}{
+		{
+			name:    "below the threshold",
+			used:    79.9,
+			master:  &unit{gib: 20},
+			workers: []unit{{gib: 5}, {gib: 8}},
+			opts:    MemoryOptions{Threshold: 80, RestartWorkers: true, RestartMaster: true},
+		},
+		{
+			name:       "heaviest first until under the threshold",
+			used:       90,
+			master:     &unit{gib: 20},
+			workers:    []unit{{gib: 5}, {gib: 8}, {gib: 3}, {gib: 4}},
+			opts:       MemoryOptions{Threshold: 80, RestartWorkers: true, RestartMaster: true},
+			want:       []string{"worker-2", "worker-1"}, // 90 - 8 - 5 = 77
+			wantReason: hostReason,
+		},
+		{
+			name:    "projected use at the threshold",
+			used:    90,
+			workers: []unit{{gib: 5}, {gib: 5}, {gib: 3}},
+			opts:    MemoryOptions{Threshold: 80, RestartWorkers: true},
+			want:    []string{"worker-1", "worker-2", "worker-3"}, // 80 is still at the threshold
+		},
+		{
+			name:    "equal workers keep their order",
+			used:    84,
+			workers: []unit{{gib: 3}, {gib: 5}, {gib: 5}},
+			opts:    MemoryOptions{Threshold: 80, RestartWorkers: true},
+			want:    []string{"worker-2"},
+		},
+		{
+			name:    "cooling down",
+			used:    90,
+			workers: []unit{{gib: 5}, {gib: 8, lastRestart: recently}, {gib: 3}, {gib: 4}},
+			opts:    MemoryOptions{Threshold: 80, RestartWorkers: true, Cooldown: 10 * time.Minute},
+			want:    []string{"worker-1", "worker-4", "worker-3"},
+		},
+		{
+			name:    "cooldown over",
+			used:    90,
+			workers: []unit{{gib: 5}, {gib: 8, lastRestart: longAgo}, {gib: 3}, {gib: 4}},
+			opts:    MemoryOptions{Threshold: 80, RestartWorkers: true, Cooldown: 10 * time.Minute},
+			want:    []string{"worker-2", "worker-1"},
+		},
+		{
+			name:    "master left alone",
+			used:    90,
+			master:  &unit{gib: 20},
+			workers: []unit{{gib: 2}},
+			opts:    MemoryOptions{Threshold: 80, RestartWorkers: true},
+			want:    []string{"worker-1"},
+		},
+		{
+			name:    "master last with restart_master",
+			used:    90,
+			master:  &unit{gib: 20},
+			workers: []unit{{gib: 2}},
+			opts:    MemoryOptions{Threshold: 80, RestartWorkers: true, RestartMaster: true},
+			want:    []string{"worker-1", "master"},
+		},
+		{
+			name:    "master not needed",
+			used:    90,
+			master:  &unit{gib: 20},
+			workers: []unit{{gib: 12}},
+			opts:    MemoryOptions{Threshold: 80, RestartWorkers: true, RestartMaster: true},
+			want:    []string{"worker-1"},
+		},
+		{
+			name:    "master only",
+			used:    90,
+			master:  &unit{gib: 20},
+			workers: []unit{{gib: 12}},
+			opts:    MemoryOptions{Threshold: 80, RestartMaster: true},
+			want:    []string{"master"},
+		},
+		{
+			name:       "master cooling down",
+			used:       90,
+			master:     &unit{gib: 20, lastRestart: recently},
+			workers:    []unit{{gib: 2}},
+			opts:       MemoryOptions{Threshold: 80, RestartWorkers: true, RestartMaster: true, Cooldown: 10 * time.Minute},
+			lastNotify: longAgo,
+			want:       []string{"worker-1"},
+		},
+		{
+			name:       "worker above its own limit",
+			used:       50,
+			workers:    []unit{{gib: 5}, {gib: 7}, {gib: 6}},
+			opts:       MemoryOptions{Threshold: 80, WorkerThreshold: 6 * gib, RestartWorkers: true},
+			want:       []string{"worker-2", "worker-3"},
+			wantReason: "worker memory above 6.0 GiB",
+		},
+		{
+			// The workers over their limit count towards the projection
+			name:    "worker limit and host threshold",
+			used:    90,
+			workers: []unit{{gib: 5}, {gib: 7}, {gib: 4}},
+			opts:    MemoryOptions{Threshold: 80, WorkerThreshold: 6 * gib, RestartWorkers: true},
+			want:    []string{"worker-2", "worker-1"},
+		},
+		{
+			name:       "worker limit skips workers cooling down",
+			used:       50,
+			workers:    []unit{{gib: 7, lastRestart: recently}},
+			opts:       MemoryOptions{Threshold: 80, WorkerThreshold: 6 * gib, Cooldown: 10 * time.Minute},
+			lastNotify: recently,
+		},
+		{
+			name:       "nothing left to restart",
+			used:       90,
+			master:     &unit{gib: 20},
+			workers:    []unit{{gib: 5, lastRestart: recently}},
+			opts:       MemoryOptions{Threshold: 80, RestartWorkers: true, Cooldown: 10 * time.Minute},
+			lastNotify: longAgo,
+			wantNotify: true,
+		},
+		{
+			name:       "notified within the cooldown",
+			used:       90,
+			master:     &unit{gib: 20},
+			workers:    []unit{{gib: 5, lastRestart: recently}},
+			opts:       MemoryOptions{Threshold: 80, RestartWorkers: true, Cooldown: 10 * time.Minute},
+			lastNotify: recently,
+		},
+		{
+			name:       "notified without a cooldown",
+			used:       90,
+			opts:       MemoryOptions{Threshold: 80, RestartWorkers: true},
+			lastNotify: now,
+			wantNotify: true,
+		},
+	}
+
+	for _, tt := range tests {
+		host := HostMemory{TotalBytes: 100 * gib, UsedPercent: tt.used}
+		usage := func(name string, u unit) *UnitMemory {
+			return &UnitMemory{Unit: name, Bytes: u.gib * gib, Percent: float64(u.gib), LastRestart: u.lastRestart}
+		}
+		var master *UnitMemory
+		if tt.master != nil {
+			master = usage("master", *tt.master)
+		}
+		var workers []*UnitMemory
+		for idx, worker := range tt.workers {
+			workers = append(workers, usage(unitName(idx+1), worker))
+		}
+
+		plan := planMemoryRestarts(host, master, workers, &tt.opts, tt.lastNotify, now)
+		var got []string
+		for _, restart := range plan.Restarts {
+			got = append(got, restart.Unit.Unit)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: restarts = %v, want %v", tt.name, got, tt.want)
+		}
+		if tt.wantReason != "" && len(plan.Restarts) > 0 && plan.Restarts[0].Reason != tt.wantReason {
+			t.Errorf("%s: reason = %q, want %q", tt.name, plan.Restarts[0].Reason, tt.wantReason)
+		}
+		if (plan.Notify != "") != tt.wantNotify {
+			t.Errorf("%s: notify = %q, want notify %t", tt.name, plan.Notify, tt.wantNotify)
+		}
+		if tt.wantNotify && plan.Notify != hostReason {
+			t.Errorf("%s: notify reason = %q, want %q", tt.name, plan.Notify, hostReason)
+		}
+
+		// The workers keep their order for the report
+		for idx, worker := range workers {
+			if worker.Unit != unitName(idx+1) {
+				t.Errorf("%s: workers reordered: %s at %d", tt.name, worker.Unit, idx)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package watchdog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+	"github.com/tjsturos/qtools/go-qtools/internal/service"
+)
+
+// DefaultStallThreshold is how long the node may go without a frame before it is restarted
+// (matches monitor-frame-proofs.sh)
+const DefaultStallThreshold = 275 * time.Second
+
+// stallWatchdogName names the frame stall watchdog in recorded actions
+const stallWatchdogName = "frame_stall"
+
+// StallOptions represents options for the frame stall watchdog
+type StallOptions struct {
+	Threshold time.Duration
+	DryRun    bool
+	Recorder  *Recorder
+}
+
+// StallWatchdog restarts the node when no frame arrives, backing off between restarts
+// After N restarts without the backoff running out, the next restart waits Threshold*(N+1)
+// after the last one; once that passes without a stall, the count is reset
+type StallWatchdog struct {
+	opts        StallOptions
+	restarts    int
+	lastRestart time.Time
+}
+
+// NewStallWatchdog creates a frame stall watchdog
+func NewStallWatchdog(opts StallOptions) *StallWatchdog {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultStallThreshold
+	}
+	return &StallWatchdog{opts: opts}
+}
+
+// Restarts returns the restarts since the count was last reset
+func (w *StallWatchdog) Restarts() int {
+	return w.restarts
+}
+
+// LastRestart returns when the node was last restarted, or zero
+func (w *StallWatchdog) LastRestart() time.Time {
+	return w.lastRestart
+}
+
+// Check restarts the master and workers when no frame was received within the threshold
+// Nothing is checked before the first frame. The action taken is returned, or nil
+func (w *StallWatchdog) Check(cfg *config.Config, lastFrameAt, now time.Time) *Action {
+	if lastFrameAt.IsZero() {
+		return nil
+	}
+
+	backoff := w.opts.Threshold * time.Duration(w.restarts+1)
+	sinceFrame := now.Sub(lastFrameAt)
+	sinceRestart := now.Sub(w.lastRestart)
+
+	if sinceRestart <= backoff {
+		return nil
+	}
+	if sinceFrame <= w.opts.Threshold {
+		w.restarts = 0
+		w.lastRestart = time.Time{}
+		return nil
+	}
+
+	action := Action{
+		Unit:   "node",
+		Action: "restart",
+		Reason: fmt.Sprintf("no frame for %s", sinceFrame.Round(time.Second)),
+		Detail: fmt.Sprintf("restart %d; the next waits at least %s", w.restarts+1, w.opts.Threshold*time.Duration(w.restarts+2)),
+	}
+	if !w.opts.DryRun {
+		if err := service.RestartService(service.RestartOptions{WorkerOptions: service.WorkerOptions{ContinueOnError: true}}, cfg); err != nil {
+			action.Error = err.Error()
+		}
+	}
+	w.restarts++
+	w.lastRestart = now
+
+	recorded := recordAction(w.opts.Recorder, stallWatchdogName, w.opts.DryRun, action)
+	return &recorded
+}
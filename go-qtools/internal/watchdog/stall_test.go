@@ -0,0 +1,98 @@
+package watchdog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+func TestStallWatchdogCheck(t *testing.T) {
+	var actions []Action
+	watchdog := NewStallWatchdog(StallOptions{
+		Threshold: 100 * time.Second,
+		DryRun:    true,
+		Recorder:  &Recorder{OnAction: func(action Action) { actions = append(actions, action) }},
+	})
+	cfg := config.GenerateDefaultConfig()
+	start := time.Date(2024, 6, 3, 16, 0, 0, 0, time.UTC)
+	lastFrame := start
+
+	steps := []struct {
+		name         string
+		at           time.Duration // since start
+		frameAt      time.Duration // moves the last frame, when set
+		wantRestart  bool
+		wantRestarts int
+		wantDetail   string
+	}{
+		{name: "frame is recent", at: 50 * time.Second},
+		{name: "at the threshold", at: 100 * time.Second},
+		{name: "stalled", at: 101 * time.Second, wantRestart: true, wantRestarts: 1, wantDetail: "restart 1; the next waits at least 3m20s"},
+		// The second restart waits twice the threshold after the first
+		{name: "backing off", at: 300 * time.Second, wantRestarts: 1},
+		{name: "still stalled", at: 302 * time.Second, wantRestart: true, wantRestarts: 2, wantDetail: "restart 2; the next waits at least 5m0s"},
+		{name: "backing off longer", at: 500 * time.Second, wantRestarts: 2},
+		// A frame arrives; once the backoff passes without a stall, the count is reset
+		{name: "recovered during backoff", at: 580 * time.Second, frameAt: 550 * time.Second, wantRestarts: 2},
+		{name: "recovered", at: 603 * time.Second, wantRestarts: 0},
+		{name: "stalled again", at: 651 * time.Second, wantRestart: true, wantRestarts: 1, wantDetail: "restart 1; the next waits at least 3m20s"},
+	}
+
+	for _, step := range steps {
+		if step.frameAt != 0 {
+			lastFrame = start.Add(step.frameAt)
+		}
+		now := start.Add(step.at)
+		actions = nil
+		action := watchdog.Check(cfg, lastFrame, now)
+
+		if (action != nil) != step.wantRestart {
+			t.Fatalf("%s: action = %+v, want restart %t", step.name, action, step.wantRestart)
+		}
+		if got := watchdog.Restarts(); got != step.wantRestarts {
+			t.Errorf("%s: restarts = %d, want %d", step.name, got, step.wantRestarts)
+		}
+		if step.wantRestarts == 0 && !watchdog.LastRestart().IsZero() {
+			t.Errorf("%s: last restart = %s after a reset", step.name, watchdog.LastRestart())
+		}
+		if action == nil {
+			if len(actions) != 0 {
+				t.Errorf("%s: recorded %+v without a restart", step.name, actions)
+			}
+			continue
+		}
+
+		if action.Unit != "node" || action.Action != "restart" || !action.DryRun || action.Watchdog != stallWatchdogName || action.Error != "" {
+			t.Errorf("%s: action = %+v", step.name, action)
+		}
+		if action.Detail != step.wantDetail {
+			t.Errorf("%s: detail = %q, want %q", step.name, action.Detail, step.wantDetail)
+		}
+		if want := "no frame for " + now.Sub(lastFrame).String(); action.Reason != want {
+			t.Errorf("%s: reason = %q, want %q", step.name, action.Reason, want)
+		}
+		if !watchdog.LastRestart().Equal(now) {
+			t.Errorf("%s: last restart = %s, want %s", step.name, watchdog.LastRestart(), now)
+		}
+		if len(actions) != 1 || actions[0].Detail != action.Detail {
+			t.Errorf("%s: recorded %+v, want the restart", step.name, actions)
+		}
+	}
+}
+
+func TestStallWatchdogBeforeFirstFrame(t *testing.T) {
+	watchdog := NewStallWatchdog(StallOptions{DryRun: true})
+	if action := watchdog.Check(config.GenerateDefaultConfig(), time.Time{}, time.Now()); action != nil {
+		t.Errorf("action before the first frame = %+v", action)
+	}
+
+	// The default threshold applies
+	lastFrame := time.Date(2024, 6, 3, 16, 0, 0, 0, time.UTC)
+	if action := watchdog.Check(config.GenerateDefaultConfig(), lastFrame, lastFrame.Add(DefaultStallThreshold)); action != nil {
+		t.Errorf("action at the default threshold = %+v", action)
+	}
+	if action := watchdog.Check(config.GenerateDefaultConfig(), lastFrame, lastFrame.Add(DefaultStallThreshold+time.Second)); action == nil {
+		t.Error("no restart past the default threshold")
+	}
+}
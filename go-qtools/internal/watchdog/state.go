@@ -0,0 +1,46 @@
+package watchdog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tjsturos/qtools/go-qtools/internal/config"
+)
+
+// statePath returns the file a watchdog keeps its state in between checks
+func statePath(name string) string {
+	return filepath.Join(config.GetQtoolsPath(), "watchdog", name+".json")
+}
+
+// loadState reads a watchdog's state into v; a missing state file leaves v unchanged
+func loadState(name string, v interface{}) error {
+	data, err := os.ReadFile(statePath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read watchdog state: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse watchdog state %s: %w", statePath(name), err)
+	}
+	return nil
+}
+
+// saveState writes a watchdog's state
+func saveState(name string, v interface{}) error {
+	path := statePath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watchdog state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write watchdog state: %w", err)
+	}
+	return nil
+}
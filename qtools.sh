@@ -181,7 +181,6 @@ if [ ! -f "$QUIL_SERVICE_FILE" ] && [ "$1" != "update-service" ]; then
 fi
 
 # Function to find the script and set SERVICE_PATH
-IS_GO_SCRIPT=false
 IS_SH_SCRIPT=false
 
 find_script() {
@@ -197,11 +196,6 @@ find_script() {
           export SERVICE_PATH="${subsubdir%/}"
           return 0
         fi
-        if [ -f "$subsubdir/$1.go" ]; then
-          IS_GO_SCRIPT=true
-          export SERVICE_PATH="${subsubdir%/}"
-          return 0
-        fi
       done
     done
   done
@@ -251,11 +245,7 @@ if [ "$CLEAR_SCREEN" == "true" ]; then
 fi
 
 # Construct the full filename
-if [ "$IS_GO_SCRIPT" == "true" ]; then
-  SCRIPT="$SERVICE_PATH/$1.go"
-else
-  SCRIPT="$SERVICE_PATH/$1.sh"
-fi
+SCRIPT="$SERVICE_PATH/$1.sh"
 
 # Check if the file exists
 if [ ! -f "$SCRIPT" ]; then
@@ -291,12 +281,7 @@ if [[ " ${ROOT_SCRIPTS[@]} " =~ " $(basename "$SCRIPT" .sh) " ]]; then
   log "Running script $SCRIPT as root"
   sudo su -c "QTOOLS_PATH=$QTOOLS_PATH $SCRIPT $*" - root
 else
-  if [ "$IS_GO_SCRIPT" == "true" ]; then
-    cd $QTOOLS_PATH/scripts/go
-    go run "$SCRIPT" "$@"
-  else
-    source "$SCRIPT" "$@"
-  fi
+  source "$SCRIPT" "$@"
 fi
 
 exit 0
\ No newline at end of file
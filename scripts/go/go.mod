@@ -1,10 +0,0 @@
-module qtools/scripts/go
-
-go 1.22.4
-
-require github.com/iden3/go-iden3-crypto v0.0.17
-
-require (
-	github.com/mr-tron/base58 v1.2.0 // indirect
-	golang.org/x/sys v0.6.0 // indirect
-)
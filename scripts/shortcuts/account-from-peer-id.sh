@@ -0,0 +1,20 @@
+# HELP: Prints the QUIL account address of a peer ID (this node's peer ID by default).
+# USAGE: qtools account-from-peer-id [--peer-id <peer-id>]
+
+PEER_ID=""
+
+while [[ $# -gt 0 ]]; do
+    case $1 in
+        --peer-id)
+            PEER_ID="$2"
+            shift 2
+            ;;
+        *)
+            shift
+            ;;
+    esac
+done
+
+# The address is derived by the Go CLI (qtools node identity)
+cd $QTOOLS_PATH/go-qtools
+go run ./cmd/qtools node identity $PEER_ID --json | yq -p json '.account_address'